@@ -1,6 +1,10 @@
 package garden
 
-import "time"
+import (
+	"time"
+
+	"golang.org/x/net/context"
+)
 
 //go:generate counterfeiter . Backend
 
@@ -12,3 +16,13 @@ type Backend interface {
 
 	GraceTime(Container) time.Duration
 }
+
+// ContextualBackend is implemented by backends that take the context of the
+// request each operation is performed for, which carries its request ID and
+// trace context (see the tracing package). The server performs each request's
+// operations on the backend WithContext returns for it.
+type ContextualBackend interface {
+	Backend
+
+	WithContext(ctx context.Context) Backend
+}
@@ -1,9 +1,6 @@
 package garden
 
-import (
-	"fmt"
-	"time"
-)
+import "time"
 
 //go:generate counterfeiter . Client
 
@@ -48,19 +45,85 @@ type Client interface {
 	// * None.
 	Containers(Properties) ([]Container, error)
 
+	// List lists the containers selected by the options, sorted by handle or
+	// by a property, a page at a time.
+	//
+	// Backends without a native implementation may return ErrNotImplemented,
+	// in which case the server filters, sorts and pages containers itself.
+	//
+	// Errors:
+	// * When the cursor is invalid, or was returned for a different sort.
+	List(ListOptions) (ContainerPage, error)
+
 	// Lookup returns the container with the specified handle.
 	//
 	// Errors:
 	// * Container not found.
 	Lookup(handle string) (Container, error)
+
+	// BulkInfo returns information about each of the containers with the
+	// specified handles, keyed by handle. A failure to get the information for
+	// one container is reported in its entry and does not fail the others.
+	//
+	// Backends without a native implementation may return ErrNotImplemented,
+	// in which case the server looks up each container in parallel.
+	//
+	// Errors:
+	// * None.
+	BulkInfo(handles []string) (map[string]ContainerInfoEntry, error)
+
+	// Events streams lifecycle events (create, destroy, reap, stop, OOM, port
+	// mapping and limit changes) of containers matching Properties (which are
+	// ANDed together), until the returned stream is closed.
+	//
+	// The server also publishes events for the requests it handles. An event
+	// the backend publishes for the same container and of the same type shortly
+	// before or after the server's is dropped as a duplicate.
+	//
+	// Backends may return ErrNotImplemented, in which case the server only
+	// streams the events it originates itself.
+	//
+	// Errors:
+	// * None.
+	Events(Properties) (EventStream, error)
 }
 
-type ContainerNotFoundError struct {
-	Handle string
+// ListOptions specifies which containers to list, in which order, and which
+// page of them. All options are optional.
+type ListOptions struct {
+	// Selector selects the containers to list. All containers are listed if
+	// it is empty.
+	Selector Selector
+
+	// SortBy is the property to sort containers by. Containers without it
+	// sort first, and ties are broken by handle. Containers are sorted by
+	// handle if it is empty.
+	SortBy string
+
+	// Limit is the most containers to list in a page. All of the containers
+	// are listed in one page if it is zero.
+	Limit int
+
+	// Cursor is the NextCursor of the previous page, to list the page after
+	// it. The first page is listed if it is empty.
+	Cursor string
+
+	// Summarize lists the state and properties of each container along with
+	// it, in the page's Summaries.
+	Summarize bool
 }
 
-func (err ContainerNotFoundError) Error() string {
-	return fmt.Sprintf("unknown handle: %s", err.Handle)
+// ContainerPage is a page of the containers listed with ListOptions.
+type ContainerPage struct {
+	Containers []Container
+
+	// Summaries holds the summary of each of Containers, in the same order,
+	// if they were listed with Summarize.
+	Summaries []ContainerSummary
+
+	// NextCursor lists the next page when passed as the Cursor of the same
+	// ListOptions. It is empty on the last page.
+	NextCursor string
 }
 
 // ContainerSpec specifies the parameters for creating a container. All parameters are optional.
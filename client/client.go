@@ -50,6 +50,28 @@ func (client *client) Containers(properties garden.Properties) ([]garden.Contain
 	return containers, nil
 }
 
+func (client *client) List(options garden.ListOptions) (garden.ContainerPage, error) {
+	summaries, next, err := client.connection.ListPage(options)
+	if err != nil {
+		return garden.ContainerPage{}, err
+	}
+
+	page := garden.ContainerPage{
+		Containers: []garden.Container{},
+		NextCursor: next,
+	}
+
+	for _, summary := range summaries {
+		page.Containers = append(page.Containers, newContainer(summary.Handle, client.connection))
+	}
+
+	if options.Summarize {
+		page.Summaries = summaries
+	}
+
+	return page, nil
+}
+
 func (client *client) Destroy(handle string) error {
 	err := client.connection.Destroy(handle)
 
@@ -61,6 +83,20 @@ func (client *client) Destroy(handle string) error {
 }
 
 func (client *client) Lookup(handle string) (garden.Container, error) {
+	_, err := client.connection.Lookup(handle)
+	if err, ok := err.(connection.Error); ok && err.StatusCode == 404 {
+		// server predates the lookup route
+		return client.lookupByListing(handle)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newContainer(handle, client.connection), nil
+}
+
+func (client *client) lookupByListing(handle string) (garden.Container, error) {
 	handles, err := client.connection.List(nil)
 	if err != nil {
 		return nil, err
@@ -74,3 +110,11 @@ func (client *client) Lookup(handle string) (garden.Container, error) {
 
 	return nil, garden.ContainerNotFoundError{handle}
 }
+
+func (client *client) BulkInfo(handles []string) (map[string]garden.ContainerInfoEntry, error) {
+	return client.connection.BulkInfo(handles)
+}
+
+func (client *client) Events(properties garden.Properties) (garden.EventStream, error) {
+	return client.connection.Events(properties)
+}
@@ -10,6 +10,7 @@ import (
 	. "github.com/cloudfoundry-incubator/garden/client"
 	"github.com/cloudfoundry-incubator/garden/client/connection"
 	"github.com/cloudfoundry-incubator/garden/client/connection/fakes"
+	gfakes "github.com/cloudfoundry-incubator/garden/fakes"
 )
 
 var _ = Describe("Client", func() {
@@ -119,6 +120,64 @@ var _ = Describe("Client", func() {
 		})
 	})
 
+	Describe("List", func() {
+		It("lists a page of containers", func() {
+			fakeConnection.ListPageReturns([]garden.ContainerSummary{
+				{Handle: "handle-a"},
+				{Handle: "handle-b"},
+			}, "some-cursor", nil)
+
+			options := garden.ListOptions{
+				Selector: garden.Selector{{Key: "owner", Operator: garden.SelectorExists}},
+				SortBy:   "owner",
+				Limit:    2,
+			}
+
+			page, err := client.List(options)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.ListPageArgsForCall(0)).Should(Equal(options))
+
+			Ω(page.Containers).Should(HaveLen(2))
+			Ω(page.Containers[0].Handle()).Should(Equal("handle-a"))
+			Ω(page.Containers[1].Handle()).Should(Equal("handle-b"))
+			Ω(page.NextCursor).Should(Equal("some-cursor"))
+			Ω(page.Summaries).Should(BeNil())
+		})
+
+		It("lists the summary of each container when summarizing", func() {
+			summaries := []garden.ContainerSummary{
+				{Handle: "handle-a", State: "active", Properties: garden.Properties{"owner": "me"}},
+				{Handle: "handle-b", State: "stopped", Properties: garden.Properties{}},
+			}
+
+			fakeConnection.ListPageReturns(summaries, "", nil)
+
+			page, err := client.List(garden.ListOptions{Summarize: true})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.ListPageArgsForCall(0).Summarize).Should(BeTrue())
+
+			Ω(page.Containers).Should(HaveLen(2))
+			Ω(page.Containers[0].Handle()).Should(Equal("handle-a"))
+			Ω(page.Containers[1].Handle()).Should(Equal("handle-b"))
+			Ω(page.Summaries).Should(Equal(summaries))
+		})
+
+		Context("when there is a connection error", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.ListPageReturns(nil, "", disaster)
+			})
+
+			It("returns it", func() {
+				_, err := client.List(garden.ListOptions{})
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("Destroy", func() {
 		It("sends a destroy request", func() {
 			err := client.Destroy("some-handle")
@@ -154,19 +213,78 @@ var _ = Describe("Client", func() {
 		})
 	})
 
+	Describe("BulkInfo", func() {
+		It("sends a bulk info request and returns the entries", func() {
+			entries := map[string]garden.ContainerInfoEntry{
+				"handle-a": {Info: garden.ContainerInfo{State: "active"}},
+			}
+
+			fakeConnection.BulkInfoReturns(entries, nil)
+
+			result, err := client.BulkInfo([]string{"handle-a"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(result).Should(Equal(entries))
+
+			Ω(fakeConnection.BulkInfoArgsForCall(0)).Should(Equal([]string{"handle-a"}))
+		})
+
+		Context("when there is a connection error", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.BulkInfoReturns(nil, disaster)
+			})
+
+			It("returns it", func() {
+				_, err := client.BulkInfo([]string{"handle-a"})
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("Events", func() {
+		It("subscribes to events matching the properties", func() {
+			fakeEventStream := new(gfakes.FakeEventStream)
+
+			fakeConnection.EventsReturns(fakeEventStream, nil)
+
+			events, err := client.Events(garden.Properties{"owner": "me"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(events).Should(Equal(fakeEventStream))
+
+			Ω(fakeConnection.EventsArgsForCall(0)).Should(Equal(garden.Properties{"owner": "me"}))
+		})
+
+		Context("when there is a connection error", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.EventsReturns(nil, disaster)
+			})
+
+			It("returns it", func() {
+				_, err := client.Events(nil)
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("Lookup", func() {
-		It("sends a list request", func() {
-			fakeConnection.ListReturns([]string{"some-handle", "some-other-handle"}, nil)
+		It("sends a lookup request", func() {
+			fakeConnection.LookupReturns(garden.ContainerSummary{Handle: "some-handle"}, nil)
 
 			container, err := client.Lookup("some-handle")
 			Ω(err).ShouldNot(HaveOccurred())
 
 			Ω(container.Handle()).Should(Equal("some-handle"))
+
+			Ω(fakeConnection.LookupArgsForCall(0)).Should(Equal("some-handle"))
+			Ω(fakeConnection.ListCallCount()).Should(Equal(0))
 		})
 
 		Context("when the container is not found", func() {
 			BeforeEach(func() {
-				fakeConnection.ListReturns([]string{"some-other-handle"}, nil)
+				fakeConnection.LookupReturns(garden.ContainerSummary{}, garden.ContainerNotFoundError{"some-handle"})
 			})
 
 			It("returns ContainerNotFoundError", func() {
@@ -179,7 +297,7 @@ var _ = Describe("Client", func() {
 			disaster := errors.New("oh no!")
 
 			BeforeEach(func() {
-				fakeConnection.ListReturns(nil, disaster)
+				fakeConnection.LookupReturns(garden.ContainerSummary{}, disaster)
 			})
 
 			It("returns it", func() {
@@ -187,5 +305,44 @@ var _ = Describe("Client", func() {
 				Ω(err).Should(Equal(disaster))
 			})
 		})
+
+		Context("when the server does not support lookups", func() {
+			BeforeEach(func() {
+				fakeConnection.LookupReturns(garden.ContainerSummary{}, connection.Error{404, "404 page not found"})
+			})
+
+			It("sends a list request", func() {
+				fakeConnection.ListReturns([]string{"some-handle", "some-other-handle"}, nil)
+
+				container, err := client.Lookup("some-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(container.Handle()).Should(Equal("some-handle"))
+			})
+
+			Context("when the container is not found", func() {
+				BeforeEach(func() {
+					fakeConnection.ListReturns([]string{"some-other-handle"}, nil)
+				})
+
+				It("returns ContainerNotFoundError", func() {
+					_, err := client.Lookup("some-handle")
+					Ω(err).Should(MatchError(garden.ContainerNotFoundError{"some-handle"}))
+				})
+			})
+
+			Context("when there is a connection error", func() {
+				disaster := errors.New("oh no!")
+
+				BeforeEach(func() {
+					fakeConnection.ListReturns(nil, disaster)
+				})
+
+				It("returns it", func() {
+					_, err := client.Lookup("some-handle")
+					Ω(err).Should(Equal(disaster))
+				})
+			})
+		})
 	})
 })
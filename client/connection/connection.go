@@ -3,7 +3,7 @@ package connection
 import (
 	"bufio"
 	"bytes"
-	"encoding/json"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
@@ -13,17 +13,20 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cloudfoundry-incubator/garden"
 	protocol "github.com/cloudfoundry-incubator/garden/protocol"
 	"github.com/cloudfoundry-incubator/garden/routes"
+	"github.com/cloudfoundry-incubator/garden/tracing"
 	"github.com/cloudfoundry-incubator/garden/transport"
 	"github.com/gogo/protobuf/proto"
 	"github.com/tedsuo/rata"
 )
 
-var ErrDisconnected = errors.New("disconnected")
+var ErrDisconnected = garden.ErrDisconnected
 var ErrInvalidMessage = errors.New("invalid message payload")
 
 //go:generate counterfeiter . Connection
@@ -36,14 +39,27 @@ type Connection interface {
 	Create(spec garden.ContainerSpec) (string, error)
 	List(properties garden.Properties) ([]string, error)
 
+	// ListPage lists a page of the containers selected by the options, and
+	// the cursor of the next page, which is empty on the last. Only the
+	// handles of the summaries are set unless the options Summarize. If the
+	// server is too old to understand the options, garden.ErrNotImplemented
+	// is returned.
+	ListPage(options garden.ListOptions) ([]garden.ContainerSummary, string, error)
+
 	// Destroys the container with the given handle. If the container cannot be
-	// found, garden.ContainerNotFoundError is returned. If deletion fails for another
-	// reason, another error type is returned.
+	// found, garden.ContainerNotFoundError is returned. If another destroy of the
+	// same handle is in progress, garden.ConcurrentDestroyError is returned. If
+	// deletion fails for another reason, another error type is returned.
 	Destroy(handle string) error
 
 	Stop(handle string, kill bool) error
 
+	// Lookup returns a summary of the container with the given handle. If the
+	// container cannot be found, garden.ContainerNotFoundError is returned.
+	Lookup(handle string) (garden.ContainerSummary, error)
+
 	Info(handle string) (garden.ContainerInfo, error)
+	BulkInfo(handles []string) (map[string]garden.ContainerInfoEntry, error)
 
 	StreamIn(handle string, dstPath string, reader io.Reader) error
 	StreamOut(handle string, srcPath string) (io.ReadCloser, error)
@@ -60,6 +76,8 @@ type Connection interface {
 
 	Run(handle string, spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error)
 	Attach(handle string, processID uint32, io garden.ProcessIO) (garden.Process, error)
+	AttachFrom(handle string, processID uint32, offset uint64, io garden.ProcessIO) (garden.Process, error)
+	Processes(handle string) ([]garden.ProcessInfo, error)
 
 	NetIn(handle string, hostPort, containerPort uint32) (uint32, uint32, error)
 	NetOut(handle string, rule garden.NetOutRule) error
@@ -67,6 +85,18 @@ type Connection interface {
 	GetProperty(handle string, name string) (string, error)
 	SetProperty(handle string, name string, value string) error
 	RemoveProperty(handle string, name string) error
+
+	// Properties returns all of the properties of the container with the
+	// given handle.
+	Properties(handle string) (garden.Properties, error)
+
+	// SetProperties sets and removes properties of the container with the
+	// given handle atomically, if its properties meet the expected selector.
+	// If they do not, garden.PropertyConflictError is returned. An empty
+	// selector is always met.
+	SetProperties(handle string, expected garden.Selector, properties garden.Properties, removals []string) error
+
+	Events(properties garden.Properties) (garden.EventStream, error)
 }
 
 type connection struct {
@@ -76,8 +106,62 @@ type connection struct {
 
 	httpClient        *http.Client
 	noKeepaliveClient *http.Client
+
+	// set once the server has responded with protobuf, showing that it
+	// accepts it too
+	protobuf int32
+
+	tlsConfig *tls.Config
+
+	// bearer token sent with every request, if any
+	token string
+
+	// the trace context of the caller's current span, if known
+	traceContext func() (tracing.TraceContext, bool)
+
+	// how process streams reattach once disconnected; nil if they do not
+	reattachPolicy *ReattachPolicy
+
+	// whether process streams are multiplexed over a session; cleared if
+	// the server does not support sessions
+	multiplexing bool
+	session      *session
+	sessionL     sync.Mutex
 }
 
+// Option configures optional behaviour of a connection.
+type Option func(*connection)
+
+// WithMultiplexing carries the streams of every process run or attached to
+// over a single connection to the server, rather than dialing one for each.
+// Against servers that do not support it, each process gets its own
+// connection as usual.
+func WithMultiplexing() Option {
+	return func(c *connection) {
+		c.multiplexing = true
+	}
+}
+
+// WithTLS connects to the server over TLS with the config, which should
+// carry the client's certificate if the server requires one. Unless the config
+// names the server, it is named after the address's host; connections over
+// unix sockets must name it.
+func WithTLS(config *tls.Config) Option {
+	return func(c *connection) {
+		c.tlsConfig = config
+	}
+}
+
+// WithToken authenticates every request, including the hijacked ones that
+// process streams are carried over, with the bearer token.
+func WithToken(token string) Option {
+	return func(c *connection) {
+		c.token = token
+	}
+}
+
+// Error is returned for failed requests whose response does not carry a
+// typed error, e.g. from servers predating the JSON error envelope.
 type Error struct {
 	StatusCode int
 	Message    string
@@ -87,28 +171,58 @@ func (err Error) Error() string {
 	return err.Message
 }
 
-func New(network, address string) Connection {
+func New(network, address string, options ...Option) Connection {
+	c := &connection{
+		req: rata.NewRequestGenerator("http://api", routes.Routes),
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
 	dialer := func(string, string) (net.Conn, error) {
 		return net.DialTimeout(network, address, time.Second)
 	}
 
-	return &connection{
-		req: rata.NewRequestGenerator("http://api", routes.Routes),
+	if c.tlsConfig != nil {
+		tlsConfig := tlsConfigFor(network, address, c.tlsConfig)
 
-		dialer: dialer,
+		dialer = func(string, string) (net.Conn, error) {
+			return tls.DialWithDialer(&net.Dialer{Timeout: time.Second}, network, address, tlsConfig)
+		}
+	}
 
-		httpClient: &http.Client{
-			Transport: &http.Transport{
-				Dial: dialer,
-			},
+	c.dialer = dialer
+
+	c.httpClient = &http.Client{
+		Transport: &http.Transport{
+			Dial: dialer,
 		},
-		noKeepaliveClient: &http.Client{
-			Transport: &http.Transport{
-				Dial:              dialer,
-				DisableKeepAlives: true,
-			},
+	}
+
+	c.noKeepaliveClient = &http.Client{
+		Transport: &http.Transport{
+			Dial:              dialer,
+			DisableKeepAlives: true,
 		},
 	}
+
+	return c
+}
+
+// tlsConfigFor returns the config to connect to the address with, naming the
+// server after the address's host unless the config names it already.
+func tlsConfigFor(network, address string, config *tls.Config) *tls.Config {
+	config = config.Clone()
+
+	if config.ServerName == "" && network != "unix" {
+		host, _, err := net.SplitHostPort(address)
+		if err == nil {
+			config.ServerName = host
+		}
+	}
+
+	return config
 }
 
 func (c *connection) Ping() error {
@@ -131,6 +245,16 @@ func (c *connection) Capacity() (garden.Capacity, error) {
 }
 
 func (c *connection) Create(spec garden.ContainerSpec) (string, error) {
+	res := &protocol.CreateResponse{}
+	err := c.do(routes.Create, createRequest(spec), res, nil, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return res.GetHandle(), nil
+}
+
+func createRequest(spec garden.ContainerSpec) *protocol.CreateRequest {
 	req := &protocol.CreateRequest{}
 
 	if spec.Handle != "" {
@@ -181,23 +305,9 @@ func (c *connection) Create(spec garden.ContainerSpec) (string, error) {
 		})
 	}
 
-	props := []*protocol.Property{}
-	for key, val := range spec.Properties {
-		props = append(props, &protocol.Property{
-			Key:   proto.String(key),
-			Value: proto.String(val),
-		})
-	}
-
-	req.Properties = props
-
-	res := &protocol.CreateResponse{}
-	err := c.do(routes.Create, req, res, nil, nil)
-	if err != nil {
-		return "", err
-	}
+	req.Properties = propertyMessages(spec.Properties)
 
-	return res.GetHandle(), nil
+	return req
 }
 
 func (c *connection) Stop(handle string, kill bool) error {
@@ -228,8 +338,67 @@ func (c *connection) Destroy(handle string) error {
 }
 
 func (c *connection) Run(handle string, spec garden.ProcessSpec, processIO garden.ProcessIO) (garden.Process, error) {
+	request := runRequest(handle, spec)
+
+	session, err := c.processSession()
+	if err != nil {
+		return nil, err
+	}
+
+	if session != nil {
+		stream, startedID, err := session.Open(&protocol.ProcessPayload_Open{Run: request}, 0)
+		if err != nil && startedID != 0 {
+			// the process is running, but another stream in the session
+			// has its ID; stream it on its own connection instead
+			return c.attach(handle, startedID, proto.Uint64(0), processIO)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return c.streamInSession(handle, session, stream, processIO), nil
+	}
+
 	reqBody := new(bytes.Buffer)
 
+	contentType := c.contentType()
+
+	err = transport.NewMessageWriter(reqBody, contentType).WriteMessage(request)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, br, header, err := c.doHijack(
+		routes.Run,
+		reqBody,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+		processStreamHeader(contentType),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := transport.NewMessageReader(br, header.Get("Content-Type"))
+
+	firstResponse := &protocol.ProcessPayload{}
+	err = reader.ReadMessage(firstResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	p := newProcess(firstResponse.GetProcessId(), conn, transport.NewMessageWriter(conn, header.Get("Content-Type")), header)
+	p.reattach = c.reattacher(handle)
+
+	go p.streamPayloads(reader, processIO)
+
+	return p, nil
+}
+
+func runRequest(handle string, spec garden.ProcessSpec) *protocol.RunRequest {
 	var dir *string
 	if spec.Dir != "" {
 		dir = proto.String(spec.Dir)
@@ -247,7 +416,7 @@ func (c *connection) Run(handle string, spec garden.ProcessSpec, processIO garde
 		}
 	}
 
-	err := transport.WriteMessage(reqBody, &protocol.RunRequest{
+	return &protocol.RunRequest{
 		Handle:     proto.String(handle),
 		Path:       proto.String(spec.Path),
 		Args:       spec.Args,
@@ -273,72 +442,191 @@ func (c *connection) Run(handle string, spec garden.ProcessSpec, processIO garde
 			Stack:      spec.Limits.Stack,
 		},
 		Env: convertEnvironmentVariables(spec.Env),
-	})
+	}
+}
+
+func (c *connection) Attach(handle string, processID uint32, processIO garden.ProcessIO) (garden.Process, error) {
+	return c.attach(handle, processID, nil, processIO)
+}
+
+func (c *connection) AttachFrom(handle string, processID uint32, offset uint64, processIO garden.ProcessIO) (garden.Process, error) {
+	return c.attach(handle, processID, &offset, processIO)
+}
+
+// attach attaches to the process, replaying its output from offset if one is
+// given.
+func (c *connection) attach(handle string, processID uint32, offset *uint64, processIO garden.ProcessIO) (garden.Process, error) {
+	request := &protocol.AttachRequest{
+		Handle:    proto.String(handle),
+		ProcessId: proto.Uint32(processID),
+	}
+
+	session, err := c.processSession()
 	if err != nil {
 		return nil, err
 	}
 
-	conn, br, err := c.doHijack(
-		routes.Run,
+	if session != nil {
+		open := &protocol.ProcessPayload_Open{
+			Attach: request,
+			Offset: offset,
+		}
+
+		stream, _, err := session.Open(open, processID)
+		if err == nil {
+			return c.streamInSession(handle, session, stream, processIO), nil
+		}
+
+		if err != errStreamInUse {
+			return nil, err
+		}
+
+		// the session already streams the process; attach to it on its own
+		// connection
+	}
+
+	reqBody := new(bytes.Buffer)
+
+	err = transport.WriteMessage(reqBody, request)
+	if err != nil {
+		return nil, err
+	}
+
+	var query url.Values
+	if offset != nil {
+		query = url.Values{"offset": []string{fmt.Sprintf("%d", *offset)}}
+	}
+
+	conn, br, header, err := c.doHijack(
+		routes.Attach,
 		reqBody,
 		rata.Params{
 			"handle": handle,
+			"pid":    fmt.Sprintf("%d", processID),
 		},
-		nil,
-		"application/json",
+		query,
+		processStreamHeader(""),
 	)
+
 	if err != nil {
 		return nil, err
 	}
 
-	decoder := json.NewDecoder(br)
-
-	firstResponse := &protocol.ProcessPayload{}
-	err = decoder.Decode(firstResponse)
-	if err != nil {
-		return nil, err
+	if query != nil && !transport.HasStreamFeature(header, transport.FeatureOutputReplay) {
+		// an older server attached without replaying anything
+		conn.Close()
+		return nil, garden.ErrNotImplemented
 	}
 
-	p := newProcess(firstResponse.GetProcessId(), conn)
+	p := newProcess(processID, conn, transport.NewMessageWriter(conn, header.Get("Content-Type")), header)
+	p.reattach = c.reattacher(handle)
 
-	go p.streamPayloads(decoder, processIO)
+	go p.streamPayloads(transport.NewMessageReader(br, header.Get("Content-Type")), processIO)
 
 	return p, nil
 }
 
-func (c *connection) Attach(handle string, processID uint32, processIO garden.ProcessIO) (garden.Process, error) {
-	reqBody := new(bytes.Buffer)
+func (c *connection) streamInSession(handle string, session *session, stream *sessionStream, processIO garden.ProcessIO) garden.Process {
+	p := newProcess(stream.id, stream, stream, session.header)
+	p.reattach = c.reattacher(handle)
 
-	err := transport.WriteMessage(reqBody, &protocol.AttachRequest{
-		Handle:    proto.String(handle),
-		ProcessId: proto.Uint32(processID),
+	go p.streamPayloads(stream, processIO)
+
+	return p
+}
+
+// reattacher returns how processes in the container reattach once
+// disconnected, if they do.
+func (c *connection) reattacher(handle string) *reattacher {
+	return newReattacher(c.reattachPolicy, handle, func(processID uint32, offset *uint64, processIO garden.ProcessIO) (garden.Process, error) {
+		return c.attach(handle, processID, offset, processIO)
 	})
-	if err != nil {
-		return nil, err
+}
+
+// processSession returns the session to stream processes in, opening one if
+// there is none or it has been disconnected. It returns nil if process
+// streams are not multiplexed.
+func (c *connection) processSession() (*session, error) {
+	c.sessionL.Lock()
+	defer c.sessionL.Unlock()
+
+	if !c.multiplexing {
+		return nil, nil
 	}
 
-	conn, br, err := c.doHijack(
-		routes.Attach,
-		reqBody,
-		rata.Params{
-			"handle": handle,
-			"pid":    fmt.Sprintf("%d", processID),
-		},
+	if c.session != nil && !c.session.Disconnected() {
+		return c.session, nil
+	}
+
+	conn, br, header, err := c.doHijack(
+		routes.Session,
 		nil,
-		"",
+		nil,
+		nil,
+		processStreamHeader(""),
 	)
+	if err != nil {
+		if httpErr, ok := err.(Error); ok && httpErr.StatusCode == http.StatusNotFound {
+			// the server predates sessions
+			c.multiplexing = false
+			return nil, nil
+		}
+
+		return nil, err
+	}
 
+	c.session = newSession(conn, br, header)
+
+	return c.session, nil
+}
+
+func (c *connection) Processes(handle string) ([]garden.ProcessInfo, error) {
+	res := &protocol.ProcessesResponse{}
+
+	err := c.do(routes.Processes, nil, res, rata.Params{"handle": handle}, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	decoder := json.NewDecoder(br)
+	return processInfos(res), nil
+}
 
-	p := newProcess(processID, conn)
+func processInfos(res *protocol.ProcessesResponse) []garden.ProcessInfo {
+	processes := []garden.ProcessInfo{}
+	for _, process := range res.GetProcesses() {
+		env := []string{}
+		for _, variable := range process.GetEnv() {
+			env = append(env, variable.GetKey()+"="+variable.GetValue())
+		}
 
-	go p.streamPayloads(decoder, processIO)
+		var startedAt time.Time
+		if process.StartedAt != nil {
+			startedAt = time.Unix(0, process.GetStartedAt())
+		}
 
-	return p, nil
+		state := garden.ProcessStateRunning
+		if process.GetState() == protocol.ProcessesResponse_ProcessInfo_exited {
+			state = garden.ProcessStateExited
+		}
+
+		processes = append(processes, garden.ProcessInfo{
+			ID: process.GetProcessId(),
+			Spec: garden.ProcessSpec{
+				Path:       process.GetPath(),
+				Args:       process.GetArgs(),
+				Dir:        process.GetDir(),
+				User:       process.GetUser(),
+				Privileged: process.GetPrivileged(),
+				Env:        env,
+			},
+			StartedAt:       startedAt,
+			State:           state,
+			ExitStatus:      int(process.GetExitStatus()),
+			AttachedStreams: int(process.GetAttachedStreams()),
+		})
+	}
+
+	return processes
 }
 
 func (c *connection) NetIn(handle string, hostPort, containerPort uint32) (uint32, uint32, error) {
@@ -366,7 +654,23 @@ func (c *connection) NetIn(handle string, hostPort, containerPort uint32) (uint3
 }
 
 func (c *connection) NetOut(handle string, rule garden.NetOutRule) error {
+	req, err := netOutRequest(handle, rule)
+	if err != nil {
+		return err
+	}
 
+	return c.do(
+		routes.NetOut,
+		req,
+		&protocol.NetOutResponse{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
+func netOutRequest(handle string, rule garden.NetOutRule) (*protocol.NetOutRequest, error) {
 	var np protocol.NetOutRequest_Protocol
 
 	switch rule.Protocol {
@@ -379,7 +683,7 @@ func (c *connection) NetOut(handle string, rule garden.NetOutRule) error {
 	case garden.ProtocolAll:
 		np = protocol.NetOutRequest_ALL
 	default:
-		return errors.New("invalid protocol")
+		return nil, errors.New("invalid protocol")
 	}
 
 	var networks []*protocol.NetOutRequest_IPRange
@@ -408,22 +712,14 @@ func (c *connection) NetOut(handle string, rule garden.NetOutRule) error {
 		}
 	}
 
-	return c.do(
-		routes.NetOut,
-		&protocol.NetOutRequest{
-			Handle:   proto.String(handle),
-			Protocol: &np,
-			Networks: networks,
-			Ports:    ports,
-			Icmps:    icmps,
-			Log:      proto.Bool(rule.Log),
-		},
-		&protocol.NetOutResponse{},
-		rata.Params{
-			"handle": handle,
-		},
-		nil,
-	)
+	return &protocol.NetOutRequest{
+		Handle:   proto.String(handle),
+		Protocol: &np,
+		Networks: networks,
+		Ports:    ports,
+		Icmps:    icmps,
+		Log:      proto.Bool(rule.Log),
+	}, nil
 }
 
 func (c *connection) GetProperty(handle string, name string) (string, error) {
@@ -499,6 +795,60 @@ func (c *connection) RemoveProperty(handle string, name string) error {
 	return nil
 }
 
+func (c *connection) Properties(handle string) (garden.Properties, error) {
+	res := &protocol.PropertiesResponse{}
+
+	err := c.do(
+		routes.Properties,
+		nil,
+		res,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return propertiesFrom(res.GetProperties()), nil
+}
+
+func (c *connection) SetProperties(handle string, expected garden.Selector, properties garden.Properties, removals []string) error {
+	res := &protocol.SetPropertiesResponse{}
+
+	err := c.do(
+		routes.SetProperties,
+		setPropertiesRequest(handle, expected, properties, removals),
+		res,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func setPropertiesRequest(handle string, expected garden.Selector, properties garden.Properties, removals []string) *protocol.SetPropertiesRequest {
+	request := &protocol.SetPropertiesRequest{
+		Handle:     proto.String(handle),
+		Properties: propertyMessages(properties),
+		Removals:   removals,
+	}
+
+	if len(expected) > 0 {
+		request.Expected = proto.String(expected.String())
+	}
+
+	return request
+}
+
 func (c *connection) LimitBandwidth(handle string, limits garden.BandwidthLimits) (garden.BandwidthLimits, error) {
 	res := &protocol.LimitBandwidthResponse{}
 
@@ -624,16 +974,7 @@ func (c *connection) LimitDisk(handle string, limits garden.DiskLimits) (garden.
 		return garden.DiskLimits{}, err
 	}
 
-	return garden.DiskLimits{
-		BlockSoft: res.GetBlockSoft(),
-		BlockHard: res.GetBlockHard(),
-
-		InodeSoft: res.GetInodeSoft(),
-		InodeHard: res.GetInodeHard(),
-
-		ByteSoft: res.GetByteSoft(),
-		ByteHard: res.GetByteHard(),
-	}, nil
+	return diskLimits(res), nil
 }
 
 func (c *connection) CurrentDiskLimits(handle string) (garden.DiskLimits, error) {
@@ -653,16 +994,7 @@ func (c *connection) CurrentDiskLimits(handle string) (garden.DiskLimits, error)
 		return garden.DiskLimits{}, err
 	}
 
-	return garden.DiskLimits{
-		BlockSoft: res.GetBlockSoft(),
-		BlockHard: res.GetBlockHard(),
-
-		InodeSoft: res.GetInodeSoft(),
-		InodeHard: res.GetInodeHard(),
-
-		ByteSoft: res.GetByteSoft(),
-		ByteHard: res.GetByteHard(),
-	}, nil
+	return diskLimits(res), nil
 }
 
 func (c *connection) LimitMemory(handle string, limits garden.MemoryLimits) (garden.MemoryLimits, error) {
@@ -713,7 +1045,7 @@ func (c *connection) CurrentMemoryLimits(handle string) (garden.MemoryLimits, er
 }
 
 func (c *connection) StreamIn(handle string, dstPath string, reader io.Reader) error {
-	body, err := c.doStream(
+	response, err := c.doStream(
 		routes.StreamIn,
 		reader,
 		rata.Params{
@@ -722,17 +1054,17 @@ func (c *connection) StreamIn(handle string, dstPath string, reader io.Reader) e
 		url.Values{
 			"destination": []string{dstPath},
 		},
-		"application/x-tar",
+		http.Header{"Content-Type": {"application/x-tar"}},
 	)
 	if err != nil {
 		return err
 	}
 
-	return body.Close()
+	return response.Body.Close()
 }
 
 func (c *connection) StreamOut(handle string, srcPath string) (io.ReadCloser, error) {
-	return c.doStream(
+	response, err := c.doStream(
 		routes.StreamOut,
 		nil,
 		rata.Params{
@@ -741,8 +1073,13 @@ func (c *connection) StreamOut(handle string, srcPath string) (io.ReadCloser, er
 		url.Values{
 			"source": []string{srcPath},
 		},
-		"",
+		nil,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Body, nil
 }
 
 func (c *connection) List(filterProperties garden.Properties) ([]string, error) {
@@ -767,6 +1104,108 @@ func (c *connection) List(filterProperties garden.Properties) ([]string, error)
 	return res.GetHandles(), nil
 }
 
+func (c *connection) ListPage(options garden.ListOptions) ([]garden.ContainerSummary, string, error) {
+	values := url.Values{}
+	if len(options.Selector) > 0 {
+		values.Set("$selector", options.Selector.String())
+	}
+
+	if options.SortBy != "" {
+		values.Set("$sort_by", options.SortBy)
+	}
+
+	if options.Limit > 0 {
+		values.Set("$limit", fmt.Sprintf("%d", options.Limit))
+	}
+
+	if options.Cursor != "" {
+		values.Set("$cursor", options.Cursor)
+	}
+
+	if options.Summarize {
+		values.Set("$summarize", "true")
+	}
+
+	res := &protocol.ListResponse{}
+
+	header, err := c.doWithHeader(
+		routes.List,
+		nil,
+		res,
+		nil,
+		values,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// older servers take the options for properties, and list no containers
+	if len(values) > 0 && header.Get(transport.ListOptionsHeader) == "" {
+		return nil, "", garden.ErrNotImplemented
+	}
+
+	return containerSummaries(res), res.GetNextCursor(), nil
+}
+
+// containerSummaries returns the summaries of the containers listed, or just
+// their handles if they were not summarized.
+func containerSummaries(res *protocol.ListResponse) []garden.ContainerSummary {
+	if len(res.GetSummaries()) > 0 {
+		summaries := make([]garden.ContainerSummary, len(res.GetSummaries()))
+		for i, summary := range res.GetSummaries() {
+			summaries[i] = garden.ContainerSummary{
+				Handle:     summary.GetHandle(),
+				State:      summary.GetState(),
+				Properties: propertiesFrom(summary.GetProperties()),
+			}
+		}
+
+		return summaries
+	}
+
+	summaries := make([]garden.ContainerSummary, len(res.GetHandles()))
+	for i, handle := range res.GetHandles() {
+		summaries[i] = garden.ContainerSummary{Handle: handle}
+	}
+
+	return summaries
+}
+
+func (c *connection) Events(filterProperties garden.Properties) (garden.EventStream, error) {
+	values := url.Values{}
+	for name, val := range filterProperties {
+		values[name] = []string{val}
+	}
+
+	conn, br, header, err := c.doHijack(
+		routes.Events,
+		nil,
+		nil,
+		values,
+		http.Header{"Accept": {transport.AcceptHeader}},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return newEventStream(conn, transport.NewMessageReader(br, header.Get("Content-Type"))), nil
+}
+
+func (c *connection) Lookup(handle string) (garden.ContainerSummary, error) {
+	res := &protocol.LookupResponse{}
+
+	err := c.do(routes.Lookup, nil, res, rata.Params{"handle": handle}, nil)
+	if err != nil {
+		return garden.ContainerSummary{}, err
+	}
+
+	return garden.ContainerSummary{
+		Handle:     res.GetHandle(),
+		State:      res.GetState(),
+		Properties: propertiesFrom(res.GetProperties()),
+	}, nil
+}
+
 func (c *connection) Info(handle string) (garden.ContainerInfo, error) {
 	res := &protocol.InfoResponse{}
 
@@ -775,6 +1214,46 @@ func (c *connection) Info(handle string) (garden.ContainerInfo, error) {
 		return garden.ContainerInfo{}, err
 	}
 
+	return containerInfo(res), nil
+}
+
+func (c *connection) BulkInfo(handles []string) (map[string]garden.ContainerInfoEntry, error) {
+	res := &protocol.BulkInfoResponse{}
+
+	err := c.do(
+		routes.BulkInfo,
+		nil,
+		res,
+		nil,
+		url.Values{
+			"handles": handles,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return containerInfoEntries(res), nil
+}
+
+func containerInfoEntries(res *protocol.BulkInfoResponse) map[string]garden.ContainerInfoEntry {
+	entries := map[string]garden.ContainerInfoEntry{}
+	for _, entry := range res.GetContainers() {
+		if entry.Error != nil {
+			entries[entry.GetHandle()] = garden.ContainerInfoEntry{
+				Err: typedError(0, entry.GetError()),
+			}
+		} else {
+			entries[entry.GetHandle()] = garden.ContainerInfoEntry{
+				Info: containerInfo(entry.GetInfo()),
+			}
+		}
+	}
+
+	return entries
+}
+
+func containerInfo(res *protocol.InfoResponse) garden.ContainerInfo {
 	processIDs := []uint32{}
 	for _, pid := range res.GetProcessIds() {
 		processIDs = append(processIDs, uint32(pid))
@@ -862,7 +1341,42 @@ func (c *connection) Info(handle string) (garden.ContainerInfo, error) {
 		},
 
 		MappedPorts: mappedPorts,
-	}, nil
+	}
+}
+
+func diskLimits(res *protocol.LimitDiskResponse) garden.DiskLimits {
+	return garden.DiskLimits{
+		BlockSoft: res.GetBlockSoft(),
+		BlockHard: res.GetBlockHard(),
+
+		InodeSoft: res.GetInodeSoft(),
+		InodeHard: res.GetInodeHard(),
+
+		ByteSoft: res.GetByteSoft(),
+		ByteHard: res.GetByteHard(),
+	}
+}
+
+func propertiesFrom(props []*protocol.Property) garden.Properties {
+	properties := garden.Properties{}
+
+	for _, prop := range props {
+		properties[prop.GetKey()] = prop.GetValue()
+	}
+
+	return properties
+}
+
+func propertyMessages(properties garden.Properties) []*protocol.Property {
+	props := []*protocol.Property{}
+	for key, val := range properties {
+		props = append(props, &protocol.Property{
+			Key:   proto.String(key),
+			Value: proto.String(val),
+		})
+	}
+
+	return props
 }
 
 func convertEnvironmentVariables(environmentVariables []string) []*protocol.EnvironmentVariable {
@@ -891,22 +1405,34 @@ func (c *connection) do(
 	params rata.Params,
 	query url.Values,
 ) error {
+	_, err := c.doWithHeader(handler, req, res, params, query)
+	return err
+}
+
+// doWithHeader is do, also returning the header of the response.
+func (c *connection) doWithHeader(
+	handler string,
+	req, res proto.Message,
+	params rata.Params,
+	query url.Values,
+) (http.Header, error) {
 	var body io.Reader
 
+	header := http.Header{"Accept": {transport.AcceptHeader}}
+
 	if req != nil {
+		contentType := c.contentType()
+
 		buf := new(bytes.Buffer)
 
-		err := transport.WriteMessage(buf, req)
+		err := transport.NewMessageWriter(buf, contentType).WriteMessage(req)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		body = buf
-	}
 
-	contentType := ""
-	if req != nil {
-		contentType = "application/json"
+		header.Set("Content-Type", contentType)
 	}
 
 	response, err := c.doStream(
@@ -914,15 +1440,15 @@ func (c *connection) do(
 		body,
 		params,
 		query,
-		contentType,
+		header,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	defer response.Close()
+	defer response.Body.Close()
 
-	return json.NewDecoder(response).Decode(res)
+	return response.Header, transport.NewMessageReader(response.Body, response.Header.Get("Content-Type")).ReadMessage(res)
 }
 
 func (c *connection) doStream(
@@ -930,15 +1456,23 @@ func (c *connection) doStream(
 	body io.Reader,
 	params rata.Params,
 	query url.Values,
-	contentType string,
-) (io.ReadCloser, error) {
+	header http.Header,
+) (*http.Response, error) {
 	request, err := c.req.CreateRequest(handler, params, body)
 	if err != nil {
 		return nil, err
 	}
 
-	if contentType != "" {
-		request.Header.Set("Content-Type", contentType)
+	for name, values := range header {
+		request.Header[name] = values
+	}
+
+	if c.token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	for name, value := range c.traceHeaders() {
+		request.Header.Set(name, value)
 	}
 
 	if query != nil {
@@ -950,17 +1484,13 @@ func (c *connection) doStream(
 		return nil, err
 	}
 
-	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
-		errResponse, err := ioutil.ReadAll(httpResp.Body)
-		httpResp.Body.Close()
-		if err != nil {
-			return nil, fmt.Errorf("bad response: %s", httpResp.Status)
-		}
+	c.noteContentType(httpResp.Header)
 
-		return nil, Error{httpResp.StatusCode, string(errResponse)}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		return nil, errorFromResponse(httpResp)
 	}
 
-	return httpResp.Body, nil
+	return httpResp, nil
 }
 
 func (c *connection) doHijack(
@@ -968,15 +1498,23 @@ func (c *connection) doHijack(
 	body io.Reader,
 	params rata.Params,
 	query url.Values,
-	contentType string,
-) (net.Conn, *bufio.Reader, error) {
+	header http.Header,
+) (net.Conn, *bufio.Reader, http.Header, error) {
 	request, err := c.req.CreateRequest(handler, params, body)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
-	if contentType != "" {
-		request.Header.Set("Content-Type", contentType)
+	for name, values := range header {
+		request.Header[name] = values
+	}
+
+	if c.token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	for name, value := range c.traceHeaders() {
+		request.Header.Set(name, value)
 	}
 
 	if query != nil {
@@ -985,22 +1523,86 @@ func (c *connection) doHijack(
 
 	conn, err := c.dialer("tcp", "api") // net/addr don't matter here
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	client := httputil.NewClientConn(conn, nil)
 
 	httpResp, err := client.Do(request)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
+	c.noteContentType(httpResp.Header)
+
 	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
-		httpResp.Body.Close()
-		return nil, nil, fmt.Errorf("bad response: %s", httpResp.Status)
+		return nil, nil, nil, errorFromResponse(httpResp)
 	}
 
 	conn, br := client.Hijack()
 
-	return conn, br, nil
+	return conn, br, httpResp.Header, nil
+}
+
+// contentType returns the content type to encode requests in.
+func (c *connection) contentType() string {
+	if atomic.LoadInt32(&c.protobuf) == 1 {
+		return transport.ProtobufContentType
+	}
+
+	return transport.JSONContentType
+}
+
+func (c *connection) noteContentType(header http.Header) {
+	if header.Get("Content-Type") == transport.ProtobufContentType {
+		atomic.StoreInt32(&c.protobuf, 1)
+	}
+}
+
+func errorFromResponse(httpResp *http.Response) error {
+	body, err := ioutil.ReadAll(httpResp.Body)
+	httpResp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("bad response: %s", httpResp.Status)
+	}
+
+	contentType := httpResp.Header.Get("Content-Type")
+	if contentType != transport.JSONContentType && contentType != transport.ProtobufContentType {
+		return Error{httpResp.StatusCode, string(body)}
+	}
+
+	var errResponse protocol.ErrorResponse
+	err = transport.NewMessageReader(bytes.NewReader(body), contentType).ReadMessage(&errResponse)
+	if err != nil {
+		return Error{httpResp.StatusCode, string(body)}
+	}
+
+	return typedError(httpResp.StatusCode, &errResponse)
+}
+
+func typedError(statusCode int, errResponse *protocol.ErrorResponse) error {
+	message := errResponse.GetMessage()
+
+	switch errResponse.GetType() {
+	case protocol.ErrorResponse_ContainerNotFound:
+		return garden.ContainerNotFoundError{Handle: errResponse.GetData()}
+	case protocol.ErrorResponse_ConcurrentDestroy:
+		return garden.ConcurrentDestroyError{Handle: errResponse.GetData()}
+	case protocol.ErrorResponse_InvalidContentType:
+		return garden.InvalidContentTypeError{ContentType: errResponse.GetData()}
+	case protocol.ErrorResponse_CapacityExhausted:
+		return garden.CapacityExhaustedError{Message: message}
+	case protocol.ErrorResponse_BackendFailure:
+		return garden.BackendError{Message: message}
+	case protocol.ErrorResponse_Unauthenticated:
+		return garden.UnauthenticatedError{Message: message}
+	case protocol.ErrorResponse_Forbidden:
+		return garden.ForbiddenError{Operation: errResponse.GetData()}
+	case protocol.ErrorResponse_Draining:
+		return garden.DrainingError{}
+	case protocol.ErrorResponse_PropertyConflict:
+		return garden.PropertyConflictError{Requirement: errResponse.GetData()}
+	}
+
+	return Error{statusCode, message}
 }
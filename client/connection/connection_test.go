@@ -9,6 +9,8 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gogo/protobuf/proto"
@@ -20,6 +22,7 @@ import (
 	"github.com/cloudfoundry-incubator/garden"
 	. "github.com/cloudfoundry-incubator/garden/client/connection"
 	protocol "github.com/cloudfoundry-incubator/garden/protocol"
+	"github.com/cloudfoundry-incubator/garden/tracing"
 	"github.com/cloudfoundry-incubator/garden/transport"
 )
 
@@ -108,6 +111,46 @@ var _ = Describe("Connection", func() {
 				Ω(err).Should(HaveOccurred())
 			})
 		})
+
+		Context("with a trace context", func() {
+			var caller tracing.TraceContext
+
+			BeforeEach(func() {
+				caller = tracing.NewTraceContext()
+				caller.State = "vendor=value"
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/ping"),
+						func(w http.ResponseWriter, r *http.Request) {
+							Ω(r.Header.Get("X-Request-Id")).Should(HaveLen(32))
+							Ω(r.Header.Get("Tracestate")).Should(Equal("vendor=value"))
+
+							tc, ok := tracing.ParseTraceParent(r.Header.Get("Traceparent"), "")
+							Ω(ok).Should(BeTrue())
+							Ω(tc.TraceID).Should(Equal(caller.TraceID))
+							Ω(tc.SpanID).ShouldNot(Equal(caller.SpanID))
+						},
+						ghttp.RespondWith(200, marshalProto(&protocol.PingResponse{})),
+					),
+				)
+			})
+
+			JustBeforeEach(func() {
+				connection = New(
+					"tcp",
+					server.HTTPTestServer.Listener.Addr().String(),
+					WithTraceContext(func() (tracing.TraceContext, bool) {
+						return caller, true
+					}),
+				)
+			})
+
+			It("sends the request as a span within the caller's trace", func() {
+				err := connection.Ping()
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
 	})
 
 	Describe("Getting capacity", func() {
@@ -255,6 +298,109 @@ var _ = Describe("Connection", func() {
 				Ω(err).Should(MatchError(Error{423, "some error"}))
 			})
 		})
+
+		Context("when the server responds with a typed error", func() {
+			var errResponse *protocol.ErrorResponse
+
+			BeforeEach(func() {
+				errResponse = &protocol.ErrorResponse{}
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("DELETE", "/containers/foo"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.Header().Set("Content-Type", "application/json")
+							w.WriteHeader(http.StatusInternalServerError)
+							transport.WriteMessage(w, errResponse)
+						},
+					),
+				)
+			})
+
+			Context("of type ContainerNotFound", func() {
+				BeforeEach(func() {
+					errResponse.Type = protocol.ErrorResponse_ContainerNotFound.Enum()
+					errResponse.Message = proto.String("unknown handle: foo")
+					errResponse.Data = proto.String("foo")
+				})
+
+				It("returns a ContainerNotFoundError", func() {
+					err := connection.Destroy("foo")
+					Ω(err).Should(Equal(garden.ContainerNotFoundError{Handle: "foo"}))
+				})
+			})
+
+			Context("of type ConcurrentDestroy", func() {
+				BeforeEach(func() {
+					errResponse.Type = protocol.ErrorResponse_ConcurrentDestroy.Enum()
+					errResponse.Data = proto.String("foo")
+				})
+
+				It("returns a ConcurrentDestroyError", func() {
+					err := connection.Destroy("foo")
+					Ω(err).Should(Equal(garden.ConcurrentDestroyError{Handle: "foo"}))
+				})
+			})
+
+			Context("of type InvalidContentType", func() {
+				BeforeEach(func() {
+					errResponse.Type = protocol.ErrorResponse_InvalidContentType.Enum()
+					errResponse.Data = proto.String("text/plain")
+				})
+
+				It("returns an InvalidContentTypeError", func() {
+					err := connection.Destroy("foo")
+					Ω(err).Should(Equal(garden.InvalidContentTypeError{ContentType: "text/plain"}))
+				})
+			})
+
+			Context("of type CapacityExhausted", func() {
+				BeforeEach(func() {
+					errResponse.Type = protocol.ErrorResponse_CapacityExhausted.Enum()
+					errResponse.Message = proto.String("out of subnets")
+				})
+
+				It("returns a CapacityExhaustedError", func() {
+					err := connection.Destroy("foo")
+					Ω(err).Should(Equal(garden.CapacityExhaustedError{Message: "out of subnets"}))
+				})
+			})
+
+			Context("of type BackendFailure", func() {
+				BeforeEach(func() {
+					errResponse.Type = protocol.ErrorResponse_BackendFailure.Enum()
+					errResponse.Message = proto.String("oh no!")
+				})
+
+				It("returns a BackendError", func() {
+					err := connection.Destroy("foo")
+					Ω(err).Should(Equal(garden.BackendError{Message: "oh no!"}))
+				})
+			})
+
+			Context("of type Draining", func() {
+				BeforeEach(func() {
+					errResponse.Type = protocol.ErrorResponse_Draining.Enum()
+					errResponse.Message = proto.String("server is draining")
+				})
+
+				It("returns a DrainingError", func() {
+					err := connection.Destroy("foo")
+					Ω(err).Should(Equal(garden.DrainingError{}))
+				})
+			})
+
+			Context("of an unknown type", func() {
+				BeforeEach(func() {
+					errResponse.Message = proto.String("bad request")
+				})
+
+				It("returns an Error with the code and message", func() {
+					err := connection.Destroy("foo")
+					Ω(err).Should(Equal(Error{500, "bad request"}))
+				})
+			})
+		})
 	})
 
 	Describe("Stopping", func() {
@@ -275,6 +421,83 @@ var _ = Describe("Connection", func() {
 		})
 	})
 
+	Describe("Getting properties", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo/properties"),
+					ghttp.RespondWith(200, marshalProto(&protocol.PropertiesResponse{
+						Properties: []*protocol.Property{
+							{Key: proto.String("owner"), Value: proto.String("me")},
+						},
+					}))))
+		})
+
+		It("should return all of the container's properties", func() {
+			properties, err := connection.Properties("foo")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(properties).Should(Equal(garden.Properties{"owner": "me"}))
+		})
+	})
+
+	Describe("Setting properties", func() {
+		var errResponse *protocol.ErrorResponse
+
+		BeforeEach(func() {
+			errResponse = nil
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PATCH", "/containers/foo/properties"),
+					verifyProtoBody(&protocol.SetPropertiesRequest{
+						Handle: proto.String("foo"),
+						Properties: []*protocol.Property{
+							{Key: proto.String("owner"), Value: proto.String("me")},
+						},
+						Removals: []string{"queued"},
+						Expected: proto.String("!owner,queued"),
+					}),
+					func(w http.ResponseWriter, r *http.Request) {
+						w.Header().Set("Content-Type", "application/json")
+
+						if errResponse != nil {
+							w.WriteHeader(http.StatusConflict)
+							transport.WriteMessage(w, errResponse)
+							return
+						}
+
+						transport.WriteMessage(w, &protocol.SetPropertiesResponse{})
+					},
+				),
+			)
+		})
+
+		expected := garden.Selector{
+			{Key: "owner", Operator: garden.SelectorNotExists},
+			{Key: "queued", Operator: garden.SelectorExists},
+		}
+
+		It("should send the expected selector with the changes", func() {
+			err := connection.SetProperties("foo", expected, garden.Properties{"owner": "me"}, []string{"queued"})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		Context("when the properties do not meet the selector", func() {
+			BeforeEach(func() {
+				errResponse = &protocol.ErrorResponse{
+					Type:    protocol.ErrorResponse_PropertyConflict.Enum(),
+					Message: proto.String("property conflict: !owner not met"),
+					Data:    proto.String("!owner"),
+				}
+			})
+
+			It("returns a PropertyConflictError", func() {
+				err := connection.SetProperties("foo", expected, garden.Properties{"owner": "me"}, []string{"queued"})
+				Ω(err).Should(Equal(garden.PropertyConflictError{Requirement: "!owner"}))
+			})
+		})
+	})
+
 	Describe("Limiting Memory", func() {
 		Describe("setting the memory limit", func() {
 			BeforeEach(func() {
@@ -511,6 +734,68 @@ var _ = Describe("Connection", func() {
 		})
 	})
 
+	Describe("Listing processes", func() {
+		BeforeEach(func() {
+			exited := protocol.ProcessesResponse_ProcessInfo_exited
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo-handle/processes"),
+					ghttp.RespondWith(200, marshalProto(&protocol.ProcessesResponse{
+						Processes: []*protocol.ProcessesResponse_ProcessInfo{
+							{
+								ProcessId:  proto.Uint32(42),
+								Path:       proto.String("/some/script"),
+								Args:       []string{"arg1"},
+								Dir:        proto.String("/some/dir"),
+								User:       proto.String("alice"),
+								Privileged: proto.Bool(true),
+								Env: []*protocol.EnvironmentVariable{
+									{Key: proto.String("PASSWORD"), Value: proto.String("[redacted]")},
+								},
+								StartedAt:       proto.Int64(time.Unix(123, 456).UnixNano()),
+								State:           &exited,
+								ExitStatus:      proto.Uint32(2),
+								AttachedStreams: proto.Uint32(1),
+							},
+							{
+								ProcessId: proto.Uint32(43),
+							},
+						},
+					}))))
+		})
+
+		It("returns the processes", func() {
+			processes, err := connection.Processes("foo-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(processes).Should(Equal([]garden.ProcessInfo{
+				{
+					ID: 42,
+					Spec: garden.ProcessSpec{
+						Path:       "/some/script",
+						Args:       []string{"arg1"},
+						Dir:        "/some/dir",
+						User:       "alice",
+						Privileged: true,
+						Env:        []string{"PASSWORD=[redacted]"},
+					},
+					StartedAt:       time.Unix(123, 456),
+					State:           garden.ProcessStateExited,
+					ExitStatus:      2,
+					AttachedStreams: 1,
+				},
+				{
+					ID: 43,
+					Spec: garden.ProcessSpec{
+						Env: []string{},
+					},
+					State: garden.ProcessStateRunning,
+				},
+			}))
+		})
+	})
+
 	Describe("NetIn", func() {
 		BeforeEach(func() {
 			server.AppendHandlers(
@@ -720,102 +1005,456 @@ var _ = Describe("Connection", func() {
 		})
 	})
 
-	Describe("Getting container info", func() {
+	Describe("Listing a page of containers", func() {
 		BeforeEach(func() {
 			server.AppendHandlers(
 				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("GET", "/containers/some-handle/info"),
-					ghttp.RespondWith(200, marshalProto(&protocol.InfoResponse{
-						State:         proto.String("chilling out"),
-						Events:        []string{"maxing", "relaxing all cool"},
-						HostIp:        proto.String("host-ip"),
-						ContainerIp:   proto.String("container-ip"),
-						ContainerPath: proto.String("container-path"), ProcessIds: []uint64{1, 2}, Properties: []*protocol.Property{
+					ghttp.VerifyRequest("GET", "/containers"),
+					ghttp.VerifyForm(url.Values{
+						"$selector": {"tenant=a,zone in (z1,z2)"},
+						"$sort_by":  {"owner"},
+						"$limit":    {"2"},
+						"$cursor":   {"some-cursor"},
+					}),
+					ghttp.RespondWith(200, marshalProto(&protocol.ListResponse{
+						Handles:    []string{"container1", "container2"},
+						NextCursor: proto.String("next-cursor"),
+					}), http.Header{transport.ListOptionsHeader: {"true"}})))
+		})
+
+		It("should return the page of containers and the cursor of the next", func() {
+			handles, next, err := connection.ListPage(garden.ListOptions{
+				Selector: garden.Selector{
+					{Key: "tenant", Operator: garden.SelectorEquals, Values: []string{"a"}},
+					{Key: "zone", Operator: garden.SelectorIn, Values: []string{"z1", "z2"}},
+				},
+				SortBy: "owner",
+				Limit:  2,
+				Cursor: "some-cursor",
+			})
+
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(handles).Should(Equal([]garden.ContainerSummary{
+				{Handle: "container1"},
+				{Handle: "container2"},
+			}))
+			Ω(next).Should(Equal("next-cursor"))
+		})
+	})
+
+	Describe("Listing a page of container summaries", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers", "%24summarize=true"),
+					ghttp.RespondWith(200, marshalProto(&protocol.ListResponse{
+						Handles: []string{"container1"},
+						Summaries: []*protocol.ListResponse_ContainerSummary{
 							{
-								Key:   proto.String("prop-key"),
-								Value: proto.String("prop-value"),
+								Handle: proto.String("container1"),
+								State:  proto.String("active"),
+								Properties: []*protocol.Property{
+									{Key: proto.String("owner"), Value: proto.String("me")},
+								},
 							},
 						},
+					}), http.Header{transport.ListOptionsHeader: {"true"}})))
+		})
 
-						MemoryStat: &protocol.InfoResponse_MemoryStat{
-							Cache:                   proto.Uint64(1),
-							Rss:                     proto.Uint64(2),
-							MappedFile:              proto.Uint64(3),
-							Pgpgin:                  proto.Uint64(4),
-							Pgpgout:                 proto.Uint64(5),
-							Swap:                    proto.Uint64(6),
-							Pgfault:                 proto.Uint64(7),
-							Pgmajfault:              proto.Uint64(8),
-							InactiveAnon:            proto.Uint64(9),
-							ActiveAnon:              proto.Uint64(10),
-							InactiveFile:            proto.Uint64(11),
-							ActiveFile:              proto.Uint64(12),
-							Unevictable:             proto.Uint64(13),
-							HierarchicalMemoryLimit: proto.Uint64(14),
-							HierarchicalMemswLimit:  proto.Uint64(15),
-							TotalCache:              proto.Uint64(16),
-							TotalRss:                proto.Uint64(17),
-							TotalMappedFile:         proto.Uint64(18),
-							TotalPgpgin:             proto.Uint64(19),
-							TotalPgpgout:            proto.Uint64(20),
-							TotalSwap:               proto.Uint64(21),
-							TotalPgfault:            proto.Uint64(22),
-							TotalPgmajfault:         proto.Uint64(23),
-							TotalInactiveAnon:       proto.Uint64(24),
-							TotalActiveAnon:         proto.Uint64(25),
-							TotalInactiveFile:       proto.Uint64(26),
-							TotalActiveFile:         proto.Uint64(27),
-							TotalUnevictable:        proto.Uint64(28),
-						},
+		It("should return the state and properties of each container", func() {
+			summaries, next, err := connection.ListPage(garden.ListOptions{Summarize: true})
 
-						CpuStat: &protocol.InfoResponse_CpuStat{
-							Usage:  proto.Uint64(1),
-							User:   proto.Uint64(2),
-							System: proto.Uint64(3),
-						},
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(summaries).Should(Equal([]garden.ContainerSummary{
+				{
+					Handle:     "container1",
+					State:      "active",
+					Properties: garden.Properties{"owner": "me"},
+				},
+			}))
+			Ω(next).Should(BeEmpty())
+		})
+	})
 
-						DiskStat: &protocol.InfoResponse_DiskStat{
-							BytesUsed:  proto.Uint64(1),
-							InodesUsed: proto.Uint64(2),
-						},
+	Describe("Listing a page of containers from a server that does not understand the options", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers", "%24limit=2"),
+					ghttp.RespondWith(200, marshalProto(&protocol.ListResponse{
+						Handles: []string{},
+					}))))
+		})
 
-						BandwidthStat: &protocol.InfoResponse_BandwidthStat{
-							InRate:   proto.Uint64(1),
-							InBurst:  proto.Uint64(2),
-							OutRate:  proto.Uint64(3),
-							OutBurst: proto.Uint64(4),
-						},
+		It("should return ErrNotImplemented rather than an empty page", func() {
+			_, _, err := connection.ListPage(garden.ListOptions{Limit: 2})
+			Ω(err).Should(Equal(garden.ErrNotImplemented))
+		})
+	})
 
-						MappedPorts: []*protocol.InfoResponse_PortMapping{
-							&protocol.InfoResponse_PortMapping{
-								HostPort:      proto.Uint32(1234),
-								ContainerPort: proto.Uint32(5678),
+	Describe("Getting info for many containers", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/bulk_info", "handles=handle-a&handles=handle-b"),
+					ghttp.RespondWith(200, marshalProto(&protocol.BulkInfoResponse{
+						Containers: []*protocol.BulkInfoResponse_ContainerInfoEntry{
+							{
+								Handle: proto.String("handle-a"),
+								Info: &protocol.InfoResponse{
+									State:       proto.String("active"),
+									ContainerIp: proto.String("1.2.3.4"),
+								},
 							},
-							&protocol.InfoResponse_PortMapping{
-								HostPort:      proto.Uint32(1235),
-								ContainerPort: proto.Uint32(5679),
+							{
+								Handle: proto.String("handle-b"),
+								Error: &protocol.ErrorResponse{
+									Type:    protocol.ErrorResponse_ContainerNotFound.Enum(),
+									Message: proto.String("unknown handle: handle-b"),
+									Data:    proto.String("handle-b"),
+								},
 							},
 						},
 					}))))
 		})
 
-		It("should return the container's info", func() {
-			info, err := connection.Info("some-handle")
+		It("should return the info or error of each container", func() {
+			entries, err := connection.BulkInfo([]string{"handle-a", "handle-b"})
 			Ω(err).ShouldNot(HaveOccurred())
 
-			Ω(info.State).Should(Equal("chilling out"))
-			Ω(info.Events).Should(Equal([]string{"maxing", "relaxing all cool"}))
-			Ω(info.HostIP).Should(Equal("host-ip"))
-			Ω(info.ContainerIP).Should(Equal("container-ip"))
-			Ω(info.ContainerPath).Should(Equal("container-path"))
-			Ω(info.ProcessIDs).Should(Equal([]uint32{1, 2}))
+			Ω(entries).Should(HaveLen(2))
+			Ω(entries["handle-a"].Err).ShouldNot(HaveOccurred())
+			Ω(entries["handle-a"].Info.State).Should(Equal("active"))
+			Ω(entries["handle-a"].Info.ContainerIP).Should(Equal("1.2.3.4"))
+			Ω(entries["handle-b"].Err).Should(Equal(garden.ContainerNotFoundError{Handle: "handle-b"}))
+		})
+	})
 
-			Ω(info.Properties).Should(Equal(garden.Properties{
-				"prop-key": "prop-value",
-			}))
+	Describe("Streaming events", func() {
+		BeforeEach(func() {
+			create := protocol.Event_create
+			netIn := protocol.Event_net_in
 
-			Ω(info.MemoryStat).Should(Equal(garden.ContainerMemoryStat{
-				Cache:                   1,
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/events", "owner=me"),
+					func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(http.StatusOK)
+
+						conn, _, err := w.(http.Hijacker).Hijack()
+						Ω(err).ShouldNot(HaveOccurred())
+
+						defer conn.Close()
+
+						transport.WriteMessage(conn, &protocol.Event{
+							Type:      &create,
+							Handle:    proto.String("some-handle"),
+							Timestamp: proto.Int64(time.Unix(123, 456).UnixNano()),
+							Properties: []*protocol.Property{
+								{Key: proto.String("owner"), Value: proto.String("me")},
+							},
+						})
+
+						transport.WriteMessage(conn, &protocol.Event{
+							Type:      &netIn,
+							Handle:    proto.String("some-handle"),
+							Timestamp: proto.Int64(time.Unix(124, 0).UnixNano()),
+							Data: []*protocol.Property{
+								{Key: proto.String("host_port"), Value: proto.String("1234")},
+							},
+						})
+					},
+				),
+			)
+		})
+
+		It("streams the events until the server closes the connection", func() {
+			events, err := connection.Events(garden.Properties{"owner": "me"})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			defer events.Close()
+
+			event, err := events.Next()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(event).Should(Equal(garden.Event{
+				Type:       garden.EventTypeCreate,
+				Handle:     "some-handle",
+				Time:       time.Unix(123, 456),
+				Properties: garden.Properties{"owner": "me"},
+				Data:       map[string]string{},
+			}))
+
+			event, err = events.Next()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(event.Type).Should(Equal(garden.EventTypeNetIn))
+			Ω(event.Data).Should(Equal(map[string]string{"host_port": "1234"}))
+
+			_, err = events.Next()
+			Ω(err).Should(Equal(io.EOF))
+		})
+	})
+
+	Describe("Negotiating protobuf", func() {
+		protobufHeader := http.Header{"Content-Type": {transport.ProtobufContentType}}
+
+		marshalProtobuf := func(messages ...proto.Message) string {
+			result := new(bytes.Buffer)
+			for _, msg := range messages {
+				err := transport.WriteProtobufMessage(result, msg)
+				Ω(err).ShouldNot(HaveOccurred())
+			}
+
+			return result.String()
+		}
+
+		Context("when the server responds with protobuf", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/ping"),
+						ghttp.VerifyHeaderKV("Accept", transport.AcceptHeader),
+						ghttp.RespondWith(200, marshalProtobuf(&protocol.PingResponse{}), protobufHeader),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/stop"),
+						ghttp.VerifyHeaderKV("Content-Type", transport.ProtobufContentType),
+						func(w http.ResponseWriter, r *http.Request) {
+							var request protocol.StopRequest
+							err := transport.NewMessageReader(r.Body, transport.ProtobufContentType).ReadMessage(&request)
+							Ω(err).ShouldNot(HaveOccurred())
+
+							Ω(request.GetHandle()).Should(Equal("foo"))
+							Ω(request.GetKill()).Should(BeTrue())
+						},
+						ghttp.RespondWith(200, marshalProtobuf(&protocol.StopResponse{}), protobufHeader),
+					),
+				)
+			})
+
+			It("sends subsequent requests as protobuf", func() {
+				err := connection.Ping()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				err = connection.Stop("foo", true)
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Context("when the server responds with a protobuf error", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.RespondWith(500, marshalProtobuf(&protocol.ErrorResponse{
+						Type:    protocol.ErrorResponse_ContainerNotFound.Enum(),
+						Message: proto.String("unknown handle: foo"),
+						Data:    proto.String("foo"),
+					}), protobufHeader),
+				)
+			})
+
+			It("returns the typed error", func() {
+				err := connection.Stop("foo", true)
+				Ω(err).Should(Equal(garden.ContainerNotFoundError{Handle: "foo"}))
+			})
+		})
+
+		Context("when a process stream is negotiated as protobuf", func() {
+			stdout := protocol.ProcessPayload_stdout
+
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/processes/42"),
+						func(w http.ResponseWriter, r *http.Request) {
+							Ω(transport.NegotiateContentType(r.Header.Get("Accept"))).Should(Equal(transport.ProtobufContentType))
+
+							w.Header().Set("Content-Type", transport.ProtobufContentType)
+							w.WriteHeader(http.StatusOK)
+
+							conn, br, err := w.(http.Hijacker).Hijack()
+							Ω(err).ShouldNot(HaveOccurred())
+
+							defer conn.Close()
+
+							reader := transport.NewMessageReader(br, transport.ProtobufContentType)
+
+							var payload protocol.ProcessPayload
+							for payload.GetData() == "" {
+								payload = protocol.ProcessPayload{}
+
+								err = reader.ReadMessage(&payload)
+								Ω(err).ShouldNot(HaveOccurred())
+							}
+
+							Ω(payload.GetData()).Should(Equal("stdin data"))
+
+							messages := transport.NewMessageWriter(conn, transport.ProtobufContentType)
+							messages.WriteMessage(&protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stdout, Data: proto.String("stdout data")})
+							messages.WriteMessage(&protocol.ProcessPayload{ProcessId: proto.Uint32(42), ExitStatus: proto.Uint32(3)})
+						},
+					),
+				)
+			})
+
+			It("reads and writes payloads as protobuf", func() {
+				stdout := gbytes.NewBuffer()
+
+				process, err := connection.Attach("foo-handle", 42, garden.ProcessIO{
+					Stdin:  bytes.NewBufferString("stdin data"),
+					Stdout: stdout,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				status, err := process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status).Should(Equal(3))
+
+				Ω(stdout).Should(gbytes.Say("stdout data"))
+			})
+		})
+	})
+
+	Describe("Looking up a container", func() {
+		Context("when the container exists", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/some-handle"),
+						ghttp.RespondWith(200, marshalProto(&protocol.LookupResponse{
+							Handle: proto.String("some-handle"),
+							State:  proto.String("active"),
+							Properties: []*protocol.Property{
+								{Key: proto.String("owner"), Value: proto.String("me")},
+							},
+						}))))
+			})
+
+			It("returns a summary of the container", func() {
+				summary, err := connection.Lookup("some-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(summary).Should(Equal(garden.ContainerSummary{
+					Handle:     "some-handle",
+					State:      "active",
+					Properties: garden.Properties{"owner": "me"},
+				}))
+			})
+		})
+
+		Context("when the container does not exist", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/some-handle"),
+						ghttp.RespondWith(404, marshalProto(&protocol.ErrorResponse{
+							Type:    protocol.ErrorResponse_ContainerNotFound.Enum(),
+							Message: proto.String("unknown handle: some-handle"),
+							Data:    proto.String("some-handle"),
+						}), http.Header{"Content-Type": {"application/json"}})))
+			})
+
+			It("returns ContainerNotFoundError", func() {
+				_, err := connection.Lookup("some-handle")
+				Ω(err).Should(Equal(garden.ContainerNotFoundError{Handle: "some-handle"}))
+			})
+		})
+	})
+
+	Describe("Getting container info", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/some-handle/info"),
+					ghttp.RespondWith(200, marshalProto(&protocol.InfoResponse{
+						State:         proto.String("chilling out"),
+						Events:        []string{"maxing", "relaxing all cool"},
+						HostIp:        proto.String("host-ip"),
+						ContainerIp:   proto.String("container-ip"),
+						ContainerPath: proto.String("container-path"), ProcessIds: []uint64{1, 2}, Properties: []*protocol.Property{
+							{
+								Key:   proto.String("prop-key"),
+								Value: proto.String("prop-value"),
+							},
+						},
+
+						MemoryStat: &protocol.InfoResponse_MemoryStat{
+							Cache:                   proto.Uint64(1),
+							Rss:                     proto.Uint64(2),
+							MappedFile:              proto.Uint64(3),
+							Pgpgin:                  proto.Uint64(4),
+							Pgpgout:                 proto.Uint64(5),
+							Swap:                    proto.Uint64(6),
+							Pgfault:                 proto.Uint64(7),
+							Pgmajfault:              proto.Uint64(8),
+							InactiveAnon:            proto.Uint64(9),
+							ActiveAnon:              proto.Uint64(10),
+							InactiveFile:            proto.Uint64(11),
+							ActiveFile:              proto.Uint64(12),
+							Unevictable:             proto.Uint64(13),
+							HierarchicalMemoryLimit: proto.Uint64(14),
+							HierarchicalMemswLimit:  proto.Uint64(15),
+							TotalCache:              proto.Uint64(16),
+							TotalRss:                proto.Uint64(17),
+							TotalMappedFile:         proto.Uint64(18),
+							TotalPgpgin:             proto.Uint64(19),
+							TotalPgpgout:            proto.Uint64(20),
+							TotalSwap:               proto.Uint64(21),
+							TotalPgfault:            proto.Uint64(22),
+							TotalPgmajfault:         proto.Uint64(23),
+							TotalInactiveAnon:       proto.Uint64(24),
+							TotalActiveAnon:         proto.Uint64(25),
+							TotalInactiveFile:       proto.Uint64(26),
+							TotalActiveFile:         proto.Uint64(27),
+							TotalUnevictable:        proto.Uint64(28),
+						},
+
+						CpuStat: &protocol.InfoResponse_CpuStat{
+							Usage:  proto.Uint64(1),
+							User:   proto.Uint64(2),
+							System: proto.Uint64(3),
+						},
+
+						DiskStat: &protocol.InfoResponse_DiskStat{
+							BytesUsed:  proto.Uint64(1),
+							InodesUsed: proto.Uint64(2),
+						},
+
+						BandwidthStat: &protocol.InfoResponse_BandwidthStat{
+							InRate:   proto.Uint64(1),
+							InBurst:  proto.Uint64(2),
+							OutRate:  proto.Uint64(3),
+							OutBurst: proto.Uint64(4),
+						},
+
+						MappedPorts: []*protocol.InfoResponse_PortMapping{
+							&protocol.InfoResponse_PortMapping{
+								HostPort:      proto.Uint32(1234),
+								ContainerPort: proto.Uint32(5678),
+							},
+							&protocol.InfoResponse_PortMapping{
+								HostPort:      proto.Uint32(1235),
+								ContainerPort: proto.Uint32(5679),
+							},
+						},
+					}))))
+		})
+
+		It("should return the container's info", func() {
+			info, err := connection.Info("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(info.State).Should(Equal("chilling out"))
+			Ω(info.Events).Should(Equal([]string{"maxing", "relaxing all cool"}))
+			Ω(info.HostIP).Should(Equal("host-ip"))
+			Ω(info.ContainerIP).Should(Equal("container-ip"))
+			Ω(info.ContainerPath).Should(Equal("container-path"))
+			Ω(info.ProcessIDs).Should(Equal([]uint32{1, 2}))
+
+			Ω(info.Properties).Should(Equal(garden.Properties{
+				"prop-key": "prop-value",
+			}))
+
+			Ω(info.MemoryStat).Should(Equal(garden.ContainerMemoryStat{
+				Cache:                   1,
 				Rss:                     2,
 				MappedFile:              3,
 				Pgpgin:                  4,
@@ -1185,26 +1824,15 @@ var _ = Describe("Connection", func() {
 			})
 		})
 
-		Context("when the process's window is resized", func() {
+		Context("when the server supports extended signals", func() {
+			hangupSignal := protocol.ProcessPayload_hangup
+
 			BeforeEach(func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
 						ghttp.VerifyRequest("POST", "/containers/foo-handle/processes"),
-						ghttp.VerifyJSONRepresenting(&protocol.RunRequest{
-							Handle:     proto.String("foo-handle"),
-							Path:       proto.String("lol"),
-							Args:       []string{"arg1", "arg2"},
-							Privileged: proto.Bool(false),
-							User:       proto.String(""),
-							Tty: &protocol.TTY{
-								WindowSize: &protocol.TTY_WindowSize{
-									Columns: proto.Uint32(100),
-									Rows:    proto.Uint32(200),
-								},
-							},
-							Rlimits: &protocol.ResourceLimits{},
-						}),
 						func(w http.ResponseWriter, r *http.Request) {
+							w.Header().Set(transport.StreamFeaturesHeader, transport.FeatureExtendedSignals)
 							w.WriteHeader(http.StatusOK)
 
 							conn, br, err := w.(http.Hijacker).Hijack()
@@ -1222,12 +1850,7 @@ var _ = Describe("Connection", func() {
 
 							Ω(payload).Should(Equal(protocol.ProcessPayload{
 								ProcessId: proto.Uint32(42),
-								Tty: &protocol.TTY{
-									WindowSize: &protocol.TTY_WindowSize{
-										Columns: proto.Uint32(80),
-										Rows:    proto.Uint32(24),
-									},
-								},
+								Signal:    &hangupSignal,
 							}))
 
 							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), ExitStatus: proto.Uint32(3)})
@@ -1237,27 +1860,135 @@ var _ = Describe("Connection", func() {
 			})
 
 			It("sends the appropriate protocol message", func() {
-				process, err := connection.Run("foo-handle", garden.ProcessSpec{
-					Path: "lol",
-					Args: []string{"arg1", "arg2"},
-					TTY: &garden.TTYSpec{
-						WindowSize: &garden.WindowSize{
-							Columns: 100,
-							Rows:    200,
-						},
-					},
-				}, garden.ProcessIO{
-					Stdin:  bytes.NewBufferString("stdin data"),
-					Stdout: gbytes.NewBuffer(),
-					Stderr: gbytes.NewBuffer(),
-				})
+				process, err := connection.Run("foo-handle", garden.ProcessSpec{}, garden.ProcessIO{})
+				Ω(err).ShouldNot(HaveOccurred())
 
+				err = process.Signal(garden.SignalHangUp)
 				Ω(err).ShouldNot(HaveOccurred())
-				Ω(process.ID()).Should(Equal(uint32(42)))
 
-				err = process.SetTTY(garden.TTYSpec{
-					WindowSize: &garden.WindowSize{
-						Columns: 80,
+				status, err := process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status).Should(Equal(3))
+			})
+
+			It("rejects signals it does not know", func() {
+				process, err := connection.Run("foo-handle", garden.ProcessSpec{}, garden.ProcessIO{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				err = process.Signal(garden.Signal(42))
+				Ω(err).Should(Equal(garden.UnsupportedSignalError{Signal: garden.Signal(42)}))
+
+				err = process.Signal(garden.SignalHangUp)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Context("when the server does not support extended signals", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/containers/foo-handle/processes"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.WriteHeader(http.StatusOK)
+
+							conn, _, err := w.(http.Hijacker).Hijack()
+							Ω(err).ShouldNot(HaveOccurred())
+
+							defer conn.Close()
+
+							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42)})
+							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), ExitStatus: proto.Uint32(3)})
+						},
+					),
+				)
+			})
+
+			It("returns an UnsupportedSignalError without sending anything", func() {
+				process, err := connection.Run("foo-handle", garden.ProcessSpec{}, garden.ProcessIO{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				err = process.Signal(garden.SignalUser1)
+				Ω(err).Should(Equal(garden.UnsupportedSignalError{Signal: garden.SignalUser1}))
+				Ω(err).Should(MatchError("unsupported signal: SIGUSR1"))
+			})
+		})
+
+		Context("when the process's window is resized", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/containers/foo-handle/processes"),
+						ghttp.VerifyJSONRepresenting(&protocol.RunRequest{
+							Handle:     proto.String("foo-handle"),
+							Path:       proto.String("lol"),
+							Args:       []string{"arg1", "arg2"},
+							Privileged: proto.Bool(false),
+							User:       proto.String(""),
+							Tty: &protocol.TTY{
+								WindowSize: &protocol.TTY_WindowSize{
+									Columns: proto.Uint32(100),
+									Rows:    proto.Uint32(200),
+								},
+							},
+							Rlimits: &protocol.ResourceLimits{},
+						}),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.WriteHeader(http.StatusOK)
+
+							conn, br, err := w.(http.Hijacker).Hijack()
+							Ω(err).ShouldNot(HaveOccurred())
+
+							defer conn.Close()
+
+							decoder := json.NewDecoder(br)
+
+							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42)})
+
+							var payload protocol.ProcessPayload
+							err = decoder.Decode(&payload)
+							Ω(err).ShouldNot(HaveOccurred())
+
+							Ω(payload).Should(Equal(protocol.ProcessPayload{
+								ProcessId: proto.Uint32(42),
+								Tty: &protocol.TTY{
+									WindowSize: &protocol.TTY_WindowSize{
+										Columns: proto.Uint32(80),
+										Rows:    proto.Uint32(24),
+									},
+								},
+							}))
+
+							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), ExitStatus: proto.Uint32(3)})
+						},
+					),
+				)
+			})
+
+			It("sends the appropriate protocol message", func() {
+				process, err := connection.Run("foo-handle", garden.ProcessSpec{
+					Path: "lol",
+					Args: []string{"arg1", "arg2"},
+					TTY: &garden.TTYSpec{
+						WindowSize: &garden.WindowSize{
+							Columns: 100,
+							Rows:    200,
+						},
+					},
+				}, garden.ProcessIO{
+					Stdin:  bytes.NewBufferString("stdin data"),
+					Stdout: gbytes.NewBuffer(),
+					Stderr: gbytes.NewBuffer(),
+				})
+
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(process.ID()).Should(Equal(uint32(42)))
+
+				err = process.SetTTY(garden.TTYSpec{
+					WindowSize: &garden.WindowSize{
+						Columns: 80,
 						Rows:    24,
 					},
 				})
@@ -1340,6 +2071,377 @@ var _ = Describe("Connection", func() {
 		})
 	})
 
+	Describe("streaming with flow control", func() {
+		stdout := protocol.ProcessPayload_stdout
+
+		var windowUpdates chan uint64
+
+		BeforeEach(func() {
+			windowUpdates = make(chan uint64, 10)
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo-handle/processes/42"),
+					func(w http.ResponseWriter, r *http.Request) {
+						Ω(transport.HasStreamFeature(r.Header, transport.FeatureFlowControl)).Should(BeTrue())
+
+						w.Header().Set(transport.StreamFeaturesHeader, transport.FeatureFlowControl)
+						w.WriteHeader(http.StatusOK)
+
+						conn, br, err := w.(http.Hijacker).Hijack()
+						Ω(err).ShouldNot(HaveOccurred())
+
+						defer conn.Close()
+
+						decoder := json.NewDecoder(br)
+
+						var payload protocol.ProcessPayload
+						err = decoder.Decode(&payload)
+						Ω(err).ShouldNot(HaveOccurred())
+						windowUpdates <- payload.GetWindowUpdate()
+
+						data := strings.Repeat("x", 1024)
+						for i := 0; i < 32; i++ {
+							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stdout, Data: proto.String(data)})
+						}
+
+						err = decoder.Decode(&payload)
+						Ω(err).ShouldNot(HaveOccurred())
+						windowUpdates <- payload.GetWindowUpdate()
+
+						transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), ExitStatus: proto.Uint32(0)})
+					},
+				),
+			)
+		})
+
+		It("grants a window up front and returns credit as output is written", func() {
+			stdout := gbytes.NewBuffer()
+
+			process, err := connection.Attach("foo-handle", 42, garden.ProcessIO{Stdout: stdout})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(windowUpdates).Should(Receive(Equal(uint64(64 * 1024))))
+			Eventually(windowUpdates).Should(Receive(Equal(uint64(32 * 1024))))
+
+			_, err = process.Wait()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(stdout.Contents()).Should(HaveLen(32 * 1024))
+		})
+	})
+
+	Describe("streaming binary data", func() {
+		stdout := protocol.ProcessPayload_stdout
+
+		binary := []byte{0x00, 0xff, 0xfe, 0xc3, 0x28}
+
+		var received chan *protocol.ProcessPayload
+
+		BeforeEach(func() {
+			received = make(chan *protocol.ProcessPayload, 10)
+		})
+
+		handler := func(features string) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				Ω(transport.HasStreamFeature(r.Header, transport.FeatureBinaryData)).Should(BeTrue())
+
+				w.Header().Set(transport.StreamFeaturesHeader, features)
+				w.WriteHeader(http.StatusOK)
+
+				conn, br, err := w.(http.Hijacker).Hijack()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				defer conn.Close()
+
+				payload := &protocol.ProcessPayload{}
+				err = json.NewDecoder(br).Decode(payload)
+				Ω(err).ShouldNot(HaveOccurred())
+				received <- payload
+
+				transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stdout, RawData: binary})
+				transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), ExitStatus: proto.Uint32(0)})
+			}
+		}
+
+		Context("when the server supports binary data", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(handler(transport.FeatureBinaryData))
+			})
+
+			It("sends stdin as raw data and writes raw output unchanged", func() {
+				stdout := new(bytes.Buffer)
+
+				process, err := connection.Attach("foo-handle", 42, garden.ProcessIO{
+					Stdin:  bytes.NewReader(binary),
+					Stdout: stdout,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				var payload *protocol.ProcessPayload
+				Eventually(received).Should(Receive(&payload))
+				Ω(payload.RawData).Should(Equal(binary))
+				Ω(payload.Data).Should(BeNil())
+
+				_, err = process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(stdout.Bytes()).Should(Equal(binary))
+			})
+		})
+
+		Context("when the server does not support binary data", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(handler(""))
+			})
+
+			It("sends stdin as data", func() {
+				process, err := connection.Attach("foo-handle", 42, garden.ProcessIO{
+					Stdin: bytes.NewBufferString("stdin data"),
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				var payload *protocol.ProcessPayload
+				Eventually(received).Should(Receive(&payload))
+				Ω(payload.GetData()).Should(Equal("stdin data"))
+				Ω(payload.RawData).Should(BeNil())
+
+				_, err = process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Multiplexing process streams", func() {
+		stdout := protocol.ProcessPayload_stdout
+
+		JustBeforeEach(func() {
+			connection = New("tcp", server.HTTPTestServer.Listener.Addr().String(), WithMultiplexing())
+		})
+
+		Context("when the server supports sessions", func() {
+			var opened chan *protocol.ProcessPayload_Open
+
+			BeforeEach(func() {
+				opened = make(chan *protocol.ProcessPayload_Open, 10)
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/session"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.WriteHeader(http.StatusOK)
+
+							conn, br, err := w.(http.Hijacker).Hijack()
+							Ω(err).ShouldNot(HaveOccurred())
+
+							defer conn.Close()
+
+							decoder := json.NewDecoder(br)
+
+							for {
+								var frame protocol.ProcessPayload
+								err := decoder.Decode(&frame)
+								if err != nil {
+									return
+								}
+
+								if frame.Open == nil {
+									// the client closing streams that exited
+									continue
+								}
+
+								opened <- frame.Open
+
+								id := uint32(42)
+								if frame.Open.Attach != nil {
+									id = frame.Open.Attach.GetProcessId()
+								}
+
+								transport.WriteMessage(conn, &protocol.ProcessPayload{
+									ProcessId: proto.Uint32(id),
+									Open:      &protocol.ProcessPayload_Open{RequestId: frame.Open.RequestId},
+								})
+
+								transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(id), Source: &stdout, Data: proto.String(fmt.Sprintf("output of %d", id))})
+								transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(id), ExitStatus: proto.Uint32(id)})
+								transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(id), Close: proto.Bool(true)})
+							}
+						},
+					),
+				)
+			})
+
+			It("opens each process's stream in one session", func() {
+				runStdout := gbytes.NewBuffer()
+
+				process, err := connection.Run("foo-handle", garden.ProcessSpec{Path: "lol"}, garden.ProcessIO{Stdout: runStdout})
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(process.ID()).Should(Equal(uint32(42)))
+
+				var open *protocol.ProcessPayload_Open
+				Eventually(opened).Should(Receive(&open))
+				Ω(open.GetRun().GetHandle()).Should(Equal("foo-handle"))
+				Ω(open.GetRun().GetPath()).Should(Equal("lol"))
+
+				status, err := process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status).Should(Equal(42))
+				Ω(runStdout).Should(gbytes.Say("output of 42"))
+
+				attachStdout := gbytes.NewBuffer()
+
+				process, err = connection.AttachFrom("foo-handle", 7, 100, garden.ProcessIO{Stdout: attachStdout})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Eventually(opened).Should(Receive(&open))
+				Ω(open.GetAttach().GetHandle()).Should(Equal("foo-handle"))
+				Ω(open.GetAttach().GetProcessId()).Should(Equal(uint32(7)))
+				Ω(open.GetOffset()).Should(Equal(uint64(100)))
+
+				status, err = process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status).Should(Equal(7))
+				Ω(attachStdout).Should(gbytes.Say("output of 7"))
+			})
+		})
+
+		Context("when the server refuses to open a stream", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/session"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.WriteHeader(http.StatusOK)
+
+							conn, br, err := w.(http.Hijacker).Hijack()
+							Ω(err).ShouldNot(HaveOccurred())
+
+							defer conn.Close()
+
+							var frame protocol.ProcessPayload
+							err = json.NewDecoder(br).Decode(&frame)
+							Ω(err).ShouldNot(HaveOccurred())
+
+							transport.WriteMessage(conn, &protocol.ProcessPayload{
+								ProcessId: proto.Uint32(0),
+								Open: &protocol.ProcessPayload_Open{
+									RequestId: frame.Open.RequestId,
+									Error: &protocol.ErrorResponse{
+										Type:    protocol.ErrorResponse_ContainerNotFound.Enum(),
+										Message: proto.String("unknown handle: foo-handle"),
+										Data:    proto.String("foo-handle"),
+									},
+								},
+							})
+						},
+					),
+				)
+			})
+
+			It("returns the typed error", func() {
+				_, err := connection.Run("foo-handle", garden.ProcessSpec{Path: "lol"}, garden.ProcessIO{})
+				Ω(err).Should(Equal(garden.ContainerNotFoundError{Handle: "foo-handle"}))
+			})
+		})
+
+		Context("when the server does not support sessions", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/session"),
+						ghttp.RespondWith(404, "404 page not found"),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/containers/foo-handle/processes"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.WriteHeader(http.StatusOK)
+
+							conn, _, err := w.(http.Hijacker).Hijack()
+							Ω(err).ShouldNot(HaveOccurred())
+
+							defer conn.Close()
+
+							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42)})
+							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), ExitStatus: proto.Uint32(3)})
+						},
+					),
+				)
+			})
+
+			It("streams the process on its own connection", func() {
+				process, err := connection.Run("foo-handle", garden.ProcessSpec{Path: "lol"}, garden.ProcessIO{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				status, err := process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status).Should(Equal(3))
+			})
+		})
+	})
+
+	Describe("Attaching from an offset", func() {
+		stdout := protocol.ProcessPayload_stdout
+
+		Context("when the server replays output", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/processes/42", "offset=123"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.Header().Set(transport.StreamFeaturesHeader, transport.FeatureOutputReplay)
+							w.WriteHeader(http.StatusOK)
+
+							conn, _, err := w.(http.Hijacker).Hijack()
+							Ω(err).ShouldNot(HaveOccurred())
+
+							defer conn.Close()
+
+							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stdout, Data: proto.String("replayed"), Offset: proto.Uint64(123)})
+							transport.WriteMessage(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), ExitStatus: proto.Uint32(3)})
+						},
+					),
+				)
+			})
+
+			It("streams the replayed output", func() {
+				stdout := gbytes.NewBuffer()
+
+				process, err := connection.AttachFrom("foo-handle", 42, 123, garden.ProcessIO{
+					Stdout: stdout,
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Eventually(stdout).Should(gbytes.Say("replayed"))
+
+				status, err := process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status).Should(Equal(3))
+			})
+		})
+
+		Context("when the server does not support replaying output", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/processes/42"),
+						func(w http.ResponseWriter, r *http.Request) {
+							w.WriteHeader(http.StatusOK)
+
+							conn, _, err := w.(http.Hijacker).Hijack()
+							Ω(err).ShouldNot(HaveOccurred())
+
+							conn.Close()
+						},
+					),
+				)
+			})
+
+			It("returns ErrNotImplemented", func() {
+				_, err := connection.AttachFrom("foo-handle", 42, 123, garden.ProcessIO{})
+				Ω(err).Should(Equal(garden.ErrNotImplemented))
+			})
+		})
+	})
+
 	Describe("Attaching", func() {
 		stdin := protocol.ProcessPayload_stdin
 		stdout := protocol.ProcessPayload_stdout
@@ -1512,10 +2614,69 @@ var _ = Describe("Connection", func() {
 					Ω(process.ID()).Should(Equal(uint32(42)))
 
 					_, err = process.Wait()
-					Ω(err).Should(HaveOccurred())
+					Ω(err).Should(Equal(ErrDisconnected))
 				})
 			})
 		})
+
+		Context("when the exit status carries exit information", func() {
+			BeforeEach(func() {
+				killSignal := protocol.ProcessPayload_kill
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/processes/42"),
+						ghttp.RespondWith(200, marshalProto(
+							&protocol.ProcessPayload{
+								ProcessId:  proto.Uint32(42),
+								ExitStatus: proto.Uint32(137),
+								ExitInfo: &protocol.ProcessPayload_ExitInfo{
+									Signal:    &killSignal,
+									OomKilled: proto.Bool(true),
+									Duration:  proto.Int64(int64(5 * time.Second)),
+								},
+							})),
+					),
+				)
+			})
+
+			It("returns it from WaitForExit", func() {
+				process, err := connection.Attach("foo-handle", 42, garden.ProcessIO{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				exitInfo, err := process.WaitForExit()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				signal := garden.SignalKill
+				Ω(exitInfo).Should(Equal(garden.ExitInfo{
+					ExitStatus: 137,
+					Signal:     &signal,
+					OOMKilled:  true,
+					Duration:   5 * time.Second,
+				}))
+			})
+		})
+
+		Context("when the exit status carries no exit information", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/processes/42"),
+						ghttp.RespondWith(200, marshalProto(
+							&protocol.ProcessPayload{ProcessId: proto.Uint32(42), ExitStatus: proto.Uint32(3)})),
+					),
+				)
+			})
+
+			It("returns only the exit status from WaitForExit", func() {
+				process, err := connection.Attach("foo-handle", 42, garden.ProcessIO{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				exitInfo, err := process.WaitForExit()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(exitInfo).Should(Equal(garden.ExitInfo{ExitStatus: 3}))
+			})
+		})
 	})
 })
 
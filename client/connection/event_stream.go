@@ -0,0 +1,43 @@
+package connection
+
+import (
+	"io"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	protocol "github.com/cloudfoundry-incubator/garden/protocol"
+	"github.com/cloudfoundry-incubator/garden/transport"
+)
+
+type eventStream struct {
+	conn   io.Closer
+	reader transport.MessageReader
+}
+
+func newEventStream(conn io.Closer, reader transport.MessageReader) *eventStream {
+	return &eventStream{
+		conn:   conn,
+		reader: reader,
+	}
+}
+
+func (s *eventStream) Next() (garden.Event, error) {
+	var event protocol.Event
+
+	err := s.reader.ReadMessage(&event)
+	if err != nil {
+		return garden.Event{}, err
+	}
+
+	return garden.Event{
+		Type:       garden.EventType(event.GetType()),
+		Handle:     event.GetHandle(),
+		Time:       time.Unix(0, event.GetTimestamp()),
+		Properties: propertiesFrom(event.GetProperties()),
+		Data:       propertiesFrom(event.GetData()),
+	}, nil
+}
+
+func (s *eventStream) Close() error {
+	return s.conn.Close()
+}
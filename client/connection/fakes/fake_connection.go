@@ -41,6 +41,16 @@ type FakeConnection struct {
 		result1 []string
 		result2 error
 	}
+	ListPageStub        func(options garden.ListOptions) ([]garden.ContainerSummary, string, error)
+	listPageMutex       sync.RWMutex
+	listPageArgsForCall []struct {
+		options garden.ListOptions
+	}
+	listPageReturns struct {
+		result1 []garden.ContainerSummary
+		result2 string
+		result3 error
+	}
 	DestroyStub        func(handle string) error
 	destroyMutex       sync.RWMutex
 	destroyArgsForCall []struct {
@@ -235,6 +245,74 @@ type FakeConnection struct {
 	removePropertyReturns struct {
 		result1 error
 	}
+	PropertiesStub        func(handle string) (garden.Properties, error)
+	propertiesMutex       sync.RWMutex
+	propertiesArgsForCall []struct {
+		handle string
+	}
+	propertiesReturns struct {
+		result1 garden.Properties
+		result2 error
+	}
+	SetPropertiesStub        func(handle string, expected garden.Selector, properties garden.Properties, removals []string) error
+	setPropertiesMutex       sync.RWMutex
+	setPropertiesArgsForCall []struct {
+		handle string
+		expected garden.Selector
+		properties garden.Properties
+		removals []string
+	}
+	setPropertiesReturns struct {
+		result1 error
+	}
+	BulkInfoStub        func(handles []string) (map[string]garden.ContainerInfoEntry, error)
+	bulkInfoMutex       sync.RWMutex
+	bulkInfoArgsForCall []struct {
+		handles []string
+	}
+	bulkInfoReturns struct {
+		result1 map[string]garden.ContainerInfoEntry
+		result2 error
+	}
+	EventsStub        func(properties garden.Properties) (garden.EventStream, error)
+	eventsMutex       sync.RWMutex
+	eventsArgsForCall []struct {
+		properties garden.Properties
+	}
+	eventsReturns struct {
+		result1 garden.EventStream
+		result2 error
+	}
+	LookupStub        func(handle string) (garden.ContainerSummary, error)
+	lookupMutex       sync.RWMutex
+	lookupArgsForCall []struct {
+		handle string
+	}
+	lookupReturns struct {
+		result1 garden.ContainerSummary
+		result2 error
+	}
+	ProcessesStub        func(handle string) ([]garden.ProcessInfo, error)
+	processesMutex       sync.RWMutex
+	processesArgsForCall []struct {
+		handle string
+	}
+	processesReturns struct {
+		result1 []garden.ProcessInfo
+		result2 error
+	}
+	AttachFromStub        func(handle string, processID uint32, offset uint64, io garden.ProcessIO) (garden.Process, error)
+	attachFromMutex       sync.RWMutex
+	attachFromArgsForCall []struct {
+		handle    string
+		processID uint32
+		offset    uint64
+		io        garden.ProcessIO
+	}
+	attachFromReturns struct {
+		result1 garden.Process
+		result2 error
+	}
 }
 
 func (fake *FakeConnection) Ping() error {
@@ -352,6 +430,40 @@ func (fake *FakeConnection) ListReturns(result1 []string, result2 error) {
 	}{result1, result2}
 }
 
+func (fake *FakeConnection) ListPage(options garden.ListOptions) ([]garden.ContainerSummary, string, error) {
+	fake.listPageMutex.Lock()
+	fake.listPageArgsForCall = append(fake.listPageArgsForCall, struct {
+		options garden.ListOptions
+	}{options})
+	fake.listPageMutex.Unlock()
+	if fake.ListPageStub != nil {
+		return fake.ListPageStub(options)
+	} else {
+		return fake.listPageReturns.result1, fake.listPageReturns.result2, fake.listPageReturns.result3
+	}
+}
+
+func (fake *FakeConnection) ListPageCallCount() int {
+	fake.listPageMutex.RLock()
+	defer fake.listPageMutex.RUnlock()
+	return len(fake.listPageArgsForCall)
+}
+
+func (fake *FakeConnection) ListPageArgsForCall(i int) garden.ListOptions {
+	fake.listPageMutex.RLock()
+	defer fake.listPageMutex.RUnlock()
+	return fake.listPageArgsForCall[i].options
+}
+
+func (fake *FakeConnection) ListPageReturns(result1 []garden.ContainerSummary, result2 string, result3 error) {
+	fake.ListPageStub = nil
+	fake.listPageReturns = struct {
+		result1 []garden.ContainerSummary
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
 func (fake *FakeConnection) Destroy(handle string) error {
 	fake.destroyMutex.Lock()
 	fake.destroyArgsForCall = append(fake.destroyArgsForCall, struct {
@@ -1026,4 +1138,240 @@ func (fake *FakeConnection) RemovePropertyReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeConnection) Properties(handle string) (garden.Properties, error) {
+	fake.propertiesMutex.Lock()
+	fake.propertiesArgsForCall = append(fake.propertiesArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.propertiesMutex.Unlock()
+	if fake.PropertiesStub != nil {
+		return fake.PropertiesStub(handle)
+	} else {
+		return fake.propertiesReturns.result1, fake.propertiesReturns.result2
+	}
+}
+
+func (fake *FakeConnection) PropertiesCallCount() int {
+	fake.propertiesMutex.RLock()
+	defer fake.propertiesMutex.RUnlock()
+	return len(fake.propertiesArgsForCall)
+}
+
+func (fake *FakeConnection) PropertiesArgsForCall(i int) string {
+	fake.propertiesMutex.RLock()
+	defer fake.propertiesMutex.RUnlock()
+	return fake.propertiesArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) PropertiesReturns(result1 garden.Properties, result2 error) {
+	fake.PropertiesStub = nil
+	fake.propertiesReturns = struct {
+		result1 garden.Properties
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) SetProperties(handle string, expected garden.Selector, properties garden.Properties, removals []string) error {
+	fake.setPropertiesMutex.Lock()
+	fake.setPropertiesArgsForCall = append(fake.setPropertiesArgsForCall, struct {
+		handle string
+		expected garden.Selector
+		properties garden.Properties
+		removals []string
+	}{handle, expected, properties, removals})
+	fake.setPropertiesMutex.Unlock()
+	if fake.SetPropertiesStub != nil {
+		return fake.SetPropertiesStub(handle, expected, properties, removals)
+	} else {
+		return fake.setPropertiesReturns.result1
+	}
+}
+
+func (fake *FakeConnection) SetPropertiesCallCount() int {
+	fake.setPropertiesMutex.RLock()
+	defer fake.setPropertiesMutex.RUnlock()
+	return len(fake.setPropertiesArgsForCall)
+}
+
+func (fake *FakeConnection) SetPropertiesArgsForCall(i int) (string, garden.Selector, garden.Properties, []string) {
+	fake.setPropertiesMutex.RLock()
+	defer fake.setPropertiesMutex.RUnlock()
+	return fake.setPropertiesArgsForCall[i].handle, fake.setPropertiesArgsForCall[i].expected, fake.setPropertiesArgsForCall[i].properties, fake.setPropertiesArgsForCall[i].removals
+}
+
+func (fake *FakeConnection) SetPropertiesReturns(result1 error) {
+	fake.SetPropertiesStub = nil
+	fake.setPropertiesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) BulkInfo(handles []string) (map[string]garden.ContainerInfoEntry, error) {
+	fake.bulkInfoMutex.Lock()
+	fake.bulkInfoArgsForCall = append(fake.bulkInfoArgsForCall, struct {
+		handles []string
+	}{handles})
+	fake.bulkInfoMutex.Unlock()
+	if fake.BulkInfoStub != nil {
+		return fake.BulkInfoStub(handles)
+	} else {
+		return fake.bulkInfoReturns.result1, fake.bulkInfoReturns.result2
+	}
+}
+
+func (fake *FakeConnection) BulkInfoCallCount() int {
+	fake.bulkInfoMutex.RLock()
+	defer fake.bulkInfoMutex.RUnlock()
+	return len(fake.bulkInfoArgsForCall)
+}
+
+func (fake *FakeConnection) BulkInfoArgsForCall(i int) []string {
+	fake.bulkInfoMutex.RLock()
+	defer fake.bulkInfoMutex.RUnlock()
+	return fake.bulkInfoArgsForCall[i].handles
+}
+
+func (fake *FakeConnection) BulkInfoReturns(result1 map[string]garden.ContainerInfoEntry, result2 error) {
+	fake.BulkInfoStub = nil
+	fake.bulkInfoReturns = struct {
+		result1 map[string]garden.ContainerInfoEntry
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) Events(properties garden.Properties) (garden.EventStream, error) {
+	fake.eventsMutex.Lock()
+	fake.eventsArgsForCall = append(fake.eventsArgsForCall, struct {
+		properties garden.Properties
+	}{properties})
+	fake.eventsMutex.Unlock()
+	if fake.EventsStub != nil {
+		return fake.EventsStub(properties)
+	} else {
+		return fake.eventsReturns.result1, fake.eventsReturns.result2
+	}
+}
+
+func (fake *FakeConnection) EventsCallCount() int {
+	fake.eventsMutex.RLock()
+	defer fake.eventsMutex.RUnlock()
+	return len(fake.eventsArgsForCall)
+}
+
+func (fake *FakeConnection) EventsArgsForCall(i int) garden.Properties {
+	fake.eventsMutex.RLock()
+	defer fake.eventsMutex.RUnlock()
+	return fake.eventsArgsForCall[i].properties
+}
+
+func (fake *FakeConnection) EventsReturns(result1 garden.EventStream, result2 error) {
+	fake.EventsStub = nil
+	fake.eventsReturns = struct {
+		result1 garden.EventStream
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) Lookup(handle string) (garden.ContainerSummary, error) {
+	fake.lookupMutex.Lock()
+	fake.lookupArgsForCall = append(fake.lookupArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.lookupMutex.Unlock()
+	if fake.LookupStub != nil {
+		return fake.LookupStub(handle)
+	} else {
+		return fake.lookupReturns.result1, fake.lookupReturns.result2
+	}
+}
+
+func (fake *FakeConnection) LookupCallCount() int {
+	fake.lookupMutex.RLock()
+	defer fake.lookupMutex.RUnlock()
+	return len(fake.lookupArgsForCall)
+}
+
+func (fake *FakeConnection) LookupArgsForCall(i int) string {
+	fake.lookupMutex.RLock()
+	defer fake.lookupMutex.RUnlock()
+	return fake.lookupArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) LookupReturns(result1 garden.ContainerSummary, result2 error) {
+	fake.LookupStub = nil
+	fake.lookupReturns = struct {
+		result1 garden.ContainerSummary
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) Processes(handle string) ([]garden.ProcessInfo, error) {
+	fake.processesMutex.Lock()
+	fake.processesArgsForCall = append(fake.processesArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.processesMutex.Unlock()
+	if fake.ProcessesStub != nil {
+		return fake.ProcessesStub(handle)
+	} else {
+		return fake.processesReturns.result1, fake.processesReturns.result2
+	}
+}
+
+func (fake *FakeConnection) ProcessesCallCount() int {
+	fake.processesMutex.RLock()
+	defer fake.processesMutex.RUnlock()
+	return len(fake.processesArgsForCall)
+}
+
+func (fake *FakeConnection) ProcessesArgsForCall(i int) string {
+	fake.processesMutex.RLock()
+	defer fake.processesMutex.RUnlock()
+	return fake.processesArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) ProcessesReturns(result1 []garden.ProcessInfo, result2 error) {
+	fake.ProcessesStub = nil
+	fake.processesReturns = struct {
+		result1 []garden.ProcessInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) AttachFrom(handle string, processID uint32, offset uint64, io garden.ProcessIO) (garden.Process, error) {
+	fake.attachFromMutex.Lock()
+	fake.attachFromArgsForCall = append(fake.attachFromArgsForCall, struct {
+		handle    string
+		processID uint32
+		offset    uint64
+		io        garden.ProcessIO
+	}{handle, processID, offset, io})
+	fake.attachFromMutex.Unlock()
+	if fake.AttachFromStub != nil {
+		return fake.AttachFromStub(handle, processID, offset, io)
+	} else {
+		return fake.attachFromReturns.result1, fake.attachFromReturns.result2
+	}
+}
+
+func (fake *FakeConnection) AttachFromCallCount() int {
+	fake.attachFromMutex.RLock()
+	defer fake.attachFromMutex.RUnlock()
+	return len(fake.attachFromArgsForCall)
+}
+
+func (fake *FakeConnection) AttachFromArgsForCall(i int) (string, uint32, uint64, garden.ProcessIO) {
+	fake.attachFromMutex.RLock()
+	defer fake.attachFromMutex.RUnlock()
+	return fake.attachFromArgsForCall[i].handle, fake.attachFromArgsForCall[i].processID, fake.attachFromArgsForCall[i].offset, fake.attachFromArgsForCall[i].io
+}
+
+func (fake *FakeConnection) AttachFromReturns(result1 garden.Process, result2 error) {
+	fake.AttachFromStub = nil
+	fake.attachFromReturns = struct {
+		result1 garden.Process
+		result2 error
+	}{result1, result2}
+}
+
 var _ connection.Connection = new(FakeConnection)
@@ -0,0 +1,755 @@
+package connection
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	protocol "github.com/cloudfoundry-incubator/garden/protocol"
+	"github.com/cloudfoundry-incubator/garden/transport"
+	"github.com/gogo/protobuf/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// streamInChunkSize is the most data sent in each payload of a stream in.
+const streamInChunkSize = 32 * 1024
+
+type grpcConnection struct {
+	conn   *grpc.ClientConn
+	client protocol.GardenClient
+
+	reattachPolicy *ReattachPolicy
+}
+
+// NewGRPC returns a connection to the Garden gRPC service at the given
+// address, as served by servers started with server.WithGRPC. Streams are
+// always multiplexed over one connection, so WithMultiplexing has no effect.
+func NewGRPC(network, address string, options ...Option) Connection {
+	config := &connection{}
+	for _, option := range options {
+		option(config)
+	}
+
+	dialer := func(ctx context.Context, _ string) (net.Conn, error) {
+		return (&net.Dialer{Timeout: time.Second}).DialContext(ctx, network, address)
+	}
+
+	creds := insecure.NewCredentials()
+	if config.tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfigFor(network, address, config.tlsConfig))
+	}
+
+	dialOptions := []grpc.DialOption{
+		grpc.WithContextDialer(dialer),
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(transport.GRPCCodec{})),
+	}
+
+	if config.token != "" {
+		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(bearerToken(config.token)))
+	}
+
+	dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(traceMetadata{config.traceHeaders}))
+
+	// dialing is lazy, so this only fails on bad options
+	conn, err := grpc.Dial(
+		"api", // the dialer ignores it
+		dialOptions...,
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	return &grpcConnection{
+		conn:   conn,
+		client: protocol.NewGardenClient(conn),
+
+		reattachPolicy: config.reattachPolicy,
+	}
+}
+
+// bearerToken authenticates every call with the token, as WithToken does
+// requests over HTTP.
+type bearerToken string
+
+func (token bearerToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(token)}, nil
+}
+
+// RequireTransportSecurity is false so that tokens can be sent over unix
+// sockets; over TCP, use WithTLS too.
+func (token bearerToken) RequireTransportSecurity() bool {
+	return false
+}
+
+func (c *grpcConnection) Ping() error {
+	return c.invoke("Ping", &protocol.PingRequest{}, &protocol.PingResponse{})
+}
+
+func (c *grpcConnection) Capacity() (garden.Capacity, error) {
+	capacity := &protocol.CapacityResponse{}
+
+	err := c.invoke("Capacity", &protocol.CapacityRequest{}, capacity)
+	if err != nil {
+		return garden.Capacity{}, err
+	}
+
+	return garden.Capacity{
+		MemoryInBytes: capacity.GetMemoryInBytes(),
+		DiskInBytes:   capacity.GetDiskInBytes(),
+		MaxContainers: capacity.GetMaxContainers(),
+	}, nil
+}
+
+func (c *grpcConnection) Create(spec garden.ContainerSpec) (string, error) {
+	res := &protocol.CreateResponse{}
+
+	err := c.invoke("Create", createRequest(spec), res)
+	if err != nil {
+		return "", err
+	}
+
+	return res.GetHandle(), nil
+}
+
+func (c *grpcConnection) List(filterProperties garden.Properties) ([]string, error) {
+	res := &protocol.ListResponse{}
+
+	err := c.invoke("List", &protocol.ListRequest{
+		Properties: propertyMessages(filterProperties),
+	}, res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.GetHandles(), nil
+}
+
+func (c *grpcConnection) ListPage(options garden.ListOptions) ([]garden.ContainerSummary, string, error) {
+	request := &protocol.ListRequest{}
+	if len(options.Selector) > 0 {
+		request.Selector = proto.String(options.Selector.String())
+	}
+
+	if options.SortBy != "" {
+		request.SortBy = proto.String(options.SortBy)
+	}
+
+	if options.Limit > 0 {
+		request.Limit = proto.Uint32(uint32(options.Limit))
+	}
+
+	if options.Cursor != "" {
+		request.Cursor = proto.String(options.Cursor)
+	}
+
+	if options.Summarize {
+		request.Summarize = proto.Bool(true)
+	}
+
+	res := &protocol.ListResponse{}
+
+	err := c.invoke("List", request, res)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return containerSummaries(res), res.GetNextCursor(), nil
+}
+
+func (c *grpcConnection) Destroy(handle string) error {
+	return c.invoke("Destroy", &protocol.DestroyRequest{
+		Handle: proto.String(handle),
+	}, &protocol.DestroyResponse{})
+}
+
+func (c *grpcConnection) Stop(handle string, kill bool) error {
+	return c.invoke("Stop", &protocol.StopRequest{
+		Handle: proto.String(handle),
+		Kill:   proto.Bool(kill),
+	}, &protocol.StopResponse{})
+}
+
+func (c *grpcConnection) Lookup(handle string) (garden.ContainerSummary, error) {
+	res := &protocol.LookupResponse{}
+
+	err := c.invoke("Lookup", &protocol.LookupRequest{
+		Handle: proto.String(handle),
+	}, res)
+	if err != nil {
+		return garden.ContainerSummary{}, err
+	}
+
+	return garden.ContainerSummary{
+		Handle:     res.GetHandle(),
+		State:      res.GetState(),
+		Properties: propertiesFrom(res.GetProperties()),
+	}, nil
+}
+
+func (c *grpcConnection) Info(handle string) (garden.ContainerInfo, error) {
+	res := &protocol.InfoResponse{}
+
+	err := c.invoke("Info", &protocol.InfoRequest{
+		Handle: proto.String(handle),
+	}, res)
+	if err != nil {
+		return garden.ContainerInfo{}, err
+	}
+
+	return containerInfo(res), nil
+}
+
+func (c *grpcConnection) BulkInfo(handles []string) (map[string]garden.ContainerInfoEntry, error) {
+	res := &protocol.BulkInfoResponse{}
+
+	err := c.invoke("BulkInfo", &protocol.BulkInfoRequest{
+		Handles: handles,
+	}, res)
+	if err != nil {
+		return nil, err
+	}
+
+	return containerInfoEntries(res), nil
+}
+
+func (c *grpcConnection) StreamIn(handle string, dstPath string, reader io.Reader) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := c.client.StreamIn(ctx)
+	if err != nil {
+		return err
+	}
+
+	if reader == nil {
+		reader = bytes.NewReader(nil)
+	}
+
+	payload := &protocol.StreamInPayload{
+		Request: &protocol.StreamInRequest{
+			Handle:  proto.String(handle),
+			DstPath: proto.String(dstPath),
+		},
+	}
+
+	for {
+		data := make([]byte, streamInChunkSize)
+
+		n, readErr := reader.Read(data)
+		if n > 0 || payload.Request != nil {
+			payload.Data = data[:n]
+
+			err := stream.Send(payload)
+			if err != nil {
+				// the server ended the stream; its error comes with the response
+				break
+			}
+
+			payload = &protocol.StreamInPayload{}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+
+		if readErr != nil {
+			return readErr
+		}
+	}
+
+	_, err = stream.CloseAndRecv()
+	if err != nil {
+		return grpcError(err, stream.Trailer())
+	}
+
+	return nil
+}
+
+func (c *grpcConnection) StreamOut(handle string, srcPath string) (io.ReadCloser, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stream, err := c.client.StreamOut(ctx, &protocol.StreamOutRequest{
+		Handle:  proto.String(handle),
+		SrcPath: proto.String(srcPath),
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	header, err := stream.Header()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if header == nil {
+		// the server ended the stream without streaming anything
+		defer cancel()
+
+		_, err := stream.Recv()
+		if err == io.EOF {
+			return ioutil.NopCloser(bytes.NewReader(nil)), nil
+		}
+
+		return nil, grpcError(err, stream.Trailer())
+	}
+
+	return &streamOutReader{
+		stream: stream,
+		cancel: cancel,
+	}, nil
+}
+
+func (c *grpcConnection) LimitBandwidth(handle string, limits garden.BandwidthLimits) (garden.BandwidthLimits, error) {
+	res := &protocol.LimitBandwidthResponse{}
+
+	err := c.invoke("LimitBandwidth", &protocol.LimitBandwidthRequest{
+		Handle: proto.String(handle),
+		Rate:   proto.Uint64(limits.RateInBytesPerSecond),
+		Burst:  proto.Uint64(limits.BurstRateInBytesPerSecond),
+	}, res)
+	if err != nil {
+		return garden.BandwidthLimits{}, err
+	}
+
+	return garden.BandwidthLimits{
+		RateInBytesPerSecond:      res.GetRate(),
+		BurstRateInBytesPerSecond: res.GetBurst(),
+	}, nil
+}
+
+func (c *grpcConnection) CurrentBandwidthLimits(handle string) (garden.BandwidthLimits, error) {
+	res := &protocol.LimitBandwidthResponse{}
+
+	err := c.invoke("CurrentBandwidthLimits", currentLimitsRequest(handle), res)
+	if err != nil {
+		return garden.BandwidthLimits{}, err
+	}
+
+	return garden.BandwidthLimits{
+		RateInBytesPerSecond:      res.GetRate(),
+		BurstRateInBytesPerSecond: res.GetBurst(),
+	}, nil
+}
+
+func (c *grpcConnection) LimitCPU(handle string, limits garden.CPULimits) (garden.CPULimits, error) {
+	res := &protocol.LimitCpuResponse{}
+
+	err := c.invoke("LimitCpu", &protocol.LimitCpuRequest{
+		Handle:        proto.String(handle),
+		LimitInShares: proto.Uint64(limits.LimitInShares),
+	}, res)
+	if err != nil {
+		return garden.CPULimits{}, err
+	}
+
+	return garden.CPULimits{
+		LimitInShares: res.GetLimitInShares(),
+	}, nil
+}
+
+func (c *grpcConnection) CurrentCPULimits(handle string) (garden.CPULimits, error) {
+	res := &protocol.LimitCpuResponse{}
+
+	err := c.invoke("CurrentCpuLimits", currentLimitsRequest(handle), res)
+	if err != nil {
+		return garden.CPULimits{}, err
+	}
+
+	return garden.CPULimits{
+		LimitInShares: res.GetLimitInShares(),
+	}, nil
+}
+
+func (c *grpcConnection) LimitDisk(handle string, limits garden.DiskLimits) (garden.DiskLimits, error) {
+	res := &protocol.LimitDiskResponse{}
+
+	err := c.invoke("LimitDisk", &protocol.LimitDiskRequest{
+		Handle: proto.String(handle),
+
+		BlockSoft: proto.Uint64(limits.BlockSoft),
+		BlockHard: proto.Uint64(limits.BlockHard),
+
+		InodeSoft: proto.Uint64(limits.InodeSoft),
+		InodeHard: proto.Uint64(limits.InodeHard),
+
+		ByteSoft: proto.Uint64(limits.ByteSoft),
+		ByteHard: proto.Uint64(limits.ByteHard),
+	}, res)
+	if err != nil {
+		return garden.DiskLimits{}, err
+	}
+
+	return diskLimits(res), nil
+}
+
+func (c *grpcConnection) CurrentDiskLimits(handle string) (garden.DiskLimits, error) {
+	res := &protocol.LimitDiskResponse{}
+
+	err := c.invoke("CurrentDiskLimits", currentLimitsRequest(handle), res)
+	if err != nil {
+		return garden.DiskLimits{}, err
+	}
+
+	return diskLimits(res), nil
+}
+
+func (c *grpcConnection) LimitMemory(handle string, limits garden.MemoryLimits) (garden.MemoryLimits, error) {
+	res := &protocol.LimitMemoryResponse{}
+
+	err := c.invoke("LimitMemory", &protocol.LimitMemoryRequest{
+		Handle:       proto.String(handle),
+		LimitInBytes: proto.Uint64(limits.LimitInBytes),
+	}, res)
+	if err != nil {
+		return garden.MemoryLimits{}, err
+	}
+
+	return garden.MemoryLimits{
+		LimitInBytes: res.GetLimitInBytes(),
+	}, nil
+}
+
+func (c *grpcConnection) CurrentMemoryLimits(handle string) (garden.MemoryLimits, error) {
+	res := &protocol.LimitMemoryResponse{}
+
+	err := c.invoke("CurrentMemoryLimits", currentLimitsRequest(handle), res)
+	if err != nil {
+		return garden.MemoryLimits{}, err
+	}
+
+	return garden.MemoryLimits{
+		LimitInBytes: res.GetLimitInBytes(),
+	}, nil
+}
+
+func (c *grpcConnection) Run(handle string, spec garden.ProcessSpec, processIO garden.ProcessIO) (garden.Process, error) {
+	ctx, cancel := processStreamContext()
+
+	stream, err := c.client.Run(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	header, err := openProcessStream(stream, &protocol.ProcessPayload_Open{
+		Run: runRequest(handle, spec),
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	messages := grpcMessages{stream}
+
+	firstResponse := &protocol.ProcessPayload{}
+	err = messages.ReadMessage(firstResponse)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	p := newProcess(firstResponse.GetProcessId(), cancelCloser(cancel), messages, header)
+	p.reattach = c.reattacher(handle)
+
+	go p.streamPayloads(messages, processIO)
+
+	return p, nil
+}
+
+func (c *grpcConnection) Attach(handle string, processID uint32, processIO garden.ProcessIO) (garden.Process, error) {
+	return c.attach(handle, processID, nil, processIO)
+}
+
+func (c *grpcConnection) AttachFrom(handle string, processID uint32, offset uint64, processIO garden.ProcessIO) (garden.Process, error) {
+	return c.attach(handle, processID, &offset, processIO)
+}
+
+func (c *grpcConnection) attach(handle string, processID uint32, offset *uint64, processIO garden.ProcessIO) (garden.Process, error) {
+	ctx, cancel := processStreamContext()
+
+	stream, err := c.client.Attach(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	header, err := openProcessStream(stream, &protocol.ProcessPayload_Open{
+		Attach: &protocol.AttachRequest{
+			Handle:    proto.String(handle),
+			ProcessId: proto.Uint32(processID),
+		},
+		Offset: offset,
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	messages := grpcMessages{stream}
+
+	p := newProcess(processID, cancelCloser(cancel), messages, header)
+	p.reattach = c.reattacher(handle)
+
+	go p.streamPayloads(messages, processIO)
+
+	return p, nil
+}
+
+// reattacher returns how processes in the container reattach once
+// disconnected, if they do.
+func (c *grpcConnection) reattacher(handle string) *reattacher {
+	return newReattacher(c.reattachPolicy, handle, func(processID uint32, offset *uint64, processIO garden.ProcessIO) (garden.Process, error) {
+		return c.attach(handle, processID, offset, processIO)
+	})
+}
+
+func (c *grpcConnection) Processes(handle string) ([]garden.ProcessInfo, error) {
+	res := &protocol.ProcessesResponse{}
+
+	err := c.invoke("Processes", &protocol.ProcessesRequest{
+		Handle: proto.String(handle),
+	}, res)
+	if err != nil {
+		return nil, err
+	}
+
+	return processInfos(res), nil
+}
+
+func (c *grpcConnection) NetIn(handle string, hostPort, containerPort uint32) (uint32, uint32, error) {
+	res := &protocol.NetInResponse{}
+
+	err := c.invoke("NetIn", &protocol.NetInRequest{
+		Handle:        proto.String(handle),
+		HostPort:      proto.Uint32(hostPort),
+		ContainerPort: proto.Uint32(containerPort),
+	}, res)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return res.GetHostPort(), res.GetContainerPort(), nil
+}
+
+func (c *grpcConnection) NetOut(handle string, rule garden.NetOutRule) error {
+	req, err := netOutRequest(handle, rule)
+	if err != nil {
+		return err
+	}
+
+	return c.invoke("NetOut", req, &protocol.NetOutResponse{})
+}
+
+func (c *grpcConnection) GetProperty(handle string, name string) (string, error) {
+	res := &protocol.GetPropertyResponse{}
+
+	err := c.invoke("GetProperty", &protocol.GetPropertyRequest{
+		Handle: proto.String(handle),
+		Key:    proto.String(name),
+	}, res)
+	if err != nil {
+		return "", err
+	}
+
+	return res.GetValue(), nil
+}
+
+func (c *grpcConnection) SetProperty(handle string, name string, value string) error {
+	return c.invoke("SetProperty", &protocol.SetPropertyRequest{
+		Handle: proto.String(handle),
+		Key:    proto.String(name),
+		Value:  proto.String(value),
+	}, &protocol.SetPropertyResponse{})
+}
+
+func (c *grpcConnection) RemoveProperty(handle string, name string) error {
+	return c.invoke("RemoveProperty", &protocol.RemovePropertyRequest{
+		Handle: proto.String(handle),
+		Key:    proto.String(name),
+	}, &protocol.RemovePropertyResponse{})
+}
+
+func (c *grpcConnection) Properties(handle string) (garden.Properties, error) {
+	res := &protocol.PropertiesResponse{}
+
+	err := c.invoke("Properties", &protocol.PropertiesRequest{
+		Handle: proto.String(handle),
+	}, res)
+	if err != nil {
+		return nil, err
+	}
+
+	return propertiesFrom(res.GetProperties()), nil
+}
+
+func (c *grpcConnection) SetProperties(handle string, expected garden.Selector, properties garden.Properties, removals []string) error {
+	return c.invoke(
+		"SetProperties",
+		setPropertiesRequest(handle, expected, properties, removals),
+		&protocol.SetPropertiesResponse{},
+	)
+}
+
+func (c *grpcConnection) Events(filterProperties garden.Properties) (garden.EventStream, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stream, err := c.client.Events(ctx, &protocol.EventsRequest{
+		Properties: propertyMessages(filterProperties),
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// the server sends its header once subscribed
+	header, err := stream.Header()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if header == nil {
+		defer cancel()
+		return nil, grpcError(stream.RecvMsg(&protocol.Event{}), stream.Trailer())
+	}
+
+	return newEventStream(cancelCloser(cancel), grpcMessages{stream}), nil
+}
+
+// invoke makes the unary call to the named method of the service, returning
+// the typed error of a failed call.
+func (c *grpcConnection) invoke(method string, req, res proto.Message) error {
+	var trailer metadata.MD
+
+	err := c.conn.Invoke(context.Background(), "/garden.Garden/"+method, req, res, grpc.Trailer(&trailer))
+	if err != nil {
+		return grpcError(err, trailer)
+	}
+
+	return nil
+}
+
+// grpcError rebuilds the typed error of a failed call from the ErrorResponse
+// in its trailer, if the server sent one.
+func grpcError(err error, trailer metadata.MD) error {
+	values := trailer.Get(transport.GRPCErrorTrailer)
+	if len(values) == 0 {
+		return err
+	}
+
+	var errResponse protocol.ErrorResponse
+	if proto.Unmarshal([]byte(values[0]), &errResponse) != nil {
+		return err
+	}
+
+	return typedError(0, &errResponse)
+}
+
+func currentLimitsRequest(handle string) *protocol.CurrentLimitsRequest {
+	return &protocol.CurrentLimitsRequest{
+		Handle: proto.String(handle),
+	}
+}
+
+// processStreamContext returns the context of a process stream, opting in to
+// the stream features the client uses.
+func processStreamContext() (context.Context, context.CancelFunc) {
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs(
+		transport.StreamFeaturesHeader,
+		strings.Join([]string{
+			transport.FeatureFlowControl,
+			transport.FeatureBinaryData,
+		}, ","),
+	))
+
+	return context.WithCancel(ctx)
+}
+
+// openProcessStream opens the process stream with the payload, and returns
+// the stream features the server lists once it has accepted it.
+func openProcessStream(stream grpc.ClientStream, open *protocol.ProcessPayload_Open) (http.Header, error) {
+	err := stream.SendMsg(&protocol.ProcessPayload{Open: open})
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	header, err := stream.Header()
+	if err != nil {
+		return nil, err
+	}
+
+	if header == nil {
+		// the server refused the stream
+		return nil, grpcError(stream.RecvMsg(&protocol.ProcessPayload{}), stream.Trailer())
+	}
+
+	return http.Header{
+		transport.StreamFeaturesHeader: header.Get(transport.StreamFeaturesHeader),
+	}, nil
+}
+
+// grpcMessages reads and writes messages as those of a gRPC stream.
+type grpcMessages struct {
+	stream grpc.ClientStream
+}
+
+func (m grpcMessages) ReadMessage(msg proto.Message) error {
+	return m.stream.RecvMsg(msg)
+}
+
+func (m grpcMessages) WriteMessage(msg proto.Message) error {
+	return m.stream.SendMsg(msg)
+}
+
+// cancelCloser closes a stream by cancelling its context.
+type cancelCloser context.CancelFunc
+
+func (c cancelCloser) Close() error {
+	c()
+	return nil
+}
+
+// streamOutReader reads the data of the payloads streamed out, until the
+// server ends the stream.
+type streamOutReader struct {
+	stream protocol.Garden_StreamOutClient
+	cancel context.CancelFunc
+	data   []byte
+}
+
+func (r *streamOutReader) Read(p []byte) (int, error) {
+	for len(r.data) == 0 {
+		payload, err := r.stream.Recv()
+		if err == io.EOF {
+			return 0, io.EOF
+		}
+
+		if err != nil {
+			return 0, grpcError(err, r.stream.Trailer())
+		}
+
+		r.data = payload.GetData()
+	}
+
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+
+	return n, nil
+}
+
+func (r *streamOutReader) Close() error {
+	r.cancel()
+	return nil
+}
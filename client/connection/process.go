@@ -1,14 +1,15 @@
 package connection
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
-	"net"
+	"net/http"
 	"sync"
+	"time"
 
 	"github.com/cloudfoundry-incubator/garden"
 	protocol "github.com/cloudfoundry-incubator/garden/protocol"
+	"github.com/cloudfoundry-incubator/garden/transport"
 )
 
 type process struct {
@@ -16,21 +17,40 @@ type process struct {
 
 	stream *processStream
 
-	done       bool
-	exitStatus int
-	exitErr    error
-	doneL      *sync.Cond
+	// set if the process is reattached to once its stream is disconnected
+	reattach *reattacher
+
+	// the process reattached to, which streams it from then on
+	successor *process
+
+	// closed once the stream has ended, or been handed to the successor
+	ended chan struct{}
+
+	done     bool
+	exitInfo garden.ExitInfo
+	exitErr  error
+	doneL    *sync.Cond
 }
 
-func newProcess(id uint32, netConn net.Conn) *process {
+// newProcess returns a process whose stream writes its payloads to messages,
+// and is closed by closing conn. The header lists the stream features the
+// server supports.
+func newProcess(id uint32, conn io.Closer, messages transport.MessageWriter, header http.Header) *process {
 	return &process{
 		id: id,
 
 		stream: &processStream{
-			id:   id,
-			conn: netConn,
+			id:       id,
+			conn:     conn,
+			messages: messages,
+
+			extendedSignals: transport.HasStreamFeature(header, transport.FeatureExtendedSignals),
+			flowControl:     transport.HasStreamFeature(header, transport.FeatureFlowControl),
+			binaryData:      transport.HasStreamFeature(header, transport.FeatureBinaryData),
 		},
 
+		ended: make(chan struct{}),
+
 		doneL: sync.NewCond(&sync.Mutex{}),
 	}
 }
@@ -40,6 +60,11 @@ func (p *process) ID() uint32 {
 }
 
 func (p *process) Wait() (int, error) {
+	exitInfo, err := p.WaitForExit()
+	return exitInfo.ExitStatus, err
+}
+
+func (p *process) WaitForExit() (garden.ExitInfo, error) {
 	p.doneL.L.Lock()
 
 	for !p.done {
@@ -48,20 +73,53 @@ func (p *process) Wait() (int, error) {
 
 	defer p.doneL.L.Unlock()
 
-	return p.exitStatus, p.exitErr
+	return p.exitInfo, p.exitErr
 }
 
 func (p *process) SetTTY(tty garden.TTYSpec) error {
-	return p.stream.SetTTY(tty)
+	return p.send(func(stream *processStream) error {
+		return stream.SetTTY(tty)
+	})
 }
 
 func (p *process) Signal(signal garden.Signal) error {
-	return p.stream.Signal(signal)
+	return p.send(func(stream *processStream) error {
+		return stream.Signal(signal)
+	})
 }
 
-func (p *process) exited(exitStatus int, err error) {
+// send sends on the process's stream, or on its successor's once it has
+// been reattached to. A send that fails as the stream is disconnected is
+// retried on the successor, if reattaching succeeds.
+func (p *process) send(send func(*processStream) error) error {
+	if successor := p.currentSuccessor(); successor != nil {
+		return successor.send(send)
+	}
+
+	err := send(p.stream)
+	if err == nil || p.reattach == nil {
+		return err
+	}
+
+	<-p.ended
+
+	if successor := p.currentSuccessor(); successor != nil {
+		return successor.send(send)
+	}
+
+	return err
+}
+
+func (p *process) currentSuccessor() *process {
+	p.doneL.L.Lock()
+	defer p.doneL.L.Unlock()
+
+	return p.successor
+}
+
+func (p *process) exited(exitInfo garden.ExitInfo, err error) {
 	p.doneL.L.Lock()
-	p.exitStatus = exitStatus
+	p.exitInfo = exitInfo
 	p.exitErr = err
 	p.done = true
 	p.doneL.L.Unlock()
@@ -69,11 +127,20 @@ func (p *process) exited(exitStatus int, err error) {
 	p.doneL.Broadcast()
 }
 
-func (p *process) streamPayloads(decoder *json.Decoder, processIO garden.ProcessIO) {
-	defer p.stream.Close()
+func (p *process) streamPayloads(reader transport.MessageReader, processIO garden.ProcessIO) {
+	var successor *process
+
+	defer func() {
+		p.stream.Close()
+		close(p.ended)
+
+		if successor != nil {
+			p.exited(successor.WaitForExit())
+		}
+	}()
 
 	if processIO.Stdin != nil {
-		writer := &stdinWriter{p.stream}
+		writer := &stdinWriter{p}
 
 		go func() {
 			_, err := io.Copy(writer, processIO.Stdin)
@@ -85,34 +152,108 @@ func (p *process) streamPayloads(decoder *json.Decoder, processIO garden.Process
 		}()
 	}
 
+	var consumed uint64
+
+	// the offset of the output to replay from if reattached, once the
+	// server has numbered it
+	var offset *uint64
+
+	if p.stream.flowControl {
+		p.stream.GrantWindow(processStreamWindow)
+	}
+
 	for {
 		payload := &protocol.ProcessPayload{}
 
-		err := decoder.Decode(payload)
+		err := reader.ReadMessage(payload)
 		if err != nil {
-			p.exited(0, err)
+			if p.reattach != nil {
+				successor = p.reattachFrom(offset, processIO)
+			}
+
+			if successor != nil {
+				p.doneL.L.Lock()
+				p.successor = successor
+				p.doneL.L.Unlock()
+			} else {
+				p.exited(garden.ExitInfo{}, ErrDisconnected)
+			}
+
 			break
 		}
 
 		if payload.Error != nil {
-			p.exited(0, fmt.Errorf("process error: %s", payload.GetError()))
+			p.exited(garden.ExitInfo{}, fmt.Errorf("process error: %s", payload.GetError()))
 			break
 		}
 
 		if payload.ExitStatus != nil {
-			p.exited(int(payload.GetExitStatus()), nil)
+			p.exited(exitInfo(payload), nil)
 			break
 		}
 
+		data := payloadData(payload)
+
+		if payload.Offset != nil {
+			next := payload.GetOffset() + uint64(len(data))
+			offset = &next
+		}
+
 		switch payload.GetSource() {
 		case protocol.ProcessPayload_stdout:
 			if processIO.Stdout != nil {
-				processIO.Stdout.Write([]byte(payload.GetData()))
+				processIO.Stdout.Write(data)
 			}
 		case protocol.ProcessPayload_stderr:
 			if processIO.Stderr != nil {
-				processIO.Stderr.Write([]byte(payload.GetData()))
+				processIO.Stderr.Write(data)
+			}
+		}
+
+		if p.stream.flowControl {
+			// hand the credit back in batches once the writers have taken the
+			// output
+			consumed += uint64(len(data))
+			if consumed >= processStreamWindow/2 {
+				p.stream.GrantWindow(consumed)
+				consumed = 0
 			}
 		}
 	}
 }
+
+// payloadData returns the output sent in the payload, whether as raw_data by
+// a server that sends binary data or as data.
+func payloadData(payload *protocol.ProcessPayload) []byte {
+	if payload.RawData != nil {
+		return payload.GetRawData()
+	}
+
+	return []byte(payload.GetData())
+}
+
+func exitInfo(payload *protocol.ProcessPayload) garden.ExitInfo {
+	exitInfo := garden.ExitInfo{
+		ExitStatus: int(payload.GetExitStatus()),
+	}
+
+	info := payload.GetExitInfo()
+	if info == nil {
+		// sent by a server that only reports exit statuses
+		return exitInfo
+	}
+
+	if info.Signal != nil {
+		for signal, payloadSignal := range payloadSignals {
+			if payloadSignal == info.GetSignal() {
+				signal := signal
+				exitInfo.Signal = &signal
+			}
+		}
+	}
+
+	exitInfo.OOMKilled = info.GetOomKilled()
+	exitInfo.Duration = time.Duration(info.GetDuration())
+
+	return exitInfo
+}
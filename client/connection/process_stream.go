@@ -1,8 +1,9 @@
 package connection
 
 import (
-	"fmt"
-	"net"
+	"io"
+	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/cloudfoundry-incubator/garden"
@@ -12,22 +13,97 @@ import (
 )
 
 var stdin = protocol.ProcessPayload_stdin
-var sigKill = protocol.ProcessPayload_kill
-var sigTerm = protocol.ProcessPayload_terminate
+
+// processStreamWindow is the number of bytes of output the server may send
+// ahead of the process's stdout and stderr writers, when it supports flow
+// control.
+const processStreamWindow = 64 * 1024
+
+// processStreamHeader returns the headers requesting a process stream,
+// opting in to the stream features the client uses.
+func processStreamHeader(contentType string) http.Header {
+	header := http.Header{"Accept": {transport.AcceptHeader}}
+
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+
+	header.Set(transport.StreamFeaturesHeader, strings.Join([]string{
+		transport.FeatureFlowControl,
+		transport.FeatureBinaryData,
+	}, ","))
+
+	return header
+}
+
+var payloadSignals = map[garden.Signal]protocol.ProcessPayload_Signal{
+	garden.SignalTerminate:          protocol.ProcessPayload_terminate,
+	garden.SignalKill:               protocol.ProcessPayload_kill,
+	garden.SignalHangUp:             protocol.ProcessPayload_hangup,
+	garden.SignalInterrupt:          protocol.ProcessPayload_interrupt,
+	garden.SignalQuit:               protocol.ProcessPayload_quit,
+	garden.SignalAbort:              protocol.ProcessPayload_abort,
+	garden.SignalUser1:              protocol.ProcessPayload_user1,
+	garden.SignalUser2:              protocol.ProcessPayload_user2,
+	garden.SignalAlarm:              protocol.ProcessPayload_alarm,
+	garden.SignalContinue:           protocol.ProcessPayload_continue,
+	garden.SignalStop:               protocol.ProcessPayload_stop,
+	garden.SignalTerminalStop:       protocol.ProcessPayload_terminal_stop,
+	garden.SignalWindowChange:       protocol.ProcessPayload_window_change,
+	garden.SignalPipe:               protocol.ProcessPayload_pipe,
+	garden.SignalChild:              protocol.ProcessPayload_child,
+	garden.SignalTerminalInput:      protocol.ProcessPayload_terminal_input,
+	garden.SignalTerminalOutput:     protocol.ProcessPayload_terminal_output,
+	garden.SignalTrap:               protocol.ProcessPayload_trap,
+	garden.SignalUrgent:             protocol.ProcessPayload_urgent,
+	garden.SignalProfile:            protocol.ProcessPayload_profile,
+	garden.SignalVirtualAlarm:       protocol.ProcessPayload_virtual_alarm,
+	garden.SignalCPULimit:           protocol.ProcessPayload_cpu_limit,
+	garden.SignalFileSizeLimit:      protocol.ProcessPayload_file_size_limit,
+	garden.SignalBadSystemCall:      protocol.ProcessPayload_bad_system_call,
+	garden.SignalBusError:           protocol.ProcessPayload_bus_error,
+	garden.SignalFloatingPoint:      protocol.ProcessPayload_floating_point,
+	garden.SignalSegmentationFault:  protocol.ProcessPayload_segmentation_fault,
+	garden.SignalIllegalInstruction: protocol.ProcessPayload_illegal_instruction,
+}
 
 type processStream struct {
-	id   uint32
-	conn net.Conn
+	id       uint32
+	conn     io.Closer
+	messages transport.MessageWriter
+
+	// whether the server delivers signals other than terminate and kill;
+	// servers predating them drop stdin when sent one
+	extendedSignals bool
+
+	// whether the server only sends output as far as granted by
+	// GrantWindow
+	flowControl bool
+
+	// whether the server accepts stdin as raw_data
+	binaryData bool
 
 	sync.Mutex
 }
 
 func (s *processStream) WriteStdin(data []byte) error {
-	return s.sendPayload(&protocol.ProcessPayload{
+	if len(data) == 0 {
+		// an empty payload would close stdin
+		return nil
+	}
+
+	payload := &protocol.ProcessPayload{
 		ProcessId: proto.Uint32(s.id),
 		Source:    &stdin,
-		Data:      proto.String(string(data)),
-	})
+	}
+
+	if s.binaryData {
+		payload.RawData = data
+	} else {
+		payload.Data = proto.String(string(data))
+	}
+
+	return s.sendPayload(payload)
 }
 
 func (s *processStream) CloseStdin() error {
@@ -54,14 +130,13 @@ func (s *processStream) SetTTY(spec garden.TTYSpec) error {
 }
 
 func (s *processStream) Signal(signal garden.Signal) error {
-	var payloadSignal protocol.ProcessPayload_Signal
-	switch signal {
-	case garden.SignalKill:
-		payloadSignal = sigKill
-	case garden.SignalTerminate:
-		payloadSignal = sigTerm
-	default:
-		return fmt.Errorf("Unknown signal type: %d", signal)
+	payloadSignal, found := payloadSignals[signal]
+	if !found {
+		return garden.UnsupportedSignalError{Signal: signal}
+	}
+
+	if !s.extendedSignals && signal != garden.SignalTerminate && signal != garden.SignalKill {
+		return garden.UnsupportedSignalError{Signal: signal}
 	}
 
 	return s.sendPayload(&protocol.ProcessPayload{
@@ -70,6 +145,13 @@ func (s *processStream) Signal(signal garden.Signal) error {
 	})
 }
 
+func (s *processStream) GrantWindow(bytes uint64) error {
+	return s.sendPayload(&protocol.ProcessPayload{
+		ProcessId:    proto.Uint32(s.id),
+		WindowUpdate: proto.Uint64(bytes),
+	})
+}
+
 func (s *processStream) Close() error {
 	return s.conn.Close()
 }
@@ -77,7 +159,7 @@ func (s *processStream) Close() error {
 func (s *processStream) sendPayload(payload *protocol.ProcessPayload) error {
 	s.Lock()
 
-	err := transport.WriteMessage(s.conn, payload)
+	err := s.messages.WriteMessage(payload)
 	if err != nil {
 		s.Unlock()
 		return err
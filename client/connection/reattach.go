@@ -0,0 +1,100 @@
+package connection
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+// ReattachPolicy decides how process streams reattach to their processes
+// once their connection to the server is lost, e.g. as the server restarts.
+type ReattachPolicy struct {
+	// MaxAttempts is the number of times reattaching is tried after each
+	// disconnect before giving up with ErrDisconnected.
+	MaxAttempts int
+
+	// Backoff is how long to wait before the first attempt. It doubles after
+	// each failed attempt, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Reattached, if set, is called once each time a process stream has
+	// reattached.
+	Reattached func(handle string, processID uint32)
+}
+
+var DefaultReattachPolicy = ReattachPolicy{
+	MaxAttempts: 10,
+	Backoff:     100 * time.Millisecond,
+	MaxBackoff:  5 * time.Second,
+}
+
+// WithReattach reattaches process streams to their processes once
+// disconnected, replaying the output missed meanwhile where the server
+// supports it. Waiting on, signalling and resizing the processes, and their
+// stdin, carry on over the new stream.
+func WithReattach(policy ReattachPolicy) Option {
+	return func(c *connection) {
+		c.reattachPolicy = &policy
+	}
+}
+
+// reattacher attaches anew to a process whose stream was disconnected.
+type reattacher struct {
+	policy ReattachPolicy
+	handle string
+
+	attach func(processID uint32, offset *uint64, processIO garden.ProcessIO) (garden.Process, error)
+}
+
+// newReattacher returns nil unless the policy is set, so that processes
+// streamed without one give up as soon as they are disconnected.
+func newReattacher(policy *ReattachPolicy, handle string, attach func(uint32, *uint64, garden.ProcessIO) (garden.Process, error)) *reattacher {
+	if policy == nil {
+		return nil
+	}
+
+	return &reattacher{
+		policy: *policy,
+		handle: handle,
+		attach: attach,
+	}
+}
+
+// reattachFrom attaches to the process again, replaying its output from
+// offset if it is known, and returns the process streaming it from then on,
+// or nil if every attempt failed.
+func (p *process) reattachFrom(offset *uint64, processIO garden.ProcessIO) *process {
+	policy := p.reattach.policy
+	backoff := policy.Backoff
+
+	// stdin keeps being copied to whichever stream is current
+	outputIO := garden.ProcessIO{
+		Stdout: processIO.Stdout,
+		Stderr: processIO.Stderr,
+	}
+
+	for attempt := 0; attempt < policy.MaxAttempts; attempt++ {
+		time.Sleep(backoff)
+
+		successor, err := p.reattach.attach(p.id, offset, outputIO)
+		if err == nil {
+			if policy.Reattached != nil {
+				policy.Reattached(p.reattach.handle, p.id)
+			}
+
+			return successor.(*process)
+		}
+
+		if _, notFound := err.(garden.ContainerNotFoundError); notFound {
+			return nil
+		}
+
+		backoff *= 2
+		if backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,252 @@
+package connection
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+
+	protocol "github.com/cloudfoundry-incubator/garden/protocol"
+	"github.com/cloudfoundry-incubator/garden/transport"
+	"github.com/gogo/protobuf/proto"
+)
+
+var errStreamInUse = errors.New("process is already streaming in this session")
+
+// session multiplexes the streams of many processes over a single hijacked
+// connection. Payloads are keyed by their process ID; streams are opened and
+// closed with open and close frames.
+type session struct {
+	conn net.Conn
+
+	// the headers of the session's response, listing the stream features the
+	// server supports
+	header http.Header
+
+	messages transport.MessageWriter
+	writeL   sync.Mutex
+
+	streams       map[uint32]*sessionStream
+	opening       map[uint32]chan openAck
+	nextRequestID uint32
+	disconnected  bool
+	mu            sync.Mutex
+}
+
+func newSession(conn net.Conn, br *bufio.Reader, header http.Header) *session {
+	contentType := header.Get("Content-Type")
+
+	s := &session{
+		conn:   conn,
+		header: header,
+
+		messages: transport.NewMessageWriter(conn, contentType),
+
+		streams: make(map[uint32]*sessionStream),
+		opening: make(map[uint32]chan openAck),
+	}
+
+	go s.readFrames(transport.NewMessageReader(br, contentType))
+
+	return s
+}
+
+// Disconnected returns whether the session's connection has gone away.
+func (s *session) Disconnected() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.disconnected
+}
+
+// Open asks the server to run or attach to a process and waits for it to
+// open the process's stream. If the server refuses, the process ID it
+// returns is that of a process it ran regardless.
+func (s *session) Open(open *protocol.ProcessPayload_Open, processID uint32) (*sessionStream, uint32, error) {
+	s.mu.Lock()
+
+	if s.disconnected {
+		s.mu.Unlock()
+		return nil, 0, ErrDisconnected
+	}
+
+	if _, found := s.streams[processID]; found && open.Attach != nil {
+		s.mu.Unlock()
+		return nil, 0, errStreamInUse
+	}
+
+	s.nextRequestID++
+	requestID := s.nextRequestID
+
+	acknowledged := make(chan openAck, 1)
+	s.opening[requestID] = acknowledged
+
+	s.mu.Unlock()
+
+	open.RequestId = proto.Uint32(requestID)
+
+	err := s.send(&protocol.ProcessPayload{
+		ProcessId: proto.Uint32(processID),
+		Open:      open,
+	})
+	if err != nil {
+		s.mu.Lock()
+		delete(s.opening, requestID)
+		s.mu.Unlock()
+
+		return nil, 0, err
+	}
+
+	ack, ok := <-acknowledged
+	if !ok {
+		return nil, 0, ErrDisconnected
+	}
+
+	if ack.stream == nil {
+		// refusals carry no status code
+		return nil, ack.payload.GetProcessId(), typedError(0, ack.payload.GetOpen().GetError())
+	}
+
+	return ack.stream, 0, nil
+}
+
+func (s *session) send(msg proto.Message) error {
+	s.writeL.Lock()
+	defer s.writeL.Unlock()
+
+	return s.messages.WriteMessage(msg)
+}
+
+func (s *session) readFrames(reader transport.MessageReader) {
+	defer s.disconnect()
+
+	for {
+		payload := &protocol.ProcessPayload{}
+
+		err := reader.ReadMessage(payload)
+		if err != nil {
+			return
+		}
+
+		id := payload.GetProcessId()
+
+		s.mu.Lock()
+
+		switch {
+		case payload.Open != nil:
+			requestID := payload.GetOpen().GetRequestId()
+
+			acknowledged, found := s.opening[requestID]
+			delete(s.opening, requestID)
+
+			ack := openAck{payload: payload}
+
+			if found && payload.GetOpen().Error == nil {
+				// register the stream before reading the payloads that follow
+				ack.stream = newSessionStream(s, id)
+				s.streams[id] = ack.stream
+			}
+
+			s.mu.Unlock()
+
+			if found {
+				acknowledged <- ack
+			}
+
+		case payload.GetClose():
+			stream, found := s.streams[id]
+			delete(s.streams, id)
+
+			s.mu.Unlock()
+
+			if found {
+				stream.input.Close(ErrDisconnected)
+			}
+
+		default:
+			stream, found := s.streams[id]
+
+			s.mu.Unlock()
+
+			if found {
+				stream.input.Push(payload)
+			}
+		}
+	}
+}
+
+// closeStream closes the process's stream, unless the server already has.
+func (s *session) closeStream(id uint32) error {
+	s.mu.Lock()
+	stream, found := s.streams[id]
+	delete(s.streams, id)
+	s.mu.Unlock()
+
+	if !found {
+		return nil
+	}
+
+	stream.input.Close(ErrDisconnected)
+
+	return s.send(&protocol.ProcessPayload{
+		ProcessId: proto.Uint32(id),
+		Close:     proto.Bool(true),
+	})
+}
+
+func (s *session) disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.disconnected = true
+
+	s.conn.Close()
+
+	for requestID, acknowledged := range s.opening {
+		close(acknowledged)
+		delete(s.opening, requestID)
+	}
+
+	for id, stream := range s.streams {
+		stream.input.Close(ErrDisconnected)
+		delete(s.streams, id)
+	}
+}
+
+// openAck is the server's reply to an open frame, and the stream opened
+// unless it refused.
+type openAck struct {
+	payload *protocol.ProcessPayload
+	stream  *sessionStream
+}
+
+// sessionStream is a process's stream in a session. It reads the payloads
+// sent for the process, and writes payloads to the session.
+type sessionStream struct {
+	session *session
+	id      uint32
+
+	input *transport.MessageQueue
+}
+
+func newSessionStream(session *session, id uint32) *sessionStream {
+	return &sessionStream{
+		session: session,
+		id:      id,
+
+		input: transport.NewMessageQueue(0),
+	}
+}
+
+func (s *sessionStream) ReadMessage(msg proto.Message) error {
+	return s.input.ReadMessage(msg)
+}
+
+func (s *sessionStream) WriteMessage(msg proto.Message) error {
+	return s.session.send(msg)
+}
+
+func (s *sessionStream) Close() error {
+	return s.session.closeStream(s.id)
+}
@@ -1,11 +1,15 @@
 package connection
 
+// stdinWriter writes to the stdin of the process, over whichever stream
+// currently carries it.
 type stdinWriter struct {
-	stream *processStream
+	process *process
 }
 
 func (w *stdinWriter) Write(d []byte) (int, error) {
-	err := w.stream.WriteStdin(d)
+	err := w.process.send(func(stream *processStream) error {
+		return stream.WriteStdin(d)
+	})
 	if err != nil {
 		return 0, err
 	}
@@ -14,5 +18,7 @@ func (w *stdinWriter) Write(d []byte) (int, error) {
 }
 
 func (w *stdinWriter) Close() error {
-	return w.stream.CloseStdin()
+	return w.process.send(func(stream *processStream) error {
+		return stream.CloseStdin()
+	})
 }
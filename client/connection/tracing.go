@@ -0,0 +1,58 @@
+package connection
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/cloudfoundry-incubator/garden/tracing"
+)
+
+// WithTraceContext sends every request as a span within the trace the
+// caller's current span belongs to, as returned by source. Requests made when
+// it returns false, or without this option, each start a trace of their own.
+func WithTraceContext(source func() (tracing.TraceContext, bool)) Option {
+	return func(c *connection) {
+		c.traceContext = source
+	}
+}
+
+// traceHeaders returns the headers carrying a new request ID and the trace
+// context of a request.
+func (c *connection) traceHeaders() map[string]string {
+	var tc tracing.TraceContext
+
+	parent, ok := tracing.TraceContext{}, false
+	if c.traceContext != nil {
+		parent, ok = c.traceContext()
+	}
+
+	if ok {
+		tc = parent.Child()
+	} else {
+		tc = tracing.NewTraceContext()
+	}
+
+	headers := map[string]string{
+		tracing.RequestIDHeader:   tracing.NewRequestID(),
+		tracing.TraceParentHeader: tc.TraceParent(),
+	}
+
+	if tc.State != "" {
+		headers[tracing.TraceStateHeader] = tc.State
+	}
+
+	return headers
+}
+
+// traceMetadata sends a request ID and trace context with every call, as
+// requests over HTTP are sent with.
+type traceMetadata struct {
+	headers func() map[string]string
+}
+
+func (metadata traceMetadata) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return metadata.headers(), nil
+}
+
+func (metadata traceMetadata) RequireTransportSecurity() bool {
+	return false
+}
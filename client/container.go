@@ -101,6 +101,14 @@ func (container *container) Attach(processID uint32, io garden.ProcessIO) (garde
 	return container.connection.Attach(container.handle, processID, io)
 }
 
+func (container *container) AttachFrom(processID uint32, offset uint64, io garden.ProcessIO) (garden.Process, error) {
+	return container.connection.AttachFrom(container.handle, processID, offset, io)
+}
+
+func (container *container) Processes() ([]garden.ProcessInfo, error) {
+	return container.connection.Processes(container.handle)
+}
+
 func (container *container) NetIn(hostPort, containerPort uint32) (uint32, uint32, error) {
 	return container.connection.NetIn(container.handle, hostPort, containerPort)
 }
@@ -120,3 +128,15 @@ func (container *container) SetProperty(name string, value string) error {
 func (container *container) RemoveProperty(name string) error {
 	return container.connection.RemoveProperty(container.handle, name)
 }
+
+func (container *container) Properties() (garden.Properties, error) {
+	return container.connection.Properties(container.handle)
+}
+
+func (container *container) SetProperties(properties garden.Properties, removals []string) error {
+	return container.connection.SetProperties(container.handle, nil, properties, removals)
+}
+
+func (container *container) CompareAndSetProperties(expected garden.Selector, properties garden.Properties, removals []string) error {
+	return container.connection.SetProperties(container.handle, expected, properties, removals)
+}
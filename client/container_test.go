@@ -432,6 +432,25 @@ var _ = Describe("Container", func() {
 		})
 	})
 
+	Describe("AttachFrom", func() {
+		It("sends an attach request with the offset to replay from", func() {
+			process := new(wfakes.FakeProcess)
+			fakeConnection.AttachFromReturns(process, nil)
+
+			processIO := garden.ProcessIO{Stdout: gbytes.NewBuffer()}
+
+			attached, err := container.AttachFrom(42, 123, processIO)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(attached).Should(Equal(process))
+
+			attachedHandle, attachedID, attachedOffset, attachedIO := fakeConnection.AttachFromArgsForCall(0)
+			Ω(attachedHandle).Should(Equal("some-handle"))
+			Ω(attachedID).Should(Equal(uint32(42)))
+			Ω(attachedOffset).Should(Equal(uint64(123)))
+			Ω(attachedIO).Should(Equal(processIO))
+		})
+	})
+
 	Describe("Attach", func() {
 		It("sends an attach request and returns a stream", func() {
 			fakeConnection.AttachStub = func(handle string, processID uint32, io garden.ProcessIO) (garden.Process, error) {
@@ -480,6 +499,35 @@ var _ = Describe("Container", func() {
 		})
 	})
 
+	Describe("Processes", func() {
+		It("sends a processes request", func() {
+			processes := []garden.ProcessInfo{
+				{ID: 42, State: garden.ProcessStateRunning},
+			}
+
+			fakeConnection.ProcessesReturns(processes, nil)
+
+			result, err := container.Processes()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(result).Should(Equal(processes))
+
+			Ω(fakeConnection.ProcessesArgsForCall(0)).Should(Equal("some-handle"))
+		})
+
+		Context("when the request fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.ProcessesReturns(nil, disaster)
+			})
+
+			It("returns the error", func() {
+				_, err := container.Processes()
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("NetIn", func() {
 		It("sends a net in request", func() {
 			fakeConnection.NetInReturns(111, 222, nil)
@@ -532,6 +580,53 @@ var _ = Describe("Container", func() {
 		})
 	})
 
+	Describe("Properties", func() {
+		It("gets all of the properties over the connection", func() {
+			fakeConnection.PropertiesReturns(garden.Properties{"owner": "me"}, nil)
+
+			properties, err := container.Properties()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(properties).Should(Equal(garden.Properties{"owner": "me"}))
+
+			Ω(fakeConnection.PropertiesArgsForCall(0)).Should(Equal("some-handle"))
+		})
+	})
+
+	Describe("SetProperties", func() {
+		It("sets the properties without expecting any", func() {
+			Ω(container.SetProperties(garden.Properties{"owner": "me"}, []string{"queued"})).Should(Succeed())
+
+			h, expected, properties, removals := fakeConnection.SetPropertiesArgsForCall(0)
+			Ω(h).Should(Equal("some-handle"))
+			Ω(expected).Should(BeEmpty())
+			Ω(properties).Should(Equal(garden.Properties{"owner": "me"}))
+			Ω(removals).Should(Equal([]string{"queued"}))
+		})
+	})
+
+	Describe("CompareAndSetProperties", func() {
+		It("sets the properties expecting the selector", func() {
+			owned := garden.Selector{{Key: "owner", Operator: garden.SelectorNotExists}}
+
+			Ω(container.CompareAndSetProperties(owned, garden.Properties{"owner": "me"}, nil)).Should(Succeed())
+
+			_, expected, properties, _ := fakeConnection.SetPropertiesArgsForCall(0)
+			Ω(expected).Should(Equal(owned))
+			Ω(properties).Should(Equal(garden.Properties{"owner": "me"}))
+		})
+
+		Context("when the properties do not meet it", func() {
+			BeforeEach(func() {
+				fakeConnection.SetPropertiesReturns(garden.PropertyConflictError{Requirement: "!owner"})
+			})
+
+			It("returns the error", func() {
+				err := container.CompareAndSetProperties(garden.Selector{{Key: "owner", Operator: garden.SelectorNotExists}}, nil, nil)
+				Ω(err).Should(Equal(garden.PropertyConflictError{Requirement: "!owner"}))
+			})
+		})
+	})
+
 	Context("when the request fails", func() {
 		disaster := errors.New("oh no!")
 
@@ -1,6 +1,10 @@
 package garden
 
-import "io"
+import (
+	"fmt"
+	"io"
+	"time"
+)
 
 //go:generate counterfeiter . Container
 
@@ -111,6 +115,23 @@ type Container interface {
 	// * processID does not refer to a running process.
 	Attach(processID uint32, io ProcessIO) (Process, error)
 
+	// AttachFrom is like Attach, but first replays the process's buffered
+	// output, starting at offset bytes into its combined stdout and stderr. An
+	// offset of zero replays from the beginning of what is still buffered.
+	//
+	// Errors:
+	// * processID does not refer to a running process.
+	// * ErrNotImplemented, if the backend does not buffer output. The server
+	//   then replays the output it has buffered itself, if any.
+	AttachFrom(processID uint32, offset uint64, io ProcessIO) (Process, error)
+
+	// Processes lists the processes that have been run in the container.
+	//
+	// Errors:
+	// * ErrNotImplemented, if the backend does not track processes. The server
+	//   then lists the processes it has run in the container itself.
+	Processes() ([]ProcessInfo, error)
+
 	// GetProperty returns the value of the property with the specified name.
 	//
 	// Errors:
@@ -128,6 +149,39 @@ type Container interface {
 	// Errors:
 	// * None.
 	RemoveProperty(name string) error
+
+	// Properties returns all of the container's properties.
+	//
+	// Errors:
+	// * ErrNotImplemented, if the backend has no native implementation. The
+	//   server then returns the properties in the container's info.
+	Properties() (Properties, error)
+
+	// SetProperties sets the properties and removes those named in removals,
+	// atomically: no other change to the container's properties is made
+	// between them, and either all or none of them are made.
+	//
+	// Errors:
+	// * ErrNotImplemented, if the backend has no native implementation. The
+	//   server then makes them one at a time, serialized with every other
+	//   change it makes to the container's properties, and undoes them if
+	//   one fails.
+	SetProperties(properties Properties, removals []string) error
+
+	// CompareAndSetProperties sets and removes properties as SetProperties
+	// does, if and only if the container's properties meet the expected
+	// selector at the time, e.g. to take ownership of a container:
+	//
+	//	container.CompareAndSetProperties(
+	//		Selector{{Key: "owner", Operator: SelectorNotExists}},
+	//		Properties{"owner": "me"},
+	//		nil,
+	//	)
+	//
+	// Errors:
+	// * PropertyConflictError, if the properties do not meet the selector.
+	// * ErrNotImplemented, as for SetProperties.
+	CompareAndSetProperties(expected Selector, properties Properties, removals []string) error
 }
 
 // ProcessSpec contains parameters for running a script inside a container.
@@ -159,6 +213,23 @@ type ProcessIO struct {
 	Stderr io.Writer
 }
 
+// ProcessInfo describes a process that has been run in a container.
+type ProcessInfo struct {
+	ID              uint32
+	Spec            ProcessSpec  // The spec the process was run with. Environment variable values are redacted; limits and TTY are not reported.
+	StartedAt       time.Time    //
+	State           ProcessState //
+	ExitStatus      int          // Only meaningful once the process has exited.
+	AttachedStreams int          // Number of clients currently streaming the process's output.
+}
+
+type ProcessState int
+
+const (
+	ProcessStateRunning ProcessState = iota
+	ProcessStateExited
+)
+
 //go:generate counterfeiter . Process
 
 type Process interface {
@@ -166,15 +237,100 @@ type Process interface {
 	Wait() (int, error)
 	SetTTY(TTYSpec) error
 	Signal(Signal) error
+
+	// WaitForExit blocks until the process exits, like Wait, but also reports
+	// how it exited.
+	//
+	// Errors:
+	// * ErrNotImplemented, if the backend can only report the exit status.
+	// * ErrDisconnected, if the connection to the server was lost first.
+	WaitForExit() (ExitInfo, error)
+}
+
+// ExitInfo describes how a process exited.
+type ExitInfo struct {
+	ExitStatus int
+	Signal     *Signal       // The signal that terminated the process, if any.
+	OOMKilled  bool          // Whether the process was killed for exceeding the container's memory limit.
+	Duration   time.Duration // How long the process ran for. Zero if unknown.
 }
 
 type Signal int
 
+// Signals that can be sent to a process. SignalTerminate and SignalKill are
+// supported by every server; the others require a server that advertises
+// extended signal support, and otherwise fail with UnsupportedSignalError.
 const (
-	SignalTerminate Signal = iota
-	SignalKill
+	SignalTerminate          Signal = iota // SIGTERM
+	SignalKill                             // SIGKILL
+	SignalHangUp                           // SIGHUP
+	SignalInterrupt                        // SIGINT
+	SignalQuit                             // SIGQUIT
+	SignalAbort                            // SIGABRT
+	SignalUser1                            // SIGUSR1
+	SignalUser2                            // SIGUSR2
+	SignalAlarm                            // SIGALRM
+	SignalContinue                         // SIGCONT
+	SignalStop                             // SIGSTOP
+	SignalTerminalStop                     // SIGTSTP
+	SignalWindowChange                     // SIGWINCH
+	SignalPipe                             // SIGPIPE
+	SignalChild                            // SIGCHLD
+	SignalTerminalInput                    // SIGTTIN
+	SignalTerminalOutput                   // SIGTTOU
+	SignalTrap                             // SIGTRAP
+	SignalUrgent                           // SIGURG
+	SignalProfile                          // SIGPROF
+	SignalVirtualAlarm                     // SIGVTALRM
+	SignalCPULimit                         // SIGXCPU
+	SignalFileSizeLimit                    // SIGXFSZ
+	SignalBadSystemCall                    // SIGSYS
+	SignalBusError                         // SIGBUS
+	SignalFloatingPoint                    // SIGFPE
+	SignalSegmentationFault                // SIGSEGV
+	SignalIllegalInstruction               // SIGILL
 )
 
+var signalNames = map[Signal]string{
+	SignalTerminate:          "SIGTERM",
+	SignalKill:               "SIGKILL",
+	SignalHangUp:             "SIGHUP",
+	SignalInterrupt:          "SIGINT",
+	SignalQuit:               "SIGQUIT",
+	SignalAbort:              "SIGABRT",
+	SignalUser1:              "SIGUSR1",
+	SignalUser2:              "SIGUSR2",
+	SignalAlarm:              "SIGALRM",
+	SignalContinue:           "SIGCONT",
+	SignalStop:               "SIGSTOP",
+	SignalTerminalStop:       "SIGTSTP",
+	SignalWindowChange:       "SIGWINCH",
+	SignalPipe:               "SIGPIPE",
+	SignalChild:              "SIGCHLD",
+	SignalTerminalInput:      "SIGTTIN",
+	SignalTerminalOutput:     "SIGTTOU",
+	SignalTrap:               "SIGTRAP",
+	SignalUrgent:             "SIGURG",
+	SignalProfile:            "SIGPROF",
+	SignalVirtualAlarm:       "SIGVTALRM",
+	SignalCPULimit:           "SIGXCPU",
+	SignalFileSizeLimit:      "SIGXFSZ",
+	SignalBadSystemCall:      "SIGSYS",
+	SignalBusError:           "SIGBUS",
+	SignalFloatingPoint:      "SIGFPE",
+	SignalSegmentationFault:  "SIGSEGV",
+	SignalIllegalInstruction: "SIGILL",
+}
+
+func (s Signal) String() string {
+	name, found := signalNames[s]
+	if !found {
+		return fmt.Sprintf("Signal(%d)", int(s))
+	}
+
+	return name
+}
+
 type PortMapping struct {
 	HostPort      uint32
 	ContainerPort uint32
@@ -197,6 +353,23 @@ type ContainerInfo struct {
 	MappedPorts   []PortMapping          //
 }
 
+// ContainerInfoEntry holds the result of getting information about one of
+// the containers in a BulkInfo call.
+type ContainerInfoEntry struct {
+	Info ContainerInfo
+	Err  error
+}
+
+// ContainerSummary is the minimal description of a container returned when
+// looking it up by handle, or listing containers with Summarize. State is
+// left empty by lookups against backends that return a container's properties
+// without reading its info.
+type ContainerSummary struct {
+	Handle     string
+	State      string
+	Properties Properties
+}
+
 type ContainerMemoryStat struct {
 	Cache                   uint64
 	Rss                     uint64
@@ -0,0 +1,111 @@
+package garden
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotImplemented is returned by backends from optional operations they
+// have no native implementation of.
+var ErrNotImplemented = errors.New("not implemented")
+
+// ErrDisconnected is returned by clients waiting on a process when their
+// connection to the server is lost before the process exits.
+var ErrDisconnected = errors.New("disconnected")
+
+type ContainerNotFoundError struct {
+	Handle string
+}
+
+func (err ContainerNotFoundError) Error() string {
+	return fmt.Sprintf("unknown handle: %s", err.Handle)
+}
+
+// ConcurrentDestroyError is returned when a container is destroyed while
+// another destroy of the same handle is still in progress.
+type ConcurrentDestroyError struct {
+	Handle string
+}
+
+func (err ConcurrentDestroyError) Error() string {
+	return fmt.Sprintf("container already being destroyed: %s", err.Handle)
+}
+
+// InvalidContentTypeError is returned when a request body is sent with a
+// content type the server does not understand.
+type InvalidContentTypeError struct {
+	ContentType string
+}
+
+func (err InvalidContentTypeError) Error() string {
+	return fmt.Sprintf("invalid content-type: %q", err.ContentType)
+}
+
+// CapacityExhaustedError is returned by backends when a request cannot be
+// satisfied because the server is out of resources (e.g. max containers).
+type CapacityExhaustedError struct {
+	Message string
+}
+
+func (err CapacityExhaustedError) Error() string {
+	return err.Message
+}
+
+// BackendError is returned by clients when the server's backend failed to
+// perform an operation for any reason not covered by a more specific error.
+type BackendError struct {
+	Message string
+}
+
+func (err BackendError) Error() string {
+	return err.Message
+}
+
+// UnauthenticatedError is returned when a request carries no credentials, or
+// ones the server does not accept.
+type UnauthenticatedError struct {
+	Message string
+}
+
+func (err UnauthenticatedError) Error() string {
+	return err.Message
+}
+
+// ForbiddenError is returned when the caller is not allowed to perform the
+// operation.
+type ForbiddenError struct {
+	Operation string
+}
+
+func (err ForbiddenError) Error() string {
+	return fmt.Sprintf("forbidden: %s", err.Operation)
+}
+
+// DrainingError is returned when the server is draining and refuses to create
+// containers or run processes. Such requests may be retried against another
+// server.
+type DrainingError struct{}
+
+func (err DrainingError) Error() string {
+	return "server is draining"
+}
+
+// PropertyConflictError is returned when a container's properties do not
+// meet the selector they were expected to when compared and set.
+type PropertyConflictError struct {
+	Requirement string
+}
+
+func (err PropertyConflictError) Error() string {
+	return fmt.Sprintf("property conflict: %s not met", err.Requirement)
+}
+
+// UnsupportedSignalError is returned when signalling a process with a signal
+// that the server it is streamed from cannot deliver.
+type UnsupportedSignalError struct {
+	Signal Signal
+}
+
+func (err UnsupportedSignalError) Error() string {
+	return fmt.Sprintf("unsupported signal: %s", err.Signal)
+}
@@ -0,0 +1,42 @@
+package garden
+
+import "time"
+
+type EventType uint8
+
+const (
+	EventTypeCreate EventType = iota
+	EventTypeDestroy
+	EventTypeReap
+	EventTypeStop
+	EventTypeOOM
+	EventTypeNetIn
+	EventTypeLimit
+)
+
+// Event describes something that happened to a container.
+type Event struct {
+	Type   EventType
+	Handle string
+	Time   time.Time
+
+	// Properties holds the container's properties at the time of the event,
+	// and is what event streams are filtered by.
+	Properties Properties
+
+	// Data holds details specific to the event type, e.g. the ports mapped by
+	// a NetIn event or the kind of limit changed by a Limit event.
+	Data map[string]string
+}
+
+//go:generate counterfeiter . EventStream
+
+type EventStream interface {
+	// Next blocks until the next event is available.
+	//
+	// Errors:
+	// * When the stream has been closed or its connection is lost.
+	Next() (Event, error)
+
+	Close() error
+}
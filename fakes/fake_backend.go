@@ -48,6 +48,15 @@ type FakeBackend struct {
 		result1 []garden.Container
 		result2 error
 	}
+	ListStub        func(arg1 garden.ListOptions) (garden.ContainerPage, error)
+	listMutex       sync.RWMutex
+	listArgsForCall []struct {
+		arg1 garden.ListOptions
+	}
+	listReturns struct {
+		result1 garden.ContainerPage
+		result2 error
+	}
 	LookupStub        func(handle string) (garden.Container, error)
 	lookupMutex       sync.RWMutex
 	lookupArgsForCall []struct {
@@ -74,6 +83,24 @@ type FakeBackend struct {
 	graceTimeReturns struct {
 		result1 time.Duration
 	}
+	BulkInfoStub        func(handles []string) (map[string]garden.ContainerInfoEntry, error)
+	bulkInfoMutex       sync.RWMutex
+	bulkInfoArgsForCall []struct {
+		handles []string
+	}
+	bulkInfoReturns struct {
+		result1 map[string]garden.ContainerInfoEntry
+		result2 error
+	}
+	EventsStub        func(garden.Properties) (garden.EventStream, error)
+	eventsMutex       sync.RWMutex
+	eventsArgsForCall []struct {
+		arg1 garden.Properties
+	}
+	eventsReturns struct {
+		result1 garden.EventStream
+		result2 error
+	}
 }
 
 func (fake *FakeBackend) Ping() error {
@@ -223,6 +250,39 @@ func (fake *FakeBackend) ContainersReturns(result1 []garden.Container, result2 e
 	}{result1, result2}
 }
 
+func (fake *FakeBackend) List(arg1 garden.ListOptions) (garden.ContainerPage, error) {
+	fake.listMutex.Lock()
+	fake.listArgsForCall = append(fake.listArgsForCall, struct {
+		arg1 garden.ListOptions
+	}{arg1})
+	fake.listMutex.Unlock()
+	if fake.ListStub != nil {
+		return fake.ListStub(arg1)
+	} else {
+		return fake.listReturns.result1, fake.listReturns.result2
+	}
+}
+
+func (fake *FakeBackend) ListCallCount() int {
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	return len(fake.listArgsForCall)
+}
+
+func (fake *FakeBackend) ListArgsForCall(i int) garden.ListOptions {
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	return fake.listArgsForCall[i].arg1
+}
+
+func (fake *FakeBackend) ListReturns(result1 garden.ContainerPage, result2 error) {
+	fake.ListStub = nil
+	fake.listReturns = struct {
+		result1 garden.ContainerPage
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeBackend) Lookup(handle string) (garden.Container, error) {
 	fake.lookupMutex.Lock()
 	fake.lookupArgsForCall = append(fake.lookupArgsForCall, struct {
@@ -327,4 +387,70 @@ func (fake *FakeBackend) GraceTimeReturns(result1 time.Duration) {
 	}{result1}
 }
 
+func (fake *FakeBackend) BulkInfo(handles []string) (map[string]garden.ContainerInfoEntry, error) {
+	fake.bulkInfoMutex.Lock()
+	fake.bulkInfoArgsForCall = append(fake.bulkInfoArgsForCall, struct {
+		handles []string
+	}{handles})
+	fake.bulkInfoMutex.Unlock()
+	if fake.BulkInfoStub != nil {
+		return fake.BulkInfoStub(handles)
+	} else {
+		return fake.bulkInfoReturns.result1, fake.bulkInfoReturns.result2
+	}
+}
+
+func (fake *FakeBackend) BulkInfoCallCount() int {
+	fake.bulkInfoMutex.RLock()
+	defer fake.bulkInfoMutex.RUnlock()
+	return len(fake.bulkInfoArgsForCall)
+}
+
+func (fake *FakeBackend) BulkInfoArgsForCall(i int) []string {
+	fake.bulkInfoMutex.RLock()
+	defer fake.bulkInfoMutex.RUnlock()
+	return fake.bulkInfoArgsForCall[i].handles
+}
+
+func (fake *FakeBackend) BulkInfoReturns(result1 map[string]garden.ContainerInfoEntry, result2 error) {
+	fake.BulkInfoStub = nil
+	fake.bulkInfoReturns = struct {
+		result1 map[string]garden.ContainerInfoEntry
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) Events(arg1 garden.Properties) (garden.EventStream, error) {
+	fake.eventsMutex.Lock()
+	fake.eventsArgsForCall = append(fake.eventsArgsForCall, struct {
+		arg1 garden.Properties
+	}{arg1})
+	fake.eventsMutex.Unlock()
+	if fake.EventsStub != nil {
+		return fake.EventsStub(arg1)
+	} else {
+		return fake.eventsReturns.result1, fake.eventsReturns.result2
+	}
+}
+
+func (fake *FakeBackend) EventsCallCount() int {
+	fake.eventsMutex.RLock()
+	defer fake.eventsMutex.RUnlock()
+	return len(fake.eventsArgsForCall)
+}
+
+func (fake *FakeBackend) EventsArgsForCall(i int) garden.Properties {
+	fake.eventsMutex.RLock()
+	defer fake.eventsMutex.RUnlock()
+	return fake.eventsArgsForCall[i].arg1
+}
+
+func (fake *FakeBackend) EventsReturns(result1 garden.EventStream, result2 error) {
+	fake.EventsStub = nil
+	fake.eventsReturns = struct {
+		result1 garden.EventStream
+		result2 error
+	}{result1, result2}
+}
+
 var _ garden.Backend = new(FakeBackend)
@@ -173,6 +173,50 @@ type FakeContainer struct {
 	removePropertyReturns struct {
 		result1 error
 	}
+	PropertiesStub        func() (garden.Properties, error)
+	propertiesMutex       sync.RWMutex
+	propertiesArgsForCall []struct{}
+	propertiesReturns struct {
+		result1 garden.Properties
+		result2 error
+	}
+	SetPropertiesStub        func(properties garden.Properties, removals []string) error
+	setPropertiesMutex       sync.RWMutex
+	setPropertiesArgsForCall []struct {
+		properties garden.Properties
+		removals []string
+	}
+	setPropertiesReturns struct {
+		result1 error
+	}
+	CompareAndSetPropertiesStub        func(expected garden.Selector, properties garden.Properties, removals []string) error
+	compareAndSetPropertiesMutex       sync.RWMutex
+	compareAndSetPropertiesArgsForCall []struct {
+		expected garden.Selector
+		properties garden.Properties
+		removals []string
+	}
+	compareAndSetPropertiesReturns struct {
+		result1 error
+	}
+	ProcessesStub        func() ([]garden.ProcessInfo, error)
+	processesMutex       sync.RWMutex
+	processesArgsForCall []struct{}
+	processesReturns struct {
+		result1 []garden.ProcessInfo
+		result2 error
+	}
+	AttachFromStub        func(processID uint32, offset uint64, io garden.ProcessIO) (garden.Process, error)
+	attachFromMutex       sync.RWMutex
+	attachFromArgsForCall []struct {
+		processID uint32
+		offset    uint64
+		io        garden.ProcessIO
+	}
+	attachFromReturns struct {
+		result1 garden.Process
+		result2 error
+	}
 }
 
 func (fake *FakeContainer) Handle() string {
@@ -783,4 +827,156 @@ func (fake *FakeContainer) RemovePropertyReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeContainer) Properties() (garden.Properties, error) {
+	fake.propertiesMutex.Lock()
+	fake.propertiesArgsForCall = append(fake.propertiesArgsForCall, struct{}{})
+	fake.propertiesMutex.Unlock()
+	if fake.PropertiesStub != nil {
+		return fake.PropertiesStub()
+	} else {
+		return fake.propertiesReturns.result1, fake.propertiesReturns.result2
+	}
+}
+
+func (fake *FakeContainer) PropertiesCallCount() int {
+	fake.propertiesMutex.RLock()
+	defer fake.propertiesMutex.RUnlock()
+	return len(fake.propertiesArgsForCall)
+}
+
+func (fake *FakeContainer) PropertiesReturns(result1 garden.Properties, result2 error) {
+	fake.PropertiesStub = nil
+	fake.propertiesReturns = struct {
+		result1 garden.Properties
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeContainer) SetProperties(properties garden.Properties, removals []string) error {
+	fake.setPropertiesMutex.Lock()
+	fake.setPropertiesArgsForCall = append(fake.setPropertiesArgsForCall, struct {
+		properties garden.Properties
+		removals []string
+	}{properties, removals})
+	fake.setPropertiesMutex.Unlock()
+	if fake.SetPropertiesStub != nil {
+		return fake.SetPropertiesStub(properties, removals)
+	} else {
+		return fake.setPropertiesReturns.result1
+	}
+}
+
+func (fake *FakeContainer) SetPropertiesCallCount() int {
+	fake.setPropertiesMutex.RLock()
+	defer fake.setPropertiesMutex.RUnlock()
+	return len(fake.setPropertiesArgsForCall)
+}
+
+func (fake *FakeContainer) SetPropertiesArgsForCall(i int) (garden.Properties, []string) {
+	fake.setPropertiesMutex.RLock()
+	defer fake.setPropertiesMutex.RUnlock()
+	return fake.setPropertiesArgsForCall[i].properties, fake.setPropertiesArgsForCall[i].removals
+}
+
+func (fake *FakeContainer) SetPropertiesReturns(result1 error) {
+	fake.SetPropertiesStub = nil
+	fake.setPropertiesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContainer) CompareAndSetProperties(expected garden.Selector, properties garden.Properties, removals []string) error {
+	fake.compareAndSetPropertiesMutex.Lock()
+	fake.compareAndSetPropertiesArgsForCall = append(fake.compareAndSetPropertiesArgsForCall, struct {
+		expected garden.Selector
+		properties garden.Properties
+		removals []string
+	}{expected, properties, removals})
+	fake.compareAndSetPropertiesMutex.Unlock()
+	if fake.CompareAndSetPropertiesStub != nil {
+		return fake.CompareAndSetPropertiesStub(expected, properties, removals)
+	} else {
+		return fake.compareAndSetPropertiesReturns.result1
+	}
+}
+
+func (fake *FakeContainer) CompareAndSetPropertiesCallCount() int {
+	fake.compareAndSetPropertiesMutex.RLock()
+	defer fake.compareAndSetPropertiesMutex.RUnlock()
+	return len(fake.compareAndSetPropertiesArgsForCall)
+}
+
+func (fake *FakeContainer) CompareAndSetPropertiesArgsForCall(i int) (garden.Selector, garden.Properties, []string) {
+	fake.compareAndSetPropertiesMutex.RLock()
+	defer fake.compareAndSetPropertiesMutex.RUnlock()
+	return fake.compareAndSetPropertiesArgsForCall[i].expected, fake.compareAndSetPropertiesArgsForCall[i].properties, fake.compareAndSetPropertiesArgsForCall[i].removals
+}
+
+func (fake *FakeContainer) CompareAndSetPropertiesReturns(result1 error) {
+	fake.CompareAndSetPropertiesStub = nil
+	fake.compareAndSetPropertiesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContainer) Processes() ([]garden.ProcessInfo, error) {
+	fake.processesMutex.Lock()
+	fake.processesArgsForCall = append(fake.processesArgsForCall, struct{}{})
+	fake.processesMutex.Unlock()
+	if fake.ProcessesStub != nil {
+		return fake.ProcessesStub()
+	} else {
+		return fake.processesReturns.result1, fake.processesReturns.result2
+	}
+}
+
+func (fake *FakeContainer) ProcessesCallCount() int {
+	fake.processesMutex.RLock()
+	defer fake.processesMutex.RUnlock()
+	return len(fake.processesArgsForCall)
+}
+
+func (fake *FakeContainer) ProcessesReturns(result1 []garden.ProcessInfo, result2 error) {
+	fake.ProcessesStub = nil
+	fake.processesReturns = struct {
+		result1 []garden.ProcessInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeContainer) AttachFrom(processID uint32, offset uint64, io garden.ProcessIO) (garden.Process, error) {
+	fake.attachFromMutex.Lock()
+	fake.attachFromArgsForCall = append(fake.attachFromArgsForCall, struct {
+		processID uint32
+		offset    uint64
+		io        garden.ProcessIO
+	}{processID, offset, io})
+	fake.attachFromMutex.Unlock()
+	if fake.AttachFromStub != nil {
+		return fake.AttachFromStub(processID, offset, io)
+	} else {
+		return fake.attachFromReturns.result1, fake.attachFromReturns.result2
+	}
+}
+
+func (fake *FakeContainer) AttachFromCallCount() int {
+	fake.attachFromMutex.RLock()
+	defer fake.attachFromMutex.RUnlock()
+	return len(fake.attachFromArgsForCall)
+}
+
+func (fake *FakeContainer) AttachFromArgsForCall(i int) (uint32, uint64, garden.ProcessIO) {
+	fake.attachFromMutex.RLock()
+	defer fake.attachFromMutex.RUnlock()
+	return fake.attachFromArgsForCall[i].processID, fake.attachFromArgsForCall[i].offset, fake.attachFromArgsForCall[i].io
+}
+
+func (fake *FakeContainer) AttachFromReturns(result1 garden.Process, result2 error) {
+	fake.AttachFromStub = nil
+	fake.attachFromReturns = struct {
+		result1 garden.Process
+		result2 error
+	}{result1, result2}
+}
+
 var _ garden.Container = new(FakeContainer)
@@ -37,6 +37,13 @@ type FakeProcess struct {
 	signalReturns struct {
 		result1 error
 	}
+	WaitForExitStub        func() (garden.ExitInfo, error)
+	waitForExitMutex       sync.RWMutex
+	waitForExitArgsForCall []struct{}
+	waitForExitReturns struct {
+		result1 garden.ExitInfo
+		result2 error
+	}
 }
 
 func (fake *FakeProcess) ID() uint32 {
@@ -152,4 +159,29 @@ func (fake *FakeProcess) SignalReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeProcess) WaitForExit() (garden.ExitInfo, error) {
+	fake.waitForExitMutex.Lock()
+	fake.waitForExitArgsForCall = append(fake.waitForExitArgsForCall, struct{}{})
+	fake.waitForExitMutex.Unlock()
+	if fake.WaitForExitStub != nil {
+		return fake.WaitForExitStub()
+	} else {
+		return fake.waitForExitReturns.result1, fake.waitForExitReturns.result2
+	}
+}
+
+func (fake *FakeProcess) WaitForExitCallCount() int {
+	fake.waitForExitMutex.RLock()
+	defer fake.waitForExitMutex.RUnlock()
+	return len(fake.waitForExitArgsForCall)
+}
+
+func (fake *FakeProcess) WaitForExitReturns(result1 garden.ExitInfo, result2 error) {
+	fake.WaitForExitStub = nil
+	fake.waitForExitReturns = struct {
+		result1 garden.ExitInfo
+		result2 error
+	}{result1, result2}
+}
+
 var _ garden.Process = new(FakeProcess)
@@ -7,11 +7,15 @@ Package garden is a generated protocol buffer package.
 
 It is generated from these files:
 	attach.proto
+	bulk_info.proto
 	capacity.proto
 	create.proto
+	current_limits.proto
 	destroy.proto
 	environment_variable.proto
 	error.proto
+	events.proto
+	garden.proto
 	get_property.proto
 	info.proto
 	limit_bandwidth.proto
@@ -19,11 +23,13 @@ It is generated from these files:
 	limit_disk.proto
 	limit_memory.proto
 	list.proto
+	lookup.proto
 	message.proto
 	net_in.proto
 	net_out.proto
 	ping.proto
 	process_payload.proto
+	processes.proto
 	property.proto
 	remove_property.proto
 	resource_limits.proto
@@ -0,0 +1,79 @@
+// Code generated by protoc-gen-gogo.
+// source: bulk_info.proto
+// DO NOT EDIT!
+
+package garden
+
+import proto "github.com/gogo/protobuf/proto"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = math.Inf
+
+type BulkInfoRequest struct {
+	Handles          []string `protobuf:"bytes,1,rep,name=handles" json:"handles,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *BulkInfoRequest) Reset()         { *m = BulkInfoRequest{} }
+func (m *BulkInfoRequest) String() string { return proto.CompactTextString(m) }
+func (*BulkInfoRequest) ProtoMessage()    {}
+
+func (m *BulkInfoRequest) GetHandles() []string {
+	if m != nil {
+		return m.Handles
+	}
+	return nil
+}
+
+type BulkInfoResponse struct {
+	Containers       []*BulkInfoResponse_ContainerInfoEntry `protobuf:"bytes,1,rep,name=containers" json:"containers,omitempty"`
+	XXX_unrecognized []byte                                 `json:"-"`
+}
+
+func (m *BulkInfoResponse) Reset()         { *m = BulkInfoResponse{} }
+func (m *BulkInfoResponse) String() string { return proto.CompactTextString(m) }
+func (*BulkInfoResponse) ProtoMessage()    {}
+
+func (m *BulkInfoResponse) GetContainers() []*BulkInfoResponse_ContainerInfoEntry {
+	if m != nil {
+		return m.Containers
+	}
+	return nil
+}
+
+type BulkInfoResponse_ContainerInfoEntry struct {
+	Handle           *string        `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
+	Info             *InfoResponse  `protobuf:"bytes,2,opt,name=info" json:"info,omitempty"`
+	Error            *ErrorResponse `protobuf:"bytes,3,opt,name=error" json:"error,omitempty"`
+	XXX_unrecognized []byte         `json:"-"`
+}
+
+func (m *BulkInfoResponse_ContainerInfoEntry) Reset()         { *m = BulkInfoResponse_ContainerInfoEntry{} }
+func (m *BulkInfoResponse_ContainerInfoEntry) String() string { return proto.CompactTextString(m) }
+func (*BulkInfoResponse_ContainerInfoEntry) ProtoMessage()    {}
+
+func (m *BulkInfoResponse_ContainerInfoEntry) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *BulkInfoResponse_ContainerInfoEntry) GetInfo() *InfoResponse {
+	if m != nil {
+		return m.Info
+	}
+	return nil
+}
+
+func (m *BulkInfoResponse_ContainerInfoEntry) GetError() *ErrorResponse {
+	if m != nil {
+		return m.Error
+	}
+	return nil
+}
+
+func init() {
+}
@@ -0,0 +1,31 @@
+// Code generated by protoc-gen-gogo.
+// source: current_limits.proto
+// DO NOT EDIT!
+
+package garden
+
+import proto "github.com/gogo/protobuf/proto"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = math.Inf
+
+type CurrentLimitsRequest struct {
+	Handle           *string `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *CurrentLimitsRequest) Reset()         { *m = CurrentLimitsRequest{} }
+func (m *CurrentLimitsRequest) String() string { return proto.CompactTextString(m) }
+func (*CurrentLimitsRequest) ProtoMessage()    {}
+
+func (m *CurrentLimitsRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func init() {
+}
@@ -11,11 +11,69 @@ import math "math"
 var _ = proto.Marshal
 var _ = math.Inf
 
+type ErrorResponse_Type int32
+
+const (
+	ErrorResponse_Unknown            ErrorResponse_Type = 0
+	ErrorResponse_ContainerNotFound  ErrorResponse_Type = 1
+	ErrorResponse_ConcurrentDestroy  ErrorResponse_Type = 2
+	ErrorResponse_InvalidContentType ErrorResponse_Type = 3
+	ErrorResponse_CapacityExhausted  ErrorResponse_Type = 4
+	ErrorResponse_BackendFailure     ErrorResponse_Type = 5
+	ErrorResponse_Unauthenticated    ErrorResponse_Type = 6
+	ErrorResponse_Forbidden          ErrorResponse_Type = 7
+	ErrorResponse_Draining           ErrorResponse_Type = 8
+	ErrorResponse_PropertyConflict   ErrorResponse_Type = 9
+)
+
+var ErrorResponse_Type_name = map[int32]string{
+	0: "Unknown",
+	1: "ContainerNotFound",
+	2: "ConcurrentDestroy",
+	3: "InvalidContentType",
+	4: "CapacityExhausted",
+	5: "BackendFailure",
+	6: "Unauthenticated",
+	7: "Forbidden",
+	8: "Draining",
+	9: "PropertyConflict",
+}
+var ErrorResponse_Type_value = map[string]int32{
+	"Unknown":            0,
+	"ContainerNotFound":  1,
+	"ConcurrentDestroy":  2,
+	"InvalidContentType": 3,
+	"CapacityExhausted":  4,
+	"BackendFailure":     5,
+	"Unauthenticated":    6,
+	"Forbidden":          7,
+	"Draining":           8,
+	"PropertyConflict":   9,
+}
+
+func (x ErrorResponse_Type) Enum() *ErrorResponse_Type {
+	p := new(ErrorResponse_Type)
+	*p = x
+	return p
+}
+func (x ErrorResponse_Type) String() string {
+	return proto.EnumName(ErrorResponse_Type_name, int32(x))
+}
+func (x *ErrorResponse_Type) UnmarshalJSON(data []byte) error {
+	value, err := proto.UnmarshalJSONEnum(ErrorResponse_Type_value, data, "ErrorResponse_Type")
+	if err != nil {
+		return err
+	}
+	*x = ErrorResponse_Type(value)
+	return nil
+}
+
 type ErrorResponse struct {
-	Message          *string  `protobuf:"bytes,2,opt,name=message" json:"message,omitempty"`
-	Data             *string  `protobuf:"bytes,4,opt,name=data" json:"data,omitempty"`
-	Backtrace        []string `protobuf:"bytes,3,rep,name=backtrace" json:"backtrace,omitempty"`
-	XXX_unrecognized []byte   `json:"-"`
+	Message          *string             `protobuf:"bytes,2,opt,name=message" json:"message,omitempty"`
+	Data             *string             `protobuf:"bytes,4,opt,name=data" json:"data,omitempty"`
+	Backtrace        []string            `protobuf:"bytes,3,rep,name=backtrace" json:"backtrace,omitempty"`
+	Type             *ErrorResponse_Type `protobuf:"varint,5,opt,name=type,enum=garden.ErrorResponse_Type" json:"type,omitempty"`
+	XXX_unrecognized []byte              `json:"-"`
 }
 
 func (m *ErrorResponse) Reset()         { *m = ErrorResponse{} }
@@ -43,5 +101,13 @@ func (m *ErrorResponse) GetBacktrace() []string {
 	return nil
 }
 
+func (m *ErrorResponse) GetType() ErrorResponse_Type {
+	if m != nil && m.Type != nil {
+		return *m.Type
+	}
+	return ErrorResponse_Unknown
+}
+
 func init() {
+	proto.RegisterEnum("garden.ErrorResponse_Type", ErrorResponse_Type_name, ErrorResponse_Type_value)
 }
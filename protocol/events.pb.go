@@ -0,0 +1,128 @@
+// Code generated by protoc-gen-gogo.
+// source: events.proto
+// DO NOT EDIT!
+
+package garden
+
+import proto "github.com/gogo/protobuf/proto"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = math.Inf
+
+type Event_Type int32
+
+const (
+	Event_create  Event_Type = 0
+	Event_destroy Event_Type = 1
+	Event_reap    Event_Type = 2
+	Event_stop    Event_Type = 3
+	Event_oom     Event_Type = 4
+	Event_net_in  Event_Type = 5
+	Event_limit   Event_Type = 6
+)
+
+var Event_Type_name = map[int32]string{
+	0: "create",
+	1: "destroy",
+	2: "reap",
+	3: "stop",
+	4: "oom",
+	5: "net_in",
+	6: "limit",
+}
+var Event_Type_value = map[string]int32{
+	"create":  0,
+	"destroy": 1,
+	"reap":    2,
+	"stop":    3,
+	"oom":     4,
+	"net_in":  5,
+	"limit":   6,
+}
+
+func (x Event_Type) Enum() *Event_Type {
+	p := new(Event_Type)
+	*p = x
+	return p
+}
+func (x Event_Type) String() string {
+	return proto.EnumName(Event_Type_name, int32(x))
+}
+func (x *Event_Type) UnmarshalJSON(data []byte) error {
+	value, err := proto.UnmarshalJSONEnum(Event_Type_value, data, "Event_Type")
+	if err != nil {
+		return err
+	}
+	*x = Event_Type(value)
+	return nil
+}
+
+type EventsRequest struct {
+	Properties       []*Property `protobuf:"bytes,1,rep,name=properties" json:"properties,omitempty"`
+	XXX_unrecognized []byte      `json:"-"`
+}
+
+func (m *EventsRequest) Reset()         { *m = EventsRequest{} }
+func (m *EventsRequest) String() string { return proto.CompactTextString(m) }
+func (*EventsRequest) ProtoMessage()    {}
+
+func (m *EventsRequest) GetProperties() []*Property {
+	if m != nil {
+		return m.Properties
+	}
+	return nil
+}
+
+type Event struct {
+	Type             *Event_Type `protobuf:"varint,1,req,name=type,enum=garden.Event_Type" json:"type,omitempty"`
+	Handle           *string     `protobuf:"bytes,2,req,name=handle" json:"handle,omitempty"`
+	Timestamp        *int64      `protobuf:"varint,3,req,name=timestamp" json:"timestamp,omitempty"`
+	Properties       []*Property `protobuf:"bytes,4,rep,name=properties" json:"properties,omitempty"`
+	Data             []*Property `protobuf:"bytes,5,rep,name=data" json:"data,omitempty"`
+	XXX_unrecognized []byte      `json:"-"`
+}
+
+func (m *Event) Reset()         { *m = Event{} }
+func (m *Event) String() string { return proto.CompactTextString(m) }
+func (*Event) ProtoMessage()    {}
+
+func (m *Event) GetType() Event_Type {
+	if m != nil && m.Type != nil {
+		return *m.Type
+	}
+	return Event_create
+}
+
+func (m *Event) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *Event) GetTimestamp() int64 {
+	if m != nil && m.Timestamp != nil {
+		return *m.Timestamp
+	}
+	return 0
+}
+
+func (m *Event) GetProperties() []*Property {
+	if m != nil {
+		return m.Properties
+	}
+	return nil
+}
+
+func (m *Event) GetData() []*Property {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterEnum("garden.Event_Type", Event_Type_name, Event_Type_value)
+}
@@ -0,0 +1,1197 @@
+// Code generated by protoc-gen-gogo.
+// source: garden.proto
+// DO NOT EDIT!
+
+package garden
+
+import proto "github.com/gogo/protobuf/proto"
+import math "math"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = math.Inf
+
+func init() {
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// Client API for Garden service
+
+type GardenClient interface {
+	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
+	Capacity(ctx context.Context, in *CapacityRequest, opts ...grpc.CallOption) (*CapacityResponse, error)
+	Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*CreateResponse, error)
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error)
+	Destroy(ctx context.Context, in *DestroyRequest, opts ...grpc.CallOption) (*DestroyResponse, error)
+	Stop(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*StopResponse, error)
+	Lookup(ctx context.Context, in *LookupRequest, opts ...grpc.CallOption) (*LookupResponse, error)
+	Info(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error)
+	BulkInfo(ctx context.Context, in *BulkInfoRequest, opts ...grpc.CallOption) (*BulkInfoResponse, error)
+	StreamIn(ctx context.Context, opts ...grpc.CallOption) (Garden_StreamInClient, error)
+	StreamOut(ctx context.Context, in *StreamOutRequest, opts ...grpc.CallOption) (Garden_StreamOutClient, error)
+	LimitBandwidth(ctx context.Context, in *LimitBandwidthRequest, opts ...grpc.CallOption) (*LimitBandwidthResponse, error)
+	LimitCpu(ctx context.Context, in *LimitCpuRequest, opts ...grpc.CallOption) (*LimitCpuResponse, error)
+	LimitDisk(ctx context.Context, in *LimitDiskRequest, opts ...grpc.CallOption) (*LimitDiskResponse, error)
+	LimitMemory(ctx context.Context, in *LimitMemoryRequest, opts ...grpc.CallOption) (*LimitMemoryResponse, error)
+	CurrentBandwidthLimits(ctx context.Context, in *CurrentLimitsRequest, opts ...grpc.CallOption) (*LimitBandwidthResponse, error)
+	CurrentCpuLimits(ctx context.Context, in *CurrentLimitsRequest, opts ...grpc.CallOption) (*LimitCpuResponse, error)
+	CurrentDiskLimits(ctx context.Context, in *CurrentLimitsRequest, opts ...grpc.CallOption) (*LimitDiskResponse, error)
+	CurrentMemoryLimits(ctx context.Context, in *CurrentLimitsRequest, opts ...grpc.CallOption) (*LimitMemoryResponse, error)
+	Run(ctx context.Context, opts ...grpc.CallOption) (Garden_RunClient, error)
+	Attach(ctx context.Context, opts ...grpc.CallOption) (Garden_AttachClient, error)
+	Processes(ctx context.Context, in *ProcessesRequest, opts ...grpc.CallOption) (*ProcessesResponse, error)
+	NetIn(ctx context.Context, in *NetInRequest, opts ...grpc.CallOption) (*NetInResponse, error)
+	NetOut(ctx context.Context, in *NetOutRequest, opts ...grpc.CallOption) (*NetOutResponse, error)
+	GetProperty(ctx context.Context, in *GetPropertyRequest, opts ...grpc.CallOption) (*GetPropertyResponse, error)
+	SetProperty(ctx context.Context, in *SetPropertyRequest, opts ...grpc.CallOption) (*SetPropertyResponse, error)
+	RemoveProperty(ctx context.Context, in *RemovePropertyRequest, opts ...grpc.CallOption) (*RemovePropertyResponse, error)
+	Properties(ctx context.Context, in *PropertiesRequest, opts ...grpc.CallOption) (*PropertiesResponse, error)
+	SetProperties(ctx context.Context, in *SetPropertiesRequest, opts ...grpc.CallOption) (*SetPropertiesResponse, error)
+	Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (Garden_EventsClient, error)
+}
+
+type gardenClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewGardenClient(cc *grpc.ClientConn) GardenClient {
+	return &gardenClient{cc}
+}
+
+func (c *gardenClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error) {
+	out := new(PingResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/Ping", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) Capacity(ctx context.Context, in *CapacityRequest, opts ...grpc.CallOption) (*CapacityResponse, error) {
+	out := new(CapacityResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/Capacity", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*CreateResponse, error) {
+	out := new(CreateResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/Create", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error) {
+	out := new(ListResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/List", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) Destroy(ctx context.Context, in *DestroyRequest, opts ...grpc.CallOption) (*DestroyResponse, error) {
+	out := new(DestroyResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/Destroy", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) Stop(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*StopResponse, error) {
+	out := new(StopResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/Stop", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) Lookup(ctx context.Context, in *LookupRequest, opts ...grpc.CallOption) (*LookupResponse, error) {
+	out := new(LookupResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/Lookup", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) Info(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error) {
+	out := new(InfoResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/Info", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) BulkInfo(ctx context.Context, in *BulkInfoRequest, opts ...grpc.CallOption) (*BulkInfoResponse, error) {
+	out := new(BulkInfoResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/BulkInfo", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) StreamIn(ctx context.Context, opts ...grpc.CallOption) (Garden_StreamInClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Garden_serviceDesc.Streams[0], c.cc, "/garden.Garden/StreamIn", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gardenStreamInClient{stream}
+	return x, nil
+}
+
+type Garden_StreamInClient interface {
+	Send(*StreamInPayload) error
+	CloseAndRecv() (*StreamInResponse, error)
+	grpc.ClientStream
+}
+
+type gardenStreamInClient struct {
+	grpc.ClientStream
+}
+
+func (x *gardenStreamInClient) Send(m *StreamInPayload) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *gardenStreamInClient) CloseAndRecv() (*StreamInResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(StreamInResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *gardenClient) StreamOut(ctx context.Context, in *StreamOutRequest, opts ...grpc.CallOption) (Garden_StreamOutClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Garden_serviceDesc.Streams[1], c.cc, "/garden.Garden/StreamOut", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gardenStreamOutClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Garden_StreamOutClient interface {
+	Recv() (*StreamOutPayload, error)
+	grpc.ClientStream
+}
+
+type gardenStreamOutClient struct {
+	grpc.ClientStream
+}
+
+func (x *gardenStreamOutClient) Recv() (*StreamOutPayload, error) {
+	m := new(StreamOutPayload)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *gardenClient) LimitBandwidth(ctx context.Context, in *LimitBandwidthRequest, opts ...grpc.CallOption) (*LimitBandwidthResponse, error) {
+	out := new(LimitBandwidthResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/LimitBandwidth", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) LimitCpu(ctx context.Context, in *LimitCpuRequest, opts ...grpc.CallOption) (*LimitCpuResponse, error) {
+	out := new(LimitCpuResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/LimitCpu", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) LimitDisk(ctx context.Context, in *LimitDiskRequest, opts ...grpc.CallOption) (*LimitDiskResponse, error) {
+	out := new(LimitDiskResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/LimitDisk", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) LimitMemory(ctx context.Context, in *LimitMemoryRequest, opts ...grpc.CallOption) (*LimitMemoryResponse, error) {
+	out := new(LimitMemoryResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/LimitMemory", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) CurrentBandwidthLimits(ctx context.Context, in *CurrentLimitsRequest, opts ...grpc.CallOption) (*LimitBandwidthResponse, error) {
+	out := new(LimitBandwidthResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/CurrentBandwidthLimits", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) CurrentCpuLimits(ctx context.Context, in *CurrentLimitsRequest, opts ...grpc.CallOption) (*LimitCpuResponse, error) {
+	out := new(LimitCpuResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/CurrentCpuLimits", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) CurrentDiskLimits(ctx context.Context, in *CurrentLimitsRequest, opts ...grpc.CallOption) (*LimitDiskResponse, error) {
+	out := new(LimitDiskResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/CurrentDiskLimits", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) CurrentMemoryLimits(ctx context.Context, in *CurrentLimitsRequest, opts ...grpc.CallOption) (*LimitMemoryResponse, error) {
+	out := new(LimitMemoryResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/CurrentMemoryLimits", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) Run(ctx context.Context, opts ...grpc.CallOption) (Garden_RunClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Garden_serviceDesc.Streams[2], c.cc, "/garden.Garden/Run", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gardenRunClient{stream}
+	return x, nil
+}
+
+type Garden_RunClient interface {
+	Send(*ProcessPayload) error
+	Recv() (*ProcessPayload, error)
+	grpc.ClientStream
+}
+
+type gardenRunClient struct {
+	grpc.ClientStream
+}
+
+func (x *gardenRunClient) Send(m *ProcessPayload) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *gardenRunClient) Recv() (*ProcessPayload, error) {
+	m := new(ProcessPayload)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *gardenClient) Attach(ctx context.Context, opts ...grpc.CallOption) (Garden_AttachClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Garden_serviceDesc.Streams[3], c.cc, "/garden.Garden/Attach", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gardenAttachClient{stream}
+	return x, nil
+}
+
+type Garden_AttachClient interface {
+	Send(*ProcessPayload) error
+	Recv() (*ProcessPayload, error)
+	grpc.ClientStream
+}
+
+type gardenAttachClient struct {
+	grpc.ClientStream
+}
+
+func (x *gardenAttachClient) Send(m *ProcessPayload) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *gardenAttachClient) Recv() (*ProcessPayload, error) {
+	m := new(ProcessPayload)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *gardenClient) Processes(ctx context.Context, in *ProcessesRequest, opts ...grpc.CallOption) (*ProcessesResponse, error) {
+	out := new(ProcessesResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/Processes", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) NetIn(ctx context.Context, in *NetInRequest, opts ...grpc.CallOption) (*NetInResponse, error) {
+	out := new(NetInResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/NetIn", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) NetOut(ctx context.Context, in *NetOutRequest, opts ...grpc.CallOption) (*NetOutResponse, error) {
+	out := new(NetOutResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/NetOut", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) GetProperty(ctx context.Context, in *GetPropertyRequest, opts ...grpc.CallOption) (*GetPropertyResponse, error) {
+	out := new(GetPropertyResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/GetProperty", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) SetProperty(ctx context.Context, in *SetPropertyRequest, opts ...grpc.CallOption) (*SetPropertyResponse, error) {
+	out := new(SetPropertyResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/SetProperty", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) RemoveProperty(ctx context.Context, in *RemovePropertyRequest, opts ...grpc.CallOption) (*RemovePropertyResponse, error) {
+	out := new(RemovePropertyResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/RemoveProperty", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) Properties(ctx context.Context, in *PropertiesRequest, opts ...grpc.CallOption) (*PropertiesResponse, error) {
+	out := new(PropertiesResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/Properties", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) SetProperties(ctx context.Context, in *SetPropertiesRequest, opts ...grpc.CallOption) (*SetPropertiesResponse, error) {
+	out := new(SetPropertiesResponse)
+	err := grpc.Invoke(ctx, "/garden.Garden/SetProperties", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gardenClient) Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (Garden_EventsClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Garden_serviceDesc.Streams[4], c.cc, "/garden.Garden/Events", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gardenEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Garden_EventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type gardenEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *gardenEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Server API for Garden service
+
+type GardenServer interface {
+	Ping(context.Context, *PingRequest) (*PingResponse, error)
+	Capacity(context.Context, *CapacityRequest) (*CapacityResponse, error)
+	Create(context.Context, *CreateRequest) (*CreateResponse, error)
+	List(context.Context, *ListRequest) (*ListResponse, error)
+	Destroy(context.Context, *DestroyRequest) (*DestroyResponse, error)
+	Stop(context.Context, *StopRequest) (*StopResponse, error)
+	Lookup(context.Context, *LookupRequest) (*LookupResponse, error)
+	Info(context.Context, *InfoRequest) (*InfoResponse, error)
+	BulkInfo(context.Context, *BulkInfoRequest) (*BulkInfoResponse, error)
+	StreamIn(Garden_StreamInServer) error
+	StreamOut(*StreamOutRequest, Garden_StreamOutServer) error
+	LimitBandwidth(context.Context, *LimitBandwidthRequest) (*LimitBandwidthResponse, error)
+	LimitCpu(context.Context, *LimitCpuRequest) (*LimitCpuResponse, error)
+	LimitDisk(context.Context, *LimitDiskRequest) (*LimitDiskResponse, error)
+	LimitMemory(context.Context, *LimitMemoryRequest) (*LimitMemoryResponse, error)
+	CurrentBandwidthLimits(context.Context, *CurrentLimitsRequest) (*LimitBandwidthResponse, error)
+	CurrentCpuLimits(context.Context, *CurrentLimitsRequest) (*LimitCpuResponse, error)
+	CurrentDiskLimits(context.Context, *CurrentLimitsRequest) (*LimitDiskResponse, error)
+	CurrentMemoryLimits(context.Context, *CurrentLimitsRequest) (*LimitMemoryResponse, error)
+	Run(Garden_RunServer) error
+	Attach(Garden_AttachServer) error
+	Processes(context.Context, *ProcessesRequest) (*ProcessesResponse, error)
+	NetIn(context.Context, *NetInRequest) (*NetInResponse, error)
+	NetOut(context.Context, *NetOutRequest) (*NetOutResponse, error)
+	GetProperty(context.Context, *GetPropertyRequest) (*GetPropertyResponse, error)
+	SetProperty(context.Context, *SetPropertyRequest) (*SetPropertyResponse, error)
+	RemoveProperty(context.Context, *RemovePropertyRequest) (*RemovePropertyResponse, error)
+	Properties(context.Context, *PropertiesRequest) (*PropertiesResponse, error)
+	SetProperties(context.Context, *SetPropertiesRequest) (*SetPropertiesResponse, error)
+	Events(*EventsRequest, Garden_EventsServer) error
+}
+
+func RegisterGardenServer(s *grpc.Server, srv GardenServer) {
+	s.RegisterService(&_Garden_serviceDesc, srv)
+}
+
+func _Garden_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/Ping",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).Ping(ctx, req.(*PingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_Capacity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CapacityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).Capacity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/Capacity",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).Capacity(ctx, req.(*CapacityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/Create",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).Create(ctx, req.(*CreateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/List",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_Destroy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DestroyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).Destroy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/Destroy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).Destroy(ctx, req.(*DestroyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_Stop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).Stop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/Stop",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).Stop(ctx, req.(*StopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_Lookup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).Lookup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/Lookup",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).Lookup(ctx, req.(*LookupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_Info_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).Info(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/Info",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).Info(ctx, req.(*InfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_BulkInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BulkInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).BulkInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/BulkInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).BulkInfo(ctx, req.(*BulkInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_StreamIn_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GardenServer).StreamIn(&gardenStreamInServer{stream})
+}
+
+type Garden_StreamInServer interface {
+	SendAndClose(*StreamInResponse) error
+	Recv() (*StreamInPayload, error)
+	grpc.ServerStream
+}
+
+type gardenStreamInServer struct {
+	grpc.ServerStream
+}
+
+func (x *gardenStreamInServer) SendAndClose(m *StreamInResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *gardenStreamInServer) Recv() (*StreamInPayload, error) {
+	m := new(StreamInPayload)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Garden_StreamOut_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamOutRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GardenServer).StreamOut(m, &gardenStreamOutServer{stream})
+}
+
+type Garden_StreamOutServer interface {
+	Send(*StreamOutPayload) error
+	grpc.ServerStream
+}
+
+type gardenStreamOutServer struct {
+	grpc.ServerStream
+}
+
+func (x *gardenStreamOutServer) Send(m *StreamOutPayload) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Garden_LimitBandwidth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LimitBandwidthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).LimitBandwidth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/LimitBandwidth",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).LimitBandwidth(ctx, req.(*LimitBandwidthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_LimitCpu_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LimitCpuRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).LimitCpu(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/LimitCpu",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).LimitCpu(ctx, req.(*LimitCpuRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_LimitDisk_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LimitDiskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).LimitDisk(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/LimitDisk",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).LimitDisk(ctx, req.(*LimitDiskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_LimitMemory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LimitMemoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).LimitMemory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/LimitMemory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).LimitMemory(ctx, req.(*LimitMemoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_CurrentBandwidthLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CurrentLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).CurrentBandwidthLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/CurrentBandwidthLimits",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).CurrentBandwidthLimits(ctx, req.(*CurrentLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_CurrentCpuLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CurrentLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).CurrentCpuLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/CurrentCpuLimits",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).CurrentCpuLimits(ctx, req.(*CurrentLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_CurrentDiskLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CurrentLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).CurrentDiskLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/CurrentDiskLimits",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).CurrentDiskLimits(ctx, req.(*CurrentLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_CurrentMemoryLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CurrentLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).CurrentMemoryLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/CurrentMemoryLimits",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).CurrentMemoryLimits(ctx, req.(*CurrentLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_Run_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GardenServer).Run(&gardenRunServer{stream})
+}
+
+type Garden_RunServer interface {
+	Send(*ProcessPayload) error
+	Recv() (*ProcessPayload, error)
+	grpc.ServerStream
+}
+
+type gardenRunServer struct {
+	grpc.ServerStream
+}
+
+func (x *gardenRunServer) Send(m *ProcessPayload) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *gardenRunServer) Recv() (*ProcessPayload, error) {
+	m := new(ProcessPayload)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Garden_Attach_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GardenServer).Attach(&gardenAttachServer{stream})
+}
+
+type Garden_AttachServer interface {
+	Send(*ProcessPayload) error
+	Recv() (*ProcessPayload, error)
+	grpc.ServerStream
+}
+
+type gardenAttachServer struct {
+	grpc.ServerStream
+}
+
+func (x *gardenAttachServer) Send(m *ProcessPayload) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *gardenAttachServer) Recv() (*ProcessPayload, error) {
+	m := new(ProcessPayload)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Garden_Processes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProcessesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).Processes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/Processes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).Processes(ctx, req.(*ProcessesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_NetIn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NetInRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).NetIn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/NetIn",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).NetIn(ctx, req.(*NetInRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_NetOut_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NetOutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).NetOut(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/NetOut",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).NetOut(ctx, req.(*NetOutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_GetProperty_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPropertyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).GetProperty(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/GetProperty",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).GetProperty(ctx, req.(*GetPropertyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_SetProperty_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetPropertyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).SetProperty(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/SetProperty",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).SetProperty(ctx, req.(*SetPropertyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_RemoveProperty_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemovePropertyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).RemoveProperty(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/RemoveProperty",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).RemoveProperty(ctx, req.(*RemovePropertyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_Properties_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PropertiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).Properties(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/Properties",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).Properties(ctx, req.(*PropertiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_SetProperties_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetPropertiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GardenServer).SetProperties(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/garden.Garden/SetProperties",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GardenServer).SetProperties(ctx, req.(*SetPropertiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Garden_Events_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GardenServer).Events(m, &gardenEventsServer{stream})
+}
+
+type Garden_EventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type gardenEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *gardenEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _Garden_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "garden.Garden",
+	HandlerType: (*GardenServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Ping",
+			Handler:    _Garden_Ping_Handler,
+		},
+		{
+			MethodName: "Capacity",
+			Handler:    _Garden_Capacity_Handler,
+		},
+		{
+			MethodName: "Create",
+			Handler:    _Garden_Create_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _Garden_List_Handler,
+		},
+		{
+			MethodName: "Destroy",
+			Handler:    _Garden_Destroy_Handler,
+		},
+		{
+			MethodName: "Stop",
+			Handler:    _Garden_Stop_Handler,
+		},
+		{
+			MethodName: "Lookup",
+			Handler:    _Garden_Lookup_Handler,
+		},
+		{
+			MethodName: "Info",
+			Handler:    _Garden_Info_Handler,
+		},
+		{
+			MethodName: "BulkInfo",
+			Handler:    _Garden_BulkInfo_Handler,
+		},
+		{
+			MethodName: "LimitBandwidth",
+			Handler:    _Garden_LimitBandwidth_Handler,
+		},
+		{
+			MethodName: "LimitCpu",
+			Handler:    _Garden_LimitCpu_Handler,
+		},
+		{
+			MethodName: "LimitDisk",
+			Handler:    _Garden_LimitDisk_Handler,
+		},
+		{
+			MethodName: "LimitMemory",
+			Handler:    _Garden_LimitMemory_Handler,
+		},
+		{
+			MethodName: "CurrentBandwidthLimits",
+			Handler:    _Garden_CurrentBandwidthLimits_Handler,
+		},
+		{
+			MethodName: "CurrentCpuLimits",
+			Handler:    _Garden_CurrentCpuLimits_Handler,
+		},
+		{
+			MethodName: "CurrentDiskLimits",
+			Handler:    _Garden_CurrentDiskLimits_Handler,
+		},
+		{
+			MethodName: "CurrentMemoryLimits",
+			Handler:    _Garden_CurrentMemoryLimits_Handler,
+		},
+		{
+			MethodName: "Processes",
+			Handler:    _Garden_Processes_Handler,
+		},
+		{
+			MethodName: "NetIn",
+			Handler:    _Garden_NetIn_Handler,
+		},
+		{
+			MethodName: "NetOut",
+			Handler:    _Garden_NetOut_Handler,
+		},
+		{
+			MethodName: "GetProperty",
+			Handler:    _Garden_GetProperty_Handler,
+		},
+		{
+			MethodName: "SetProperty",
+			Handler:    _Garden_SetProperty_Handler,
+		},
+		{
+			MethodName: "RemoveProperty",
+			Handler:    _Garden_RemoveProperty_Handler,
+		},
+		{
+			MethodName: "Properties",
+			Handler:    _Garden_Properties_Handler,
+		},
+		{
+			MethodName: "SetProperties",
+			Handler:    _Garden_SetProperties_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamIn",
+			Handler:       _Garden_StreamIn_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "StreamOut",
+			Handler:       _Garden_StreamOut_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Run",
+			Handler:       _Garden_Run_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Attach",
+			Handler:       _Garden_Attach_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Events",
+			Handler:       _Garden_Events_Handler,
+			ServerStreams: true,
+		},
+	},
+}
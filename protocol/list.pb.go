@@ -13,6 +13,11 @@ var _ = math.Inf
 
 type ListRequest struct {
 	Properties       []*Property `protobuf:"bytes,1,rep,name=properties" json:"properties,omitempty"`
+	Selector         *string     `protobuf:"bytes,2,opt,name=selector" json:"selector,omitempty"`
+	SortBy           *string     `protobuf:"bytes,3,opt,name=sort_by" json:"sort_by,omitempty"`
+	Limit            *uint32     `protobuf:"varint,4,opt,name=limit" json:"limit,omitempty"`
+	Cursor           *string     `protobuf:"bytes,5,opt,name=cursor" json:"cursor,omitempty"`
+	Summarize        *bool       `protobuf:"varint,6,opt,name=summarize" json:"summarize,omitempty"`
 	XXX_unrecognized []byte      `json:"-"`
 }
 
@@ -27,9 +32,46 @@ func (m *ListRequest) GetProperties() []*Property {
 	return nil
 }
 
+func (m *ListRequest) GetSelector() string {
+	if m != nil && m.Selector != nil {
+		return *m.Selector
+	}
+	return ""
+}
+
+func (m *ListRequest) GetSortBy() string {
+	if m != nil && m.SortBy != nil {
+		return *m.SortBy
+	}
+	return ""
+}
+
+func (m *ListRequest) GetLimit() uint32 {
+	if m != nil && m.Limit != nil {
+		return *m.Limit
+	}
+	return 0
+}
+
+func (m *ListRequest) GetCursor() string {
+	if m != nil && m.Cursor != nil {
+		return *m.Cursor
+	}
+	return ""
+}
+
+func (m *ListRequest) GetSummarize() bool {
+	if m != nil && m.Summarize != nil {
+		return *m.Summarize
+	}
+	return false
+}
+
 type ListResponse struct {
-	Handles          []string `protobuf:"bytes,1,rep,name=handles" json:"handles,omitempty"`
-	XXX_unrecognized []byte   `json:"-"`
+	Handles          []string                         `protobuf:"bytes,1,rep,name=handles" json:"handles,omitempty"`
+	NextCursor       *string                          `protobuf:"bytes,2,opt,name=next_cursor" json:"next_cursor,omitempty"`
+	Summaries        []*ListResponse_ContainerSummary `protobuf:"bytes,3,rep,name=summaries" json:"summaries,omitempty"`
+	XXX_unrecognized []byte                           `json:"-"`
 }
 
 func (m *ListResponse) Reset()         { *m = ListResponse{} }
@@ -43,5 +85,51 @@ func (m *ListResponse) GetHandles() []string {
 	return nil
 }
 
+func (m *ListResponse) GetNextCursor() string {
+	if m != nil && m.NextCursor != nil {
+		return *m.NextCursor
+	}
+	return ""
+}
+
+func (m *ListResponse) GetSummaries() []*ListResponse_ContainerSummary {
+	if m != nil {
+		return m.Summaries
+	}
+	return nil
+}
+
+type ListResponse_ContainerSummary struct {
+	Handle           *string     `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
+	State            *string     `protobuf:"bytes,2,opt,name=state" json:"state,omitempty"`
+	Properties       []*Property `protobuf:"bytes,3,rep,name=properties" json:"properties,omitempty"`
+	XXX_unrecognized []byte      `json:"-"`
+}
+
+func (m *ListResponse_ContainerSummary) Reset()         { *m = ListResponse_ContainerSummary{} }
+func (m *ListResponse_ContainerSummary) String() string { return proto.CompactTextString(m) }
+func (*ListResponse_ContainerSummary) ProtoMessage()    {}
+
+func (m *ListResponse_ContainerSummary) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *ListResponse_ContainerSummary) GetState() string {
+	if m != nil && m.State != nil {
+		return *m.State
+	}
+	return ""
+}
+
+func (m *ListResponse_ContainerSummary) GetProperties() []*Property {
+	if m != nil {
+		return m.Properties
+	}
+	return nil
+}
+
 func init() {
 }
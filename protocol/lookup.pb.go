@@ -0,0 +1,63 @@
+// Code generated by protoc-gen-gogo.
+// source: lookup.proto
+// DO NOT EDIT!
+
+package garden
+
+import proto "github.com/gogo/protobuf/proto"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = math.Inf
+
+type LookupRequest struct {
+	Handle           *string `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *LookupRequest) Reset()         { *m = LookupRequest{} }
+func (m *LookupRequest) String() string { return proto.CompactTextString(m) }
+func (*LookupRequest) ProtoMessage()    {}
+
+func (m *LookupRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+type LookupResponse struct {
+	Handle           *string     `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
+	State            *string     `protobuf:"bytes,2,opt,name=state" json:"state,omitempty"`
+	Properties       []*Property `protobuf:"bytes,3,rep,name=properties" json:"properties,omitempty"`
+	XXX_unrecognized []byte      `json:"-"`
+}
+
+func (m *LookupResponse) Reset()         { *m = LookupResponse{} }
+func (m *LookupResponse) String() string { return proto.CompactTextString(m) }
+func (*LookupResponse) ProtoMessage()    {}
+
+func (m *LookupResponse) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *LookupResponse) GetState() string {
+	if m != nil && m.State != nil {
+		return *m.State
+	}
+	return ""
+}
+
+func (m *LookupResponse) GetProperties() []*Property {
+	if m != nil {
+		return m.Properties
+	}
+	return nil
+}
+
+func init() {
+}
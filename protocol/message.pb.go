@@ -19,6 +19,10 @@ const (
 	Message_Stop           Message_Type = 12
 	Message_Destroy        Message_Type = 13
 	Message_Info           Message_Type = 14
+	Message_BulkInfo       Message_Type = 15
+	Message_Lookup         Message_Type = 16
+	Message_Properties     Message_Type = 17
+	Message_SetProperties  Message_Type = 18
 	Message_NetIn          Message_Type = 31
 	Message_NetOut         Message_Type = 32
 	Message_LimitMemory    Message_Type = 51
@@ -28,11 +32,13 @@ const (
 	Message_Run            Message_Type = 71
 	Message_Attach         Message_Type = 72
 	Message_ProcessPayload Message_Type = 73
+	Message_Processes      Message_Type = 74
 	Message_Ping           Message_Type = 91
 	Message_List           Message_Type = 92
 	Message_Capacity       Message_Type = 94
 	Message_StreamIn       Message_Type = 95
 	Message_StreamOut      Message_Type = 96
+	Message_Events         Message_Type = 97
 )
 
 var Message_Type_name = map[int32]string{
@@ -41,6 +47,10 @@ var Message_Type_name = map[int32]string{
 	12: "Stop",
 	13: "Destroy",
 	14: "Info",
+	15: "BulkInfo",
+	16: "Lookup",
+	17: "Properties",
+	18: "SetProperties",
 	31: "NetIn",
 	32: "NetOut",
 	51: "LimitMemory",
@@ -50,11 +60,13 @@ var Message_Type_name = map[int32]string{
 	71: "Run",
 	72: "Attach",
 	73: "ProcessPayload",
+	74: "Processes",
 	91: "Ping",
 	92: "List",
 	94: "Capacity",
 	95: "StreamIn",
 	96: "StreamOut",
+	97: "Events",
 }
 var Message_Type_value = map[string]int32{
 	"Error":          1,
@@ -62,6 +74,10 @@ var Message_Type_value = map[string]int32{
 	"Stop":           12,
 	"Destroy":        13,
 	"Info":           14,
+	"BulkInfo":       15,
+	"Lookup":         16,
+	"Properties":     17,
+	"SetProperties":  18,
 	"NetIn":          31,
 	"NetOut":         32,
 	"LimitMemory":    51,
@@ -71,11 +87,13 @@ var Message_Type_value = map[string]int32{
 	"Run":            71,
 	"Attach":         72,
 	"ProcessPayload": 73,
+	"Processes":      74,
 	"Ping":           91,
 	"List":           92,
 	"Capacity":       94,
 	"StreamIn":       95,
 	"StreamOut":      96,
+	"Events":         97,
 }
 
 func (x Message_Type) Enum() *Message_Type {
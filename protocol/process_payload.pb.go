@@ -50,17 +50,95 @@ func (x *ProcessPayload_Source) UnmarshalJSON(data []byte) error {
 type ProcessPayload_Signal int32
 
 const (
-	ProcessPayload_terminate ProcessPayload_Signal = 0
-	ProcessPayload_kill      ProcessPayload_Signal = 1
+	ProcessPayload_terminate           ProcessPayload_Signal = 0
+	ProcessPayload_kill                ProcessPayload_Signal = 1
+	ProcessPayload_hangup              ProcessPayload_Signal = 2
+	ProcessPayload_interrupt           ProcessPayload_Signal = 3
+	ProcessPayload_quit                ProcessPayload_Signal = 4
+	ProcessPayload_abort               ProcessPayload_Signal = 5
+	ProcessPayload_user1               ProcessPayload_Signal = 6
+	ProcessPayload_user2               ProcessPayload_Signal = 7
+	ProcessPayload_alarm               ProcessPayload_Signal = 8
+	ProcessPayload_continue            ProcessPayload_Signal = 9
+	ProcessPayload_stop                ProcessPayload_Signal = 10
+	ProcessPayload_terminal_stop       ProcessPayload_Signal = 11
+	ProcessPayload_window_change       ProcessPayload_Signal = 12
+	ProcessPayload_pipe                ProcessPayload_Signal = 13
+	ProcessPayload_child               ProcessPayload_Signal = 14
+	ProcessPayload_terminal_input      ProcessPayload_Signal = 15
+	ProcessPayload_terminal_output     ProcessPayload_Signal = 16
+	ProcessPayload_trap                ProcessPayload_Signal = 17
+	ProcessPayload_urgent              ProcessPayload_Signal = 18
+	ProcessPayload_profile             ProcessPayload_Signal = 19
+	ProcessPayload_virtual_alarm       ProcessPayload_Signal = 20
+	ProcessPayload_cpu_limit           ProcessPayload_Signal = 21
+	ProcessPayload_file_size_limit     ProcessPayload_Signal = 22
+	ProcessPayload_bad_system_call     ProcessPayload_Signal = 23
+	ProcessPayload_bus_error           ProcessPayload_Signal = 24
+	ProcessPayload_floating_point      ProcessPayload_Signal = 25
+	ProcessPayload_segmentation_fault  ProcessPayload_Signal = 26
+	ProcessPayload_illegal_instruction ProcessPayload_Signal = 27
 )
 
 var ProcessPayload_Signal_name = map[int32]string{
-	0: "terminate",
-	1: "kill",
+	0:  "terminate",
+	1:  "kill",
+	2:  "hangup",
+	3:  "interrupt",
+	4:  "quit",
+	5:  "abort",
+	6:  "user1",
+	7:  "user2",
+	8:  "alarm",
+	9:  "continue",
+	10: "stop",
+	11: "terminal_stop",
+	12: "window_change",
+	13: "pipe",
+	14: "child",
+	15: "terminal_input",
+	16: "terminal_output",
+	17: "trap",
+	18: "urgent",
+	19: "profile",
+	20: "virtual_alarm",
+	21: "cpu_limit",
+	22: "file_size_limit",
+	23: "bad_system_call",
+	24: "bus_error",
+	25: "floating_point",
+	26: "segmentation_fault",
+	27: "illegal_instruction",
 }
 var ProcessPayload_Signal_value = map[string]int32{
-	"terminate": 0,
-	"kill":      1,
+	"terminate":           0,
+	"kill":                1,
+	"hangup":              2,
+	"interrupt":           3,
+	"quit":                4,
+	"abort":               5,
+	"user1":               6,
+	"user2":               7,
+	"alarm":               8,
+	"continue":            9,
+	"stop":                10,
+	"terminal_stop":       11,
+	"window_change":       12,
+	"pipe":                13,
+	"child":               14,
+	"terminal_input":      15,
+	"terminal_output":     16,
+	"trap":                17,
+	"urgent":              18,
+	"profile":             19,
+	"virtual_alarm":       20,
+	"cpu_limit":           21,
+	"file_size_limit":     22,
+	"bad_system_call":     23,
+	"bus_error":           24,
+	"floating_point":      25,
+	"segmentation_fault":  26,
+	"illegal_instruction": 27,
 }
 
 func (x ProcessPayload_Signal) Enum() *ProcessPayload_Signal {
@@ -81,14 +159,20 @@ func (x *ProcessPayload_Signal) UnmarshalJSON(data []byte) error {
 }
 
 type ProcessPayload struct {
-	ProcessId        *uint32                `protobuf:"varint,1,req,name=process_id" json:"process_id,omitempty"`
-	Source           *ProcessPayload_Source `protobuf:"varint,2,opt,name=source,enum=garden.ProcessPayload_Source" json:"source,omitempty"`
-	Data             *string                `protobuf:"bytes,3,opt,name=data" json:"data,omitempty"`
-	ExitStatus       *uint32                `protobuf:"varint,4,opt,name=exit_status" json:"exit_status,omitempty"`
-	Error            *string                `protobuf:"bytes,5,opt,name=error" json:"error,omitempty"`
-	Tty              *TTY                   `protobuf:"bytes,6,opt,name=tty" json:"tty,omitempty"`
-	Signal           *ProcessPayload_Signal `protobuf:"varint,7,opt,name=signal,enum=garden.ProcessPayload_Signal" json:"signal,omitempty"`
-	XXX_unrecognized []byte                 `json:"-"`
+	ProcessId        *uint32                  `protobuf:"varint,1,req,name=process_id" json:"process_id,omitempty"`
+	Source           *ProcessPayload_Source   `protobuf:"varint,2,opt,name=source,enum=garden.ProcessPayload_Source" json:"source,omitempty"`
+	Data             *string                  `protobuf:"bytes,3,opt,name=data" json:"data,omitempty"`
+	ExitStatus       *uint32                  `protobuf:"varint,4,opt,name=exit_status" json:"exit_status,omitempty"`
+	Error            *string                  `protobuf:"bytes,5,opt,name=error" json:"error,omitempty"`
+	Tty              *TTY                     `protobuf:"bytes,6,opt,name=tty" json:"tty,omitempty"`
+	Signal           *ProcessPayload_Signal   `protobuf:"varint,7,opt,name=signal,enum=garden.ProcessPayload_Signal" json:"signal,omitempty"`
+	ExitInfo         *ProcessPayload_ExitInfo `protobuf:"bytes,8,opt,name=exit_info" json:"exit_info,omitempty"`
+	Offset           *uint64                  `protobuf:"varint,9,opt,name=offset" json:"offset,omitempty"`
+	WindowUpdate     *uint64                  `protobuf:"varint,10,opt,name=window_update" json:"window_update,omitempty"`
+	RawData          []byte                   `protobuf:"bytes,11,opt,name=raw_data" json:"raw_data,omitempty"`
+	Open             *ProcessPayload_Open     `protobuf:"bytes,12,opt,name=open" json:"open,omitempty"`
+	Close            *bool                    `protobuf:"varint,13,opt,name=close" json:"close,omitempty"`
+	XXX_unrecognized []byte                   `json:"-"`
 }
 
 func (m *ProcessPayload) Reset()         { *m = ProcessPayload{} }
@@ -144,6 +228,128 @@ func (m *ProcessPayload) GetSignal() ProcessPayload_Signal {
 	return ProcessPayload_terminate
 }
 
+func (m *ProcessPayload) GetExitInfo() *ProcessPayload_ExitInfo {
+	if m != nil {
+		return m.ExitInfo
+	}
+	return nil
+}
+
+func (m *ProcessPayload) GetOffset() uint64 {
+	if m != nil && m.Offset != nil {
+		return *m.Offset
+	}
+	return 0
+}
+
+func (m *ProcessPayload) GetWindowUpdate() uint64 {
+	if m != nil && m.WindowUpdate != nil {
+		return *m.WindowUpdate
+	}
+	return 0
+}
+
+func (m *ProcessPayload) GetRawData() []byte {
+	if m != nil {
+		return m.RawData
+	}
+	return nil
+}
+
+func (m *ProcessPayload) GetOpen() *ProcessPayload_Open {
+	if m != nil {
+		return m.Open
+	}
+	return nil
+}
+
+func (m *ProcessPayload) GetClose() bool {
+	if m != nil && m.Close != nil {
+		return *m.Close
+	}
+	return false
+}
+
+type ProcessPayload_ExitInfo struct {
+	Signal           *ProcessPayload_Signal `protobuf:"varint,1,opt,name=signal,enum=garden.ProcessPayload_Signal" json:"signal,omitempty"`
+	OomKilled        *bool                  `protobuf:"varint,2,opt,name=oom_killed" json:"oom_killed,omitempty"`
+	Duration         *int64                 `protobuf:"varint,3,opt,name=duration" json:"duration,omitempty"`
+	XXX_unrecognized []byte                 `json:"-"`
+}
+
+func (m *ProcessPayload_ExitInfo) Reset()         { *m = ProcessPayload_ExitInfo{} }
+func (m *ProcessPayload_ExitInfo) String() string { return proto.CompactTextString(m) }
+func (*ProcessPayload_ExitInfo) ProtoMessage()    {}
+
+func (m *ProcessPayload_ExitInfo) GetSignal() ProcessPayload_Signal {
+	if m != nil && m.Signal != nil {
+		return *m.Signal
+	}
+	return ProcessPayload_terminate
+}
+
+func (m *ProcessPayload_ExitInfo) GetOomKilled() bool {
+	if m != nil && m.OomKilled != nil {
+		return *m.OomKilled
+	}
+	return false
+}
+
+func (m *ProcessPayload_ExitInfo) GetDuration() int64 {
+	if m != nil && m.Duration != nil {
+		return *m.Duration
+	}
+	return 0
+}
+
+type ProcessPayload_Open struct {
+	RequestId        *uint32        `protobuf:"varint,1,opt,name=request_id" json:"request_id,omitempty"`
+	Run              *RunRequest    `protobuf:"bytes,2,opt,name=run" json:"run,omitempty"`
+	Attach           *AttachRequest `protobuf:"bytes,3,opt,name=attach" json:"attach,omitempty"`
+	Offset           *uint64        `protobuf:"varint,4,opt,name=offset" json:"offset,omitempty"`
+	Error            *ErrorResponse `protobuf:"bytes,5,opt,name=error" json:"error,omitempty"`
+	XXX_unrecognized []byte         `json:"-"`
+}
+
+func (m *ProcessPayload_Open) Reset()         { *m = ProcessPayload_Open{} }
+func (m *ProcessPayload_Open) String() string { return proto.CompactTextString(m) }
+func (*ProcessPayload_Open) ProtoMessage()    {}
+
+func (m *ProcessPayload_Open) GetRequestId() uint32 {
+	if m != nil && m.RequestId != nil {
+		return *m.RequestId
+	}
+	return 0
+}
+
+func (m *ProcessPayload_Open) GetRun() *RunRequest {
+	if m != nil {
+		return m.Run
+	}
+	return nil
+}
+
+func (m *ProcessPayload_Open) GetAttach() *AttachRequest {
+	if m != nil {
+		return m.Attach
+	}
+	return nil
+}
+
+func (m *ProcessPayload_Open) GetOffset() uint64 {
+	if m != nil && m.Offset != nil {
+		return *m.Offset
+	}
+	return 0
+}
+
+func (m *ProcessPayload_Open) GetError() *ErrorResponse {
+	if m != nil {
+		return m.Error
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterEnum("garden.ProcessPayload_Source", ProcessPayload_Source_name, ProcessPayload_Source_value)
 	proto.RegisterEnum("garden.ProcessPayload_Signal", ProcessPayload_Signal_name, ProcessPayload_Signal_value)
@@ -0,0 +1,177 @@
+// Code generated by protoc-gen-gogo.
+// source: processes.proto
+// DO NOT EDIT!
+
+package garden
+
+import proto "github.com/gogo/protobuf/proto"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = math.Inf
+
+type ProcessesResponse_ProcessInfo_State int32
+
+const (
+	ProcessesResponse_ProcessInfo_running ProcessesResponse_ProcessInfo_State = 0
+	ProcessesResponse_ProcessInfo_exited  ProcessesResponse_ProcessInfo_State = 1
+)
+
+var ProcessesResponse_ProcessInfo_State_name = map[int32]string{
+	0: "running",
+	1: "exited",
+}
+var ProcessesResponse_ProcessInfo_State_value = map[string]int32{
+	"running": 0,
+	"exited":  1,
+}
+
+func (x ProcessesResponse_ProcessInfo_State) Enum() *ProcessesResponse_ProcessInfo_State {
+	p := new(ProcessesResponse_ProcessInfo_State)
+	*p = x
+	return p
+}
+func (x ProcessesResponse_ProcessInfo_State) String() string {
+	return proto.EnumName(ProcessesResponse_ProcessInfo_State_name, int32(x))
+}
+func (x *ProcessesResponse_ProcessInfo_State) UnmarshalJSON(data []byte) error {
+	value, err := proto.UnmarshalJSONEnum(ProcessesResponse_ProcessInfo_State_value, data, "ProcessesResponse_ProcessInfo_State")
+	if err != nil {
+		return err
+	}
+	*x = ProcessesResponse_ProcessInfo_State(value)
+	return nil
+}
+
+type ProcessesRequest struct {
+	Handle           *string `protobuf:"bytes,1,req,name=handle" json:"handle,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *ProcessesRequest) Reset()         { *m = ProcessesRequest{} }
+func (m *ProcessesRequest) String() string { return proto.CompactTextString(m) }
+func (*ProcessesRequest) ProtoMessage()    {}
+
+func (m *ProcessesRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+type ProcessesResponse struct {
+	Processes        []*ProcessesResponse_ProcessInfo `protobuf:"bytes,1,rep,name=processes" json:"processes,omitempty"`
+	XXX_unrecognized []byte                           `json:"-"`
+}
+
+func (m *ProcessesResponse) Reset()         { *m = ProcessesResponse{} }
+func (m *ProcessesResponse) String() string { return proto.CompactTextString(m) }
+func (*ProcessesResponse) ProtoMessage()    {}
+
+func (m *ProcessesResponse) GetProcesses() []*ProcessesResponse_ProcessInfo {
+	if m != nil {
+		return m.Processes
+	}
+	return nil
+}
+
+type ProcessesResponse_ProcessInfo struct {
+	ProcessId        *uint32                              `protobuf:"varint,1,req,name=process_id" json:"process_id,omitempty"`
+	Path             *string                              `protobuf:"bytes,2,opt,name=path" json:"path,omitempty"`
+	Args             []string                             `protobuf:"bytes,3,rep,name=args" json:"args,omitempty"`
+	Dir              *string                              `protobuf:"bytes,4,opt,name=dir" json:"dir,omitempty"`
+	User             *string                              `protobuf:"bytes,5,opt,name=user" json:"user,omitempty"`
+	Privileged       *bool                                `protobuf:"varint,6,opt,name=privileged" json:"privileged,omitempty"`
+	Env              []*EnvironmentVariable               `protobuf:"bytes,7,rep,name=env" json:"env,omitempty"`
+	StartedAt        *int64                               `protobuf:"varint,8,opt,name=started_at" json:"started_at,omitempty"`
+	State            *ProcessesResponse_ProcessInfo_State `protobuf:"varint,9,opt,name=state,enum=garden.ProcessesResponse_ProcessInfo_State" json:"state,omitempty"`
+	ExitStatus       *uint32                              `protobuf:"varint,10,opt,name=exit_status" json:"exit_status,omitempty"`
+	AttachedStreams  *uint32                              `protobuf:"varint,11,opt,name=attached_streams" json:"attached_streams,omitempty"`
+	XXX_unrecognized []byte                               `json:"-"`
+}
+
+func (m *ProcessesResponse_ProcessInfo) Reset()         { *m = ProcessesResponse_ProcessInfo{} }
+func (m *ProcessesResponse_ProcessInfo) String() string { return proto.CompactTextString(m) }
+func (*ProcessesResponse_ProcessInfo) ProtoMessage()    {}
+
+func (m *ProcessesResponse_ProcessInfo) GetProcessId() uint32 {
+	if m != nil && m.ProcessId != nil {
+		return *m.ProcessId
+	}
+	return 0
+}
+
+func (m *ProcessesResponse_ProcessInfo) GetPath() string {
+	if m != nil && m.Path != nil {
+		return *m.Path
+	}
+	return ""
+}
+
+func (m *ProcessesResponse_ProcessInfo) GetArgs() []string {
+	if m != nil {
+		return m.Args
+	}
+	return nil
+}
+
+func (m *ProcessesResponse_ProcessInfo) GetDir() string {
+	if m != nil && m.Dir != nil {
+		return *m.Dir
+	}
+	return ""
+}
+
+func (m *ProcessesResponse_ProcessInfo) GetUser() string {
+	if m != nil && m.User != nil {
+		return *m.User
+	}
+	return ""
+}
+
+func (m *ProcessesResponse_ProcessInfo) GetPrivileged() bool {
+	if m != nil && m.Privileged != nil {
+		return *m.Privileged
+	}
+	return false
+}
+
+func (m *ProcessesResponse_ProcessInfo) GetEnv() []*EnvironmentVariable {
+	if m != nil {
+		return m.Env
+	}
+	return nil
+}
+
+func (m *ProcessesResponse_ProcessInfo) GetStartedAt() int64 {
+	if m != nil && m.StartedAt != nil {
+		return *m.StartedAt
+	}
+	return 0
+}
+
+func (m *ProcessesResponse_ProcessInfo) GetState() ProcessesResponse_ProcessInfo_State {
+	if m != nil && m.State != nil {
+		return *m.State
+	}
+	return ProcessesResponse_ProcessInfo_running
+}
+
+func (m *ProcessesResponse_ProcessInfo) GetExitStatus() uint32 {
+	if m != nil && m.ExitStatus != nil {
+		return *m.ExitStatus
+	}
+	return 0
+}
+
+func (m *ProcessesResponse_ProcessInfo) GetAttachedStreams() uint32 {
+	if m != nil && m.AttachedStreams != nil {
+		return *m.AttachedStreams
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterEnum("garden.ProcessesResponse_ProcessInfo_State", ProcessesResponse_ProcessInfo_State_name, ProcessesResponse_ProcessInfo_State_value)
+}
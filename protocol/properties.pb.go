@@ -0,0 +1,95 @@
+// Code generated by protoc-gen-gogo.
+// source: properties.proto
+// DO NOT EDIT!
+
+package garden
+
+import proto "github.com/gogo/protobuf/proto"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = math.Inf
+
+type PropertiesRequest struct {
+	Handle           *string `protobuf:"bytes,1,opt,name=handle" json:"handle,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *PropertiesRequest) Reset()         { *m = PropertiesRequest{} }
+func (m *PropertiesRequest) String() string { return proto.CompactTextString(m) }
+func (*PropertiesRequest) ProtoMessage()    {}
+
+func (m *PropertiesRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+type PropertiesResponse struct {
+	Properties       []*Property `protobuf:"bytes,1,rep,name=properties" json:"properties,omitempty"`
+	XXX_unrecognized []byte      `json:"-"`
+}
+
+func (m *PropertiesResponse) Reset()         { *m = PropertiesResponse{} }
+func (m *PropertiesResponse) String() string { return proto.CompactTextString(m) }
+func (*PropertiesResponse) ProtoMessage()    {}
+
+func (m *PropertiesResponse) GetProperties() []*Property {
+	if m != nil {
+		return m.Properties
+	}
+	return nil
+}
+
+type SetPropertiesRequest struct {
+	Handle           *string     `protobuf:"bytes,1,opt,name=handle" json:"handle,omitempty"`
+	Properties       []*Property `protobuf:"bytes,2,rep,name=properties" json:"properties,omitempty"`
+	Removals         []string    `protobuf:"bytes,3,rep,name=removals" json:"removals,omitempty"`
+	Expected         *string     `protobuf:"bytes,4,opt,name=expected" json:"expected,omitempty"`
+	XXX_unrecognized []byte      `json:"-"`
+}
+
+func (m *SetPropertiesRequest) Reset()         { *m = SetPropertiesRequest{} }
+func (m *SetPropertiesRequest) String() string { return proto.CompactTextString(m) }
+func (*SetPropertiesRequest) ProtoMessage()    {}
+
+func (m *SetPropertiesRequest) GetHandle() string {
+	if m != nil && m.Handle != nil {
+		return *m.Handle
+	}
+	return ""
+}
+
+func (m *SetPropertiesRequest) GetProperties() []*Property {
+	if m != nil {
+		return m.Properties
+	}
+	return nil
+}
+
+func (m *SetPropertiesRequest) GetRemovals() []string {
+	if m != nil {
+		return m.Removals
+	}
+	return nil
+}
+
+func (m *SetPropertiesRequest) GetExpected() string {
+	if m != nil && m.Expected != nil {
+		return *m.Expected
+	}
+	return ""
+}
+
+type SetPropertiesResponse struct {
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *SetPropertiesResponse) Reset()         { *m = SetPropertiesResponse{} }
+func (m *SetPropertiesResponse) String() string { return proto.CompactTextString(m) }
+func (*SetPropertiesResponse) ProtoMessage()    {}
+
+func init() {
+}
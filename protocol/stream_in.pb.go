@@ -43,5 +43,29 @@ func (m *StreamInResponse) Reset()         { *m = StreamInResponse{} }
 func (m *StreamInResponse) String() string { return proto.CompactTextString(m) }
 func (*StreamInResponse) ProtoMessage()    {}
 
+type StreamInPayload struct {
+	Request          *StreamInRequest `protobuf:"bytes,1,opt,name=request" json:"request,omitempty"`
+	Data             []byte           `protobuf:"bytes,2,opt,name=data" json:"data,omitempty"`
+	XXX_unrecognized []byte           `json:"-"`
+}
+
+func (m *StreamInPayload) Reset()         { *m = StreamInPayload{} }
+func (m *StreamInPayload) String() string { return proto.CompactTextString(m) }
+func (*StreamInPayload) ProtoMessage()    {}
+
+func (m *StreamInPayload) GetRequest() *StreamInRequest {
+	if m != nil {
+		return m.Request
+	}
+	return nil
+}
+
+func (m *StreamInPayload) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
 func init() {
 }
@@ -43,5 +43,21 @@ func (m *StreamOutResponse) Reset()         { *m = StreamOutResponse{} }
 func (m *StreamOutResponse) String() string { return proto.CompactTextString(m) }
 func (*StreamOutResponse) ProtoMessage()    {}
 
+type StreamOutPayload struct {
+	Data             []byte `protobuf:"bytes,1,opt,name=data" json:"data,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *StreamOutPayload) Reset()         { *m = StreamOutPayload{} }
+func (m *StreamOutPayload) String() string { return proto.CompactTextString(m) }
+func (*StreamOutPayload) ProtoMessage()    {}
+
+func (m *StreamOutPayload) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
 func init() {
 }
@@ -43,6 +43,14 @@ func TypeForMessage(msg proto.Message) Message_Type {
 		return Message_Destroy
 	case *InfoRequest, *InfoResponse:
 		return Message_Info
+	case *BulkInfoRequest, *BulkInfoResponse:
+		return Message_BulkInfo
+	case *LookupRequest, *LookupResponse:
+		return Message_Lookup
+	case *PropertiesRequest, *PropertiesResponse:
+		return Message_Properties
+	case *SetPropertiesRequest, *SetPropertiesResponse:
+		return Message_SetProperties
 
 	case *NetInRequest, *NetInResponse:
 		return Message_NetIn
@@ -67,6 +75,8 @@ func TypeForMessage(msg proto.Message) Message_Type {
 		return Message_Run
 	case *AttachRequest:
 		return Message_Attach
+	case *ProcessesRequest, *ProcessesResponse:
+		return Message_Processes
 	case *ProcessPayload:
 		return Message_ProcessPayload
 
@@ -76,6 +86,8 @@ func TypeForMessage(msg proto.Message) Message_Type {
 		return Message_List
 	case *CapacityRequest, *CapacityResponse:
 		return Message_Capacity
+	case *EventsRequest, *Event:
+		return Message_Events
 	}
 
 	panic("unknown message type")
@@ -91,6 +103,14 @@ func RequestMessageForType(t Message_Type) proto.Message {
 		return &DestroyRequest{}
 	case Message_Info:
 		return &InfoRequest{}
+	case Message_BulkInfo:
+		return &BulkInfoRequest{}
+	case Message_Lookup:
+		return &LookupRequest{}
+	case Message_Properties:
+		return &PropertiesRequest{}
+	case Message_SetProperties:
+		return &SetPropertiesRequest{}
 
 	case Message_NetIn:
 		return &NetInRequest{}
@@ -115,6 +135,8 @@ func RequestMessageForType(t Message_Type) proto.Message {
 		return &RunRequest{}
 	case Message_Attach:
 		return &AttachRequest{}
+	case Message_Processes:
+		return &ProcessesRequest{}
 
 	case Message_Ping:
 		return &PingRequest{}
@@ -122,6 +144,8 @@ func RequestMessageForType(t Message_Type) proto.Message {
 		return &ListRequest{}
 	case Message_Capacity:
 		return &CapacityRequest{}
+	case Message_Events:
+		return &EventsRequest{}
 	}
 
 	panic("unknown message type")
@@ -137,6 +161,14 @@ func ResponseMessageForType(t Message_Type) proto.Message {
 		return &DestroyResponse{}
 	case Message_Info:
 		return &InfoResponse{}
+	case Message_BulkInfo:
+		return &BulkInfoResponse{}
+	case Message_Lookup:
+		return &LookupResponse{}
+	case Message_Properties:
+		return &PropertiesResponse{}
+	case Message_SetProperties:
+		return &SetPropertiesResponse{}
 	case Message_NetIn:
 		return &NetInResponse{}
 	case Message_NetOut:
@@ -158,6 +190,8 @@ func ResponseMessageForType(t Message_Type) proto.Message {
 
 	case Message_Run, Message_Attach:
 		return &ProcessPayload{}
+	case Message_Processes:
+		return &ProcessesResponse{}
 
 	case Message_Ping:
 		return &PingResponse{}
@@ -165,6 +199,8 @@ func ResponseMessageForType(t Message_Type) proto.Message {
 		return &ListResponse{}
 	case Message_Capacity:
 		return &CapacityResponse{}
+	case Message_Events:
+		return &Event{}
 	}
 
 	panic("unknown message type")
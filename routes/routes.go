@@ -6,10 +6,12 @@ const (
 	Ping     = "Ping"
 	Capacity = "Capacity"
 
-	List    = "List"
-	Create  = "Create"
-	Info    = "Info"
-	Destroy = "Destroy"
+	List     = "List"
+	Create   = "Create"
+	Info     = "Info"
+	BulkInfo = "BulkInfo"
+	Lookup   = "Lookup"
+	Destroy  = "Destroy"
 
 	Stop = "Stop"
 
@@ -31,12 +33,28 @@ const (
 	NetIn  = "NetIn"
 	NetOut = "NetOut"
 
-	Run    = "Run"
-	Attach = "Attach"
+	Run       = "Run"
+	Attach    = "Attach"
+	Processes = "Processes"
+
+	RunWebSocket    = "RunWebSocket"
+	AttachWebSocket = "AttachWebSocket"
 
 	GetProperty    = "GetProperty"
 	SetProperty    = "SetProperty"
 	RemoveProperty = "RemoveProperty"
+	Properties     = "Properties"
+	SetProperties  = "SetProperties"
+
+	Events = "Events"
+
+	Session = "Session"
+
+	Metrics          = "Metrics"
+	ContainerMetrics = "ContainerMetrics"
+
+	Ready = "Ready"
+	Drain = "Drain"
 )
 
 var Routes = rata.Routes{
@@ -47,6 +65,9 @@ var Routes = rata.Routes{
 	{Path: "/containers", Method: "POST", Name: Create},
 
 	{Path: "/containers/:handle/info", Method: "GET", Name: Info},
+	{Path: "/bulk_info", Method: "GET", Name: BulkInfo},
+
+	{Path: "/containers/:handle", Method: "GET", Name: Lookup},
 
 	{Path: "/containers/:handle", Method: "DELETE", Name: Destroy},
 	{Path: "/containers/:handle/stop", Method: "PUT", Name: Stop},
@@ -70,9 +91,27 @@ var Routes = rata.Routes{
 	{Path: "/containers/:handle/net/out", Method: "POST", Name: NetOut},
 
 	{Path: "/containers/:handle/processes", Method: "POST", Name: Run},
+
+	// must come before Attach
+	{Path: "/containers/:handle/processes/websocket", Method: "GET", Name: RunWebSocket},
+
 	{Path: "/containers/:handle/processes/:pid", Method: "GET", Name: Attach},
+	{Path: "/containers/:handle/processes/:pid/websocket", Method: "GET", Name: AttachWebSocket},
+	{Path: "/containers/:handle/processes", Method: "GET", Name: Processes},
 
 	{Path: "/containers/:handle/properties/:key", Method: "GET", Name: GetProperty},
 	{Path: "/containers/:handle/properties/:key", Method: "PUT", Name: SetProperty},
 	{Path: "/containers/:handle/properties/:key", Method: "DELETE", Name: RemoveProperty},
+	{Path: "/containers/:handle/properties", Method: "GET", Name: Properties},
+	{Path: "/containers/:handle/properties", Method: "PATCH", Name: SetProperties},
+
+	{Path: "/events", Method: "GET", Name: Events},
+
+	{Path: "/session", Method: "GET", Name: Session},
+
+	{Path: "/metrics", Method: "GET", Name: Metrics},
+	{Path: "/metrics/containers", Method: "GET", Name: ContainerMetrics},
+
+	{Path: "/ready", Method: "GET", Name: Ready},
+	{Path: "/drain", Method: "PUT", Name: Drain},
 }
@@ -0,0 +1,245 @@
+package garden
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Selector selects containers by their properties. Each of its requirements
+// must hold for a container to be selected.
+//
+// Selectors are written as comma-separated requirements:
+//
+//	key=value        the property is set to value
+//	key!=value       the property is not set to value, or not set at all
+//	key              the property is set
+//	!key             the property is not set
+//	key^=prefix      the property's value starts with prefix
+//	key in (a,b,c)   the property is set to one of the values
+//
+// Keys and values are trimmed of surrounding whitespace, and may not contain
+// commas, parentheses or the characters of operators.
+type Selector []SelectorRequirement
+
+type SelectorOperator string
+
+const (
+	SelectorEquals    SelectorOperator = "="
+	SelectorNotEquals SelectorOperator = "!="
+	SelectorExists    SelectorOperator = "exists"
+	SelectorNotExists SelectorOperator = "!exists"
+	SelectorPrefix    SelectorOperator = "^="
+	SelectorIn        SelectorOperator = "in"
+)
+
+// SelectorRequirement is a single requirement of a Selector. Values holds the
+// value or prefix compared against, or the set of values for SelectorIn, and
+// is empty for SelectorExists and SelectorNotExists.
+type SelectorRequirement struct {
+	Key      string
+	Operator SelectorOperator
+	Values   []string
+}
+
+// SelectorFromProperties returns the selector requiring each of the
+// properties to be set to its value, as Containers filters by them.
+func SelectorFromProperties(properties Properties) Selector {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	selector := make(Selector, len(keys))
+	for i, key := range keys {
+		selector[i] = SelectorRequirement{
+			Key:      key,
+			Operator: SelectorEquals,
+			Values:   []string{properties[key]},
+		}
+	}
+
+	return selector
+}
+
+// ParseSelector parses a selector written as described by Selector.
+func ParseSelector(selector string) (Selector, error) {
+	parsed := Selector{}
+
+	for _, requirement := range splitRequirements(selector) {
+		requirement = strings.TrimSpace(requirement)
+		if requirement == "" {
+			continue
+		}
+
+		req, err := parseRequirement(requirement)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector requirement %q: %s", requirement, err)
+		}
+
+		parsed = append(parsed, req)
+	}
+
+	return parsed, nil
+}
+
+// splitRequirements splits a selector at the commas outside of parentheses.
+func splitRequirements(selector string) []string {
+	var requirements []string
+
+	depth := 0
+	start := 0
+
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				requirements = append(requirements, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(requirements, selector[start:])
+}
+
+func parseRequirement(requirement string) (SelectorRequirement, error) {
+	if strings.HasPrefix(requirement, "!") && !strings.Contains(requirement, "=") {
+		key := strings.TrimSpace(requirement[1:])
+		return SelectorRequirement{Key: key, Operator: SelectorNotExists}, validKey(key)
+	}
+
+	for _, operator := range []SelectorOperator{SelectorNotEquals, SelectorPrefix, SelectorEquals} {
+		if i := strings.Index(requirement, string(operator)); i >= 0 {
+			key := strings.TrimSpace(requirement[:i])
+			value := strings.TrimSpace(requirement[i+len(operator):])
+
+			if err := validValue(value); err != nil {
+				return SelectorRequirement{}, err
+			}
+
+			return SelectorRequirement{Key: key, Operator: operator, Values: []string{value}}, validKey(key)
+		}
+	}
+
+	if fields := strings.Fields(requirement); len(fields) > 1 && fields[1] == string(SelectorIn) {
+		key := fields[0]
+		set := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(requirement[len(key):]), string(SelectorIn)))
+
+		if !strings.HasPrefix(set, "(") || !strings.HasSuffix(set, ")") {
+			return SelectorRequirement{}, fmt.Errorf("set of values must be in parentheses")
+		}
+
+		values := []string{}
+		for _, value := range strings.Split(set[1:len(set)-1], ",") {
+			value = strings.TrimSpace(value)
+			if err := validValue(value); err != nil {
+				return SelectorRequirement{}, err
+			}
+
+			values = append(values, value)
+		}
+
+		return SelectorRequirement{Key: key, Operator: SelectorIn, Values: values}, validKey(key)
+	}
+
+	return SelectorRequirement{Key: requirement, Operator: SelectorExists}, validKey(requirement)
+}
+
+func validKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("missing key")
+	}
+
+	if strings.ContainsAny(key, " \t!=^(),") {
+		return fmt.Errorf("invalid key %q", key)
+	}
+
+	return nil
+}
+
+func validValue(value string) error {
+	if strings.ContainsAny(value, "!=^(),") {
+		return fmt.Errorf("invalid value %q", value)
+	}
+
+	return nil
+}
+
+// Matches returns whether the properties meet all of the selector's
+// requirements.
+func (selector Selector) Matches(properties Properties) bool {
+	for _, requirement := range selector {
+		if !requirement.Matches(properties) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Matches returns whether the properties meet the requirement.
+func (requirement SelectorRequirement) Matches(properties Properties) bool {
+	value, found := properties[requirement.Key]
+
+	switch requirement.Operator {
+	case SelectorEquals:
+		return found && value == requirement.value()
+	case SelectorNotEquals:
+		return !found || value != requirement.value()
+	case SelectorExists:
+		return found
+	case SelectorNotExists:
+		return !found
+	case SelectorPrefix:
+		return found && strings.HasPrefix(value, requirement.value())
+	case SelectorIn:
+		if !found {
+			return false
+		}
+
+		for _, candidate := range requirement.Values {
+			if value == candidate {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (requirement SelectorRequirement) value() string {
+	if len(requirement.Values) == 0 {
+		return ""
+	}
+
+	return requirement.Values[0]
+}
+
+func (selector Selector) String() string {
+	requirements := make([]string, len(selector))
+	for i, requirement := range selector {
+		requirements[i] = requirement.String()
+	}
+
+	return strings.Join(requirements, ",")
+}
+
+func (requirement SelectorRequirement) String() string {
+	switch requirement.Operator {
+	case SelectorExists:
+		return requirement.Key
+	case SelectorNotExists:
+		return "!" + requirement.Key
+	case SelectorIn:
+		return requirement.Key + " in (" + strings.Join(requirement.Values, ",") + ")"
+	default:
+		return requirement.Key + string(requirement.Operator) + requirement.value()
+	}
+}
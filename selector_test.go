@@ -0,0 +1,106 @@
+package garden_test
+
+import (
+	"github.com/cloudfoundry-incubator/garden"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Selector", func() {
+	Describe("ParseSelector", func() {
+		It("parses each kind of requirement", func() {
+			selector, err := garden.ParseSelector("tenant=a, env != prod,owner,!deleted,name^=web-,zone in (z1, z2)")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(selector).Should(Equal(garden.Selector{
+				{Key: "tenant", Operator: garden.SelectorEquals, Values: []string{"a"}},
+				{Key: "env", Operator: garden.SelectorNotEquals, Values: []string{"prod"}},
+				{Key: "owner", Operator: garden.SelectorExists},
+				{Key: "deleted", Operator: garden.SelectorNotExists},
+				{Key: "name", Operator: garden.SelectorPrefix, Values: []string{"web-"}},
+				{Key: "zone", Operator: garden.SelectorIn, Values: []string{"z1", "z2"}},
+			}))
+		})
+
+		It("parses what it formats", func() {
+			selector := "tenant=a,env!=prod,owner,!deleted,name^=web-,zone in (z1,z2)"
+
+			parsed, err := garden.ParseSelector(selector)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(parsed.String()).Should(Equal(selector))
+		})
+
+		It("parses an empty selector", func() {
+			selector, err := garden.ParseSelector("")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(selector).Should(BeEmpty())
+		})
+
+		invalid := map[string]string{
+			"with no key":                     "=a",
+			"with no key to negate":           "!",
+			"with a set not in parentheses":   "zone in z1",
+			"with an operator in the value":   "a=b=c",
+			"with whitespace in the key":      "some key",
+			"with unbalanced parentheses":     "zone in (z1,z2",
+			"with an operator in a set value": "zone in (a=b)",
+		}
+
+		for description, selector := range invalid {
+			selector := selector
+
+			It("rejects a selector "+description, func() {
+				_, err := garden.ParseSelector(selector)
+				Ω(err).Should(HaveOccurred())
+			})
+		}
+	})
+
+	Describe("Matches", func() {
+		properties := garden.Properties{
+			"tenant": "a",
+			"name":   "web-1",
+			"zone":   "z2",
+		}
+
+		matches := map[string]bool{
+			"":                      true,
+			"tenant=a":              true,
+			"tenant=b":              false,
+			"tenant!=b":             true,
+			"tenant!=a":             false,
+			"env!=prod":             true,
+			"name":                  true,
+			"owner":                 false,
+			"!owner":                true,
+			"!name":                 false,
+			"name^=web-":            true,
+			"name^=worker-":         false,
+			"zone in (z1,z2)":       true,
+			"zone in (z3)":          false,
+			"owner in (z2)":         false,
+			"tenant=a,name^=web-":   true,
+			"tenant=a,name^=work-":  false,
+			"tenant=a,zone in (z1)": false,
+		}
+
+		for selector, matched := range matches {
+			selector, matched := selector, matched
+
+			It("reports whether the properties meet "+selector, func() {
+				parsed, err := garden.ParseSelector(selector)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(parsed.Matches(properties)).Should(Equal(matched))
+			})
+		}
+	})
+
+	It("selects containers by properties as Containers filters them", func() {
+		selector := garden.SelectorFromProperties(garden.Properties{"b": "2", "a": "1"})
+		Ω(selector.String()).Should(Equal("a=1,b=2"))
+
+		Ω(selector.Matches(garden.Properties{"a": "1", "b": "2", "c": "3"})).Should(BeTrue())
+		Ω(selector.Matches(garden.Properties{"a": "1"})).Should(BeFalse())
+	})
+})
@@ -0,0 +1,334 @@
+package server
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pivotal-golang/lager"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/peer"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden/server/audit"
+)
+
+// WithAudit writes a record of every mutating operation to the sink: creating,
+// destroying and stopping containers, running and attaching to processes,
+// changing limits, opening ports, streaming in files, and changing properties.
+func WithAudit(sink audit.Sink) Option {
+	return func(s *GardenServer) {
+		s.auditSink = sink
+	}
+}
+
+type peerKey struct{}
+
+func withPeer(ctx context.Context, addr string) context.Context {
+	return context.WithValue(ctx, peerKey{}, addr)
+}
+
+// peerFrom returns the address the caller of a request connected from.
+func peerFrom(ctx context.Context) string {
+	if addr, ok := ctx.Value(peerKey{}).(string); ok {
+		return addr
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+
+	return ""
+}
+
+// auditor records the operations of the caller of a request.
+type auditor struct {
+	sink   audit.Sink
+	logger lager.Logger
+
+	identity string
+	peer     string
+}
+
+func (s *GardenServer) auditorFor(ctx context.Context) *auditor {
+	auditor := &auditor{
+		sink:   s.auditSink,
+		logger: s.logger.Session("audit"),
+		peer:   peerFrom(ctx),
+	}
+
+	if identity := identityFrom(ctx); identity != nil {
+		auditor.identity = identity.Name
+	}
+
+	return auditor
+}
+
+func (a *auditor) record(operation string, handle string, parameters map[string]interface{}, err error) {
+	record := audit.Record{
+		Time:       time.Now(),
+		Identity:   a.identity,
+		Peer:       a.peer,
+		Handle:     handle,
+		Operation:  operation,
+		Parameters: parameters,
+		Outcome:    audit.OutcomeSucceeded,
+	}
+
+	if err != nil {
+		record.Outcome = audit.OutcomeFailed
+		record.Error = err.Error()
+
+		if _, forbidden := err.(garden.ForbiddenError); forbidden {
+			record.Outcome = audit.OutcomeDenied
+		}
+	}
+
+	writeErr := a.sink.Write(record)
+	if writeErr != nil {
+		a.logger.Error("failed-to-write", writeErr, lager.Data{
+			"operation": operation,
+			"handle":    handle,
+		})
+	}
+}
+
+// auditedBackend records the mutating operations on a backend.
+type auditedBackend struct {
+	garden.Backend
+
+	auditor *auditor
+}
+
+func (b *auditedBackend) Create(spec garden.ContainerSpec) (garden.Container, error) {
+	bindMounts := []map[string]interface{}{}
+	for _, bindMount := range spec.BindMounts {
+		bindMounts = append(bindMounts, map[string]interface{}{
+			"src":  bindMount.SrcPath,
+			"dst":  bindMount.DstPath,
+			"mode": bindMount.Mode,
+		})
+	}
+
+	propertyKeys := []string{}
+	for key := range spec.Properties {
+		propertyKeys = append(propertyKeys, key)
+	}
+
+	parameters := map[string]interface{}{
+		"rootfs":      spec.RootFSPath,
+		"network":     spec.Network,
+		"privileged":  spec.Privileged,
+		"grace-time":  spec.GraceTime.String(),
+		"bind-mounts": bindMounts,
+		"env":         envNames(spec.Env),
+		"properties":  propertyKeys,
+	}
+
+	container, err := b.Backend.Create(spec)
+
+	handle := spec.Handle
+	if err == nil {
+		handle = container.Handle()
+	}
+
+	b.auditor.record("create", handle, parameters, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return b.container(container), nil
+}
+
+func (b *auditedBackend) Destroy(handle string) error {
+	err := b.Backend.Destroy(handle)
+	b.auditor.record("destroy", handle, nil, err)
+	return err
+}
+
+func (b *auditedBackend) Lookup(handle string) (garden.Container, error) {
+	container, err := b.Backend.Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.container(container), nil
+}
+
+func (b *auditedBackend) container(container garden.Container) garden.Container {
+	return &auditedContainer{
+		Container: container,
+		auditor:   b.auditor,
+	}
+}
+
+// auditedContainer records the mutating operations on a container.
+type auditedContainer struct {
+	garden.Container
+
+	auditor *auditor
+}
+
+func (c *auditedContainer) record(operation string, parameters map[string]interface{}, err error) {
+	c.auditor.record(operation, c.Handle(), parameters, err)
+}
+
+func (c *auditedContainer) Stop(kill bool) error {
+	err := c.Container.Stop(kill)
+	c.record("stop", map[string]interface{}{"kill": kill}, err)
+	return err
+}
+
+func (c *auditedContainer) StreamIn(dstPath string, tarStream io.Reader) error {
+	err := c.Container.StreamIn(dstPath, tarStream)
+	c.record("stream-in", map[string]interface{}{"destination": dstPath}, err)
+	return err
+}
+
+func (c *auditedContainer) LimitBandwidth(limits garden.BandwidthLimits) error {
+	err := c.Container.LimitBandwidth(limits)
+	c.record("limit-bandwidth", map[string]interface{}{"limits": limits}, err)
+	return err
+}
+
+func (c *auditedContainer) LimitCPU(limits garden.CPULimits) error {
+	err := c.Container.LimitCPU(limits)
+	c.record("limit-cpu", map[string]interface{}{"limits": limits}, err)
+	return err
+}
+
+func (c *auditedContainer) LimitDisk(limits garden.DiskLimits) error {
+	err := c.Container.LimitDisk(limits)
+	c.record("limit-disk", map[string]interface{}{"limits": limits}, err)
+	return err
+}
+
+func (c *auditedContainer) LimitMemory(limits garden.MemoryLimits) error {
+	err := c.Container.LimitMemory(limits)
+	c.record("limit-memory", map[string]interface{}{"limits": limits}, err)
+	return err
+}
+
+func (c *auditedContainer) NetIn(hostPort, containerPort uint32) (uint32, uint32, error) {
+	assignedHostPort, assignedContainerPort, err := c.Container.NetIn(hostPort, containerPort)
+
+	// record the ports that were opened, which may have been chosen for
+	// the caller
+	if err == nil {
+		hostPort, containerPort = assignedHostPort, assignedContainerPort
+	}
+
+	c.record("net-in", map[string]interface{}{
+		"host-port":      hostPort,
+		"container-port": containerPort,
+	}, err)
+
+	return assignedHostPort, assignedContainerPort, err
+}
+
+func (c *auditedContainer) NetOut(rule garden.NetOutRule) error {
+	err := c.Container.NetOut(rule)
+	c.record("net-out", map[string]interface{}{"rule": rule}, err)
+	return err
+}
+
+func (c *auditedContainer) Run(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+	process, err := c.Container.Run(spec, io)
+
+	parameters := map[string]interface{}{
+		"path":       spec.Path,
+		"args":       spec.Args,
+		"dir":        spec.Dir,
+		"user":       spec.User,
+		"privileged": spec.Privileged,
+		"tty":        spec.TTY != nil,
+		"env":        envNames(spec.Env),
+	}
+
+	if err == nil {
+		parameters["process-id"] = process.ID()
+	}
+
+	c.record("run", parameters, err)
+
+	return process, err
+}
+
+func (c *auditedContainer) Attach(processID uint32, io garden.ProcessIO) (garden.Process, error) {
+	process, err := c.Container.Attach(processID, io)
+	c.record("attach", map[string]interface{}{"process-id": processID}, err)
+	return process, err
+}
+
+func (c *auditedContainer) AttachFrom(processID uint32, offset uint64, io garden.ProcessIO) (garden.Process, error) {
+	process, err := c.Container.AttachFrom(processID, offset, io)
+
+	// replaying is not supported everywhere; the attach that follows is
+	// recorded instead
+	if err != garden.ErrNotImplemented {
+		c.record("attach", map[string]interface{}{
+			"process-id": processID,
+			"offset":     offset,
+		}, err)
+	}
+
+	return process, err
+}
+
+func (c *auditedContainer) SetProperty(name string, value string) error {
+	err := c.Container.SetProperty(name, value)
+	c.record("set-property", map[string]interface{}{"name": name}, err)
+	return err
+}
+
+func (c *auditedContainer) RemoveProperty(name string) error {
+	err := c.Container.RemoveProperty(name)
+	c.record("remove-property", map[string]interface{}{"name": name}, err)
+	return err
+}
+
+func (c *auditedContainer) SetProperties(properties garden.Properties, removals []string) error {
+	err := c.Container.SetProperties(properties, removals)
+	c.recordProperties(properties, removals, err)
+	return err
+}
+
+func (c *auditedContainer) CompareAndSetProperties(expected garden.Selector, properties garden.Properties, removals []string) error {
+	err := c.Container.CompareAndSetProperties(expected, properties, removals)
+	c.recordProperties(properties, removals, err)
+	return err
+}
+
+// recordProperties records the names of the properties set and removed,
+// unless the backend could not set them at once, in which case each change
+// made instead is recorded on its own.
+func (c *auditedContainer) recordProperties(properties garden.Properties, removals []string, err error) {
+	if err == garden.ErrNotImplemented {
+		return
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	c.record("set-properties", map[string]interface{}{
+		"names":    names,
+		"removals": removals,
+	}, err)
+}
+
+// envNames returns the names of the environment variables, without their
+// values.
+func envNames(env []string) []string {
+	names := make([]string, len(env))
+	for i, variable := range env {
+		names[i] = strings.SplitN(variable, "=", 2)[0]
+	}
+
+	return names
+}
@@ -0,0 +1,37 @@
+package audit
+
+import "time"
+
+// Outcome is how an audited operation ended.
+type Outcome string
+
+const (
+	OutcomeSucceeded Outcome = "succeeded"
+	OutcomeFailed    Outcome = "failed"
+	OutcomeDenied    Outcome = "denied"
+)
+
+// Record is the audit record of a single mutating operation.
+type Record struct {
+	Time time.Time `json:"time"`
+
+	// Identity is the name the caller authenticated as, if the server
+	// authenticates requests; Peer is the address it connected from.
+	Identity string `json:"identity,omitempty"`
+	Peer     string `json:"peer,omitempty"`
+
+	Handle    string `json:"handle"`
+	Operation string `json:"operation"`
+
+	// Parameters are those of the operation, without secrets such as
+	// environment variable values or property values.
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+
+	Outcome Outcome `json:"outcome"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// Sink durably stores audit records.
+type Sink interface {
+	Write(Record) error
+}
@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileSink writes records to a file as lines of JSON. Once the file would grow
+// past its size limit, it is rotated: it is renamed with the suffix .1, older
+// files' suffixes are incremented, and those beyond the backups to keep are
+// removed. If it cannot be rotated, records are still written to it.
+type FileSink struct {
+	path     string
+	maxBytes int64
+	backups  int
+
+	file *os.File
+	size int64
+	mu   sync.Mutex
+}
+
+// NewFileSink opens a sink appending to the file at path, which is rotated
+// past maxBytes keeping the given number of backups.
+func NewFileSink(path string, maxBytes int64, backups int) (*FileSink, error) {
+	sink := &FileSink{
+		path:     path,
+		maxBytes: maxBytes,
+		backups:  backups,
+	}
+
+	err := sink.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return sink, nil
+}
+
+func (sink *FileSink) Write(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	line = append(line, '\n')
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	if sink.file == nil {
+		return fmt.Errorf("audit log closed: %s", sink.path)
+	}
+
+	var rotateErr error
+	if sink.size > 0 && sink.size+int64(len(line)) > sink.maxBytes {
+		rotateErr = sink.rotate()
+	}
+
+	n, err := sink.file.Write(line)
+	sink.size += int64(n)
+
+	if err != nil {
+		return err
+	}
+
+	return rotateErr
+}
+
+func (sink *FileSink) Close() error {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	if sink.file == nil {
+		return nil
+	}
+
+	err := sink.file.Close()
+	sink.file = nil
+
+	return err
+}
+
+func (sink *FileSink) open() error {
+	file, err := os.OpenFile(sink.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	sink.file = file
+	sink.size = info.Size()
+
+	return nil
+}
+
+// rotate starts a new file, leaving the sink writing to the current one if it
+// fails to.
+func (sink *FileSink) rotate() error {
+	if sink.backups == 0 {
+		err := sink.file.Truncate(0)
+		if err != nil {
+			return err
+		}
+
+		sink.size = 0
+
+		return nil
+	}
+
+	err := sink.shift()
+	if err != nil {
+		return err
+	}
+
+	previous := sink.file
+
+	err = sink.open()
+	if err != nil {
+		return err
+	}
+
+	return previous.Close()
+}
+
+// shift moves the file and its backups along by one suffix.
+func (sink *FileSink) shift() error {
+	for i := sink.backups - 1; i > 0; i-- {
+		err := os.Rename(sink.backup(i), sink.backup(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return os.Rename(sink.path, sink.backup(1))
+}
+
+func (sink *FileSink) backup(n int) string {
+	return fmt.Sprintf("%s.%d", sink.path, n)
+}
@@ -0,0 +1,177 @@
+package audit_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/garden/server/audit"
+)
+
+var _ = Describe("FileSink", func() {
+	var tmpdir string
+	var logPath string
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "audit-test")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		logPath = filepath.Join(tmpdir, "audit.log")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpdir)
+	})
+
+	readRecords := func(path string) []audit.Record {
+		file, err := os.Open(path)
+		Ω(err).ShouldNot(HaveOccurred())
+		defer file.Close()
+
+		records := []audit.Record{}
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var record audit.Record
+			Ω(json.Unmarshal(scanner.Bytes(), &record)).Should(Succeed())
+			records = append(records, record)
+		}
+
+		return records
+	}
+
+	It("writes each record as a line of JSON", func() {
+		sink, err := audit.NewFileSink(logPath, 1024*1024, 1)
+		Ω(err).ShouldNot(HaveOccurred())
+		defer sink.Close()
+
+		Ω(sink.Write(audit.Record{
+			Identity:   "some-identity",
+			Handle:     "some-handle",
+			Operation:  "create",
+			Parameters: map[string]interface{}{"rootfs": "some-rootfs"},
+			Outcome:    audit.OutcomeSucceeded,
+		})).Should(Succeed())
+
+		Ω(sink.Write(audit.Record{
+			Handle:    "some-handle",
+			Operation: "destroy",
+			Outcome:   audit.OutcomeFailed,
+			Error:     "oh no!",
+		})).Should(Succeed())
+
+		records := readRecords(logPath)
+		Ω(records).Should(HaveLen(2))
+
+		Ω(records[0].Identity).Should(Equal("some-identity"))
+		Ω(records[0].Operation).Should(Equal("create"))
+		Ω(records[0].Parameters).Should(Equal(map[string]interface{}{"rootfs": "some-rootfs"}))
+
+		Ω(records[1].Outcome).Should(Equal(audit.OutcomeFailed))
+		Ω(records[1].Error).Should(Equal("oh no!"))
+	})
+
+	It("appends to an existing file", func() {
+		sink, err := audit.NewFileSink(logPath, 1024*1024, 1)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(sink.Write(audit.Record{Operation: "create"})).Should(Succeed())
+		Ω(sink.Close()).Should(Succeed())
+
+		sink, err = audit.NewFileSink(logPath, 1024*1024, 1)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(sink.Write(audit.Record{Operation: "destroy"})).Should(Succeed())
+		Ω(sink.Close()).Should(Succeed())
+
+		Ω(readRecords(logPath)).Should(HaveLen(2))
+	})
+
+	It("creates the file readable by its owner only", func() {
+		sink, err := audit.NewFileSink(logPath, 1024*1024, 1)
+		Ω(err).ShouldNot(HaveOccurred())
+		defer sink.Close()
+
+		info, err := os.Stat(logPath)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(info.Mode().Perm()).Should(Equal(os.FileMode(0600)))
+	})
+
+	Context("when the file would grow past its size limit", func() {
+		It("rotates it, keeping the given number of backups", func() {
+			sink, err := audit.NewFileSink(logPath, 100, 2)
+			Ω(err).ShouldNot(HaveOccurred())
+			defer sink.Close()
+
+			for _, handle := range []string{"first", "second", "third", "fourth"} {
+				Ω(sink.Write(audit.Record{Handle: handle, Operation: "create"})).Should(Succeed())
+			}
+
+			current := readRecords(logPath)
+			Ω(current).Should(HaveLen(1))
+			Ω(current[0].Handle).Should(Equal("fourth"))
+
+			Ω(readRecords(logPath + ".1")[0].Handle).Should(Equal("third"))
+			Ω(readRecords(logPath + ".2")[0].Handle).Should(Equal("second"))
+
+			_, err = os.Stat(logPath + ".3")
+			Ω(os.IsNotExist(err)).Should(BeTrue())
+		})
+
+		Context("and no backups are kept", func() {
+			It("starts the file afresh", func() {
+				sink, err := audit.NewFileSink(logPath, 100, 0)
+				Ω(err).ShouldNot(HaveOccurred())
+				defer sink.Close()
+
+				Ω(sink.Write(audit.Record{Handle: "first"})).Should(Succeed())
+				Ω(sink.Write(audit.Record{Handle: "second"})).Should(Succeed())
+
+				current := readRecords(logPath)
+				Ω(current).Should(HaveLen(1))
+				Ω(current[0].Handle).Should(Equal("second"))
+
+				_, err = os.Stat(logPath + ".1")
+				Ω(os.IsNotExist(err)).Should(BeTrue())
+			})
+		})
+	})
+
+	Context("when the file cannot be rotated", func() {
+		BeforeEach(func() {
+			// a backup that cannot be replaced
+			Ω(os.MkdirAll(filepath.Join(logPath+".1", "in-the-way"), 0755)).Should(Succeed())
+		})
+
+		It("keeps writing records to the file, and reports the error", func() {
+			sink, err := audit.NewFileSink(logPath, 100, 1)
+			Ω(err).ShouldNot(HaveOccurred())
+			defer sink.Close()
+
+			Ω(sink.Write(audit.Record{Handle: "first"})).Should(Succeed())
+			Ω(sink.Write(audit.Record{Handle: "second"})).ShouldNot(Succeed())
+			Ω(sink.Write(audit.Record{Handle: "third"})).ShouldNot(Succeed())
+
+			handles := []string{}
+			for _, record := range readRecords(logPath) {
+				handles = append(handles, record.Handle)
+			}
+
+			Ω(handles).Should(Equal([]string{"first", "second", "third"}))
+		})
+	})
+
+	Context("when closed", func() {
+		It("fails to write", func() {
+			sink, err := audit.NewFileSink(logPath, 1024*1024, 1)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(sink.Close()).Should(Succeed())
+
+			Ω(sink.Write(audit.Record{})).ShouldNot(Succeed())
+		})
+	})
+})
@@ -0,0 +1,240 @@
+package server_test
+
+import (
+	"errors"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden/client"
+	"github.com/cloudfoundry-incubator/garden/client/connection"
+	"github.com/cloudfoundry-incubator/garden/fakes"
+	"github.com/cloudfoundry-incubator/garden/server"
+	"github.com/cloudfoundry-incubator/garden/server/audit"
+)
+
+type recordingSink struct {
+	records []audit.Record
+	mu      sync.Mutex
+}
+
+func (sink *recordingSink) Write(record audit.Record) error {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	sink.records = append(sink.records, record)
+
+	return nil
+}
+
+func (sink *recordingSink) Records() []audit.Record {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	return append([]audit.Record{}, sink.records...)
+}
+
+var _ = Describe("When the server keeps an audit log", func() {
+	var fixture *serverFixture
+
+	var fakeContainer *fakes.FakeContainer
+	var sink *recordingSink
+
+	var options []server.Option
+
+	var apiClient garden.Client
+
+	BeforeEach(func() {
+		fixture = newServerFixture()
+
+		fakeContainer = new(fakes.FakeContainer)
+		fakeContainer.HandleReturns("some-handle")
+		fakeContainer.InfoReturns(garden.ContainerInfo{Properties: garden.Properties{"tenant": "some-tenant"}}, nil)
+
+		fixture.Backend.CreateReturns(fakeContainer, nil)
+		fixture.Backend.LookupReturns(fakeContainer, nil)
+
+		sink = new(recordingSink)
+
+		options = []server.Option{server.WithAudit(sink)}
+	})
+
+	JustBeforeEach(func() {
+		fixture.Start(options...)
+
+		apiClient = client.New(connection.New("unix", fixture.SocketPath))
+	})
+
+	AfterEach(func() {
+		fixture.Stop()
+	})
+
+	It("records creating a container, without the values of its environment and properties", func() {
+		_, err := apiClient.Create(garden.ContainerSpec{
+			RootFSPath: "some-rootfs",
+			Env:        []string{"SECRET=shh"},
+			Properties: garden.Properties{"key": "secret-value"},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		records := sink.Records()
+		Ω(records).Should(HaveLen(1))
+
+		record := records[0]
+		Ω(record.Time).ShouldNot(BeZero())
+		Ω(record.Handle).Should(Equal("some-handle"))
+		Ω(record.Operation).Should(Equal("create"))
+		Ω(record.Outcome).Should(Equal(audit.OutcomeSucceeded))
+		Ω(record.Parameters).Should(HaveKeyWithValue("rootfs", "some-rootfs"))
+		Ω(record.Parameters).Should(HaveKeyWithValue("env", []string{"SECRET"}))
+		Ω(record.Parameters).Should(HaveKeyWithValue("properties", []string{"key"}))
+	})
+
+	It("records running a process, without the values of its environment", func() {
+		fakeContainer.RunStub = func(garden.ProcessSpec, garden.ProcessIO) (garden.Process, error) {
+			process := new(fakes.FakeProcess)
+			process.IDReturns(42)
+			process.WaitForExitReturns(garden.ExitInfo{}, nil)
+			return process, nil
+		}
+
+		container, err := apiClient.Lookup("some-handle")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		process, err := container.Run(garden.ProcessSpec{
+			Path: "echo",
+			Args: []string{"hello"},
+			Env:  []string{"TOKEN=shh"},
+		}, garden.ProcessIO{})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = process.Wait()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		records := sink.Records()
+		Ω(records).Should(HaveLen(1))
+
+		Ω(records[0].Operation).Should(Equal("run"))
+		Ω(records[0].Parameters).Should(HaveKeyWithValue("path", "echo"))
+		Ω(records[0].Parameters).Should(HaveKeyWithValue("args", []string{"hello"}))
+		Ω(records[0].Parameters).Should(HaveKeyWithValue("env", []string{"TOKEN"}))
+		Ω(records[0].Parameters).Should(HaveKeyWithValue("process-id", uint32(42)))
+	})
+
+	It("records the limits, ports and properties changed", func() {
+		container, err := apiClient.Lookup("some-handle")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		fakeContainer.NetInReturns(1234, 5678, nil)
+
+		Ω(container.LimitMemory(garden.MemoryLimits{LimitInBytes: 1024})).Should(Succeed())
+		_, _, err = container.NetIn(0, 5678)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(container.SetProperty("some-key", "secret-value")).Should(Succeed())
+
+		records := sink.Records()
+		Ω(records).Should(HaveLen(3))
+
+		Ω(records[0].Operation).Should(Equal("limit-memory"))
+		Ω(records[0].Parameters).Should(HaveKeyWithValue("limits", garden.MemoryLimits{LimitInBytes: 1024}))
+
+		Ω(records[1].Operation).Should(Equal("net-in"))
+		Ω(records[1].Parameters).Should(HaveKeyWithValue("host-port", uint32(1234)))
+
+		Ω(records[2].Operation).Should(Equal("set-property"))
+		Ω(records[2].Parameters).Should(Equal(map[string]interface{}{"name": "some-key"}))
+	})
+
+	It("records the properties set at once, or each set one at a time if the backend cannot", func() {
+		container, err := apiClient.Lookup("some-handle")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(container.SetProperties(garden.Properties{"b": "secret-value", "a": "secret-value"}, []string{"c"})).Should(Succeed())
+
+		fakeContainer.SetPropertiesReturns(garden.ErrNotImplemented)
+		Ω(container.SetProperties(garden.Properties{"d": "secret-value"}, nil)).Should(Succeed())
+
+		records := sink.Records()
+		Ω(records).Should(HaveLen(2))
+
+		Ω(records[0].Operation).Should(Equal("set-properties"))
+		Ω(records[0].Parameters).Should(Equal(map[string]interface{}{
+			"names":    []string{"a", "b"},
+			"removals": []string{"c"},
+		}))
+
+		Ω(records[1].Operation).Should(Equal("set-property"))
+		Ω(records[1].Parameters).Should(Equal(map[string]interface{}{"name": "d"}))
+	})
+
+	It("records failures with their error", func() {
+		fixture.Backend.DestroyReturns(errors.New("oh no!"))
+
+		err := apiClient.Destroy("some-handle")
+		Ω(err).Should(HaveOccurred())
+
+		records := sink.Records()
+		Ω(records).Should(HaveLen(1))
+
+		Ω(records[0].Operation).Should(Equal("destroy"))
+		Ω(records[0].Outcome).Should(Equal(audit.OutcomeFailed))
+		Ω(records[0].Error).Should(Equal("oh no!"))
+	})
+
+	It("does not record operations that change nothing", func() {
+		_, err := apiClient.Containers(nil)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		container, err := apiClient.Lookup("some-handle")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = container.Info()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = container.CurrentMemoryLimits()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(sink.Records()).Should(BeEmpty())
+	})
+
+	Context("and authenticates requests", func() {
+		BeforeEach(func() {
+			options = append(options, server.WithAuthentication(server.StaticTokens{
+				"some-token": server.Identity{
+					Name:   "some-identity",
+					Denied: []server.Operation{server.OperationNetOut},
+				},
+			}))
+		})
+
+		JustBeforeEach(func() {
+			apiClient = client.New(connection.New("unix", fixture.SocketPath, connection.WithToken("some-token")))
+		})
+
+		It("records the caller's identity", func() {
+			err := apiClient.Destroy("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			records := sink.Records()
+			Ω(records).Should(HaveLen(1))
+			Ω(records[0].Identity).Should(Equal("some-identity"))
+		})
+
+		It("records denied operations", func() {
+			container, err := apiClient.Lookup("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = container.NetOut(garden.NetOutRule{})
+			Ω(err).Should(HaveOccurred())
+
+			records := sink.Records()
+			Ω(records).Should(HaveLen(1))
+
+			Ω(records[0].Operation).Should(Equal("net-out"))
+			Ω(records[0].Outcome).Should(Equal(audit.OutcomeDenied))
+			Ω(records[0].Error).Should(Equal("forbidden: net-out"))
+		})
+	})
+})
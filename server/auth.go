@@ -0,0 +1,465 @@
+package server
+
+import (
+	"crypto/subtle"
+	"io"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+// Operation names something an identity can be denied.
+type Operation string
+
+const (
+	OperationCreate      Operation = "create"
+	OperationDestroy     Operation = "destroy"
+	OperationRun         Operation = "run" // running processes and attaching to them
+	OperationPrivileged  Operation = "privileged"
+	OperationBindMount   Operation = "bind-mount"
+	OperationNetIn       Operation = "net-in"
+	OperationNetOut      Operation = "net-out"
+	OperationLimit       Operation = "limit"
+	OperationStreamIn    Operation = "stream-in"
+	OperationStreamOut   Operation = "stream-out"
+	OperationSetProperty Operation = "set-property" // setting and removing properties
+	OperationDrain       Operation = "drain"
+)
+
+// Identity is who a request was authenticated as, and what it may do.
+type Identity struct {
+	Name string
+
+	// Selector restricts the identity to the containers with all of these
+	// properties; other containers appear not to exist to it. Containers it
+	// creates must carry them, and it may not change them.
+	Selector garden.Properties
+
+	// Denied lists the operations the identity may not perform.
+	Denied []Operation
+}
+
+func (identity Identity) authorize(operation Operation) error {
+	for _, denied := range identity.Denied {
+		if denied == operation {
+			return garden.ForbiddenError{Operation: string(operation)}
+		}
+	}
+
+	return nil
+}
+
+func (identity Identity) selects(properties garden.Properties) bool {
+	for key, value := range identity.Selector {
+		if properties[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Authenticator identifies the caller of a request by the bearer token it
+// carries.
+type Authenticator interface {
+	Authenticate(token string) (Identity, error)
+}
+
+// StaticTokens authenticates each of its tokens as the identity it maps to.
+type StaticTokens map[string]Identity
+
+func (tokens StaticTokens) Authenticate(token string) (Identity, error) {
+	for candidate, identity := range tokens {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			return identity, nil
+		}
+	}
+
+	return Identity{}, garden.UnauthenticatedError{Message: "unknown bearer token"}
+}
+
+// WithAuthentication requires every request to carry a bearer token that the
+// authenticator accepts, and restricts it to what the identity may do.
+func WithAuthentication(authenticator Authenticator) Option {
+	return func(s *GardenServer) {
+		s.authenticator = authenticator
+	}
+}
+
+// authenticate returns the identity of the bearer token in an authorization
+// header or metadata value.
+func (s *GardenServer) authenticate(authorization string) (*Identity, error) {
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	if token == authorization || token == "" {
+		return nil, garden.UnauthenticatedError{Message: "missing bearer token"}
+	}
+
+	identity, err := s.authenticator.Authenticate(token)
+	if err != nil {
+		if _, ok := err.(garden.UnauthenticatedError); !ok {
+			err = garden.UnauthenticatedError{Message: err.Error()}
+		}
+
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+type identityKey struct{}
+
+func withIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+func identityFrom(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityKey{}).(*Identity)
+	return identity
+}
+
+// authorizedBackendFor returns the backend restricted to what the caller of a
+// request may do, if it is authenticated.
+func (s *GardenServer) authorizedBackendFor(ctx context.Context, backend garden.Backend) garden.Backend {
+	identity := identityFrom(ctx)
+	if identity == nil {
+		return backend
+	}
+
+	return &authorizedBackend{
+		Backend:  backend,
+		identity: *identity,
+	}
+}
+
+// visible returns whether the caller of a request may see a container, and
+// so its events, by its properties.
+func visible(ctx context.Context, properties garden.Properties) bool {
+	identity := identityFrom(ctx)
+	return identity == nil || identity.selects(properties)
+}
+
+// authorizedBackend restricts a backend to what an identity may do.
+type authorizedBackend struct {
+	garden.Backend
+
+	identity Identity
+}
+
+func (b *authorizedBackend) Create(spec garden.ContainerSpec) (garden.Container, error) {
+	err := b.identity.authorize(OperationCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.Privileged {
+		err := b.identity.authorize(OperationPrivileged)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(spec.BindMounts) > 0 {
+		err := b.identity.authorize(OperationBindMount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !b.identity.selects(spec.Properties) {
+		return nil, garden.ForbiddenError{Operation: string(OperationCreate)}
+	}
+
+	container, err := b.Backend.Create(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.container(container), nil
+}
+
+func (b *authorizedBackend) Destroy(handle string) error {
+	err := b.identity.authorize(OperationDestroy)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.Lookup(handle)
+	if err != nil {
+		return err
+	}
+
+	return b.Backend.Destroy(handle)
+}
+
+func (b *authorizedBackend) Containers(filter garden.Properties) ([]garden.Container, error) {
+	properties := garden.Properties{}
+	for key, value := range filter {
+		properties[key] = value
+	}
+
+	for key, value := range b.identity.Selector {
+		if filtered, found := properties[key]; found && filtered != value {
+			return []garden.Container{}, nil
+		}
+
+		properties[key] = value
+	}
+
+	containers, err := b.Backend.Containers(properties)
+	if err != nil {
+		return nil, err
+	}
+
+	authorized := make([]garden.Container, len(containers))
+	for i, container := range containers {
+		authorized[i] = b.container(container)
+	}
+
+	return authorized, nil
+}
+
+func (b *authorizedBackend) List(options garden.ListOptions) (garden.ContainerPage, error) {
+	options.Selector = append(garden.SelectorFromProperties(b.identity.Selector), options.Selector...)
+
+	page, err := b.Backend.List(options)
+	if err != nil {
+		return garden.ContainerPage{}, err
+	}
+
+	authorized := make([]garden.Container, len(page.Containers))
+	for i, container := range page.Containers {
+		authorized[i] = b.container(container)
+	}
+
+	page.Containers = authorized
+
+	return page, nil
+}
+
+func (b *authorizedBackend) Lookup(handle string) (garden.Container, error) {
+	container, err := b.Backend.Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b.identity.Selector) > 0 {
+		properties, err := containerProperties(container)
+		if err != nil {
+			return nil, err
+		}
+
+		if !b.identity.selects(properties) {
+			return nil, garden.ContainerNotFoundError{Handle: handle}
+		}
+	}
+
+	return b.container(container), nil
+}
+
+func (b *authorizedBackend) BulkInfo(handles []string) (map[string]garden.ContainerInfoEntry, error) {
+	entries, err := b.Backend.BulkInfo(handles)
+	if err != nil {
+		return nil, err
+	}
+
+	for handle, entry := range entries {
+		if entry.Err == nil && !b.identity.selects(entry.Info.Properties) {
+			entries[handle] = garden.ContainerInfoEntry{
+				Err: garden.ContainerNotFoundError{Handle: handle},
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+func (b *authorizedBackend) container(container garden.Container) garden.Container {
+	return &authorizedContainer{
+		Container: container,
+		identity:  b.identity,
+	}
+}
+
+// authorizedContainer restricts a container to what an identity may do.
+type authorizedContainer struct {
+	garden.Container
+
+	identity Identity
+}
+
+func (c *authorizedContainer) StreamIn(dstPath string, tarStream io.Reader) error {
+	err := c.identity.authorize(OperationStreamIn)
+	if err != nil {
+		return err
+	}
+
+	return c.Container.StreamIn(dstPath, tarStream)
+}
+
+func (c *authorizedContainer) StreamOut(srcPath string) (io.ReadCloser, error) {
+	err := c.identity.authorize(OperationStreamOut)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Container.StreamOut(srcPath)
+}
+
+func (c *authorizedContainer) LimitBandwidth(limits garden.BandwidthLimits) error {
+	err := c.identity.authorize(OperationLimit)
+	if err != nil {
+		return err
+	}
+
+	return c.Container.LimitBandwidth(limits)
+}
+
+func (c *authorizedContainer) LimitCPU(limits garden.CPULimits) error {
+	err := c.identity.authorize(OperationLimit)
+	if err != nil {
+		return err
+	}
+
+	return c.Container.LimitCPU(limits)
+}
+
+func (c *authorizedContainer) LimitDisk(limits garden.DiskLimits) error {
+	err := c.identity.authorize(OperationLimit)
+	if err != nil {
+		return err
+	}
+
+	return c.Container.LimitDisk(limits)
+}
+
+func (c *authorizedContainer) LimitMemory(limits garden.MemoryLimits) error {
+	err := c.identity.authorize(OperationLimit)
+	if err != nil {
+		return err
+	}
+
+	return c.Container.LimitMemory(limits)
+}
+
+func (c *authorizedContainer) NetIn(hostPort, containerPort uint32) (uint32, uint32, error) {
+	err := c.identity.authorize(OperationNetIn)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return c.Container.NetIn(hostPort, containerPort)
+}
+
+func (c *authorizedContainer) NetOut(rule garden.NetOutRule) error {
+	err := c.identity.authorize(OperationNetOut)
+	if err != nil {
+		return err
+	}
+
+	return c.Container.NetOut(rule)
+}
+
+func (c *authorizedContainer) Run(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+	err := c.identity.authorize(OperationRun)
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.Privileged {
+		err := c.identity.authorize(OperationPrivileged)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c.Container.Run(spec, io)
+}
+
+func (c *authorizedContainer) Attach(processID uint32, io garden.ProcessIO) (garden.Process, error) {
+	err := c.identity.authorize(OperationRun)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Container.Attach(processID, io)
+}
+
+func (c *authorizedContainer) AttachFrom(processID uint32, offset uint64, io garden.ProcessIO) (garden.Process, error) {
+	err := c.identity.authorize(OperationRun)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Container.AttachFrom(processID, offset, io)
+}
+
+func (c *authorizedContainer) SetProperty(name string, value string) error {
+	err := c.authorizeProperty(name)
+	if err != nil {
+		return err
+	}
+
+	return c.Container.SetProperty(name, value)
+}
+
+func (c *authorizedContainer) RemoveProperty(name string) error {
+	err := c.authorizeProperty(name)
+	if err != nil {
+		return err
+	}
+
+	return c.Container.RemoveProperty(name)
+}
+
+func (c *authorizedContainer) SetProperties(properties garden.Properties, removals []string) error {
+	err := c.authorizeProperties(properties, removals)
+	if err != nil {
+		return err
+	}
+
+	return c.Container.SetProperties(properties, removals)
+}
+
+func (c *authorizedContainer) CompareAndSetProperties(expected garden.Selector, properties garden.Properties, removals []string) error {
+	err := c.authorizeProperties(properties, removals)
+	if err != nil {
+		return err
+	}
+
+	return c.Container.CompareAndSetProperties(expected, properties, removals)
+}
+
+func (c *authorizedContainer) authorizeProperties(properties garden.Properties, removals []string) error {
+	for name := range properties {
+		err := c.authorizeProperty(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range removals {
+		err := c.authorizeProperty(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// authorizeProperty refuses changes to the properties the identity is
+// selected by, which would move the container out of its reach.
+func (c *authorizedContainer) authorizeProperty(name string) error {
+	err := c.identity.authorize(OperationSetProperty)
+	if err != nil {
+		return err
+	}
+
+	if _, selected := c.identity.Selector[name]; selected {
+		return garden.ForbiddenError{Operation: string(OperationSetProperty)}
+	}
+
+	return nil
+}
@@ -0,0 +1,273 @@
+package server_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden/client"
+	"github.com/cloudfoundry-incubator/garden/client/connection"
+	"github.com/cloudfoundry-incubator/garden/fakes"
+	"github.com/cloudfoundry-incubator/garden/server"
+)
+
+var _ = Describe("When the server authenticates requests", func() {
+	var fixture *serverFixture
+	var grpcSocketPath string
+
+	tokens := server.StaticTokens{
+		"admin-token": server.Identity{
+			Name: "admin",
+		},
+		"tenant-token": server.Identity{
+			Name:     "tenant",
+			Selector: garden.Properties{"tenant": "some-tenant"},
+			Denied: []server.Operation{
+				server.OperationPrivileged,
+				server.OperationBindMount,
+				server.OperationNetOut,
+			},
+		},
+	}
+
+	BeforeEach(func() {
+		fixture = newServerFixture()
+		grpcSocketPath = fixture.Path("grpc.sock")
+
+		fixture.Start(
+			server.WithAuthentication(tokens),
+			server.WithGRPC("unix", grpcSocketPath),
+		)
+	})
+
+	AfterEach(func() {
+		fixture.Stop()
+	})
+
+	containerWith := func(handle string, properties garden.Properties) *fakes.FakeContainer {
+		container := new(fakes.FakeContainer)
+		container.HandleReturns(handle)
+		container.PropertiesReturns(properties, nil)
+		return container
+	}
+
+	Context("and the request carries no token", func() {
+		It("refuses it as unauthenticated", func() {
+			apiClient := client.New(connection.New("unix", fixture.SocketPath))
+			Ω(apiClient.Ping()).Should(Equal(garden.UnauthenticatedError{Message: "missing bearer token"}))
+			Ω(fixture.Backend.PingCallCount()).Should(Equal(0))
+		})
+
+		It("refuses gRPC calls as unauthenticated", func() {
+			grpcClient := client.New(connection.NewGRPC("unix", grpcSocketPath))
+			Ω(grpcClient.Ping()).Should(Equal(garden.UnauthenticatedError{Message: "missing bearer token"}))
+		})
+	})
+
+	Context("and the request carries an unknown token", func() {
+		It("refuses it as unauthenticated", func() {
+			apiClient := client.New(connection.New("unix", fixture.SocketPath, connection.WithToken("bogus")))
+			Ω(apiClient.Ping()).Should(Equal(garden.UnauthenticatedError{Message: "unknown bearer token"}))
+
+			grpcClient := client.New(connection.NewGRPC("unix", grpcSocketPath, connection.WithToken("bogus")))
+			Ω(grpcClient.Ping()).Should(Equal(garden.UnauthenticatedError{Message: "unknown bearer token"}))
+		})
+	})
+
+	Context("and the identity is unrestricted", func() {
+		var apiClient garden.Client
+
+		BeforeEach(func() {
+			apiClient = client.New(connection.New("unix", fixture.SocketPath, connection.WithToken("admin-token")))
+		})
+
+		It("serves requests", func() {
+			Ω(apiClient.Ping()).ShouldNot(HaveOccurred())
+		})
+
+		It("sees every container", func() {
+			fixture.Backend.ContainersReturns([]garden.Container{
+				containerWith("some-handle", nil),
+			}, nil)
+
+			containers, err := apiClient.Containers(garden.Properties{"a": "b"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(containers).Should(HaveLen(1))
+
+			Ω(fixture.Backend.ContainersArgsForCall(1)).Should(Equal(garden.Properties{"a": "b"}))
+		})
+
+		It("carries the token over hijacked process streams", func() {
+			fakeContainer := containerWith("some-handle", nil)
+			fakeContainer.RunStub = func(spec garden.ProcessSpec, processIO garden.ProcessIO) (garden.Process, error) {
+				process := new(fakes.FakeProcess)
+				process.IDReturns(42)
+				process.WaitForExitReturns(garden.ExitInfo{ExitStatus: 3}, nil)
+				return process, nil
+			}
+
+			fixture.Backend.LookupReturns(fakeContainer, nil)
+
+			container, err := apiClient.Lookup("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			process, err := container.Run(garden.ProcessSpec{Path: "true"}, garden.ProcessIO{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(process.Wait()).Should(Equal(3))
+		})
+	})
+
+	Context("and the identity is scoped to containers and denied operations", func() {
+		var apiClient garden.Client
+
+		BeforeEach(func() {
+			apiClient = client.New(connection.New("unix", fixture.SocketPath, connection.WithToken("tenant-token")))
+		})
+
+		It("lists only the selected containers", func() {
+			_, err := apiClient.Containers(garden.Properties{"a": "b"})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fixture.Backend.ContainersArgsForCall(1)).Should(Equal(garden.Properties{
+				"a":      "b",
+				"tenant": "some-tenant",
+			}))
+		})
+
+		It("lists nothing when filtering by another value of a selected property", func() {
+			containers, err := apiClient.Containers(garden.Properties{"tenant": "other-tenant"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(containers).Should(BeEmpty())
+
+			Ω(fixture.Backend.ContainersCallCount()).Should(Equal(1))
+		})
+
+		It("lists pages of only the selected containers", func() {
+			_, err := apiClient.List(garden.ListOptions{
+				Selector: garden.Selector{{Key: "owner", Operator: garden.SelectorExists}},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fixture.Backend.ListArgsForCall(0).Selector).Should(Equal(garden.Selector{
+				{Key: "tenant", Operator: garden.SelectorEquals, Values: []string{"some-tenant"}},
+				{Key: "owner", Operator: garden.SelectorExists},
+			}))
+		})
+
+		It("does not find containers outside its selector", func() {
+			fixture.Backend.LookupReturns(containerWith("other-handle", garden.Properties{"tenant": "other-tenant"}), nil)
+
+			_, err := apiClient.Lookup("other-handle")
+			Ω(err).Should(Equal(garden.ContainerNotFoundError{Handle: "other-handle"}))
+
+			err = apiClient.Destroy("other-handle")
+			Ω(err).Should(Equal(garden.ContainerNotFoundError{Handle: "other-handle"}))
+			Ω(fixture.Backend.DestroyCallCount()).Should(Equal(0))
+		})
+
+		It("reports containers outside its selector as missing in bulk info", func() {
+			fixture.Backend.BulkInfoReturns(map[string]garden.ContainerInfoEntry{
+				"some-handle":  {Info: garden.ContainerInfo{Properties: garden.Properties{"tenant": "some-tenant"}}},
+				"other-handle": {Info: garden.ContainerInfo{Properties: garden.Properties{"tenant": "other-tenant"}}},
+			}, nil)
+
+			entries, err := apiClient.BulkInfo([]string{"some-handle", "other-handle"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(entries["some-handle"].Err).ShouldNot(HaveOccurred())
+			Ω(entries["other-handle"].Err).Should(HaveOccurred())
+		})
+
+		It("creates containers that carry its selector", func() {
+			fixture.Backend.CreateReturns(containerWith("some-handle", nil), nil)
+
+			_, err := apiClient.Create(garden.ContainerSpec{
+				Properties: garden.Properties{"tenant": "some-tenant"},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("is forbidden from creating containers outside its selector", func() {
+			_, err := apiClient.Create(garden.ContainerSpec{})
+			Ω(err).Should(Equal(garden.ForbiddenError{Operation: "create"}))
+			Ω(fixture.Backend.CreateCallCount()).Should(Equal(0))
+		})
+
+		It("is forbidden from the denied operations", func() {
+			_, err := apiClient.Create(garden.ContainerSpec{
+				Privileged: true,
+				Properties: garden.Properties{"tenant": "some-tenant"},
+			})
+			Ω(err).Should(Equal(garden.ForbiddenError{Operation: "privileged"}))
+
+			_, err = apiClient.Create(garden.ContainerSpec{
+				BindMounts: []garden.BindMount{{SrcPath: "/src", DstPath: "/dst"}},
+				Properties: garden.Properties{"tenant": "some-tenant"},
+			})
+			Ω(err).Should(Equal(garden.ForbiddenError{Operation: "bind-mount"}))
+
+			Ω(fixture.Backend.CreateCallCount()).Should(Equal(0))
+
+			fakeContainer := containerWith("some-handle", garden.Properties{"tenant": "some-tenant"})
+			fixture.Backend.LookupReturns(fakeContainer, nil)
+
+			container, err := apiClient.Lookup("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = container.NetOut(garden.NetOutRule{})
+			Ω(err).Should(Equal(garden.ForbiddenError{Operation: "net-out"}))
+			Ω(fakeContainer.NetOutCallCount()).Should(Equal(0))
+
+			_, err = container.Run(garden.ProcessSpec{Path: "true", Privileged: true}, garden.ProcessIO{})
+			Ω(err).Should(Equal(garden.ForbiddenError{Operation: "privileged"}))
+			Ω(fakeContainer.RunCallCount()).Should(Equal(0))
+		})
+
+		It("is forbidden from changing the properties it is selected by", func() {
+			fakeContainer := containerWith("some-handle", garden.Properties{"tenant": "some-tenant"})
+			fixture.Backend.LookupReturns(fakeContainer, nil)
+
+			container, err := apiClient.Lookup("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = container.SetProperty("tenant", "other-tenant")
+			Ω(err).Should(Equal(garden.ForbiddenError{Operation: "set-property"}))
+
+			err = container.RemoveProperty("tenant")
+			Ω(err).Should(Equal(garden.ForbiddenError{Operation: "set-property"}))
+
+			Ω(container.SetProperty("other", "value")).Should(Succeed())
+			Ω(fakeContainer.SetPropertyCallCount()).Should(Equal(1))
+
+			err = container.SetProperties(garden.Properties{"other": "value", "tenant": "other-tenant"}, nil)
+			Ω(err).Should(Equal(garden.ForbiddenError{Operation: "set-property"}))
+
+			err = container.CompareAndSetProperties(garden.Selector{{Key: "other", Operator: garden.SelectorExists}}, nil, []string{"tenant"})
+			Ω(err).Should(Equal(garden.ForbiddenError{Operation: "set-property"}))
+
+			Ω(fakeContainer.SetPropertiesCallCount()).Should(Equal(0))
+			Ω(fakeContainer.CompareAndSetPropertiesCallCount()).Should(Equal(0))
+		})
+
+		It("applies the same restrictions over gRPC", func() {
+			grpcClient := client.New(connection.NewGRPC("unix", grpcSocketPath, connection.WithToken("tenant-token")))
+
+			fixture.Backend.LookupReturns(containerWith("other-handle", garden.Properties{"tenant": "other-tenant"}), nil)
+
+			_, err := grpcClient.Lookup("other-handle")
+			Ω(err).Should(Equal(garden.ContainerNotFoundError{Handle: "other-handle"}))
+
+			fakeContainer := containerWith("some-handle", garden.Properties{"tenant": "some-tenant"})
+			fixture.Backend.LookupReturns(fakeContainer, nil)
+
+			container, err := grpcClient.Lookup("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = container.NetOut(garden.NetOutRule{})
+			Ω(err).Should(Equal(garden.ForbiddenError{Operation: "net-out"}))
+
+			_, err = container.Run(garden.ProcessSpec{Path: "true", Privileged: true}, garden.ProcessIO{})
+			Ω(err).Should(Equal(garden.ForbiddenError{Operation: "privileged"}))
+		})
+	})
+})
@@ -15,6 +15,14 @@ type Bomberman struct {
 	unpause chan string
 	defuse  chan string
 	cleanup chan string
+	count   chan chan Counts
+}
+
+// Counts are the numbers of bombs counting down, and of those paused while
+// their containers are in use.
+type Counts struct {
+	Armed  int
+	Paused int
 }
 
 func New(backend garden.Backend, detonate func(garden.Container)) *Bomberman {
@@ -27,6 +35,7 @@ func New(backend garden.Backend, detonate func(garden.Container)) *Bomberman {
 		unpause: make(chan string),
 		defuse:  make(chan string),
 		cleanup: make(chan string),
+		count:   make(chan chan Counts),
 	}
 
 	go b.manageBombs()
@@ -50,6 +59,12 @@ func (b *Bomberman) Defuse(name string) {
 	b.defuse <- name
 }
 
+func (b *Bomberman) Counts() Counts {
+	counts := make(chan Counts, 1)
+	b.count <- counts
+	return <-counts
+}
+
 func (b *Bomberman) manageBombs() {
 	timeBombs := map[string]*timebomb.TimeBomb{}
 
@@ -100,6 +115,18 @@ func (b *Bomberman) manageBombs() {
 
 		case handle := <-b.cleanup:
 			delete(timeBombs, handle)
+
+		case counts := <-b.count:
+			var c Counts
+			for _, bomb := range timeBombs {
+				if bomb.Paused() {
+					c.Paused++
+				} else {
+					c.Armed++
+				}
+			}
+
+			counts <- c
 		}
 	}
 }
@@ -166,4 +166,28 @@ var _ = Describe("Bomberman", func() {
 			})
 		})
 	})
+
+	Describe("counting bombs", func() {
+		It("counts the armed and paused bombs", func() {
+			backend := new(fakes.FakeBackend)
+			backend.GraceTimeReturns(time.Minute)
+
+			manager := bomberman.New(backend, func(container garden.Container) {})
+
+			for _, handle := range []string{"a", "b", "c"} {
+				container := new(fakes.FakeContainer)
+				container.HandleReturns(handle)
+				manager.Strap(container)
+			}
+
+			manager.Pause("b")
+
+			Ω(manager.Counts()).Should(Equal(bomberman.Counts{Armed: 2, Paused: 1}))
+
+			manager.Unpause("b")
+			manager.Defuse("c")
+
+			Ω(manager.Counts()).Should(Equal(bomberman.Counts{Armed: 2, Paused: 0}))
+		})
+	})
 })
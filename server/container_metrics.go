@@ -0,0 +1,277 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/lager"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden/server/metrics"
+)
+
+// DefaultContainerMetricsInterval is how often containers' resource usage is
+// gathered unless configured otherwise.
+const DefaultContainerMetricsInterval = 30 * time.Second
+
+// DefaultMaxConcurrentInfo is how many containers' info is requested from the
+// backend at once unless configured otherwise.
+const DefaultMaxConcurrentInfo = 8
+
+// ContainerMetricsConfig configures the export of containers' resource usage.
+type ContainerMetricsConfig struct {
+	// Interval is how often usage is gathered.
+	Interval time.Duration
+
+	// Properties are the container properties to label each container's
+	// metrics with, besides its handle. A property's label is its name with
+	// the prefix property_, and characters not allowed in label names
+	// replaced with underscores.
+	Properties []string
+
+	// MaxConcurrentInfo caps the Info calls made to the backend at once.
+	MaxConcurrentInfo int
+}
+
+// WithContainerMetrics periodically gathers the resource usage of every
+// container, and serves it at /metrics/containers in the OpenMetrics format.
+func WithContainerMetrics(config ContainerMetricsConfig) Option {
+	return func(s *GardenServer) {
+		if config.Interval == 0 {
+			config.Interval = DefaultContainerMetricsInterval
+		}
+
+		if config.MaxConcurrentInfo == 0 {
+			config.MaxConcurrentInfo = DefaultMaxConcurrentInfo
+		}
+
+		s.containerMetrics = &containerMetrics{
+			config: config,
+			done:   make(chan struct{}),
+		}
+	}
+}
+
+// containerMetrics holds the resource usage last gathered for each
+// container.
+type containerMetrics struct {
+	config ContainerMetricsConfig
+
+	usage     []containerUsage
+	collected time.Time
+	failures  int
+	mu        sync.Mutex
+
+	// closed once collection has stopped, if it was started
+	running bool
+	done    chan struct{}
+}
+
+type containerUsage struct {
+	handle string
+	info   garden.ContainerInfo
+}
+
+// collectContainerMetrics gathers usage at the configured interval until the
+// server stops.
+func (s *GardenServer) collectContainerMetrics() {
+	defer close(s.containerMetrics.done)
+
+	logger := s.logger.Session("container-metrics")
+
+	ticker := time.NewTicker(s.containerMetrics.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.gatherContainerMetrics(logger)
+
+		select {
+		case <-ticker.C:
+		case <-s.stopping:
+			return
+		}
+	}
+}
+
+func (s *GardenServer) gatherContainerMetrics(logger lager.Logger) {
+	containers, err := s.backend.Containers(nil)
+	if err != nil {
+		logger.Error("failed-to-list-containers", err)
+		return
+	}
+
+	usage := make([]containerUsage, len(containers))
+	failed := make([]bool, len(containers))
+
+	slots := make(chan struct{}, s.containerMetrics.config.MaxConcurrentInfo)
+
+	wg := new(sync.WaitGroup)
+
+	for i, container := range containers {
+		slots <- struct{}{}
+
+		wg.Add(1)
+		go func(i int, container garden.Container) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			info, err := container.Info()
+			if err != nil {
+				logger.Error("failed-to-get-info", err, lager.Data{
+					"handle": container.Handle(),
+				})
+
+				failed[i] = true
+				return
+			}
+
+			usage[i] = containerUsage{
+				handle: container.Handle(),
+				info:   info,
+			}
+		}(i, container)
+	}
+
+	wg.Wait()
+
+	gathered := []containerUsage{}
+	failures := 0
+
+	for i := range usage {
+		if failed[i] {
+			failures++
+			continue
+		}
+
+		gathered = append(gathered, usage[i])
+	}
+
+	s.containerMetrics.mu.Lock()
+	s.containerMetrics.usage = gathered
+	s.containerMetrics.collected = time.Now()
+	s.containerMetrics.failures = failures
+	s.containerMetrics.mu.Unlock()
+}
+
+func (s *GardenServer) handleContainerMetrics(w http.ResponseWriter, r *http.Request) {
+	if s.containerMetrics == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.containerMetrics.mu.Lock()
+	usage := s.containerMetrics.usage
+	collected := s.containerMetrics.collected
+	failures := s.containerMetrics.failures
+	s.containerMetrics.mu.Unlock()
+
+	registry := metrics.NewRegistry()
+
+	labels := []string{"handle"}
+	for _, property := range s.containerMetrics.config.Properties {
+		labels = append(labels, propertyLabel(property))
+	}
+
+	gauge := func(name, help string) *metrics.Gauge {
+		return registry.NewGauge("garden_container_"+name, help, labels...)
+	}
+
+	counter := func(name, help string) *metrics.Counter {
+		return registry.NewCounter("garden_container_"+name, help, labels...)
+	}
+
+	memoryRSS := gauge("memory_rss_bytes", "Resident memory, including that of child cgroups.")
+	memoryCache := gauge("memory_cache_bytes", "Page cache memory, including that of child cgroups.")
+	memorySwap := gauge("memory_swap_bytes", "Swap used, including that of child cgroups.")
+	memoryLimit := gauge("memory_limit_bytes", "Memory limit.")
+	pageFaults := counter("memory_page_faults_total", "Page faults.")
+	majorPageFaults := counter("memory_major_page_faults_total", "Major page faults.")
+
+	cpuUsage := counter("cpu_usage_nanoseconds_total", "CPU time used.")
+	cpuUser := counter("cpu_user_ticks_total", "CPU time spent in user mode, in clock ticks.")
+	cpuSystem := counter("cpu_system_ticks_total", "CPU time spent in kernel mode, in clock ticks.")
+
+	diskBytes := gauge("disk_used_bytes", "Disk space used.")
+	diskInodes := gauge("disk_used_inodes", "Inodes used.")
+
+	bandwidthInRate := gauge("bandwidth_in_rate_bytes", "Inbound bandwidth limit, per second.")
+	bandwidthInBurst := gauge("bandwidth_in_burst_bytes", "Inbound bandwidth burst limit.")
+	bandwidthOutRate := gauge("bandwidth_out_rate_bytes", "Outbound bandwidth limit, per second.")
+	bandwidthOutBurst := gauge("bandwidth_out_burst_bytes", "Outbound bandwidth burst limit.")
+
+	for _, container := range usage {
+		if !visible(r.Context(), container.info.Properties) {
+			continue
+		}
+
+		values := []string{container.handle}
+		for _, property := range s.containerMetrics.config.Properties {
+			values = append(values, container.info.Properties[property])
+		}
+
+		memory := container.info.MemoryStat
+		memoryRSS.Set(float64(memory.TotalRss), values...)
+		memoryCache.Set(float64(memory.TotalCache), values...)
+		memorySwap.Set(float64(memory.TotalSwap), values...)
+		memoryLimit.Set(float64(memory.HierarchicalMemoryLimit), values...)
+		pageFaults.Add(float64(memory.TotalPgfault), values...)
+		majorPageFaults.Add(float64(memory.TotalPgmajfault), values...)
+
+		cpu := container.info.CPUStat
+		cpuUsage.Add(float64(cpu.Usage), values...)
+		cpuUser.Add(float64(cpu.User), values...)
+		cpuSystem.Add(float64(cpu.System), values...)
+
+		disk := container.info.DiskStat
+		diskBytes.Set(float64(disk.BytesUsed), values...)
+		diskInodes.Set(float64(disk.InodesUsed), values...)
+
+		bandwidth := container.info.BandwidthStat
+		bandwidthInRate.Set(float64(bandwidth.InRate), values...)
+		bandwidthInBurst.Set(float64(bandwidth.InBurst), values...)
+		bandwidthOutRate.Set(float64(bandwidth.OutRate), values...)
+		bandwidthOutBurst.Set(float64(bandwidth.OutBurst), values...)
+	}
+
+	registry.NewGaugeFunc(
+		"garden_container_metrics_collected_timestamp_seconds",
+		"When the metrics were last gathered.",
+		func() float64 {
+			if collected.IsZero() {
+				return 0
+			}
+
+			return float64(collected.UnixNano()) / float64(time.Second)
+		},
+	)
+
+	registry.NewGaugeFunc(
+		"garden_container_metrics_failures",
+		"Containers whose info could not be gathered last time.",
+		func() float64 {
+			return float64(failures)
+		},
+	)
+
+	w.Header().Set("Content-Type", metrics.OpenMetricsContentType)
+	registry.WriteOpenMetricsTo(w)
+}
+
+// propertyLabel returns the label a property is exported as.
+func propertyLabel(property string) string {
+	label := []byte("property_" + property)
+
+	for i, c := range label {
+		valid := c == '_' ||
+			(c >= 'a' && c <= 'z') ||
+			(c >= 'A' && c <= 'Z') ||
+			(c >= '0' && c <= '9')
+
+		if !valid {
+			label[i] = '_'
+		}
+	}
+
+	return string(label)
+}
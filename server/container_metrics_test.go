@@ -0,0 +1,213 @@
+package server_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden/fakes"
+	"github.com/cloudfoundry-incubator/garden/server"
+	"github.com/cloudfoundry-incubator/garden/server/metrics"
+)
+
+var _ = Describe("When the server exports container metrics", func() {
+	var fixture *serverFixture
+	var options []server.Option
+
+	containerWithInfo := func(handle string, info garden.ContainerInfo) *fakes.FakeContainer {
+		container := new(fakes.FakeContainer)
+		container.HandleReturns(handle)
+		container.InfoReturns(info, nil)
+		return container
+	}
+
+	BeforeEach(func() {
+		fixture = newServerFixture()
+
+		fixture.Backend.ContainersReturns([]garden.Container{
+			containerWithInfo("container-a", garden.ContainerInfo{
+				Properties: garden.Properties{"app-guid": "some-app", "tenant": "some-tenant"},
+				MemoryStat: garden.ContainerMemoryStat{TotalRss: 1024, TotalPgfault: 7},
+				CPUStat:    garden.ContainerCPUStat{Usage: 5000},
+				DiskStat:   garden.ContainerDiskStat{BytesUsed: 2048},
+			}),
+			containerWithInfo("container-b", garden.ContainerInfo{
+				Properties:    garden.Properties{"tenant": "other-tenant"},
+				BandwidthStat: garden.ContainerBandwidthStat{InRate: 100},
+			}),
+		}, nil)
+
+		options = []server.Option{
+			server.WithContainerMetrics(server.ContainerMetricsConfig{
+				Interval:   50 * time.Millisecond,
+				Properties: []string{"app-guid"},
+			}),
+		}
+	})
+
+	JustBeforeEach(func() {
+		fixture.Start(options...)
+	})
+
+	AfterEach(func() {
+		fixture.Stop()
+	})
+
+	get := func(header http.Header) *http.Response {
+		httpClient := &http.Client{
+			Transport: &http.Transport{
+				Dial: func(string, string) (net.Conn, error) {
+					return net.Dial("unix", fixture.SocketPath)
+				},
+				DisableKeepAlives: true,
+			},
+		}
+
+		request, err := http.NewRequest("GET", "http://api/metrics/containers", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		for name, values := range header {
+			request.Header[name] = values
+		}
+
+		response, err := httpClient.Do(request)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return response
+	}
+
+	scrape := func(header http.Header) func() string {
+		return func() string {
+			response := get(header)
+			defer response.Body.Close()
+
+			Ω(response.StatusCode).Should(Equal(http.StatusOK))
+			Ω(response.Header.Get("Content-Type")).Should(Equal(metrics.OpenMetricsContentType))
+
+			body, err := ioutil.ReadAll(response.Body)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			return string(body)
+		}
+	}
+
+	It("serves each container's usage, labelled by handle and the configured properties", func() {
+		Eventually(scrape(nil)).Should(ContainSubstring(`garden_container_memory_rss_bytes{handle="container-a",property_app_guid="some-app"} 1024`))
+
+		body := scrape(nil)()
+		Ω(body).Should(ContainSubstring(`garden_container_memory_page_faults_total{handle="container-a",property_app_guid="some-app"} 7`))
+		Ω(body).Should(ContainSubstring(`garden_container_cpu_usage_nanoseconds_total{handle="container-a",property_app_guid="some-app"} 5000`))
+		Ω(body).Should(ContainSubstring(`garden_container_disk_used_bytes{handle="container-a",property_app_guid="some-app"} 2048`))
+		Ω(body).Should(ContainSubstring(`garden_container_bandwidth_in_rate_bytes{handle="container-b",property_app_guid=""} 100`))
+		Ω(body).Should(ContainSubstring("# TYPE garden_container_memory_page_faults counter"))
+		Ω(body).Should(HaveSuffix("# EOF\n"))
+	})
+
+	It("gathers usage again at the interval", func() {
+		Eventually(fixture.Backend.ContainersCallCount).Should(BeNumerically(">=", 3))
+	})
+
+	Context("when a container's info cannot be gathered", func() {
+		BeforeEach(func() {
+			broken := new(fakes.FakeContainer)
+			broken.HandleReturns("broken")
+			broken.InfoReturns(garden.ContainerInfo{}, errors.New("oh no!"))
+
+			fixture.Backend.ContainersReturns([]garden.Container{broken}, nil)
+		})
+
+		It("leaves it out, and counts the failure", func() {
+			Eventually(scrape(nil)).Should(ContainSubstring("garden_container_metrics_failures 1\n"))
+			Ω(scrape(nil)()).ShouldNot(ContainSubstring(`handle="broken"`))
+		})
+	})
+
+	Context("with a concurrency cap", func() {
+		var maxInFlight int
+		var lock sync.Mutex
+
+		BeforeEach(func() {
+			var inFlight int
+
+			maxInFlight = 0
+
+			containers := []garden.Container{}
+			for i := 0; i < 6; i++ {
+				container := new(fakes.FakeContainer)
+				container.InfoStub = func() (garden.ContainerInfo, error) {
+					lock.Lock()
+					inFlight++
+					if inFlight > maxInFlight {
+						maxInFlight = inFlight
+					}
+					lock.Unlock()
+
+					time.Sleep(10 * time.Millisecond)
+
+					lock.Lock()
+					inFlight--
+					lock.Unlock()
+
+					return garden.ContainerInfo{}, nil
+				}
+
+				containers = append(containers, container)
+			}
+
+			fixture.Backend.ContainersReturns(containers, nil)
+
+			options = []server.Option{
+				server.WithContainerMetrics(server.ContainerMetricsConfig{
+					Interval:          time.Minute,
+					MaxConcurrentInfo: 2,
+				}),
+			}
+		})
+
+		It("gets no more containers' info at once", func() {
+			Eventually(scrape(nil)).ShouldNot(ContainSubstring("garden_container_metrics_collected_timestamp_seconds 0\n"))
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			Ω(maxInFlight).Should(Equal(2))
+		})
+	})
+
+	Context("when the server authenticates requests", func() {
+		BeforeEach(func() {
+			options = append(options, server.WithAuthentication(server.StaticTokens{
+				"tenant-token": server.Identity{
+					Selector: garden.Properties{"tenant": "other-tenant"},
+				},
+			}))
+		})
+
+		It("serves an identity the usage of only the containers it selects", func() {
+			header := http.Header{"Authorization": {"Bearer tenant-token"}}
+
+			Eventually(scrape(header)).Should(ContainSubstring(`handle="container-b"`))
+			Ω(scrape(header)()).ShouldNot(ContainSubstring(`handle="container-a"`))
+		})
+	})
+
+	Context("when the server does not export container metrics", func() {
+		BeforeEach(func() {
+			options = nil
+		})
+
+		It("responds 404", func() {
+			response := get(nil)
+			response.Body.Close()
+
+			Ω(response.StatusCode).Should(Equal(http.StatusNotFound))
+		})
+	})
+})
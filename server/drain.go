@@ -0,0 +1,252 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/lager"
+	"golang.org/x/net/context"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+// DefaultDrainTimeout is how long process streams may keep running once the
+// server starts draining, unless configured otherwise.
+const DefaultDrainTimeout = 5 * time.Minute
+
+// WithDrainTimeout sets how long process streams may keep running once the
+// server starts draining, after which they are detached.
+func WithDrainTimeout(timeout time.Duration) Option {
+	return func(s *GardenServer) {
+		s.drain.timeout = timeout
+	}
+}
+
+// WithDrainSignals starts draining the server when it receives any of the
+// signals.
+func WithDrainSignals(signals ...os.Signal) Option {
+	return func(s *GardenServer) {
+		s.drain.signals = signals
+	}
+}
+
+// drainState tracks the server's process streams, and whether it is
+// draining them.
+type drainState struct {
+	timeout time.Duration
+	signals []os.Signal
+
+	// closed once draining starts
+	draining     chan struct{}
+	drainingOnce sync.Once
+
+	// closed once process streams are out of time
+	expired chan struct{}
+
+	// closed once no process streams remain, or they are out of time
+	drained     chan struct{}
+	drainedOnce sync.Once
+
+	streams int
+	mu      sync.Mutex
+}
+
+func newDrainState() *drainState {
+	return &drainState{
+		timeout: DefaultDrainTimeout,
+
+		draining: make(chan struct{}),
+		expired:  make(chan struct{}),
+		drained:  make(chan struct{}),
+	}
+}
+
+// Drain starts draining the server. From then on it refuses to create
+// containers or run processes, but keeps serving all else, and lets process
+// streams run until the drain timeout. Draining an already draining server
+// has no effect.
+func (s *GardenServer) Drain() {
+	s.drain.drainingOnce.Do(func() {
+		s.logger.Info("draining", lager.Data{
+			"timeout": s.drain.timeout.String(),
+		})
+
+		close(s.drain.draining)
+
+		time.AfterFunc(s.drain.timeout, func() {
+			close(s.drain.expired)
+			s.finishDraining()
+		})
+
+		s.drain.mu.Lock()
+		idle := s.drain.streams == 0
+		s.drain.mu.Unlock()
+
+		if idle {
+			s.finishDraining()
+		}
+	})
+}
+
+// Draining returns whether the server has started draining.
+func (s *GardenServer) Draining() bool {
+	select {
+	case <-s.drain.draining:
+		return true
+	default:
+		return false
+	}
+}
+
+// Drained returns a channel that is closed once the server is draining and
+// no process streams remain, or those that did were detached at the drain
+// timeout. The server can then be stopped without cutting any short.
+func (s *GardenServer) Drained() <-chan struct{} {
+	return s.drain.drained
+}
+
+func (s *GardenServer) finishDraining() {
+	s.drain.drainedOnce.Do(func() {
+		s.logger.Info("drained")
+		close(s.drain.drained)
+	})
+}
+
+func (s *GardenServer) streamAttached() {
+	s.drain.mu.Lock()
+	s.drain.streams++
+	s.drain.mu.Unlock()
+}
+
+func (s *GardenServer) streamDetached() {
+	s.drain.mu.Lock()
+	s.drain.streams--
+	idle := s.drain.streams == 0
+	s.drain.mu.Unlock()
+
+	if idle && s.Draining() {
+		s.finishDraining()
+	}
+}
+
+// drainOnSignals starts draining when the server receives one of the drain
+// signals, until it stops.
+func (s *GardenServer) drainOnSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, s.drain.signals...)
+
+	go func() {
+		defer signal.Stop(signals)
+
+		select {
+		case sig := <-signals:
+			s.logger.Info("received-drain-signal", lager.Data{
+				"signal": sig.String(),
+			})
+
+			s.Drain()
+		case <-s.stopping:
+		}
+	}()
+}
+
+func isReadinessProbe(r *http.Request) bool {
+	return r.Method == "GET" && r.URL.Path == "/ready"
+}
+
+// ready returns whether the server should be sent new work.
+func (s *GardenServer) ready() bool {
+	select {
+	case <-s.stopping:
+		return false
+	default:
+		return !s.Draining()
+	}
+}
+
+func (s *GardenServer) handleReady(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	if !s.ready() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, "draining\n")
+		return
+	}
+
+	io.WriteString(w, "ready\n")
+}
+
+func (s *GardenServer) handleDrain(w http.ResponseWriter, r *http.Request) {
+	hLog := s.session(r.Context(), "drain")
+
+	err := authorizeDrain(r.Context())
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		return
+	}
+
+	s.Drain()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// authorizeDrain forbids draining to identities denied it, and to those
+// restricted to some containers, as it affects them all.
+func authorizeDrain(ctx context.Context) error {
+	identity := identityFrom(ctx)
+	if identity == nil {
+		return nil
+	}
+
+	if len(identity.Selector) > 0 {
+		return garden.ForbiddenError{Operation: string(OperationDrain)}
+	}
+
+	return identity.authorize(OperationDrain)
+}
+
+// drainingBackend refuses to create containers, or run processes in them,
+// while the server is draining.
+type drainingBackend struct {
+	garden.Backend
+
+	server *GardenServer
+}
+
+func (backend *drainingBackend) Create(spec garden.ContainerSpec) (garden.Container, error) {
+	if backend.server.Draining() {
+		return nil, garden.DrainingError{}
+	}
+
+	return backend.Backend.Create(spec)
+}
+
+func (backend *drainingBackend) Lookup(handle string) (garden.Container, error) {
+	container, err := backend.Backend.Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	return &drainingContainer{
+		Container: container,
+		server:    backend.server,
+	}, nil
+}
+
+type drainingContainer struct {
+	garden.Container
+
+	server *GardenServer
+}
+
+func (container *drainingContainer) Run(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+	if container.server.Draining() {
+		return nil, garden.DrainingError{}
+	}
+
+	return container.Container.Run(spec, io)
+}
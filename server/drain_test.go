@@ -0,0 +1,247 @@
+package server_test
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden/client"
+	"github.com/cloudfoundry-incubator/garden/client/connection"
+	"github.com/cloudfoundry-incubator/garden/fakes"
+	"github.com/cloudfoundry-incubator/garden/server"
+)
+
+var _ = Describe("When the server drains", func() {
+	var fixture *serverFixture
+	var grpcSocketPath string
+
+	var fakeContainer *fakes.FakeContainer
+	var options []server.Option
+
+	var apiClient garden.Client
+
+	BeforeEach(func() {
+		fixture = newServerFixture()
+		grpcSocketPath = fixture.Path("grpc.sock")
+
+		fakeContainer = new(fakes.FakeContainer)
+		fakeContainer.HandleReturns("some-handle")
+
+		fixture.Backend.CreateReturns(fakeContainer, nil)
+		fixture.Backend.LookupReturns(fakeContainer, nil)
+
+		options = []server.Option{
+			server.WithGRPC("unix", grpcSocketPath),
+		}
+	})
+
+	JustBeforeEach(func() {
+		fixture.Start(options...)
+
+		apiClient = client.New(connection.New("unix", fixture.SocketPath))
+	})
+
+	AfterEach(func() {
+		fixture.Stop()
+	})
+
+	request := func(method, path string, header http.Header) int {
+		httpClient := &http.Client{
+			Transport: &http.Transport{
+				Dial: func(string, string) (net.Conn, error) {
+					return net.Dial("unix", fixture.SocketPath)
+				},
+				DisableKeepAlives: true,
+			},
+		}
+
+		req, err := http.NewRequest(method, "http://api"+path, nil)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		for name, values := range header {
+			req.Header[name] = values
+		}
+
+		response, err := httpClient.Do(req)
+		Ω(err).ShouldNot(HaveOccurred())
+		response.Body.Close()
+
+		return response.StatusCode
+	}
+
+	runningProcess := func() (garden.Process, *gbytes.Buffer, chan struct{}) {
+		exit := make(chan struct{})
+
+		fakeContainer.RunStub = func(spec garden.ProcessSpec, processIO garden.ProcessIO) (garden.Process, error) {
+			processIO.Stdout.Write([]byte("running"))
+
+			process := new(fakes.FakeProcess)
+			process.IDReturns(42)
+			process.WaitForExitStub = func() (garden.ExitInfo, error) {
+				<-exit
+				return garden.ExitInfo{ExitStatus: 3}, nil
+			}
+
+			return process, nil
+		}
+
+		container, err := apiClient.Lookup("some-handle")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		stdout := gbytes.NewBuffer()
+
+		process, err := container.Run(garden.ProcessSpec{Path: "sleep"}, garden.ProcessIO{Stdout: stdout})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Eventually(stdout).Should(gbytes.Say("running"))
+
+		return process, stdout, exit
+	}
+
+	It("reports that it is ready until then", func() {
+		Ω(fixture.Server.Draining()).Should(BeFalse())
+		Ω(request("GET", "/ready", nil)).Should(Equal(http.StatusOK))
+
+		fixture.Server.Drain()
+
+		Ω(fixture.Server.Draining()).Should(BeTrue())
+		Ω(request("GET", "/ready", nil)).Should(Equal(http.StatusServiceUnavailable))
+	})
+
+	It("starts draining when asked over the API", func() {
+		Ω(request("PUT", "/drain", nil)).Should(Equal(http.StatusOK))
+		Ω(fixture.Server.Draining()).Should(BeTrue())
+	})
+
+	Context("when it is draining", func() {
+		JustBeforeEach(func() {
+			fixture.Server.Drain()
+		})
+
+		It("refuses to create containers, with a retryable error", func() {
+			_, err := apiClient.Create(garden.ContainerSpec{})
+			Ω(err).Should(Equal(garden.DrainingError{}))
+
+			grpcClient := client.New(connection.NewGRPC("unix", grpcSocketPath))
+
+			_, err = grpcClient.Create(garden.ContainerSpec{})
+			Ω(err).Should(Equal(garden.DrainingError{}))
+
+			Ω(fixture.Backend.CreateCallCount()).Should(BeZero())
+		})
+
+		It("refuses to run processes", func() {
+			container, err := apiClient.Lookup("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, err = container.Run(garden.ProcessSpec{Path: "ls"}, garden.ProcessIO{})
+			Ω(err).Should(Equal(garden.DrainingError{}))
+
+			Ω(fakeContainer.RunCallCount()).Should(BeZero())
+		})
+
+		It("keeps serving info and destroys", func() {
+			fakeContainer.InfoReturns(garden.ContainerInfo{State: "active"}, nil)
+
+			container, err := apiClient.Lookup("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			info, err := container.Info()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(info.State).Should(Equal("active"))
+
+			Ω(apiClient.Destroy("some-handle")).Should(Succeed())
+			Ω(fixture.Backend.DestroyCallCount()).Should(Equal(1))
+		})
+
+		It("is drained already, with no process streams", func() {
+			Eventually(fixture.Server.Drained()).Should(BeClosed())
+		})
+	})
+
+	Context("with a process streaming", func() {
+		BeforeEach(func() {
+			options = append(options, server.WithDrainTimeout(time.Minute))
+		})
+
+		It("lets it run to completion", func() {
+			process, _, exit := runningProcess()
+
+			fixture.Server.Drain()
+
+			Consistently(fixture.Server.Drained()).ShouldNot(BeClosed())
+
+			close(exit)
+
+			status, err := process.Wait()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(status).Should(Equal(3))
+
+			Eventually(fixture.Server.Drained()).Should(BeClosed())
+		})
+
+		Context("past the drain timeout", func() {
+			BeforeEach(func() {
+				options = append(options, server.WithDrainTimeout(200*time.Millisecond))
+			})
+
+			It("detaches it", func() {
+				process, _, exit := runningProcess()
+				defer close(exit)
+
+				fixture.Server.Drain()
+
+				Eventually(fixture.Server.Drained()).Should(BeClosed())
+
+				_, err := process.Wait()
+				Ω(err).Should(Equal(garden.ErrDisconnected))
+			})
+		})
+	})
+
+	Context("with drain signals", func() {
+		BeforeEach(func() {
+			options = append(options, server.WithDrainSignals(syscall.SIGUSR2))
+		})
+
+		It("starts draining on receiving one", func() {
+			Ω(syscall.Kill(os.Getpid(), syscall.SIGUSR2)).Should(Succeed())
+			Eventually(fixture.Server.Draining).Should(BeTrue())
+		})
+	})
+
+	Context("when the server authenticates requests", func() {
+		BeforeEach(func() {
+			options = append(options, server.WithAuthentication(server.StaticTokens{
+				"admin-token": server.Identity{},
+				"tenant-token": server.Identity{
+					Selector: garden.Properties{"tenant": "some-tenant"},
+				},
+				"operator-token": server.Identity{
+					Denied: []server.Operation{server.OperationDrain},
+				},
+			}))
+		})
+
+		It("reports readiness without credentials", func() {
+			Ω(request("GET", "/ready", nil)).Should(Equal(http.StatusOK))
+		})
+
+		It("only drains for identities allowed to, across all containers", func() {
+			Ω(request("PUT", "/drain", nil)).Should(Equal(http.StatusUnauthorized))
+			Ω(request("PUT", "/drain", http.Header{"Authorization": {"Bearer tenant-token"}})).Should(Equal(http.StatusForbidden))
+			Ω(request("PUT", "/drain", http.Header{"Authorization": {"Bearer operator-token"}})).Should(Equal(http.StatusForbidden))
+			Ω(fixture.Server.Draining()).Should(BeFalse())
+
+			Ω(request("PUT", "/drain", http.Header{"Authorization": {"Bearer admin-token"}})).Should(Equal(http.StatusOK))
+			Ω(fixture.Server.Draining()).Should(BeTrue())
+		})
+	})
+})
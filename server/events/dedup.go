@@ -0,0 +1,97 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+// Source is where an event was published from.
+type Source int
+
+const (
+	SourceServer Source = iota
+	SourceBackend
+)
+
+// Deduplicator recognizes an event published by both the server and the
+// backend, e.g. the create event of a container created through the server
+// by a backend that also publishes its own. Of the two, whichever is
+// published first within the window is kept, and the other is a duplicate.
+// Events published by only one source are never duplicates, however often
+// they recur.
+type Deduplicator struct {
+	window time.Duration
+
+	pending map[eventKey][]pendingEvent
+	mu      sync.Mutex
+}
+
+type eventKey struct {
+	eventType garden.EventType
+	handle    string
+}
+
+// pendingEvent is an event kept until the other source publishes it too, or
+// the window passes.
+type pendingEvent struct {
+	source Source
+	at     time.Time
+}
+
+func NewDeduplicator(window time.Duration) *Deduplicator {
+	return &Deduplicator{
+		window:  window,
+		pending: make(map[eventKey][]pendingEvent),
+	}
+}
+
+// First returns false if the other source published an event of the same
+// type for the same container within the window, and true otherwise.
+func (d *Deduplicator) First(source Source, event garden.Event) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	d.expire(now)
+
+	key := eventKey{eventType: event.Type, handle: event.Handle}
+
+	pending := d.pending[key]
+	for i, earlier := range pending {
+		if earlier.source == source {
+			continue
+		}
+
+		pending = append(pending[:i], pending[i+1:]...)
+		if len(pending) == 0 {
+			delete(d.pending, key)
+		} else {
+			d.pending[key] = pending
+		}
+
+		return false
+	}
+
+	d.pending[key] = append(pending, pendingEvent{source: source, at: now})
+
+	return true
+}
+
+func (d *Deduplicator) expire(now time.Time) {
+	for key, pending := range d.pending {
+		current := pending[:0]
+		for _, event := range pending {
+			if now.Sub(event.at) < d.window {
+				current = append(current, event)
+			}
+		}
+
+		if len(current) == 0 {
+			delete(d.pending, key)
+		} else {
+			d.pending[key] = current
+		}
+	}
+}
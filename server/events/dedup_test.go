@@ -0,0 +1,68 @@
+package events_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden/server/events"
+)
+
+var _ = Describe("Deduplicator", func() {
+	var dedup *events.Deduplicator
+
+	created := garden.Event{Type: garden.EventTypeCreate, Handle: "some-handle"}
+
+	BeforeEach(func() {
+		dedup = events.NewDeduplicator(time.Minute)
+	})
+
+	It("recognizes an event published by the other source after the server", func() {
+		Ω(dedup.First(events.SourceServer, created)).Should(BeTrue())
+		Ω(dedup.First(events.SourceBackend, created)).Should(BeFalse())
+	})
+
+	It("recognizes an event published by the other source after the backend", func() {
+		Ω(dedup.First(events.SourceBackend, created)).Should(BeTrue())
+		Ω(dedup.First(events.SourceServer, created)).Should(BeFalse())
+	})
+
+	It("recognizes each event only once", func() {
+		Ω(dedup.First(events.SourceServer, created)).Should(BeTrue())
+		Ω(dedup.First(events.SourceBackend, created)).Should(BeFalse())
+		Ω(dedup.First(events.SourceBackend, created)).Should(BeTrue())
+	})
+
+	It("keeps recurring events from the same source", func() {
+		limited := garden.Event{Type: garden.EventTypeLimit, Handle: "some-handle"}
+
+		Ω(dedup.First(events.SourceServer, limited)).Should(BeTrue())
+		Ω(dedup.First(events.SourceServer, limited)).Should(BeTrue())
+		Ω(dedup.First(events.SourceBackend, limited)).Should(BeFalse())
+		Ω(dedup.First(events.SourceBackend, limited)).Should(BeFalse())
+		Ω(dedup.First(events.SourceBackend, limited)).Should(BeTrue())
+	})
+
+	It("keeps events of other types or containers", func() {
+		Ω(dedup.First(events.SourceServer, created)).Should(BeTrue())
+
+		Ω(dedup.First(events.SourceBackend, garden.Event{Type: garden.EventTypeDestroy, Handle: "some-handle"})).Should(BeTrue())
+		Ω(dedup.First(events.SourceBackend, garden.Event{Type: garden.EventTypeCreate, Handle: "other-handle"})).Should(BeTrue())
+	})
+
+	Context("once the window has passed", func() {
+		BeforeEach(func() {
+			dedup = events.NewDeduplicator(10 * time.Millisecond)
+		})
+
+		It("keeps the other source's event", func() {
+			Ω(dedup.First(events.SourceServer, created)).Should(BeTrue())
+
+			time.Sleep(20 * time.Millisecond)
+
+			Ω(dedup.First(events.SourceBackend, created)).Should(BeTrue())
+		})
+	})
+})
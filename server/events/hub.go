@@ -0,0 +1,99 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+// SubscriptionBufferSize is the number of events buffered per subscriber
+// before further events are dropped for that subscriber.
+const SubscriptionBufferSize = 256
+
+type Hub struct {
+	subscriptions map[*Subscription]struct{}
+	mu            sync.RWMutex
+}
+
+type Subscription struct {
+	hub    *Hub
+	filter garden.Properties
+
+	events chan garden.Event
+	closed bool
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe registers a subscriber that receives every published event whose
+// properties contain all of the given filter properties.
+func (h *Hub) Subscribe(filter garden.Properties) *Subscription {
+	sub := &Subscription{
+		hub:    h,
+		filter: filter,
+		events: make(chan garden.Event, SubscriptionBufferSize),
+	}
+
+	h.mu.Lock()
+	h.subscriptions[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub
+}
+
+// Publish delivers the event to every matching subscriber. It never blocks;
+// subscribers that have fallen behind miss the event.
+func (h *Hub) Publish(event garden.Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.subscriptions {
+		if !sub.matches(event) {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+		}
+	}
+}
+
+func (h *Hub) HasSubscribers() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.subscriptions) > 0
+}
+
+func (s *Subscription) Events() <-chan garden.Event {
+	return s.events
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	s.closed = true
+
+	delete(s.hub.subscriptions, s)
+	close(s.events)
+}
+
+func (s *Subscription) matches(event garden.Event) bool {
+	for key, value := range s.filter {
+		if event.Properties[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,79 @@
+package events_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden/server/events"
+)
+
+var _ = Describe("Hub", func() {
+	var hub *events.Hub
+
+	BeforeEach(func() {
+		hub = events.NewHub()
+	})
+
+	It("delivers published events to subscribers", func() {
+		sub := hub.Subscribe(nil)
+		defer sub.Close()
+
+		hub.Publish(garden.Event{Type: garden.EventTypeCreate, Handle: "some-handle"})
+
+		Eventually(sub.Events()).Should(Receive(Equal(garden.Event{
+			Type:   garden.EventTypeCreate,
+			Handle: "some-handle",
+		})))
+	})
+
+	It("only delivers events whose properties match the filter", func() {
+		sub := hub.Subscribe(garden.Properties{"owner": "me"})
+		defer sub.Close()
+
+		hub.Publish(garden.Event{
+			Handle:     "theirs",
+			Properties: garden.Properties{"owner": "them"},
+		})
+
+		hub.Publish(garden.Event{
+			Handle:     "mine",
+			Properties: garden.Properties{"owner": "me", "other": "thing"},
+		})
+
+		var event garden.Event
+		Eventually(sub.Events()).Should(Receive(&event))
+		Ω(event.Handle).Should(Equal("mine"))
+		Consistently(sub.Events()).ShouldNot(Receive())
+	})
+
+	It("drops events for subscribers that have fallen behind", func() {
+		sub := hub.Subscribe(nil)
+		defer sub.Close()
+
+		for i := 0; i < events.SubscriptionBufferSize+10; i++ {
+			hub.Publish(garden.Event{Handle: "some-handle"})
+		}
+
+		Ω(sub.Events()).Should(HaveLen(events.SubscriptionBufferSize))
+	})
+
+	Describe("closing a subscription", func() {
+		It("closes its events channel and unregisters it", func() {
+			sub := hub.Subscribe(nil)
+			Ω(hub.HasSubscribers()).Should(BeTrue())
+
+			sub.Close()
+			Ω(hub.HasSubscribers()).Should(BeFalse())
+			Ω(sub.Events()).Should(BeClosed())
+
+			hub.Publish(garden.Event{Handle: "some-handle"})
+		})
+
+		It("can be closed more than once", func() {
+			sub := hub.Subscribe(nil)
+			sub.Close()
+			sub.Close()
+		})
+	})
+})
@@ -0,0 +1,714 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pivotal-golang/lager"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/cloudfoundry-incubator/garden"
+	protocol "github.com/cloudfoundry-incubator/garden/protocol"
+	"github.com/cloudfoundry-incubator/garden/transport"
+)
+
+// WithGRPC also serves the API as the Garden gRPC service, on a listener of
+// its own at the given address.
+func WithGRPC(network, address string) Option {
+	return func(s *GardenServer) {
+		s.grpcNetwork = network
+		s.grpcAddr = address
+	}
+}
+
+var grpcCodes = map[protocol.ErrorResponse_Type]codes.Code{
+	protocol.ErrorResponse_Unknown:            codes.InvalidArgument,
+	protocol.ErrorResponse_ContainerNotFound:  codes.NotFound,
+	protocol.ErrorResponse_ConcurrentDestroy:  codes.Aborted,
+	protocol.ErrorResponse_InvalidContentType: codes.InvalidArgument,
+	protocol.ErrorResponse_CapacityExhausted:  codes.ResourceExhausted,
+	protocol.ErrorResponse_BackendFailure:     codes.Unknown,
+	protocol.ErrorResponse_Unauthenticated:    codes.Unauthenticated,
+	protocol.ErrorResponse_Forbidden:          codes.PermissionDenied,
+	protocol.ErrorResponse_Draining:           codes.Unavailable,
+	protocol.ErrorResponse_PropertyConflict:   codes.Aborted,
+}
+
+// grpcError logs the error and converts it to a gRPC status, sending its
+// ErrorResponse in the trailer so that clients can rebuild the typed error.
+func grpcError(ctx context.Context, err error, logger lager.Logger) error {
+	logger.Error("failed", err)
+
+	_, response := errorResponse(err)
+
+	data, marshalErr := proto.Marshal(response)
+	if marshalErr == nil {
+		grpc.SetTrailer(ctx, metadata.Pairs(transport.GRPCErrorTrailer, string(data)))
+	}
+
+	return status.Error(grpcCodes[response.GetType()], err.Error())
+}
+
+// authenticateGRPC returns the context of a call with the identity of the
+// bearer token in its authorization metadata.
+func (s *GardenServer) authenticateGRPC(ctx context.Context) (context.Context, error) {
+	var authorization string
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		authorization = values[0]
+	}
+
+	identity, err := s.authenticate(authorization)
+	if err != nil {
+		return nil, grpcError(ctx, err, s.session(ctx, "authenticate"))
+	}
+
+	return withIdentity(ctx, identity), nil
+}
+
+func (s *GardenServer) authenticateUnary(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.authenticateGRPC(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, request)
+}
+
+func (s *GardenServer) authenticateStream(service interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticateGRPC(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(service, contextStream{stream, ctx})
+}
+
+// contextStream is a server stream whose context is replaced, e.g. by one
+// carrying the identity of its caller.
+type contextStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+func (stream contextStream) Context() context.Context {
+	return stream.ctx
+}
+
+// grpcService serves the Garden gRPC service with the server's handling of
+// each request.
+type grpcService struct {
+	server *GardenServer
+	logger lager.Logger
+}
+
+// session returns the logger session of handling a call, whose data carries
+// the call's request ID and trace context.
+func (g *grpcService) session(ctx context.Context, task string, data ...lager.Data) lager.Logger {
+	return g.logger.Session(task, append(data, requestData(ctx))...)
+}
+
+func (g *grpcService) Ping(ctx context.Context, request *protocol.PingRequest) (*protocol.PingResponse, error) {
+	err := g.server.backend.Ping()
+	if err != nil {
+		g.session(ctx, "ping").Error("failed", err)
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+
+	return &protocol.PingResponse{}, nil
+}
+
+func (g *grpcService) Capacity(ctx context.Context, request *protocol.CapacityRequest) (*protocol.CapacityResponse, error) {
+	hLog := g.session(ctx, "capacity")
+
+	response, err := g.server.capacity(ctx)
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) Create(ctx context.Context, request *protocol.CreateRequest) (*protocol.CreateResponse, error) {
+	hLog := g.session(ctx, "create", lager.Data{
+		"request": request,
+	})
+
+	response, err := g.server.create(ctx, hLog, request)
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) List(ctx context.Context, request *protocol.ListRequest) (*protocol.ListResponse, error) {
+	hLog := g.session(ctx, "list")
+
+	response, err := g.server.list(ctx, hLog, request)
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) Destroy(ctx context.Context, request *protocol.DestroyRequest) (*protocol.DestroyResponse, error) {
+	hLog := g.session(ctx, "destroy", lager.Data{
+		"handle": request.GetHandle(),
+	})
+
+	response, err := g.server.destroy(ctx, hLog, request.GetHandle())
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) Stop(ctx context.Context, request *protocol.StopRequest) (*protocol.StopResponse, error) {
+	hLog := g.session(ctx, "stop", lager.Data{
+		"handle": request.GetHandle(),
+	})
+
+	response, err := g.server.stopContainer(ctx, hLog, request.GetHandle(), request.GetKill())
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) Lookup(ctx context.Context, request *protocol.LookupRequest) (*protocol.LookupResponse, error) {
+	hLog := g.session(ctx, "lookup", lager.Data{
+		"handle": request.GetHandle(),
+	})
+
+	response, err := g.server.lookup(ctx, hLog, request.GetHandle())
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) Info(ctx context.Context, request *protocol.InfoRequest) (*protocol.InfoResponse, error) {
+	hLog := g.session(ctx, "info", lager.Data{
+		"handle": request.GetHandle(),
+	})
+
+	response, err := g.server.info(ctx, hLog, request.GetHandle())
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) BulkInfo(ctx context.Context, request *protocol.BulkInfoRequest) (*protocol.BulkInfoResponse, error) {
+	hLog := g.session(ctx, "bulk-info", lager.Data{
+		"handles": request.GetHandles(),
+	})
+
+	response, err := g.server.bulkInfo(ctx, hLog, request.GetHandles())
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) StreamIn(stream protocol.Garden_StreamInServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	request := first.GetRequest()
+
+	hLog := g.session(stream.Context(), "stream-in", lager.Data{
+		"handle":      request.GetHandle(),
+		"destination": request.GetDstPath(),
+	})
+
+	if request == nil {
+		return grpcError(stream.Context(), malformedRequestError{errors.New("first payload must carry the request")}, hLog)
+	}
+
+	reader := &streamInReader{
+		stream: stream,
+		data:   first.GetData(),
+	}
+
+	response, err := g.server.streamIn(stream.Context(), hLog, request.GetHandle(), request.GetDstPath(), reader)
+	if err != nil {
+		return grpcError(stream.Context(), err, hLog)
+	}
+
+	return stream.SendAndClose(response)
+}
+
+func (g *grpcService) StreamOut(request *protocol.StreamOutRequest, stream protocol.Garden_StreamOutServer) error {
+	hLog := g.session(stream.Context(), "stream-out", lager.Data{
+		"handle": request.GetHandle(),
+		"source": request.GetSrcPath(),
+	})
+
+	_, err := g.server.streamOut(stream.Context(), hLog, request.GetHandle(), request.GetSrcPath(), streamOutWriter{stream})
+	if err != nil {
+		return grpcError(stream.Context(), err, hLog)
+	}
+
+	return nil
+}
+
+func (g *grpcService) LimitBandwidth(ctx context.Context, request *protocol.LimitBandwidthRequest) (*protocol.LimitBandwidthResponse, error) {
+	hLog := g.session(ctx, "limit-bandwidth", lager.Data{
+		"handle": request.GetHandle(),
+	})
+
+	response, err := g.server.limitBandwidth(ctx, hLog, request.GetHandle(), request)
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) CurrentBandwidthLimits(ctx context.Context, request *protocol.CurrentLimitsRequest) (*protocol.LimitBandwidthResponse, error) {
+	hLog := g.session(ctx, "current-bandwidth-limits", lager.Data{
+		"handle": request.GetHandle(),
+	})
+
+	response, err := g.server.currentBandwidthLimits(ctx, hLog, request.GetHandle())
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) LimitCpu(ctx context.Context, request *protocol.LimitCpuRequest) (*protocol.LimitCpuResponse, error) {
+	hLog := g.session(ctx, "limit-cpu", lager.Data{
+		"handle": request.GetHandle(),
+	})
+
+	response, err := g.server.limitCPU(ctx, hLog, request.GetHandle(), request)
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) CurrentCpuLimits(ctx context.Context, request *protocol.CurrentLimitsRequest) (*protocol.LimitCpuResponse, error) {
+	hLog := g.session(ctx, "current-cpu-limits", lager.Data{
+		"handle": request.GetHandle(),
+	})
+
+	response, err := g.server.currentCPULimits(ctx, hLog, request.GetHandle())
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) LimitDisk(ctx context.Context, request *protocol.LimitDiskRequest) (*protocol.LimitDiskResponse, error) {
+	hLog := g.session(ctx, "limit-disk", lager.Data{
+		"handle": request.GetHandle(),
+	})
+
+	response, err := g.server.limitDisk(ctx, hLog, request.GetHandle(), request)
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) CurrentDiskLimits(ctx context.Context, request *protocol.CurrentLimitsRequest) (*protocol.LimitDiskResponse, error) {
+	hLog := g.session(ctx, "current-disk-limits", lager.Data{
+		"handle": request.GetHandle(),
+	})
+
+	response, err := g.server.currentDiskLimits(ctx, hLog, request.GetHandle())
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) LimitMemory(ctx context.Context, request *protocol.LimitMemoryRequest) (*protocol.LimitMemoryResponse, error) {
+	hLog := g.session(ctx, "limit-memory", lager.Data{
+		"handle": request.GetHandle(),
+	})
+
+	response, err := g.server.limitMemory(ctx, hLog, request.GetHandle(), request)
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) CurrentMemoryLimits(ctx context.Context, request *protocol.CurrentLimitsRequest) (*protocol.LimitMemoryResponse, error) {
+	hLog := g.session(ctx, "current-memory-limits", lager.Data{
+		"handle": request.GetHandle(),
+	})
+
+	response, err := g.server.currentMemoryLimits(ctx, hLog, request.GetHandle())
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) Processes(ctx context.Context, request *protocol.ProcessesRequest) (*protocol.ProcessesResponse, error) {
+	hLog := g.session(ctx, "processes", lager.Data{
+		"handle": request.GetHandle(),
+	})
+
+	response, err := g.server.listProcesses(ctx, hLog, request.GetHandle())
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) NetIn(ctx context.Context, request *protocol.NetInRequest) (*protocol.NetInResponse, error) {
+	hLog := g.session(ctx, "net-in", lager.Data{
+		"handle": request.GetHandle(),
+	})
+
+	response, err := g.server.netIn(ctx, hLog, request.GetHandle(), request)
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) NetOut(ctx context.Context, request *protocol.NetOutRequest) (*protocol.NetOutResponse, error) {
+	hLog := g.session(ctx, "net-out", lager.Data{
+		"handle": request.GetHandle(),
+	})
+
+	response, err := g.server.netOut(ctx, hLog, request.GetHandle(), request)
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) GetProperty(ctx context.Context, request *protocol.GetPropertyRequest) (*protocol.GetPropertyResponse, error) {
+	hLog := g.session(ctx, "get-property", lager.Data{
+		"handle": request.GetHandle(),
+		"key":    request.GetKey(),
+	})
+
+	response, err := g.server.getProperty(ctx, hLog, request.GetHandle(), request.GetKey())
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) SetProperty(ctx context.Context, request *protocol.SetPropertyRequest) (*protocol.SetPropertyResponse, error) {
+	hLog := g.session(ctx, "set-property", lager.Data{
+		"handle": request.GetHandle(),
+		"key":    request.GetKey(),
+	})
+
+	response, err := g.server.setProperty(ctx, hLog, request.GetHandle(), request.GetKey(), request.GetValue())
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) RemoveProperty(ctx context.Context, request *protocol.RemovePropertyRequest) (*protocol.RemovePropertyResponse, error) {
+	hLog := g.session(ctx, "remove-property", lager.Data{
+		"handle": request.GetHandle(),
+		"key":    request.GetKey(),
+	})
+
+	response, err := g.server.removeProperty(ctx, hLog, request.GetHandle(), request.GetKey())
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) Properties(ctx context.Context, request *protocol.PropertiesRequest) (*protocol.PropertiesResponse, error) {
+	hLog := g.session(ctx, "properties", lager.Data{
+		"handle": request.GetHandle(),
+	})
+
+	response, err := g.server.properties(ctx, hLog, request.GetHandle())
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) SetProperties(ctx context.Context, request *protocol.SetPropertiesRequest) (*protocol.SetPropertiesResponse, error) {
+	hLog := g.session(ctx, "set-properties", lager.Data{
+		"handle": request.GetHandle(),
+	})
+
+	response, err := g.server.setProperties(
+		ctx,
+		hLog,
+		request.GetHandle(),
+		request.GetExpected(),
+		gardenProperties(request.GetProperties()),
+		request.GetRemovals(),
+	)
+	if err != nil {
+		return nil, grpcError(ctx, err, hLog)
+	}
+
+	return response, nil
+}
+
+func (g *grpcService) Run(stream protocol.Garden_RunServer) error {
+	var first protocol.ProcessPayload
+	err := stream.RecvMsg(&first)
+	if err != nil {
+		return err
+	}
+
+	request := first.GetOpen().GetRun()
+
+	hLog := g.session(stream.Context(), "run", lager.Data{
+		"handle": request.GetHandle(),
+	})
+
+	if request == nil {
+		return grpcError(stream.Context(), malformedRequestError{errors.New("first payload must open a run")}, hLog)
+	}
+
+	container, err := g.server.backendFor(stream.Context()).Lookup(request.GetHandle())
+	if err != nil {
+		return grpcError(stream.Context(), err, hLog)
+	}
+
+	g.server.bomberman.Pause(container.Handle())
+	defer g.server.bomberman.Unpause(container.Handle())
+
+	process, output, stdinW, err := g.server.runProcess(hLog, container, request)
+	if err != nil {
+		return grpcError(stream.Context(), err, hLog)
+	}
+
+	err = stream.SendHeader(metadata.Pairs(transport.StreamFeaturesHeader, streamFeatures))
+	if err != nil {
+		stdinW.Close()
+		return err
+	}
+
+	messages := grpcMessages{stream}
+
+	messages.WriteMessage(&protocol.ProcessPayload{
+		ProcessId: proto.Uint32(process.ID()),
+	})
+
+	control := grpcStreamControl(stream.Context())
+
+	go g.server.streamInput(messages, stdinW, process, control)
+
+	g.server.processes.Attached(container.Handle(), process.ID())
+	defer g.server.processes.Detached(container.Handle(), process.ID())
+
+	g.server.streamProcess(hLog, messages, container.Handle(), process, output, 0, control, stdinW)
+
+	return nil
+}
+
+func (g *grpcService) Attach(stream protocol.Garden_AttachServer) error {
+	var first protocol.ProcessPayload
+	err := stream.RecvMsg(&first)
+	if err != nil {
+		return err
+	}
+
+	open := first.GetOpen()
+	request := open.GetAttach()
+
+	hLog := g.session(stream.Context(), "attach", lager.Data{
+		"handle": request.GetHandle(),
+	})
+
+	if request == nil {
+		return grpcError(stream.Context(), malformedRequestError{errors.New("first payload must open an attach")}, hLog)
+	}
+
+	container, err := g.server.backendFor(stream.Context()).Lookup(request.GetHandle())
+	if err != nil {
+		return grpcError(stream.Context(), err, hLog)
+	}
+
+	g.server.bomberman.Pause(container.Handle())
+	defer g.server.bomberman.Unpause(container.Handle())
+
+	process, output, offset, stdinW, err := g.server.attachProcess(hLog, container, request.GetProcessId(), open.Offset != nil, open.GetOffset())
+	if err != nil {
+		return grpcError(stream.Context(), err, hLog)
+	}
+
+	err = stream.SendHeader(metadata.Pairs(transport.StreamFeaturesHeader, streamFeatures))
+	if err != nil {
+		stdinW.Close()
+		return err
+	}
+
+	messages := grpcMessages{stream}
+
+	control := grpcStreamControl(stream.Context())
+
+	go g.server.streamInput(messages, stdinW, process, control)
+
+	g.server.processes.Attached(container.Handle(), process.ID())
+	defer g.server.processes.Detached(container.Handle(), process.ID())
+
+	g.server.streamProcess(hLog, messages, container.Handle(), process, output, offset, control, stdinW)
+
+	return nil
+}
+
+func (g *grpcService) Events(request *protocol.EventsRequest, stream protocol.Garden_EventsServer) error {
+	properties := gardenProperties(request.GetProperties())
+
+	hLog := g.session(stream.Context(), "events", lager.Data{
+		"properties": properties,
+	})
+
+	subscription := g.server.events.Subscribe(properties)
+	defer subscription.Close()
+
+	// let the client know it is subscribed before any event comes along
+	err := stream.SendHeader(metadata.MD{})
+	if err != nil {
+		return err
+	}
+
+	hLog.Debug("streaming")
+
+	for {
+		select {
+		case event := <-subscription.Events():
+			if !visible(stream.Context(), event.Properties) {
+				continue
+			}
+
+			err := stream.Send(eventMessage(event))
+			if err != nil {
+				hLog.Error("failed-to-write", err)
+				return err
+			}
+
+		case <-stream.Context().Done():
+			hLog.Info("disconnected")
+			return nil
+
+		case <-g.server.stopping:
+			hLog.Debug("detaching")
+			return nil
+		}
+	}
+}
+
+// grpcStreamControl returns the control of a process stream, with the stream
+// features the client listed in its metadata.
+func grpcStreamControl(ctx context.Context) *streamControl {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	features := http.Header{
+		transport.StreamFeaturesHeader: md.Get(transport.StreamFeaturesHeader),
+	}
+
+	return streamControlFor(
+		transport.HasStreamFeature(features, transport.FeatureBinaryData),
+		transport.HasStreamFeature(features, transport.FeatureFlowControl),
+	)
+}
+
+func gardenProperties(properties []*protocol.Property) garden.Properties {
+	converted := garden.Properties{}
+	for _, prop := range properties {
+		converted[prop.GetKey()] = prop.GetValue()
+	}
+
+	return converted
+}
+
+// grpcMessages reads and writes the payloads of a process stream as the
+// messages of a gRPC stream.
+type grpcMessages struct {
+	stream grpc.ServerStream
+}
+
+func (m grpcMessages) ReadMessage(msg proto.Message) error {
+	return m.stream.RecvMsg(msg)
+}
+
+func (m grpcMessages) WriteMessage(msg proto.Message) error {
+	return m.stream.SendMsg(msg)
+}
+
+// streamInReader reads the data of the payloads streamed in, until the client
+// closes its side of the stream.
+type streamInReader struct {
+	stream protocol.Garden_StreamInServer
+	data   []byte
+}
+
+func (r *streamInReader) Read(p []byte) (int, error) {
+	for len(r.data) == 0 {
+		payload, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+
+		r.data = payload.GetData()
+	}
+
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+
+	return n, nil
+}
+
+// streamOutWriter sends each write as a payload of the stream out.
+type streamOutWriter struct {
+	stream protocol.Garden_StreamOutServer
+}
+
+func (w streamOutWriter) Write(p []byte) (int, error) {
+	// the payload may be read after Send returns, and p will be reused
+	data := make([]byte, len(p))
+	copy(data, p)
+
+	err := w.stream.Send(&protocol.StreamOutPayload{Data: data})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
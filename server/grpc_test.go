@@ -0,0 +1,536 @@
+package server_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden/client"
+	"github.com/cloudfoundry-incubator/garden/client/connection"
+	"github.com/cloudfoundry-incubator/garden/fakes"
+	"github.com/cloudfoundry-incubator/garden/server"
+)
+
+var _ = Describe("When a client connects over gRPC", func() {
+	var tmpdir string
+	var grpcSocketPath string
+
+	var serverBackend *fakes.FakeBackend
+
+	var apiServer *server.GardenServer
+	var apiClient garden.Client
+	var isRunning bool
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		grpcSocketPath = path.Join(tmpdir, "grpc.sock")
+		serverBackend = new(fakes.FakeBackend)
+
+		apiServer = server.New(
+			"unix",
+			path.Join(tmpdir, "api.sock"),
+			42*time.Second,
+			serverBackend,
+			lagertest.NewTestLogger("test"),
+			server.WithGRPC("unix", grpcSocketPath),
+		)
+
+		err = apiServer.Start()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		isRunning = true
+
+		Eventually(ErrorDialing("unix", grpcSocketPath)).ShouldNot(HaveOccurred())
+
+		apiClient = client.New(connection.NewGRPC("unix", grpcSocketPath))
+	})
+
+	AfterEach(func() {
+		if isRunning {
+			apiServer.Stop()
+		}
+		if tmpdir != "" {
+			os.RemoveAll(tmpdir)
+		}
+	})
+
+	Context("and the client sends a PingRequest", func() {
+		It("does not error", func() {
+			Ω(apiClient.Ping()).ShouldNot(HaveOccurred())
+		})
+
+		Context("when the backend ping fails", func() {
+			BeforeEach(func() {
+				serverBackend.PingReturns(errors.New("oh no!"))
+			})
+
+			It("returns an error", func() {
+				Ω(apiClient.Ping()).Should(HaveOccurred())
+			})
+		})
+
+		Context("when the server is not up", func() {
+			BeforeEach(func() {
+				isRunning = false
+				apiServer.Stop()
+			})
+
+			It("returns an error", func() {
+				Ω(apiClient.Ping()).Should(HaveOccurred())
+			})
+		})
+	})
+
+	Context("and the client sends a CreateRequest", func() {
+		BeforeEach(func() {
+			fakeContainer := new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+
+			serverBackend.CreateReturns(fakeContainer, nil)
+		})
+
+		It("creates the container with the spec", func() {
+			container, err := apiClient.Create(garden.ContainerSpec{
+				Handle:     "some-handle",
+				RootFSPath: "/some/rootfs",
+				Env:        []string{"FLAVOR=chocolate"},
+				Properties: garden.Properties{"owner": "me"},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(container.Handle()).Should(Equal("some-handle"))
+
+			spec := serverBackend.CreateArgsForCall(0)
+			Ω(spec.Handle).Should(Equal("some-handle"))
+			Ω(spec.RootFSPath).Should(Equal("/some/rootfs"))
+			Ω(spec.Env).Should(Equal([]string{"FLAVOR=chocolate"}))
+			Ω(spec.Properties).Should(Equal(garden.Properties{"owner": "me"}))
+		})
+
+		Context("when the backend is out of capacity", func() {
+			BeforeEach(func() {
+				serverBackend.CreateReturns(nil, garden.CapacityExhaustedError{Message: "no room"})
+			})
+
+			It("returns a CapacityExhaustedError", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{})
+				Ω(err).Should(Equal(garden.CapacityExhaustedError{Message: "no room"}))
+			})
+		})
+	})
+
+	Context("and the client sends a ListRequest", func() {
+		BeforeEach(func() {
+			c1 := new(fakes.FakeContainer)
+			c1.HandleReturns("some-handle")
+
+			c2 := new(fakes.FakeContainer)
+			c2.HandleReturns("another-handle")
+
+			serverBackend.ContainersReturns([]garden.Container{c1, c2}, nil)
+		})
+
+		It("returns the containers matching the properties", func() {
+			containers, err := apiClient.Containers(garden.Properties{"owner": "me"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(containers).Should(HaveLen(2))
+			Ω(containers[0].Handle()).Should(Equal("some-handle"))
+			Ω(containers[1].Handle()).Should(Equal("another-handle"))
+
+			Ω(serverBackend.ContainersArgsForCall(1)).Should(Equal(garden.Properties{"owner": "me"}))
+		})
+
+		It("lists the containers a page at a time", func() {
+			serverBackend.ListReturns(garden.ContainerPage{}, garden.ErrNotImplemented)
+
+			page, err := apiClient.List(garden.ListOptions{Limit: 1})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(page.Containers).Should(HaveLen(1))
+			Ω(page.Containers[0].Handle()).Should(Equal("another-handle"))
+			Ω(page.NextCursor).ShouldNot(BeEmpty())
+
+			page, err = apiClient.List(garden.ListOptions{Limit: 1, Cursor: page.NextCursor})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(page.Containers).Should(HaveLen(1))
+			Ω(page.Containers[0].Handle()).Should(Equal("some-handle"))
+			Ω(page.NextCursor).Should(BeEmpty())
+		})
+	})
+
+	Context("and the client subscribes to events", func() {
+		var events garden.EventStream
+
+		BeforeEach(func() {
+			fakeContainer := new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+
+			serverBackend.CreateReturns(fakeContainer, nil)
+		})
+
+		JustBeforeEach(func() {
+			var err error
+			events, err = apiClient.Events(garden.Properties{"owner": "me"})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			events.Close()
+		})
+
+		It("streams the events matching the properties", func() {
+			_, err := apiClient.Create(garden.ContainerSpec{
+				Properties: garden.Properties{"owner": "someone-else"},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, err = apiClient.Create(garden.ContainerSpec{
+				Properties: garden.Properties{"owner": "me"},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			event, err := events.Next()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(event.Type).Should(Equal(garden.EventTypeCreate))
+			Ω(event.Handle).Should(Equal("some-handle"))
+			Ω(event.Properties).Should(Equal(garden.Properties{"owner": "me"}))
+		})
+
+		Context("when the server is stopped", func() {
+			It("ends the stream", func() {
+				isRunning = false
+				apiServer.Stop()
+
+				_, err := events.Next()
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+	})
+
+	Context("when a container has been created", func() {
+		var container garden.Container
+
+		var fakeContainer *fakes.FakeContainer
+
+		BeforeEach(func() {
+			fakeContainer = new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+
+			serverBackend.CreateReturns(fakeContainer, nil)
+			serverBackend.LookupReturns(fakeContainer, nil)
+		})
+
+		JustBeforeEach(func() {
+			var err error
+
+			container, err = apiClient.Create(garden.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		Context("when the backend cannot find the container", func() {
+			BeforeEach(func() {
+				serverBackend.LookupReturns(nil, garden.ContainerNotFoundError{Handle: "some-handle"})
+			})
+
+			It("returns a ContainerNotFoundError from unary calls", func() {
+				err := container.Stop(false)
+				Ω(err).Should(Equal(garden.ContainerNotFoundError{Handle: "some-handle"}))
+			})
+
+			It("returns a ContainerNotFoundError from streams", func() {
+				_, err := container.Run(garden.ProcessSpec{Path: "ls"}, garden.ProcessIO{})
+				Ω(err).Should(Equal(garden.ContainerNotFoundError{Handle: "some-handle"}))
+
+				_, err = container.StreamOut("/src/path")
+				Ω(err).Should(Equal(garden.ContainerNotFoundError{Handle: "some-handle"}))
+
+				err = container.StreamIn("/dst/path", bytes.NewBufferString("data"))
+				Ω(err).Should(Equal(garden.ContainerNotFoundError{Handle: "some-handle"}))
+			})
+		})
+
+		Context("when destroying the container is already in progress", func() {
+			BeforeEach(func() {
+				serverBackend.DestroyReturns(garden.ConcurrentDestroyError{Handle: "some-handle"})
+			})
+
+			It("returns a ConcurrentDestroyError", func() {
+				err := apiClient.Destroy("some-handle")
+				Ω(err).Should(Equal(garden.ConcurrentDestroyError{Handle: "some-handle"}))
+			})
+		})
+
+		Describe("getting info", func() {
+			BeforeEach(func() {
+				fakeContainer.InfoReturns(garden.ContainerInfo{
+					State:      "active",
+					Properties: garden.Properties{"owner": "me"},
+				}, nil)
+			})
+
+			It("returns the container's info", func() {
+				info, err := container.Info()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(info.State).Should(Equal("active"))
+				Ω(info.Properties).Should(Equal(garden.Properties{"owner": "me"}))
+			})
+		})
+
+		Describe("setting a property", func() {
+			It("sets the property on the container", func() {
+				err := container.SetProperty("owner", "me")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				name, value := fakeContainer.SetPropertyArgsForCall(0)
+				Ω(name).Should(Equal("owner"))
+				Ω(value).Should(Equal("me"))
+			})
+		})
+
+		Describe("comparing and setting properties", func() {
+			It("compares and sets them on the container", func() {
+				owned := garden.Selector{{Key: "owner", Operator: garden.SelectorNotExists}}
+
+				err := container.CompareAndSetProperties(owned, garden.Properties{"owner": "me"}, []string{"queued"})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				expected, properties, removals := fakeContainer.CompareAndSetPropertiesArgsForCall(0)
+				Ω(expected).Should(Equal(owned))
+				Ω(properties).Should(Equal(garden.Properties{"owner": "me"}))
+				Ω(removals).Should(Equal([]string{"queued"}))
+			})
+
+			It("returns the requirement that was not met", func() {
+				fakeContainer.CompareAndSetPropertiesReturns(garden.PropertyConflictError{Requirement: "!owner"})
+
+				err := container.CompareAndSetProperties(garden.Selector{{Key: "owner", Operator: garden.SelectorNotExists}}, garden.Properties{"owner": "me"}, nil)
+				Ω(err).Should(Equal(garden.PropertyConflictError{Requirement: "!owner"}))
+			})
+
+			It("returns all of the properties", func() {
+				fakeContainer.PropertiesReturns(garden.Properties{"owner": "me"}, nil)
+
+				properties, err := container.Properties()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(properties).Should(Equal(garden.Properties{"owner": "me"}))
+			})
+		})
+
+		Describe("limiting memory", func() {
+			BeforeEach(func() {
+				fakeContainer.CurrentMemoryLimitsReturns(garden.MemoryLimits{LimitInBytes: 1024}, nil)
+			})
+
+			It("limits the container and returns the current limits", func() {
+				err := container.LimitMemory(garden.MemoryLimits{LimitInBytes: 1024})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.LimitMemoryArgsForCall(0)).Should(Equal(garden.MemoryLimits{LimitInBytes: 1024}))
+
+				limits, err := container.CurrentMemoryLimits()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(limits).Should(Equal(garden.MemoryLimits{LimitInBytes: 1024}))
+			})
+		})
+
+		Describe("streaming in", func() {
+			It("streams all of the data in", func() {
+				data := bytes.Repeat([]byte("chunk;"), 20000)
+
+				fakeContainer.StreamInStub = func(dest string, stream io.Reader) error {
+					Ω(dest).Should(Equal("/dst/path"))
+					Ω(ioutil.ReadAll(stream)).Should(Equal(data))
+					return nil
+				}
+
+				err := container.StreamIn("/dst/path", bytes.NewReader(data))
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.StreamInCallCount()).Should(Equal(1))
+			})
+
+			Context("when copying in to the container fails", func() {
+				BeforeEach(func() {
+					fakeContainer.StreamInReturns(errors.New("oh no!"))
+				})
+
+				It("returns the error", func() {
+					err := container.StreamIn("/dst/path", bytes.NewBufferString("data"))
+					Ω(err).Should(Equal(garden.BackendError{Message: "oh no!"}))
+				})
+			})
+		})
+
+		Describe("streaming out", func() {
+			It("streams the bits out", func() {
+				fakeContainer.StreamOutReturns(ioutil.NopCloser(bytes.NewBufferString("hello-world!")), nil)
+
+				reader, err := container.StreamOut("/src/path")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				streamedContent, err := ioutil.ReadAll(reader)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(streamedContent)).Should(Equal("hello-world!"))
+
+				Ω(fakeContainer.StreamOutArgsForCall(0)).Should(Equal("/src/path"))
+			})
+
+			Context("when the client closes the stream early", func() {
+				It("closes the backend's stream", func() {
+					closer := &closeChecker{}
+					fakeContainer.StreamOutReturns(closer, nil)
+
+					reader, err := container.StreamOut("/src/path")
+					Ω(err).ShouldNot(HaveOccurred())
+
+					err = reader.Close()
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(closer.Closed).Should(BeTrue())
+				})
+			})
+
+			Context("when streaming out of the container fails", func() {
+				BeforeEach(func() {
+					fakeContainer.StreamOutReturns(nil, errors.New("oh no!"))
+				})
+
+				It("returns the error", func() {
+					_, err := container.StreamOut("/src/path")
+					Ω(err).Should(Equal(garden.BackendError{Message: "oh no!"}))
+				})
+			})
+		})
+
+		Describe("running", func() {
+			Context("when running succeeds", func() {
+				BeforeEach(func() {
+					fakeContainer.RunStub = func(spec garden.ProcessSpec, processIO garden.ProcessIO) (garden.Process, error) {
+						mirrored := make(chan struct{})
+
+						go func() {
+							defer close(mirrored)
+
+							in, _ := ioutil.ReadAll(processIO.Stdin)
+							fmt.Fprintf(processIO.Stdout, "mirrored %s", in)
+							fmt.Fprintf(processIO.Stderr, "stderr data")
+						}()
+
+						process := new(fakes.FakeProcess)
+						process.IDReturns(42)
+						process.WaitForExitStub = func() (garden.ExitInfo, error) {
+							<-mirrored
+							return garden.ExitInfo{ExitStatus: 123}, nil
+						}
+
+						return process, nil
+					}
+				})
+
+				It("streams the process's input and output, and its exit status", func() {
+					stdout := gbytes.NewBuffer()
+					stderr := gbytes.NewBuffer()
+
+					process, err := container.Run(garden.ProcessSpec{
+						Path: "/some/script",
+						Args: []string{"arg1", "arg2"},
+					}, garden.ProcessIO{
+						Stdin:  bytes.NewBufferString("some input"),
+						Stdout: stdout,
+						Stderr: stderr,
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(process.ID()).Should(Equal(uint32(42)))
+
+					Eventually(stdout).Should(gbytes.Say("mirrored some input"))
+					Eventually(stderr).Should(gbytes.Say("stderr data"))
+
+					status, err := process.Wait()
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(status).Should(Equal(123))
+
+					spec, _ := fakeContainer.RunArgsForCall(0)
+					Ω(spec.Path).Should(Equal("/some/script"))
+					Ω(spec.Args).Should(Equal([]string{"arg1", "arg2"}))
+				})
+			})
+
+			Context("when running fails", func() {
+				BeforeEach(func() {
+					fakeContainer.RunReturns(nil, errors.New("oh no!"))
+				})
+
+				It("returns the error", func() {
+					_, err := container.Run(garden.ProcessSpec{Path: "/some/script"}, garden.ProcessIO{})
+					Ω(err).Should(Equal(garden.BackendError{Message: "oh no!"}))
+				})
+			})
+
+			Context("when the server is stopped while the process is running", func() {
+				BeforeEach(func() {
+					fakeContainer.RunStub = func(spec garden.ProcessSpec, processIO garden.ProcessIO) (garden.Process, error) {
+						process := new(fakes.FakeProcess)
+						process.IDReturns(42)
+						process.WaitForExitStub = func() (garden.ExitInfo, error) {
+							select {}
+						}
+
+						return process, nil
+					}
+				})
+
+				It("disconnects the process", func() {
+					process, err := container.Run(garden.ProcessSpec{Path: "/some/script"}, garden.ProcessIO{})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					isRunning = false
+					apiServer.Stop()
+
+					_, err = process.Wait()
+					Ω(err).Should(Equal(garden.ErrDisconnected))
+				})
+			})
+		})
+
+		Describe("attaching", func() {
+			BeforeEach(func() {
+				fakeContainer.AttachStub = func(processID uint32, processIO garden.ProcessIO) (garden.Process, error) {
+					fmt.Fprintf(processIO.Stdout, "attached to %d", processID)
+
+					process := new(fakes.FakeProcess)
+					process.IDReturns(processID)
+					process.WaitForExitReturns(garden.ExitInfo{ExitStatus: 7}, nil)
+
+					return process, nil
+				}
+			})
+
+			It("streams the process's output and exit status", func() {
+				stdout := gbytes.NewBuffer()
+
+				process, err := container.Attach(42, garden.ProcessIO{Stdout: stdout})
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(process.ID()).Should(Equal(uint32(42)))
+
+				Eventually(stdout).Should(gbytes.Say("attached to 42"))
+
+				status, err := process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status).Should(Equal(7))
+			})
+		})
+	})
+})
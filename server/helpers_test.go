@@ -1,7 +1,25 @@
 package server_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
 	"net"
+	"os"
+	"path"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden/fakes"
+	"github.com/cloudfoundry-incubator/garden/server"
 )
 
 func ErrorDialing(network, addr string) func() error {
@@ -18,3 +36,128 @@ func ErrorDialing(network, addr string) func() error {
 func uint64ptr(n uint64) *uint64 {
 	return &n
 }
+
+// serverFixture is a server in front of a fake backend, listening on a unix
+// socket in a temporary directory.
+type serverFixture struct {
+	Dir        string
+	SocketPath string
+
+	Backend *fakes.FakeBackend
+	Logger  *lagertest.TestLogger
+
+	Server *server.GardenServer
+}
+
+func newServerFixture() *serverFixture {
+	dir, err := ioutil.TempDir(os.TempDir(), "api-server-test")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return &serverFixture{
+		Dir:        dir,
+		SocketPath: path.Join(dir, "api.sock"),
+
+		Backend: new(fakes.FakeBackend),
+		Logger:  lagertest.NewTestLogger("test"),
+	}
+}
+
+// Path returns the path of a file in the fixture's directory.
+func (fixture *serverFixture) Path(name string) string {
+	return path.Join(fixture.Dir, name)
+}
+
+// Start starts the server in front of the fake backend, and waits until it
+// accepts connections.
+func (fixture *serverFixture) Start(options ...server.Option) {
+	fixture.StartWith(fixture.Backend, options...)
+}
+
+// StartWith starts the server in front of another backend, e.g. one wrapping
+// the fake backend.
+func (fixture *serverFixture) StartWith(backend garden.Backend, options ...server.Option) {
+	fixture.Server = server.New(
+		"unix",
+		fixture.SocketPath,
+		42*time.Second,
+		backend,
+		fixture.Logger,
+		options...,
+	)
+
+	err := fixture.Server.Start()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	Eventually(ErrorDialing("unix", fixture.SocketPath)).ShouldNot(HaveOccurred())
+}
+
+// Stop stops the server, if it was started, and removes the directory.
+func (fixture *serverFixture) Stop() {
+	if fixture.Server != nil {
+		fixture.Server.Stop()
+	}
+
+	os.RemoveAll(fixture.Dir)
+}
+
+// certificateAuthority issues certificates for the server and clients, named
+// after the host they are for.
+type certificateAuthority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+
+	Pool *x509.CertPool
+}
+
+func newCertificateAuthority() *certificateAuthority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "garden-test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	cert, err := x509.ParseCertificate(der)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return &certificateAuthority{
+		cert: cert,
+		key:  key,
+		Pool: pool,
+	}
+}
+
+func (ca *certificateAuthority) Issue(host string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}
+}
@@ -0,0 +1,352 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pivotal-golang/lager"
+	"golang.org/x/net/context"
+
+	"github.com/cloudfoundry-incubator/garden"
+	protocol "github.com/cloudfoundry-incubator/garden/protocol"
+)
+
+// listQuery is a ListRequest, with its selector parsed.
+type listQuery struct {
+	selector garden.Selector
+	sortBy   string
+	limit    int
+	cursor   string
+
+	summarize bool
+
+	// options is whether the request used any of the options of List, rather
+	// than only properties to filter by, which are served as they always were.
+	options bool
+}
+
+// listCursor is the position of the last container listed in a page. Pages
+// list the containers sorted after it, so that containers created or
+// destroyed meanwhile do not shift the pages after.
+type listCursor struct {
+	SortBy string `json:"sort_by"`
+	Value  string `json:"value"`
+	Handle string `json:"handle"`
+}
+
+func (cursor listCursor) String() string {
+	encoded, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func parseListCursor(cursor string) (*listCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	parsed := &listCursor{}
+	if err := json.Unmarshal(decoded, parsed); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return parsed, nil
+}
+
+// listRequestFromQuery reads a ListRequest from the query of a request to
+// list containers. Parameters starting with $ carry the list options, and the
+// rest are properties to filter by.
+func listRequestFromQuery(query url.Values) (*protocol.ListRequest, error) {
+	request := &protocol.ListRequest{}
+
+	for name, vals := range query {
+		if len(vals) == 0 {
+			continue
+		}
+
+		switch name {
+		case "$selector":
+			request.Selector = proto.String(vals[0])
+		case "$sort_by":
+			request.SortBy = proto.String(vals[0])
+		case "$cursor":
+			request.Cursor = proto.String(vals[0])
+		case "$summarize":
+			summarize, err := strconv.ParseBool(vals[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid summarize: %q", vals[0])
+			}
+
+			request.Summarize = proto.Bool(summarize)
+		case "$limit":
+			limit, err := strconv.ParseUint(vals[0], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid limit: %q", vals[0])
+			}
+
+			request.Limit = proto.Uint32(uint32(limit))
+		default:
+			request.Properties = append(request.Properties, &protocol.Property{
+				Key:   proto.String(name),
+				Value: proto.String(vals[0]),
+			})
+		}
+	}
+
+	return request, nil
+}
+
+func parseListRequest(request *protocol.ListRequest) (listQuery, error) {
+	query := listQuery{
+		selector: garden.SelectorFromProperties(gardenProperties(request.GetProperties())),
+		sortBy:   request.GetSortBy(),
+		limit:    int(request.GetLimit()),
+		cursor:   request.GetCursor(),
+
+		summarize: request.GetSummarize(),
+
+		options: request.Selector != nil || request.SortBy != nil || request.Limit != nil || request.Cursor != nil || request.Summarize != nil,
+	}
+
+	if request.GetSelector() != "" {
+		selector, err := garden.ParseSelector(request.GetSelector())
+		if err != nil {
+			return listQuery{}, err
+		}
+
+		query.selector = append(query.selector, selector...)
+	}
+
+	return query, nil
+}
+
+// backendFilter splits the selector into the properties the backend filters
+// containers by itself, being those required to equal a value, and the rest
+// of its requirements. ok is false if it requires a property to equal two
+// different values, which no container can meet.
+func backendFilter(selector garden.Selector) (filter garden.Properties, rest garden.Selector, ok bool) {
+	filter = garden.Properties{}
+
+	for _, requirement := range selector {
+		if requirement.Operator != garden.SelectorEquals || len(requirement.Values) != 1 {
+			rest = append(rest, requirement)
+			continue
+		}
+
+		if value, found := filter[requirement.Key]; found && value != requirement.Values[0] {
+			return nil, nil, false
+		}
+
+		filter[requirement.Key] = requirement.Values[0]
+	}
+
+	return filter, rest, true
+}
+
+// listEntry is a container being listed, along with its state and
+// properties once its info is read.
+type listEntry struct {
+	handle     string
+	state      string
+	properties garden.Properties
+}
+
+func (entry listEntry) sortValue(sortBy string) string {
+	if sortBy == "" {
+		return ""
+	}
+
+	return entry.properties[sortBy]
+}
+
+// listContainers lists a page of the containers selected by the query. The
+// backend filters them by the properties the selector requires to equal a
+// value, and only if the selector has any other requirements, they are sorted
+// by a property, or the page is to be summarized, is their info read. Sorted
+// by handle, it is read a page at a time until the page is full.
+func (s *GardenServer) listContainers(ctx context.Context, logger lager.Logger, query listQuery) ([]listEntry, *listCursor, error) {
+	var cursor *listCursor
+	if query.cursor != "" {
+		var err error
+
+		cursor, err = parseListCursor(query.cursor)
+		if err != nil {
+			return nil, nil, malformedRequestError{err}
+		}
+
+		if cursor.SortBy != query.sortBy {
+			return nil, nil, malformedRequestError{errors.New("cursor was returned for a different sort")}
+		}
+	}
+
+	filter, rest, ok := backendFilter(query.selector)
+	if !ok {
+		return nil, nil, nil
+	}
+
+	containers, err := s.backendFor(ctx).Containers(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries := make([]listEntry, len(containers))
+	for i, container := range containers {
+		entries[i] = listEntry{handle: container.Handle()}
+	}
+
+	read := false
+	if query.sortBy != "" {
+		entries, err = s.readInfo(ctx, logger, entries)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		read = true
+	}
+
+	sort.Sort(listEntries{entries, query.sortBy})
+
+	if cursor != nil {
+		after := listEntry{
+			handle:     cursor.Handle,
+			properties: garden.Properties{query.sortBy: cursor.Value},
+		}
+
+		entries = entries[sort.Search(len(entries), func(i int) bool {
+			return listEntries{[]listEntry{after, entries[i]}, query.sortBy}.Less(0, 1)
+		}):]
+	}
+
+	batchSize := len(entries)
+	if query.limit > 0 && query.limit < batchSize {
+		// read one more than a page, to know whether there is a page after
+		batchSize = query.limit + 1
+	}
+
+	page := []listEntry{}
+	for len(entries) > 0 && (query.limit == 0 || len(page) <= query.limit) {
+		batch := entries
+		if len(batch) > batchSize {
+			batch = batch[:batchSize]
+		}
+
+		entries = entries[len(batch):]
+
+		if len(rest) > 0 && !read {
+			batch, err = s.readInfo(ctx, logger, batch)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		for _, entry := range batch {
+			if rest.Matches(entry.properties) {
+				page = append(page, entry)
+			}
+		}
+	}
+
+	var next *listCursor
+	if query.limit > 0 && len(page) > query.limit {
+		page = page[:query.limit]
+		last := page[len(page)-1]
+
+		next = &listCursor{
+			SortBy: query.sortBy,
+			Value:  last.sortValue(query.sortBy),
+			Handle: last.handle,
+		}
+	}
+
+	if query.summarize && !read && len(rest) == 0 {
+		page, err = s.readInfo(ctx, logger, page)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return page, next, nil
+}
+
+// pageEntries returns the containers of a page the backend listed, reading
+// their info if they are to be summarized and the backend did not.
+func (s *GardenServer) pageEntries(ctx context.Context, logger lager.Logger, page garden.ContainerPage, summarize bool) ([]listEntry, error) {
+	entries := make([]listEntry, len(page.Containers))
+	for i, container := range page.Containers {
+		entries[i] = listEntry{handle: container.Handle()}
+	}
+
+	if !summarize {
+		return entries, nil
+	}
+
+	if len(page.Summaries) != len(page.Containers) {
+		return s.readInfo(ctx, logger, entries)
+	}
+
+	for i, summary := range page.Summaries {
+		entries[i].state = summary.State
+		entries[i].properties = summary.Properties
+	}
+
+	return entries, nil
+}
+
+// readInfo reads the state and properties of the containers, leaving out
+// those whose info could not be got, e.g. as they were destroyed meanwhile.
+func (s *GardenServer) readInfo(ctx context.Context, logger lager.Logger, entries []listEntry) ([]listEntry, error) {
+	handles := make([]string, len(entries))
+	for i, entry := range entries {
+		handles[i] = entry.handle
+
+		s.bomberman.Pause(entry.handle)
+		defer s.bomberman.Unpause(entry.handle)
+	}
+
+	infos, err := s.backendFor(ctx).BulkInfo(handles)
+	if err == garden.ErrNotImplemented {
+		infos = s.infoEach(ctx, handles)
+	} else if err != nil {
+		return nil, err
+	}
+
+	read := make([]listEntry, 0, len(entries))
+	for _, entry := range entries {
+		info, found := infos[entry.handle]
+		if !found || info.Err != nil {
+			logger.Info("skipping-container", lager.Data{"handle": entry.handle})
+			continue
+		}
+
+		entry.state = info.Info.State
+		entry.properties = info.Info.Properties
+		read = append(read, entry)
+	}
+
+	return read, nil
+}
+
+// listEntries sorts containers by the value of a property, and then by
+// handle.
+type listEntries struct {
+	entries []listEntry
+	sortBy  string
+}
+
+func (l listEntries) Len() int      { return len(l.entries) }
+func (l listEntries) Swap(i, j int) { l.entries[i], l.entries[j] = l.entries[j], l.entries[i] }
+
+func (l listEntries) Less(i, j int) bool {
+	vi, vj := l.entries[i].sortValue(l.sortBy), l.entries[j].sortValue(l.sortBy)
+	if vi != vj {
+		return vi < vj
+	}
+
+	return l.entries[i].handle < l.entries[j].handle
+}
@@ -0,0 +1,211 @@
+package server
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/cloudfoundry-incubator/garden/server/metrics"
+)
+
+// serverMetrics instruments the server's handlers and subsystems, and is
+// served at /metrics.
+type serverMetrics struct {
+	registry *metrics.Registry
+
+	requests        *metrics.Counter
+	requestDuration *metrics.Histogram
+
+	grpcCalls        *metrics.Counter
+	grpcCallDuration *metrics.Histogram
+
+	processStreams *metrics.Gauge
+	streamedIn     *metrics.Counter
+	streamedOut    *metrics.Counter
+	reaps          *metrics.Counter
+}
+
+func newServerMetrics(s *GardenServer) *serverMetrics {
+	registry := metrics.NewRegistry()
+
+	m := &serverMetrics{
+		registry: registry,
+
+		requests: registry.NewCounter(
+			"garden_requests_total",
+			"Requests handled, by route and response status code.",
+			"route", "code",
+		),
+		requestDuration: registry.NewHistogram(
+			"garden_request_duration_seconds",
+			"Time taken to handle requests, up to hijacking the connection for those streamed over one.",
+			metrics.DefaultBuckets,
+			"route",
+		),
+
+		grpcCalls: registry.NewCounter(
+			"garden_grpc_calls_total",
+			"gRPC calls handled, by method and status code.",
+			"method", "code",
+		),
+		grpcCallDuration: registry.NewHistogram(
+			"garden_grpc_call_duration_seconds",
+			"Time taken to handle gRPC calls, including for the life of streams.",
+			metrics.DefaultBuckets,
+			"method",
+		),
+
+		processStreams: registry.NewGauge(
+			"garden_process_streams",
+			"Process streams currently attached.",
+		),
+		streamedIn: registry.NewCounter(
+			"garden_streamed_in_bytes_total",
+			"Bytes streamed in to containers.",
+		),
+		streamedOut: registry.NewCounter(
+			"garden_streamed_out_bytes_total",
+			"Bytes streamed out of containers.",
+		),
+		reaps: registry.NewCounter(
+			"garden_reaps_total",
+			"Containers destroyed once their grace time ran out.",
+		),
+	}
+
+	registry.NewCounterFunc(
+		"garden_dropped_output_bytes_total",
+		"Bytes of process output dropped because streams could not keep up.",
+		func() float64 {
+			return float64(s.DroppedOutputBytes())
+		},
+	)
+
+	registry.NewGaugeFunc(
+		"garden_armed_bombs",
+		"Containers whose grace time is counting down.",
+		func() float64 {
+			return float64(s.bombCounts().Armed)
+		},
+	)
+
+	registry.NewGaugeFunc(
+		"garden_paused_bombs",
+		"Containers whose grace time is paused while they are in use.",
+		func() float64 {
+			return float64(s.bombCounts().Paused)
+		},
+	)
+
+	registry.NewGaugeFunc(
+		"garden_destroys_in_flight",
+		"Containers currently being destroyed.",
+		func() float64 {
+			s.destroysL.Lock()
+			defer s.destroysL.Unlock()
+
+			return float64(len(s.destroys))
+		},
+	)
+
+	return m
+}
+
+// instrument counts and times the requests to the route's handler.
+func (m *serverMetrics) instrument(route string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{
+			ResponseWriter: w,
+			status:         http.StatusOK,
+		}
+
+		started := time.Now()
+
+		recorder.observe = func(status int) {
+			m.requests.Inc(route, strconv.Itoa(status))
+			m.requestDuration.Observe(time.Since(started).Seconds(), route)
+		}
+
+		handler.ServeHTTP(recorder, r)
+
+		recorder.done()
+	})
+}
+
+// statusRecorder notes the status code of a response, and observes the
+// request once it has been handled or its connection hijacked.
+type statusRecorder struct {
+	http.ResponseWriter
+
+	status      int
+	wroteHeader bool
+
+	observe  func(status int)
+	observed sync.Once
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.done()
+	return r.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (r *statusRecorder) done() {
+	r.observed.Do(func() {
+		r.observe(r.status)
+	})
+}
+
+func (m *serverMetrics) observeCall(fullMethod string, started time.Time, err error) {
+	method := grpcMethodName(fullMethod)
+
+	m.grpcCalls.Inc(method, status.Code(err).String())
+	m.grpcCallDuration.Observe(time.Since(started).Seconds(), method)
+}
+
+func (m *serverMetrics) instrumentUnary(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	started := time.Now()
+
+	response, err := handler(ctx, request)
+	m.observeCall(info.FullMethod, started, err)
+
+	return response, err
+}
+
+func (m *serverMetrics) instrumentStream(service interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	started := time.Now()
+
+	err := handler(service, stream)
+	m.observeCall(info.FullMethod, started, err)
+
+	return err
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	io.Reader
+
+	counter *metrics.Counter
+}
+
+func (r countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.counter.Add(float64(n))
+	return n, err
+}
@@ -0,0 +1,355 @@
+// Package metrics collects counters, gauges and histograms, and serves them in
+// the Prometheus text exposition format.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is that of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// OpenMetricsContentType is that of the OpenMetrics text format.
+const OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// DefaultBuckets are the upper bounds, in seconds, of the buckets request
+// latencies are counted in.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry holds metrics in the order they were registered.
+type Registry struct {
+	metrics []metric
+	mu      sync.Mutex
+}
+
+type metric interface {
+	write(w io.Writer, openMetrics bool)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	r.metrics = append(r.metrics, m)
+	r.mu.Unlock()
+}
+
+// NewCounter registers a counter, partitioned by the given labels.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	counter := &Counter{newVec(name, help, "counter", labels)}
+	r.register(counter)
+	return counter
+}
+
+// NewGauge registers a gauge, partitioned by the given labels.
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	gauge := &Gauge{newVec(name, help, "gauge", labels)}
+	r.register(gauge)
+	return gauge
+}
+
+// NewCounterFunc registers a counter whose value is read from value whenever
+// the metrics are written.
+func (r *Registry) NewCounterFunc(name, help string, value func() float64) {
+	r.register(&valueFunc{name: name, help: help, kind: "counter", value: value})
+}
+
+// NewGaugeFunc registers a gauge whose value is read from value whenever the
+// metrics are written.
+func (r *Registry) NewGaugeFunc(name, help string, value func() float64) {
+	r.register(&valueFunc{name: name, help: help, kind: "gauge", value: value})
+}
+
+// NewHistogram registers a histogram counting observations in buckets with
+// the given upper bounds, partitioned by the given labels.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	histogram := &Histogram{
+		vec:     newVec(name, help, "histogram", labels),
+		buckets: buckets,
+	}
+
+	for _, s := range histogram.series {
+		s.buckets = make([]uint64, len(buckets))
+	}
+
+	r.register(histogram)
+
+	return histogram
+}
+
+// WriteTo writes every metric in the text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	return r.writeTo(w, false)
+}
+
+// WriteOpenMetricsTo writes every metric in the OpenMetrics text format.
+func (r *Registry) WriteOpenMetricsTo(w io.Writer) (int64, error) {
+	return r.writeTo(w, true)
+}
+
+func (r *Registry) writeTo(w io.Writer, openMetrics bool) (int64, error) {
+	r.mu.Lock()
+	metrics := append([]metric{}, r.metrics...)
+	r.mu.Unlock()
+
+	buf := new(bytes.Buffer)
+	for _, m := range metrics {
+		m.write(buf, openMetrics)
+	}
+
+	if openMetrics {
+		buf.WriteString("# EOF\n")
+	}
+
+	return buf.WriteTo(w)
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	r.WriteTo(w)
+}
+
+// Counter is a value that only goes up.
+type Counter struct {
+	*vec
+}
+
+// Add adds the delta to the counter with the given label values.
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	c.update(labelValues, func(s *series) {
+		s.value += delta
+	})
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *Counter) write(w io.Writer, openMetrics bool) {
+	c.writeHeader(w, openMetrics)
+	c.each(func(labels string, s *series) {
+		writeSample(w, c.name, labels, s.value)
+	})
+}
+
+// Gauge is a value that goes up and down.
+type Gauge struct {
+	*vec
+}
+
+// Set sets the gauge with the given label values.
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.update(labelValues, func(s *series) {
+		s.value = value
+	})
+}
+
+// Add adds the delta, which may be negative, to the gauge with the given
+// label values.
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.update(labelValues, func(s *series) {
+		s.value += delta
+	})
+}
+
+func (g *Gauge) write(w io.Writer, openMetrics bool) {
+	g.writeHeader(w, openMetrics)
+	g.each(func(labels string, s *series) {
+		writeSample(w, g.name, labels, s.value)
+	})
+}
+
+// Histogram counts observations in buckets.
+type Histogram struct {
+	*vec
+
+	buckets []float64
+}
+
+// Observe counts the value in the histogram with the given label values.
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.update(labelValues, func(s *series) {
+		if s.buckets == nil {
+			s.buckets = make([]uint64, len(h.buckets))
+		}
+
+		for i, bound := range h.buckets {
+			if value <= bound {
+				s.buckets[i]++
+			}
+		}
+
+		s.count++
+		s.value += value
+	})
+}
+
+func (h *Histogram) write(w io.Writer, openMetrics bool) {
+	h.writeHeader(w, openMetrics)
+	h.each(func(labels string, s *series) {
+		for i, bound := range h.buckets {
+			writeSample(w, h.name+"_bucket", withLabel(labels, "le", formatValue(bound)), float64(s.buckets[i]))
+		}
+
+		writeSample(w, h.name+"_bucket", withLabel(labels, "le", "+Inf"), float64(s.count))
+		writeSample(w, h.name+"_sum", labels, s.value)
+		writeSample(w, h.name+"_count", labels, float64(s.count))
+	})
+}
+
+type valueFunc struct {
+	name  string
+	help  string
+	kind  string
+	value func() float64
+}
+
+func (f *valueFunc) write(w io.Writer, openMetrics bool) {
+	writeHeader(w, f.name, f.help, f.kind, openMetrics)
+	writeSample(w, f.name, "", f.value())
+}
+
+// vec is a metric partitioned by the values of its labels into series.
+type vec struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+
+	series map[string]*series
+	mu     sync.Mutex
+}
+
+type series struct {
+	labels string
+
+	value   float64
+	count   uint64
+	buckets []uint64
+}
+
+func newVec(name, help, kind string, labels []string) *vec {
+	v := &vec{
+		name:   name,
+		help:   help,
+		kind:   kind,
+		labels: labels,
+		series: make(map[string]*series),
+	}
+
+	// a metric without labels has its one series from the start
+	if len(labels) == 0 {
+		v.series[""] = &series{}
+	}
+
+	return v
+}
+
+func (v *vec) update(labelValues []string, update func(*series)) {
+	if len(labelValues) != len(v.labels) {
+		panic(fmt.Sprintf("metric %s has %d labels; given %d values", v.name, len(v.labels), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	s, found := v.series[key]
+	if !found {
+		pairs := []string{}
+		for i, label := range v.labels {
+			pairs = append(pairs, label+"="+quoteLabel(labelValues[i]))
+		}
+
+		s = &series{labels: strings.Join(pairs, ",")}
+		v.series[key] = s
+	}
+
+	update(s)
+}
+
+// each calls f with a copy of each series, in order of their label values.
+func (v *vec) each(f func(labels string, s *series)) {
+	v.mu.Lock()
+
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	snapshot := make([]series, len(keys))
+	for i, key := range keys {
+		snapshot[i] = *v.series[key]
+		snapshot[i].buckets = append([]uint64{}, v.series[key].buckets...)
+	}
+
+	v.mu.Unlock()
+
+	for _, s := range snapshot {
+		f(s.labels, &s)
+	}
+}
+
+func (v *vec) writeHeader(w io.Writer, openMetrics bool) {
+	writeHeader(w, v.name, v.help, v.kind, openMetrics)
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric. In OpenMetrics,
+// these name the metric's family, which for counters drops the _total suffix
+// of their samples.
+func writeHeader(w io.Writer, name, help, kind string, openMetrics bool) {
+	if openMetrics && kind == "counter" {
+		name = strings.TrimSuffix(name, "_total")
+	}
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, kind)
+}
+
+func writeSample(w io.Writer, name string, labels string, value float64) {
+	if labels != "" {
+		name += "{" + labels + "}"
+	}
+
+	fmt.Fprintf(w, "%s %s\n", name, formatValue(value))
+}
+
+func withLabel(labels string, label string, value string) string {
+	pair := label + "=" + quoteLabel(value)
+	if labels == "" {
+		return pair
+	}
+
+	return labels + "," + pair
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+func quoteLabel(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
@@ -0,0 +1,148 @@
+package metrics_test
+
+import (
+	"bytes"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/garden/server/metrics"
+)
+
+var _ = Describe("Registry", func() {
+	var registry *metrics.Registry
+
+	BeforeEach(func() {
+		registry = metrics.NewRegistry()
+	})
+
+	exposition := func() string {
+		buf := new(bytes.Buffer)
+		_, err := registry.WriteTo(buf)
+		Ω(err).ShouldNot(HaveOccurred())
+		return buf.String()
+	}
+
+	It("writes counters by their label values", func() {
+		counter := registry.NewCounter("requests_total", "Requests handled.", "route", "code")
+
+		counter.Inc("Ping", "200")
+		counter.Inc("Ping", "200")
+		counter.Add(3, "Create", "500")
+
+		Ω(exposition()).Should(Equal(`# HELP requests_total Requests handled.
+# TYPE requests_total counter
+requests_total{route="Create",code="500"} 3
+requests_total{route="Ping",code="200"} 2
+`))
+	})
+
+	It("writes gauges", func() {
+		gauge := registry.NewGauge("streams", "Streams attached.")
+
+		gauge.Add(2)
+		gauge.Add(-1)
+
+		Ω(exposition()).Should(Equal(`# HELP streams Streams attached.
+# TYPE streams gauge
+streams 1
+`))
+
+		gauge.Set(5)
+
+		Ω(exposition()).Should(ContainSubstring("streams 5\n"))
+	})
+
+	It("writes values read from functions when written", func() {
+		value := 1.0
+
+		registry.NewGaugeFunc("things", "Things.", func() float64 { return value })
+		registry.NewCounterFunc("events_total", "Events.", func() float64 { return value * 2 })
+
+		value = 21
+
+		Ω(exposition()).Should(Equal(`# HELP things Things.
+# TYPE things gauge
+things 21
+# HELP events_total Events.
+# TYPE events_total counter
+events_total 42
+`))
+	})
+
+	It("writes histograms with cumulative buckets", func() {
+		histogram := registry.NewHistogram("duration_seconds", "Durations.", []float64{0.1, 1}, "route")
+
+		histogram.Observe(0.05, "Ping")
+		histogram.Observe(0.5, "Ping")
+		histogram.Observe(2, "Ping")
+
+		Ω(exposition()).Should(Equal(`# HELP duration_seconds Durations.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{route="Ping",le="0.1"} 1
+duration_seconds_bucket{route="Ping",le="1"} 2
+duration_seconds_bucket{route="Ping",le="+Inf"} 3
+duration_seconds_sum{route="Ping"} 2.55
+duration_seconds_count{route="Ping"} 3
+`))
+	})
+
+	It("writes metrics without labels before anything is counted", func() {
+		registry.NewCounter("events_total", "Events.")
+		registry.NewHistogram("duration_seconds", "Durations.", []float64{1})
+
+		Ω(exposition()).Should(Equal(`# HELP events_total Events.
+# TYPE events_total counter
+events_total 0
+# HELP duration_seconds Durations.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{le="1"} 0
+duration_seconds_bucket{le="+Inf"} 0
+duration_seconds_sum 0
+duration_seconds_count 0
+`))
+	})
+
+	It("escapes label values", func() {
+		counter := registry.NewCounter("things_total", "Things.", "name")
+
+		counter.Inc("a \"quoted\"\\name\n")
+
+		Ω(exposition()).Should(ContainSubstring(`things_total{name="a \"quoted\"\\name\n"} 1`))
+	})
+
+	It("panics when given the wrong number of label values", func() {
+		counter := registry.NewCounter("things_total", "Things.", "name")
+
+		Ω(func() { counter.Inc() }).Should(Panic())
+	})
+
+	It("writes OpenMetrics, naming counter families without their suffix", func() {
+		registry.NewCounter("events_total", "Events.", "kind").Inc("oom")
+		registry.NewGauge("streams", "Streams attached.").Set(2)
+
+		buf := new(bytes.Buffer)
+		_, err := registry.WriteOpenMetricsTo(buf)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(buf.String()).Should(Equal(`# HELP events Events.
+# TYPE events counter
+events_total{kind="oom"} 1
+# HELP streams Streams attached.
+# TYPE streams gauge
+streams 2
+# EOF
+`))
+	})
+
+	It("serves the metrics over HTTP", func() {
+		registry.NewGauge("streams", "Streams attached.").Set(1)
+
+		recorder := httptest.NewRecorder()
+		registry.ServeHTTP(recorder, nil)
+
+		Ω(recorder.Header().Get("Content-Type")).Should(Equal(metrics.ContentType))
+		Ω(recorder.Body.String()).Should(ContainSubstring("streams 1\n"))
+	})
+})
@@ -0,0 +1,193 @@
+package server_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden/client"
+	"github.com/cloudfoundry-incubator/garden/client/connection"
+	"github.com/cloudfoundry-incubator/garden/fakes"
+	"github.com/cloudfoundry-incubator/garden/server/metrics"
+)
+
+var _ = Describe("The server's metrics", func() {
+	var fixture *serverFixture
+	var fakeContainer *fakes.FakeContainer
+
+	var apiClient garden.Client
+
+	BeforeEach(func() {
+		fixture = newServerFixture()
+
+		fakeContainer = new(fakes.FakeContainer)
+		fakeContainer.HandleReturns("some-handle")
+
+		fixture.Backend.CreateReturns(fakeContainer, nil)
+		fixture.Backend.LookupReturns(fakeContainer, nil)
+
+		fixture.Start()
+
+		apiClient = client.New(connection.New("unix", fixture.SocketPath))
+	})
+
+	AfterEach(func() {
+		fixture.Stop()
+	})
+
+	scrape := func() string {
+		httpClient := &http.Client{
+			Transport: &http.Transport{
+				Dial: func(string, string) (net.Conn, error) {
+					return net.Dial("unix", fixture.SocketPath)
+				},
+				DisableKeepAlives: true,
+			},
+		}
+
+		response, err := httpClient.Get("http://api/metrics")
+		Ω(err).ShouldNot(HaveOccurred())
+		defer response.Body.Close()
+
+		Ω(response.StatusCode).Should(Equal(http.StatusOK))
+		Ω(response.Header.Get("Content-Type")).Should(Equal(metrics.ContentType))
+
+		body, err := ioutil.ReadAll(response.Body)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return string(body)
+	}
+
+	sample := func(name string) func() string {
+		return func() string {
+			for _, line := range strings.Split(scrape(), "\n") {
+				if strings.HasPrefix(line, name+" ") {
+					return strings.TrimPrefix(line, name+" ")
+				}
+			}
+
+			return ""
+		}
+	}
+
+	It("counts requests by route and status code", func() {
+		Ω(apiClient.Ping()).Should(Succeed())
+		Ω(apiClient.Ping()).Should(Succeed())
+
+		fixture.Backend.LookupReturns(nil, garden.ContainerNotFoundError{Handle: "missing"})
+		_, err := apiClient.Lookup("missing")
+		Ω(err).Should(HaveOccurred())
+
+		Ω(sample(`garden_requests_total{route="Ping",code="200"}`)()).Should(Equal("2"))
+		Ω(sample(`garden_requests_total{route="Lookup",code="404"}`)()).Should(Equal("1"))
+		Ω(sample(`garden_request_duration_seconds_count{route="Ping"}`)()).Should(Equal("2"))
+	})
+
+	It("counts the bytes streamed in and out", func() {
+		fakeContainer.StreamInStub = func(dstPath string, reader io.Reader) error {
+			_, err := ioutil.ReadAll(reader)
+			return err
+		}
+
+		fakeContainer.StreamOutReturns(ioutil.NopCloser(strings.NewReader("hello")), nil)
+
+		container, err := apiClient.Lookup("some-handle")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(container.StreamIn("/dst", bytes.NewBufferString("some-data"))).Should(Succeed())
+
+		reader, err := container.StreamOut("/src")
+		Ω(err).ShouldNot(HaveOccurred())
+		_, err = ioutil.ReadAll(reader)
+		Ω(err).ShouldNot(HaveOccurred())
+		reader.Close()
+
+		Ω(sample("garden_streamed_in_bytes_total")()).Should(Equal("9"))
+		Eventually(sample("garden_streamed_out_bytes_total")).Should(Equal("5"))
+	})
+
+	It("reports the process streams attached, pausing their containers' grace times", func() {
+		fixture.Backend.GraceTimeReturns(time.Minute)
+
+		exit := make(chan struct{})
+
+		fakeContainer.RunStub = func(spec garden.ProcessSpec, processIO garden.ProcessIO) (garden.Process, error) {
+			processIO.Stdout.Write([]byte("running"))
+
+			process := new(fakes.FakeProcess)
+			process.IDReturns(42)
+			process.WaitForExitStub = func() (garden.ExitInfo, error) {
+				<-exit
+				return garden.ExitInfo{}, nil
+			}
+
+			return process, nil
+		}
+
+		container, err := apiClient.Create(garden.ContainerSpec{})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		stdout := gbytes.NewBuffer()
+
+		process, err := container.Run(garden.ProcessSpec{Path: "sleep"}, garden.ProcessIO{Stdout: stdout})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Eventually(stdout).Should(gbytes.Say("running"))
+
+		Ω(sample("garden_process_streams")()).Should(Equal("1"))
+		Ω(sample("garden_paused_bombs")()).Should(Equal("1"))
+
+		close(exit)
+
+		_, err = process.Wait()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Eventually(sample("garden_process_streams")).Should(Equal("0"))
+		Eventually(sample("garden_armed_bombs")).Should(Equal("1"))
+	})
+
+	It("reports the containers counting down and reaped", func() {
+		fixture.Backend.GraceTimeReturns(100 * time.Millisecond)
+
+		_, err := apiClient.Create(garden.ContainerSpec{})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(sample("garden_armed_bombs")()).Should(Equal("1"))
+
+		Eventually(sample("garden_reaps_total")).Should(Equal("1"))
+		Eventually(sample("garden_armed_bombs")).Should(Equal("0"))
+	})
+
+	It("reports the destroys in flight", func() {
+		destroying := make(chan struct{})
+		destroyed := make(chan struct{})
+
+		fixture.Backend.DestroyStub = func(string) error {
+			close(destroying)
+			<-destroyed
+			return nil
+		}
+
+		go apiClient.Destroy("some-handle")
+
+		Eventually(destroying).Should(BeClosed())
+		Ω(sample("garden_destroys_in_flight")()).Should(Equal("1"))
+
+		close(destroyed)
+
+		Eventually(sample("garden_destroys_in_flight")).Should(Equal("0"))
+	})
+
+	It("reports the process output dropped", func() {
+		Ω(sample("garden_dropped_output_bytes_total")()).Should(Equal("0"))
+	})
+})
@@ -0,0 +1,295 @@
+package server
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	protocol "github.com/cloudfoundry-incubator/garden/protocol"
+)
+
+// OutputPolicy decides how much process output the server keeps and what
+// happens to it when an attached stream falls behind.
+type OutputPolicy struct {
+	// BufferSize is the number of bytes of each process's most recent output
+	// kept for replay and for streams that fall behind.
+	BufferSize int
+
+	// MaxBlock is how long writing process output may wait for attached
+	// streams to catch up before output they have yet to send is dropped. Zero
+	// drops it immediately; a negative value waits indefinitely.
+	MaxBlock time.Duration
+}
+
+var DefaultOutputPolicy = OutputPolicy{
+	BufferSize: 256 * 1024,
+	MaxBlock:   time.Minute,
+}
+
+// outputBuffer keeps the most recent stdout and stderr of a process, numbered
+// by their offset into the combined output, so that streams can replay it
+// after a reconnect and slow streams can catch up instead of losing it.
+//
+// Writes block, as far as the policy allows, rather than discard output that
+// an attached stream has yet to send.
+type outputBuffer struct {
+	policy  OutputPolicy
+	dropped func(bytes uint64)
+
+	chunks []outputChunk
+	size   int
+	end    uint64
+
+	readers map[*outputReader]struct{}
+
+	// closed and replaced whenever output is written
+	written chan struct{}
+
+	// closed and replaced whenever a reader advances or is closed
+	advanced chan struct{}
+
+	mu sync.Mutex
+}
+
+type outputChunk struct {
+	Source protocol.ProcessPayload_Source
+	Offset uint64
+	Data   []byte
+}
+
+func newOutputBuffer(policy OutputPolicy, offset uint64, dropped func(uint64)) *outputBuffer {
+	return &outputBuffer{
+		policy:  policy,
+		dropped: dropped,
+
+		end: offset,
+
+		readers: make(map[*outputReader]struct{}),
+
+		written:  make(chan struct{}),
+		advanced: make(chan struct{}),
+	}
+}
+
+// Writer returns a writer that appends to the buffer as output from source.
+func (b *outputBuffer) Writer(source protocol.ProcessPayload_Source) io.Writer {
+	return &outputWriter{buffer: b, source: source}
+}
+
+// Reader returns a reader of the output from offset onwards. Until it is
+// closed, writes wait for it before dropping output it has not read.
+func (b *outputBuffer) Reader(offset uint64) *outputReader {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	reader := &outputReader{buffer: b, offset: offset}
+	b.readers[reader] = struct{}{}
+
+	return reader
+}
+
+// End returns the offset following the last byte written.
+func (b *outputBuffer) End() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.end
+}
+
+func (b *outputBuffer) write(source protocol.ProcessPayload_Source, d []byte) {
+	for len(d) > 0 {
+		piece := d
+		if len(piece) > b.policy.BufferSize {
+			piece = piece[:b.policy.BufferSize]
+		}
+
+		b.writePiece(source, piece)
+
+		d = d[len(piece):]
+	}
+}
+
+func (b *outputBuffer) writePiece(source protocol.ProcessPayload_Source, d []byte) {
+	// prevent buffer reuse from clobbering the data
+	data := make([]byte, len(d))
+	copy(data, d)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.waitForReaders(len(data))
+
+	b.chunks = append(b.chunks, outputChunk{
+		Source: source,
+		Offset: b.end,
+		Data:   data,
+	})
+
+	b.size += len(data)
+	b.end += uint64(len(data))
+
+	b.evict()
+
+	close(b.written)
+	b.written = make(chan struct{})
+}
+
+// waitForReaders waits, as long as the policy allows, until making room for
+// length bytes would not evict output that a reader has yet to read.
+func (b *outputBuffer) waitForReaders(length int) {
+	if b.policy.MaxBlock == 0 {
+		return
+	}
+
+	var timeout <-chan time.Time
+	if b.policy.MaxBlock > 0 {
+		timer := time.NewTimer(b.policy.MaxBlock)
+		defer timer.Stop()
+
+		timeout = timer.C
+	}
+
+	for {
+		excess := b.size + length - b.policy.BufferSize
+		if excess <= 0 {
+			return
+		}
+
+		oldestUnread, found := b.oldestUnread()
+		if !found || oldestUnread >= b.end-uint64(b.size)+uint64(excess) {
+			return
+		}
+
+		advanced := b.advanced
+
+		b.mu.Unlock()
+
+		select {
+		case <-advanced:
+			b.mu.Lock()
+		case <-timeout:
+			b.mu.Lock()
+			return
+		}
+	}
+}
+
+func (b *outputBuffer) evict() {
+	start := b.end - uint64(b.size)
+
+	for b.size > b.policy.BufferSize {
+		excess := b.size - b.policy.BufferSize
+
+		oldest := &b.chunks[0]
+		if len(oldest.Data) > excess {
+			oldest.Data = oldest.Data[excess:]
+			oldest.Offset += uint64(excess)
+			b.size -= excess
+			break
+		}
+
+		b.size -= len(oldest.Data)
+		b.chunks = b.chunks[1:]
+	}
+
+	oldestUnread, found := b.oldestUnread()
+	if !found || b.dropped == nil {
+		return
+	}
+
+	newStart := b.end - uint64(b.size)
+	if oldestUnread < start {
+		oldestUnread = start
+	}
+
+	if newStart > oldestUnread {
+		b.dropped(newStart - oldestUnread)
+	}
+}
+
+func (b *outputBuffer) oldestUnread() (uint64, bool) {
+	var oldest uint64
+	found := false
+
+	for reader := range b.readers {
+		if !found || reader.offset < oldest {
+			oldest = reader.offset
+			found = true
+		}
+	}
+
+	return oldest, found
+}
+
+func (b *outputBuffer) readFrom(offset uint64) ([]outputChunk, <-chan struct{}) {
+	chunks := []outputChunk{}
+	for _, chunk := range b.chunks {
+		chunkEnd := chunk.Offset + uint64(len(chunk.Data))
+		if chunkEnd <= offset {
+			continue
+		}
+
+		if chunk.Offset < offset {
+			chunk.Data = chunk.Data[offset-chunk.Offset:]
+			chunk.Offset = offset
+		}
+
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks, b.written
+}
+
+func (b *outputBuffer) notifyAdvanced() {
+	close(b.advanced)
+	b.advanced = make(chan struct{})
+}
+
+type outputReader struct {
+	buffer *outputBuffer
+	offset uint64
+}
+
+// Read returns the buffered output the reader has yet to read, and a channel
+// that is closed once more output has been written. Output that has already
+// been dropped is skipped.
+func (r *outputReader) Read() ([]outputChunk, <-chan struct{}) {
+	r.buffer.mu.Lock()
+	defer r.buffer.mu.Unlock()
+
+	return r.buffer.readFrom(r.offset)
+}
+
+// Advance marks the output before offset as read.
+func (r *outputReader) Advance(offset uint64) {
+	r.buffer.mu.Lock()
+	defer r.buffer.mu.Unlock()
+
+	r.offset = offset
+	r.buffer.notifyAdvanced()
+}
+
+func (r *outputReader) Offset() uint64 {
+	r.buffer.mu.Lock()
+	defer r.buffer.mu.Unlock()
+
+	return r.offset
+}
+
+func (r *outputReader) Close() {
+	r.buffer.mu.Lock()
+	defer r.buffer.mu.Unlock()
+
+	delete(r.buffer.readers, r)
+	r.buffer.notifyAdvanced()
+}
+
+type outputWriter struct {
+	buffer *outputBuffer
+	source protocol.ProcessPayload_Source
+}
+
+func (w *outputWriter) Write(d []byte) (int, error) {
+	w.buffer.write(w.source, d)
+	return len(d), nil
+}
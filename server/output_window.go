@@ -0,0 +1,50 @@
+package server
+
+import "sync"
+
+// outputWindow holds the credit, in bytes of output, granted by a client that
+// controls the flow of a process stream.
+type outputWindow struct {
+	credit uint64
+
+	// closed and replaced whenever credit is granted
+	granted chan struct{}
+
+	mu sync.Mutex
+}
+
+func newOutputWindow() *outputWindow {
+	return &outputWindow{
+		granted: make(chan struct{}),
+	}
+}
+
+func (w *outputWindow) Grant(bytes uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.credit += bytes
+
+	close(w.granted)
+	w.granted = make(chan struct{})
+}
+
+// Take uses up to max bytes of credit. If there is none, it returns a channel
+// that is closed once more is granted.
+func (w *outputWindow) Take(max uint64) (uint64, <-chan struct{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.credit == 0 {
+		return 0, w.granted
+	}
+
+	taken := max
+	if taken > w.credit {
+		taken = w.credit
+	}
+
+	w.credit -= taken
+
+	return taken, nil
+}
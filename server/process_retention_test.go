@@ -0,0 +1,163 @@
+package server_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden/client"
+	"github.com/cloudfoundry-incubator/garden/client/connection"
+	"github.com/cloudfoundry-incubator/garden/fakes"
+	"github.com/cloudfoundry-incubator/garden/server"
+)
+
+var _ = Describe("Retaining exited processes", func() {
+	var tmpdir string
+	var socketPath string
+
+	var serverBackend *fakes.FakeBackend
+	var fakeContainer *fakes.FakeContainer
+	var retention server.ProcessRetention
+
+	var apiServer *server.GardenServer
+	var container garden.Container
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		socketPath = path.Join(tmpdir, "api.sock")
+
+		serverBackend = new(fakes.FakeBackend)
+
+		fakeContainer = new(fakes.FakeContainer)
+		fakeContainer.HandleReturns("some-handle")
+		fakeContainer.ProcessesReturns(nil, garden.ErrNotImplemented)
+
+		var lastID uint32
+		fakeContainer.RunStub = func(spec garden.ProcessSpec, processIO garden.ProcessIO) (garden.Process, error) {
+			id := atomic.AddUint32(&lastID, 1)
+			fmt.Fprintf(processIO.Stdout, "output of %d", id)
+
+			process := new(fakes.FakeProcess)
+			process.IDReturns(id)
+			process.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
+
+			return process, nil
+		}
+
+		serverBackend.LookupReturns(fakeContainer, nil)
+
+		retention = server.DefaultProcessRetention
+	})
+
+	JustBeforeEach(func() {
+		apiServer = server.New(
+			"unix",
+			socketPath,
+			42*time.Second,
+			serverBackend,
+			lagertest.NewTestLogger("test"),
+			server.WithProcessRetention(retention),
+		)
+
+		err := apiServer.Start()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+
+		container, err = client.New(connection.New("unix", socketPath)).Lookup("some-handle")
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		apiServer.Stop()
+		os.RemoveAll(tmpdir)
+	})
+
+	run := func() {
+		process, err := container.Run(garden.ProcessSpec{Path: "/some/health-check"}, garden.ProcessIO{})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = process.Wait()
+		Ω(err).ShouldNot(HaveOccurred())
+	}
+
+	processIDs := func() []uint32 {
+		processes, err := container.Processes()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		ids := []uint32{}
+		for _, process := range processes {
+			ids = append(ids, process.ID)
+		}
+
+		return ids
+	}
+
+	It("keeps exited processes and their output by default", func() {
+		run()
+		run()
+
+		exited := new(fakes.FakeProcess)
+		exited.IDReturns(1)
+		exited.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
+		fakeContainer.AttachReturns(exited, nil)
+
+		Eventually(processIDs).Should(Equal([]uint32{1, 2}))
+
+		stdout := gbytes.NewBuffer()
+		_, err := container.AttachFrom(1, 0, garden.ProcessIO{Stdout: stdout})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Eventually(stdout).Should(gbytes.Say("output of 1"))
+		Ω(fakeContainer.AttachFromCallCount()).Should(Equal(0))
+	})
+
+	Context("when more processes have exited than are kept", func() {
+		BeforeEach(func() {
+			retention = server.ProcessRetention{Period: -1, MaxExited: 2}
+		})
+
+		It("drops those that exited first, with their output", func() {
+			for i := 0; i < 5; i++ {
+				run()
+			}
+
+			Eventually(processIDs).Should(Equal([]uint32{4, 5}))
+
+			fakeContainer.AttachFromReturns(nil, garden.ErrNotImplemented)
+			fakeContainer.AttachReturns(nil, errors.New("unknown process: 1"))
+
+			_, err := container.AttachFrom(1, 0, garden.ProcessIO{})
+			Ω(err).Should(HaveOccurred())
+
+			Ω(fakeContainer.AttachFromCallCount()).Should(Equal(1))
+		})
+	})
+
+	Context("when exited processes have been idle for the retention period", func() {
+		BeforeEach(func() {
+			retention = server.ProcessRetention{Period: 100 * time.Millisecond, MaxExited: -1}
+		})
+
+		It("drops them", func() {
+			run()
+			run()
+
+			Ω(processIDs()).ShouldNot(BeEmpty())
+			Eventually(processIDs).Should(BeEmpty())
+		})
+	})
+})
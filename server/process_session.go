@@ -0,0 +1,149 @@
+package server
+
+import (
+	"io"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+
+	protocol "github.com/cloudfoundry-incubator/garden/protocol"
+	"github.com/cloudfoundry-incubator/garden/transport"
+)
+
+// sessionStreamQueueLimit is how many payloads a stream in a session may have
+// waiting to be handled, e.g. as the process does not read its stdin, before
+// the stream is closed rather than hold up the others or grow without bound.
+const sessionStreamQueueLimit = 256
+
+// processSession multiplexes the streams of many processes over one
+// connection. Payloads are keyed by their process ID, so a session carries at
+// most one stream per process.
+type processSession struct {
+	messages transport.MessageWriter
+	writeL   sync.Mutex
+
+	// the input of each open stream
+	streams map[uint32]*transport.MessageQueue
+	mu      sync.Mutex
+}
+
+func newProcessSession(messages transport.MessageWriter) *processSession {
+	return &processSession{
+		messages: messages,
+		streams:  make(map[uint32]*transport.MessageQueue),
+	}
+}
+
+// WriteMessage sends a message on the session, without interleaving it with
+// those sent for other streams.
+func (s *processSession) WriteMessage(msg proto.Message) error {
+	s.writeL.Lock()
+	defer s.writeL.Unlock()
+
+	return s.messages.WriteMessage(msg)
+}
+
+// Reserve opens a stream for the process, returning its input, unless one is
+// already open.
+func (s *processSession) Reserve(id uint32) (*transport.MessageQueue, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, found := s.streams[id]; found {
+		return nil, false
+	}
+
+	input := transport.NewMessageQueue(sessionStreamQueueLimit)
+	s.streams[id] = input
+
+	return input, true
+}
+
+// Release forgets a stream reserved for a process that could not be opened.
+func (s *processSession) Release(id uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.streams, id)
+}
+
+// Deliver hands the payload to its process's stream, closing the stream's
+// input if the client closed it. Payloads for streams that are not open are
+// dropped, and streams that have too many payloads waiting are ended.
+func (s *processSession) Deliver(payload *protocol.ProcessPayload) error {
+	s.mu.Lock()
+	input, found := s.streams[payload.GetProcessId()]
+	s.mu.Unlock()
+
+	if !found {
+		return nil
+	}
+
+	if payload.GetClose() {
+		input.Close(io.EOF)
+		return nil
+	}
+
+	err := input.Push(payload)
+	if err == transport.ErrMessageQueueFull {
+		s.End(payload.GetProcessId())
+		return err
+	}
+
+	return nil
+}
+
+// End closes the process's stream and tells the client it is closed.
+func (s *processSession) End(id uint32) {
+	s.mu.Lock()
+	input, found := s.streams[id]
+	delete(s.streams, id)
+	s.mu.Unlock()
+
+	if !found {
+		return
+	}
+
+	input.Close(io.EOF)
+
+	s.WriteMessage(&protocol.ProcessPayload{
+		ProcessId: proto.Uint32(id),
+		Close:     proto.Bool(true),
+	})
+}
+
+// Acknowledge tells the client the stream it asked to open is open.
+func (s *processSession) Acknowledge(requestID uint32, id uint32) {
+	s.WriteMessage(&protocol.ProcessPayload{
+		ProcessId: proto.Uint32(id),
+		Open: &protocol.ProcessPayload_Open{
+			RequestId: proto.Uint32(requestID),
+		},
+	})
+}
+
+// Refuse tells the client why the stream it asked to open could not be. A
+// refusal of a run carries the process ID if the process was started
+// regardless.
+func (s *processSession) Refuse(requestID uint32, id uint32, err error) {
+	_, response := errorResponse(err)
+
+	s.WriteMessage(&protocol.ProcessPayload{
+		ProcessId: proto.Uint32(id),
+		Open: &protocol.ProcessPayload_Open{
+			RequestId: proto.Uint32(requestID),
+			Error:     response,
+		},
+	})
+}
+
+// Disconnect closes the input of every stream, once the client has gone
+// away.
+func (s *processSession) Disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, input := range s.streams {
+		input.Close(io.EOF)
+	}
+}
@@ -0,0 +1,281 @@
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+// ProcessRetention decides how long the server keeps the info and buffered
+// output of processes that have exited and have no attached streams, so that
+// long-lived containers running many processes do not grow without bound.
+type ProcessRetention struct {
+	// Period is how long an exited process is kept once its last stream
+	// detaches, to allow clients to replay its output. A negative value keeps
+	// it until the container is destroyed.
+	Period time.Duration
+
+	// MaxExited is how many such processes are kept per container, dropping
+	// those idle the longest beyond it. A negative value keeps all of them.
+	MaxExited int
+}
+
+var DefaultProcessRetention = ProcessRetention{
+	Period:    5 * time.Minute,
+	MaxExited: 16,
+}
+
+// WithProcessRetention sets how long exited processes are kept. The default
+// is DefaultProcessRetention.
+func WithProcessRetention(retention ProcessRetention) Option {
+	return func(s *GardenServer) {
+		s.processes.retention = retention
+	}
+}
+
+// processTracker remembers the processes run and attached to through the
+// server, so that they can be listed for backends that do not track them, so
+// that attached streams can be counted, and so that their output can be
+// replayed.
+//
+// Processes that have exited and have no attached streams are dropped as the
+// retention allows, whenever the processes of their container are next
+// tracked or listed.
+type processTracker struct {
+	processes map[string]map[uint32]*garden.ProcessInfo
+	outputs   map[string]map[uint32]*outputBuffer
+
+	// when each exited process without attached streams became so
+	idle map[string]map[uint32]time.Time
+
+	retention ProcessRetention
+
+	mu sync.Mutex
+}
+
+func newProcessTracker() *processTracker {
+	return &processTracker{
+		processes: make(map[string]map[uint32]*garden.ProcessInfo),
+		outputs:   make(map[string]map[uint32]*outputBuffer),
+		idle:      make(map[string]map[uint32]time.Time),
+
+		retention: DefaultProcessRetention,
+	}
+}
+
+func (t *processTracker) Started(handle string, id uint32, spec garden.ProcessSpec) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.evict(handle)
+
+	process := t.process(handle, id)
+	process.Spec = spec
+	process.StartedAt = time.Now()
+}
+
+func (t *processTracker) Attached(handle string, id uint32) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.process(handle, id).AttachedStreams++
+	delete(t.idle[handle], id)
+}
+
+func (t *processTracker) Detached(handle string, id uint32) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	process, found := t.processes[handle][id]
+	if found {
+		process.AttachedStreams--
+		t.noteIdle(handle, process)
+	}
+
+	t.evict(handle)
+}
+
+func (t *processTracker) Exited(handle string, id uint32, status int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	process, found := t.processes[handle][id]
+	if found {
+		process.State = garden.ProcessStateExited
+		process.ExitStatus = status
+		t.noteIdle(handle, process)
+	}
+
+	t.evict(handle)
+}
+
+// Forget drops every process tracked for the container, e.g. once it has been
+// destroyed.
+func (t *processTracker) Forget(handle string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.processes, handle)
+	delete(t.outputs, handle)
+	delete(t.idle, handle)
+}
+
+// noteIdle records when the process became idle, if it has exited and has no
+// attached streams.
+func (t *processTracker) noteIdle(handle string, process *garden.ProcessInfo) {
+	if process.State != garden.ProcessStateExited || process.AttachedStreams > 0 {
+		return
+	}
+
+	idle, found := t.idle[handle]
+	if !found {
+		idle = make(map[uint32]time.Time)
+		t.idle[handle] = idle
+	}
+
+	idle[process.ID] = time.Now()
+}
+
+// evict drops the container's idle processes that the retention no longer
+// allows to be kept.
+func (t *processTracker) evict(handle string) {
+	idle := t.idle[handle]
+	if len(idle) == 0 {
+		return
+	}
+
+	if t.retention.Period >= 0 {
+		for id, since := range idle {
+			if time.Since(since) >= t.retention.Period {
+				t.drop(handle, id)
+			}
+		}
+	}
+
+	if t.retention.MaxExited >= 0 && len(idle) > t.retention.MaxExited {
+		ids := make([]uint32, 0, len(idle))
+		for id := range idle {
+			ids = append(ids, id)
+		}
+
+		sort.Sort(byIdleSince{ids, idle})
+
+		for _, id := range ids[:len(ids)-t.retention.MaxExited] {
+			t.drop(handle, id)
+		}
+	}
+
+	if len(idle) == 0 {
+		delete(t.idle, handle)
+	}
+}
+
+func (t *processTracker) drop(handle string, id uint32) {
+	delete(t.processes[handle], id)
+	if len(t.processes[handle]) == 0 {
+		delete(t.processes, handle)
+	}
+
+	delete(t.outputs[handle], id)
+	if len(t.outputs[handle]) == 0 {
+		delete(t.outputs, handle)
+	}
+
+	delete(t.idle[handle], id)
+}
+
+// Buffered records the buffer the process's output is being written to.
+func (t *processTracker) Buffered(handle string, id uint32, output *outputBuffer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	outputs, found := t.outputs[handle]
+	if !found {
+		outputs = make(map[uint32]*outputBuffer)
+		t.outputs[handle] = outputs
+	}
+
+	outputs[id] = output
+}
+
+// Output returns the buffer the process's output is being written to, or nil
+// if the server is not buffering it.
+func (t *processTracker) Output(handle string, id uint32) *outputBuffer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.outputs[handle][id]
+}
+
+func (t *processTracker) Processes(handle string) []garden.ProcessInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.evict(handle)
+
+	processes := []garden.ProcessInfo{}
+	for _, process := range t.processes[handle] {
+		processes = append(processes, *process)
+	}
+
+	sort.Sort(byID(processes))
+
+	return processes
+}
+
+func (t *processTracker) StartedAt(handle string, id uint32) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	process, found := t.processes[handle][id]
+	if !found {
+		return time.Time{}
+	}
+
+	return process.StartedAt
+}
+
+func (t *processTracker) AttachedStreams(handle string, id uint32) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	process, found := t.processes[handle][id]
+	if !found {
+		return 0
+	}
+
+	return process.AttachedStreams
+}
+
+func (t *processTracker) process(handle string, id uint32) *garden.ProcessInfo {
+	processes, found := t.processes[handle]
+	if !found {
+		processes = make(map[uint32]*garden.ProcessInfo)
+		t.processes[handle] = processes
+	}
+
+	process, found := processes[id]
+	if !found {
+		process = &garden.ProcessInfo{ID: id}
+		processes[id] = process
+	}
+
+	return process
+}
+
+type byIdleSince struct {
+	ids   []uint32
+	since map[uint32]time.Time
+}
+
+func (p byIdleSince) Len() int           { return len(p.ids) }
+func (p byIdleSince) Less(i, j int) bool { return p.since[p.ids[i]].Before(p.since[p.ids[j]]) }
+func (p byIdleSince) Swap(i, j int)      { p.ids[i], p.ids[j] = p.ids[j], p.ids[i] }
+
+type byID []garden.ProcessInfo
+
+func (p byID) Len() int           { return len(p) }
+func (p byID) Less(i, j int) bool { return p[i].ID < p[j].ID }
+func (p byID) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
@@ -0,0 +1,209 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/pivotal-golang/lager"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+// propertyLocks serializes the changes the server makes to each container's
+// properties, and keeps reads of them from overlapping a change, so that those
+// it makes one at a time on behalf of a backend without SetProperties appear
+// atomic to other clients of the server.
+type propertyLocks struct {
+	mu    sync.Mutex
+	locks map[string]*propertyLock
+}
+
+type propertyLock struct {
+	sync.RWMutex
+
+	// how many requests hold or wait for the lock
+	refs int
+}
+
+func newPropertyLocks() *propertyLocks {
+	return &propertyLocks{
+		locks: make(map[string]*propertyLock),
+	}
+}
+
+// lock locks the properties of the container with the handle for changing
+// them, and returns the function to unlock them.
+func (l *propertyLocks) lock(handle string) func() {
+	lock := l.acquire(handle)
+	lock.Lock()
+
+	return func() {
+		lock.Unlock()
+		l.release(handle, lock)
+	}
+}
+
+// rlock locks the properties of the container with the handle for reading
+// them, and returns the function to unlock them.
+func (l *propertyLocks) rlock(handle string) func() {
+	lock := l.acquire(handle)
+	lock.RLock()
+
+	return func() {
+		lock.RUnlock()
+		l.release(handle, lock)
+	}
+}
+
+func (l *propertyLocks) acquire(handle string) *propertyLock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock, found := l.locks[handle]
+	if !found {
+		lock = &propertyLock{}
+		l.locks[handle] = lock
+	}
+
+	lock.refs++
+
+	return lock
+}
+
+func (l *propertyLocks) release(handle string, lock *propertyLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock.refs--
+	if lock.refs == 0 {
+		delete(l.locks, handle)
+	}
+}
+
+// containerProperties returns the container's properties, from its info if
+// the backend cannot return them on their own.
+func containerProperties(container garden.Container) (garden.Properties, error) {
+	summary, err := containerSummary(container)
+	if err != nil {
+		return nil, err
+	}
+
+	return summary.Properties, nil
+}
+
+// containerSummary returns the container's properties, and its state only if
+// its info had to be read for them, as reading the info may be expensive.
+func containerSummary(container garden.Container) (garden.ContainerSummary, error) {
+	summary := garden.ContainerSummary{Handle: container.Handle()}
+
+	properties, err := container.Properties()
+	if err != garden.ErrNotImplemented {
+		summary.Properties = properties
+		return summary, err
+	}
+
+	info, err := container.Info()
+	if err != nil {
+		return garden.ContainerSummary{}, err
+	}
+
+	summary.State = info.State
+	summary.Properties = info.Properties
+
+	return summary, nil
+}
+
+// propertyChange is a change made to a property while setting properties one
+// at a time, and how to undo it.
+type propertyChange struct {
+	key string
+
+	// the value the property had before, if it was set
+	previous string
+	wasSet   bool
+}
+
+func (change propertyChange) undo(container garden.Container) error {
+	if change.wasSet {
+		return container.SetProperty(change.key, change.previous)
+	}
+
+	return container.RemoveProperty(change.key)
+}
+
+// compareAndSetEach compares the container's properties to the expected
+// selector and sets and removes properties one at a time, for backends that
+// cannot do so atomically. The caller must hold the lock of the container's
+// properties. If a change fails, those made before it are undone.
+func compareAndSetEach(logger lager.Logger, container garden.Container, expected garden.Selector, properties garden.Properties, removals []string) error {
+	current, err := containerProperties(container)
+	if err != nil {
+		return err
+	}
+
+	for _, requirement := range expected {
+		if !requirement.Matches(current) {
+			return garden.PropertyConflictError{Requirement: requirement.String()}
+		}
+	}
+
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	changes := []propertyChange{}
+
+	undo := func() {
+		for i := len(changes) - 1; i >= 0; i-- {
+			if err := changes[i].undo(container); err != nil {
+				logger.Error("failed-to-undo-property-change", err, lager.Data{
+					"key": changes[i].key,
+				})
+			}
+		}
+	}
+
+	for _, key := range keys {
+		previous, wasSet := current[key]
+
+		err := container.SetProperty(key, properties[key])
+		if err != nil {
+			undo()
+			return err
+		}
+
+		changes = append(changes, propertyChange{key: key, previous: previous, wasSet: wasSet})
+	}
+
+	for _, key := range removals {
+		previous, wasSet := current[key]
+		if !wasSet {
+			continue
+		}
+
+		err := container.RemoveProperty(key)
+		if err != nil {
+			undo()
+			return err
+		}
+
+		changes = append(changes, propertyChange{key: key, previous: previous, wasSet: true})
+	}
+
+	return nil
+}
+
+// validPropertyChanges refuses to both set and remove the same property.
+func validPropertyChanges(properties garden.Properties, removals []string) error {
+	for _, key := range removals {
+		if _, found := properties[key]; found {
+			return fmt.Errorf("property %q is both set and removed", key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package server_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden/client"
+	"github.com/cloudfoundry-incubator/garden/client/connection"
+	"github.com/cloudfoundry-incubator/garden/fakes"
+	"github.com/cloudfoundry-incubator/garden/server"
+)
+
+var _ = Describe("When a client reattaches to processes", func() {
+	var tmpdir string
+	var socketPath string
+	var grpcSocketPath string
+
+	var serverBackend *fakes.FakeBackend
+	var fakeContainer *fakes.FakeContainer
+	var fakeProcess *fakes.FakeProcess
+	var exit chan struct{}
+
+	var apiServer *server.GardenServer
+
+	var reattached chan uint32
+	var policy connection.ReattachPolicy
+
+	startServer := func() {
+		apiServer = server.New(
+			"unix",
+			socketPath,
+			42*time.Second,
+			serverBackend,
+			lagertest.NewTestLogger("test"),
+			server.WithGRPC("unix", grpcSocketPath),
+		)
+
+		err := apiServer.Start()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		socketPath = path.Join(tmpdir, "api.sock")
+		grpcSocketPath = path.Join(tmpdir, "grpc.sock")
+
+		serverBackend = new(fakes.FakeBackend)
+
+		fakeContainer = new(fakes.FakeContainer)
+		fakeContainer.HandleReturns("some-handle")
+
+		serverBackend.LookupReturns(fakeContainer, nil)
+
+		exited := make(chan struct{})
+		exit = exited
+
+		fakeProcess = new(fakes.FakeProcess)
+		fakeProcess.IDReturns(42)
+		fakeProcess.WaitForExitStub = func() (garden.ExitInfo, error) {
+			<-exited
+			return garden.ExitInfo{ExitStatus: 7}, nil
+		}
+
+		fakeContainer.RunStub = func(spec garden.ProcessSpec, processIO garden.ProcessIO) (garden.Process, error) {
+			processIO.Stdout.Write([]byte("before-restart\n"))
+			return fakeProcess, nil
+		}
+
+		fakeContainer.AttachFromStub = func(processID uint32, offset uint64, processIO garden.ProcessIO) (garden.Process, error) {
+			processIO.Stdout.Write([]byte("after-restart\n"))
+			return fakeProcess, nil
+		}
+
+		reattached = make(chan uint32, 10)
+
+		policy = connection.ReattachPolicy{
+			MaxAttempts: 50,
+			Backoff:     10 * time.Millisecond,
+			MaxBackoff:  50 * time.Millisecond,
+			Reattached: func(handle string, processID uint32) {
+				Ω(handle).Should(Equal("some-handle"))
+				reattached <- processID
+			},
+		}
+
+		startServer()
+	})
+
+	AfterEach(func() {
+		apiServer.Stop()
+		os.RemoveAll(tmpdir)
+	})
+
+	for _, transport := range []string{"http", "grpc"} {
+		transport := transport
+
+		Context("over "+transport, func() {
+			var apiClient garden.Client
+
+			JustBeforeEach(func() {
+				if transport == "grpc" {
+					apiClient = client.New(connection.NewGRPC("unix", grpcSocketPath, connection.WithReattach(policy)))
+				} else {
+					apiClient = client.New(connection.New("unix", socketPath, connection.WithReattach(policy)))
+				}
+			})
+
+			It("carries on streaming a process across a server restart", func() {
+				container, err := apiClient.Lookup("some-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				stdout := gbytes.NewBuffer()
+
+				process, err := container.Run(garden.ProcessSpec{Path: "sleep"}, garden.ProcessIO{Stdout: stdout})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Eventually(stdout).Should(gbytes.Say("before-restart"))
+
+				apiServer.Stop()
+				startServer()
+
+				Eventually(reattached).Should(Receive(Equal(uint32(42))))
+				Consistently(reattached).ShouldNot(Receive())
+
+				Eventually(stdout).Should(gbytes.Say("after-restart"))
+
+				Ω(fakeContainer.AttachFromCallCount()).Should(Equal(1))
+				processID, offset, _ := fakeContainer.AttachFromArgsForCall(0)
+				Ω(processID).Should(Equal(uint32(42)))
+				Ω(offset).Should(Equal(uint64(len("before-restart\n"))))
+
+				Ω(process.Signal(garden.SignalTerminate)).Should(Succeed())
+				Eventually(fakeProcess.SignalCallCount).Should(Equal(1))
+
+				Ω(process.SetTTY(garden.TTYSpec{WindowSize: &garden.WindowSize{Columns: 80, Rows: 24}})).Should(Succeed())
+				Eventually(fakeProcess.SetTTYCallCount).Should(Equal(1))
+
+				close(exit)
+
+				status, err := process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(status).Should(Equal(7))
+			})
+
+			Context("when the server does not come back", func() {
+				BeforeEach(func() {
+					policy.MaxAttempts = 3
+				})
+
+				It("gives up after the attempts allowed", func() {
+					defer close(exit)
+
+					container, err := apiClient.Lookup("some-handle")
+					Ω(err).ShouldNot(HaveOccurred())
+
+					process, err := container.Run(garden.ProcessSpec{Path: "sleep"}, garden.ProcessIO{})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					apiServer.Stop()
+
+					_, err = process.Wait()
+					Ω(err).Should(Equal(garden.ErrDisconnected))
+
+					Ω(reattached).ShouldNot(Receive())
+
+					startServer()
+				})
+			})
+		})
+	}
+})
@@ -1,12 +1,14 @@
 package server
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gogo/protobuf/proto"
@@ -15,13 +17,60 @@ import (
 	protocol "github.com/cloudfoundry-incubator/garden/protocol"
 	"github.com/cloudfoundry-incubator/garden/transport"
 	"github.com/pivotal-golang/lager"
+	"golang.org/x/net/context"
+	"golang.org/x/net/websocket"
 )
 
-var ErrInvalidContentType = errors.New("content-type must be application/json")
-var ErrConcurrentDestroy = errors.New("container already being destroyed")
+// streamFeatures lists the optional process stream features this server
+// supports, advertised on Run and Attach responses.
+var streamFeatures = strings.Join([]string{
+	transport.FeatureExtendedSignals,
+	transport.FeatureOutputReplay,
+	transport.FeatureFlowControl,
+	transport.FeatureBinaryData,
+}, ",")
+
+var signals = map[protocol.ProcessPayload_Signal]garden.Signal{
+	protocol.ProcessPayload_terminate:           garden.SignalTerminate,
+	protocol.ProcessPayload_kill:                garden.SignalKill,
+	protocol.ProcessPayload_hangup:              garden.SignalHangUp,
+	protocol.ProcessPayload_interrupt:           garden.SignalInterrupt,
+	protocol.ProcessPayload_quit:                garden.SignalQuit,
+	protocol.ProcessPayload_abort:               garden.SignalAbort,
+	protocol.ProcessPayload_user1:               garden.SignalUser1,
+	protocol.ProcessPayload_user2:               garden.SignalUser2,
+	protocol.ProcessPayload_alarm:               garden.SignalAlarm,
+	protocol.ProcessPayload_continue:            garden.SignalContinue,
+	protocol.ProcessPayload_stop:                garden.SignalStop,
+	protocol.ProcessPayload_terminal_stop:       garden.SignalTerminalStop,
+	protocol.ProcessPayload_window_change:       garden.SignalWindowChange,
+	protocol.ProcessPayload_pipe:                garden.SignalPipe,
+	protocol.ProcessPayload_child:               garden.SignalChild,
+	protocol.ProcessPayload_terminal_input:      garden.SignalTerminalInput,
+	protocol.ProcessPayload_terminal_output:     garden.SignalTerminalOutput,
+	protocol.ProcessPayload_trap:                garden.SignalTrap,
+	protocol.ProcessPayload_urgent:              garden.SignalUrgent,
+	protocol.ProcessPayload_profile:             garden.SignalProfile,
+	protocol.ProcessPayload_virtual_alarm:       garden.SignalVirtualAlarm,
+	protocol.ProcessPayload_cpu_limit:           garden.SignalCPULimit,
+	protocol.ProcessPayload_file_size_limit:     garden.SignalFileSizeLimit,
+	protocol.ProcessPayload_bad_system_call:     garden.SignalBadSystemCall,
+	protocol.ProcessPayload_bus_error:           garden.SignalBusError,
+	protocol.ProcessPayload_floating_point:      garden.SignalFloatingPoint,
+	protocol.ProcessPayload_segmentation_fault:  garden.SignalSegmentationFault,
+	protocol.ProcessPayload_illegal_instruction: garden.SignalIllegalInstruction,
+}
+
+type malformedRequestError struct {
+	err error
+}
+
+func (err malformedRequestError) Error() string {
+	return err.err.Error()
+}
 
 func (s *GardenServer) handlePing(w http.ResponseWriter, r *http.Request) {
-	hLog := s.logger.Session("ping")
+	hLog := s.session(r.Context(), "ping")
 
 	err := s.backend.Ping()
 	if err != nil {
@@ -30,23 +79,32 @@ func (s *GardenServer) handlePing(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.writeResponse(w, &protocol.PingResponse{})
+	s.writeResponse(w, r, &protocol.PingResponse{})
 }
 
 func (s *GardenServer) handleCapacity(w http.ResponseWriter, r *http.Request) {
-	hLog := s.logger.Session("capacity")
+	hLog := s.session(r.Context(), "capacity")
 
-	capacity, err := s.backend.Capacity()
+	response, err := s.capacity(r.Context())
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeError(w, r, err, hLog)
 		return
 	}
 
-	s.writeResponse(w, &protocol.CapacityResponse{
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) capacity(ctx context.Context) (*protocol.CapacityResponse, error) {
+	capacity, err := s.backendFor(ctx).Capacity()
+	if err != nil {
+		return nil, err
+	}
+
+	return &protocol.CapacityResponse{
 		MemoryInBytes: proto.Uint64(capacity.MemoryInBytes),
 		DiskInBytes:   proto.Uint64(capacity.DiskInBytes),
 		MaxContainers: proto.Uint64(capacity.MaxContainers),
-	})
+	}, nil
 }
 
 func (s *GardenServer) handleCreate(w http.ResponseWriter, r *http.Request) {
@@ -55,10 +113,20 @@ func (s *GardenServer) handleCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hLog := s.logger.Session("create", lager.Data{
+	hLog := s.session(r.Context(), "create", lager.Data{
 		"request": request,
 	})
 
+	response, err := s.create(r.Context(), hLog, &request)
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		return
+	}
+
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) create(ctx context.Context, logger lager.Logger, request *protocol.CreateRequest) (*protocol.CreateResponse, error) {
 	bindMounts := []garden.BindMount{}
 
 	for _, bm := range request.GetBindMounts() {
@@ -84,9 +152,9 @@ func (s *GardenServer) handleCreate(w http.ResponseWriter, r *http.Request) {
 		graceTime = time.Duration(request.GetGraceTime()) * time.Second
 	}
 
-	hLog.Debug("creating")
+	logger.Debug("creating")
 
-	container, err := s.backend.Create(garden.ContainerSpec{
+	container, err := s.backendFor(ctx).Create(garden.ContainerSpec{
 		Handle:     request.GetHandle(),
 		GraceTime:  graceTime,
 		RootFSPath: request.GetRootfs(),
@@ -97,35 +165,116 @@ func (s *GardenServer) handleCreate(w http.ResponseWriter, r *http.Request) {
 		Privileged: request.GetPrivileged(),
 	})
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
-	hLog.Info("created")
+	logger.Info("created")
 
 	s.bomberman.Strap(container)
 
-	s.writeResponse(w, &protocol.CreateResponse{
+	s.publishEvent(garden.EventTypeCreate, container.Handle(), properties, nil)
+
+	return &protocol.CreateResponse{
 		Handle: proto.String(container.Handle()),
-	})
+	}, nil
 }
 
 func (s *GardenServer) handleList(w http.ResponseWriter, r *http.Request) {
-	properties := garden.Properties{}
-	for name, vals := range r.URL.Query() {
-		if len(vals) > 0 {
-			properties[name] = vals[0]
-		}
+	hLog := s.session(r.Context(), "list")
+
+	request, err := listRequestFromQuery(r.URL.Query())
+	if err != nil {
+		s.writeError(w, r, malformedRequestError{err}, hLog)
+		return
 	}
 
-	hLog := s.logger.Session("list", lager.Data{
-		"properties": properties,
+	response, err := s.list(r.Context(), hLog, request)
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		return
+	}
+
+	w.Header().Set(transport.ListOptionsHeader, "true")
+
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) list(ctx context.Context, logger lager.Logger, request *protocol.ListRequest) (*protocol.ListResponse, error) {
+	query, err := parseListRequest(request)
+	if err != nil {
+		return nil, malformedRequestError{err}
+	}
+
+	logger.Debug("listing", lager.Data{
+		"selector": query.selector.String(),
+		"sort-by":  query.sortBy,
+		"limit":    query.limit,
+	})
+
+	if !query.options {
+		return s.listByProperties(ctx, gardenProperties(request.GetProperties()))
+	}
+
+	var entries []listEntry
+	var next string
+
+	page, err := s.backendFor(ctx).List(garden.ListOptions{
+		Selector:  query.selector,
+		SortBy:    query.sortBy,
+		Limit:     query.limit,
+		Cursor:    query.cursor,
+		Summarize: query.summarize,
 	})
+	if err == nil {
+		entries, err = s.pageEntries(ctx, logger, page, query.summarize)
+		if err != nil {
+			return nil, err
+		}
+
+		next = page.NextCursor
+	} else if err == garden.ErrNotImplemented {
+		logger.Debug("falling-back-to-containers")
+
+		var cursor *listCursor
 
-	containers, err := s.backend.Containers(properties)
+		entries, cursor, err = s.listContainers(ctx, logger, query)
+		if err != nil {
+			return nil, err
+		}
+
+		if cursor != nil {
+			next = cursor.String()
+		}
+	} else {
+		return nil, err
+	}
+
+	response := &protocol.ListResponse{Handles: []string{}}
+	if next != "" {
+		response.NextCursor = proto.String(next)
+	}
+
+	for _, entry := range entries {
+		response.Handles = append(response.Handles, entry.handle)
+
+		if query.summarize {
+			response.Summaries = append(response.Summaries, &protocol.ListResponse_ContainerSummary{
+				Handle:     proto.String(entry.handle),
+				State:      proto.String(entry.state),
+				Properties: protocolProperties(entry.properties),
+			})
+		}
+	}
+
+	return response, nil
+}
+
+// listByProperties lists the containers with the properties, in the order the
+// backend returns them.
+func (s *GardenServer) listByProperties(ctx context.Context, properties garden.Properties) (*protocol.ListResponse, error) {
+	containers, err := s.backendFor(ctx).Containers(properties)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
 	handles := []string{}
@@ -134,16 +283,26 @@ func (s *GardenServer) handleList(w http.ResponseWriter, r *http.Request) {
 		handles = append(handles, container.Handle())
 	}
 
-	s.writeResponse(w, &protocol.ListResponse{Handles: handles})
+	return &protocol.ListResponse{Handles: handles}, nil
 }
 
 func (s *GardenServer) handleDestroy(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("destroy", lager.Data{
+	hLog := s.session(r.Context(), "destroy", lager.Data{
 		"handle": handle,
 	})
 
+	response, err := s.destroy(r.Context(), hLog, handle)
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		return
+	}
+
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) destroy(ctx context.Context, logger lager.Logger, handle string) (*protocol.DestroyResponse, error) {
 	s.destroysL.Lock()
 
 	_, alreadyDestroying := s.destroys[handle]
@@ -154,13 +313,19 @@ func (s *GardenServer) handleDestroy(w http.ResponseWriter, r *http.Request) {
 	s.destroysL.Unlock()
 
 	if alreadyDestroying {
-		s.writeError(w, ErrConcurrentDestroy, hLog)
-		return
+		return nil, garden.ConcurrentDestroyError{Handle: handle}
+	}
+
+	var properties garden.Properties
+	if s.events.HasSubscribers() {
+		if container, err := s.backendFor(ctx).Lookup(handle); err == nil {
+			properties = s.eventProperties(container)
+		}
 	}
 
-	hLog.Debug("destroying")
+	logger.Debug("destroying")
 
-	err := s.backend.Destroy(handle)
+	err := s.backendFor(ctx).Destroy(handle)
 
 	if !alreadyDestroying {
 		s.destroysL.Lock()
@@ -169,21 +334,23 @@ func (s *GardenServer) handleDestroy(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
-	hLog.Info("destroyed")
+	logger.Info("destroyed")
 
 	s.bomberman.Defuse(handle)
+	s.processes.Forget(handle)
+
+	s.publishEvent(garden.EventTypeDestroy, handle, properties, nil)
 
-	s.writeResponse(w, &protocol.DestroyResponse{})
+	return &protocol.DestroyResponse{}, nil
 }
 
 func (s *GardenServer) handleStop(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("stop", lager.Data{
+	hLog := s.session(r.Context(), "stop", lager.Data{
 		"handle": handle,
 	})
 
@@ -192,28 +359,38 @@ func (s *GardenServer) handleStop(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	kill := request.GetKill()
-
-	container, err := s.backend.Lookup(handle)
+	response, err := s.stopContainer(r.Context(), hLog, handle, request.GetKill())
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeError(w, r, err, hLog)
 		return
 	}
 
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) stopContainer(ctx context.Context, logger lager.Logger, handle string, kill bool) (*protocol.StopResponse, error) {
+	container, err := s.backendFor(ctx).Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("stopping")
+	logger.Debug("stopping")
 
 	err = container.Stop(kill)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
-	hLog.Info("stopped")
+	logger.Info("stopped")
+
+	s.publishEvent(garden.EventTypeStop, container.Handle(), s.eventProperties(container), map[string]string{
+		"kill": fmt.Sprintf("%t", kill),
+	})
 
-	s.writeResponse(w, &protocol.StopResponse{})
+	return &protocol.StopResponse{}, nil
 }
 
 func (s *GardenServer) handleStreamIn(w http.ResponseWriter, r *http.Request) {
@@ -221,31 +398,39 @@ func (s *GardenServer) handleStreamIn(w http.ResponseWriter, r *http.Request) {
 
 	dstPath := r.URL.Query().Get("destination")
 
-	hLog := s.logger.Session("stream-in", lager.Data{
+	hLog := s.session(r.Context(), "stream-in", lager.Data{
 		"handle":      handle,
 		"destination": dstPath,
 	})
 
-	container, err := s.backend.Lookup(handle)
+	response, err := s.streamIn(r.Context(), hLog, handle, dstPath, r.Body)
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeError(w, r, err, hLog)
 		return
 	}
 
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) streamIn(ctx context.Context, logger lager.Logger, handle string, dstPath string, reader io.Reader) (*protocol.StreamInResponse, error) {
+	container, err := s.backendFor(ctx).Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("streaming-in")
+	logger.Debug("streaming-in")
 
-	err = container.StreamIn(dstPath, r.Body)
+	err = container.StreamIn(dstPath, countingReader{reader, s.metrics.streamedIn})
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
-	hLog.Info("streamed-in")
+	logger.Info("streamed-in")
 
-	s.writeResponse(w, &protocol.StreamInResponse{})
+	return &protocol.StreamInResponse{}, nil
 }
 
 func (s *GardenServer) handleStreamOut(w http.ResponseWriter, r *http.Request) {
@@ -253,42 +438,50 @@ func (s *GardenServer) handleStreamOut(w http.ResponseWriter, r *http.Request) {
 
 	srcPath := r.URL.Query().Get("source")
 
-	hLog := s.logger.Session("stream-out", lager.Data{
+	hLog := s.session(r.Context(), "stream-out", lager.Data{
 		"handle": handle,
 		"source": srcPath,
 	})
 
-	container, err := s.backend.Lookup(handle)
+	n, err := s.streamOut(r.Context(), hLog, handle, srcPath, w)
+	if err != nil && n == 0 {
+		s.writeError(w, r, err, hLog)
+	}
+}
+
+// streamOut copies the path out of the container to the writer, returning how
+// much was written before any error.
+func (s *GardenServer) streamOut(ctx context.Context, logger lager.Logger, handle string, srcPath string, w io.Writer) (int64, error) {
+	container, err := s.backendFor(ctx).Lookup(handle)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return 0, err
 	}
 
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("streaming-out")
+	logger.Debug("streaming-out")
 
 	reader, err := container.StreamOut(srcPath)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return 0, err
 	}
 
 	n, err := io.Copy(w, reader)
+
+	s.metrics.streamedOut.Add(float64(n))
+
 	if err != nil {
 		if err := reader.Close(); err != nil {
-			hLog.Error("failed-to-close", err)
-		}
-
-		if n == 0 {
-			s.writeError(w, err, hLog)
+			logger.Error("failed-to-close", err)
 		}
 
-		return
+		return n, err
 	}
 
-	hLog.Info("streamed-out")
+	logger.Info("streamed-out")
+
+	return n, nil
 }
 
 func (s *GardenServer) handleLimitBandwidth(w http.ResponseWriter, r *http.Request) {
@@ -299,16 +492,25 @@ func (s *GardenServer) handleLimitBandwidth(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	hLog := s.logger.Session("limit-bandwidth", lager.Data{
+	hLog := s.session(r.Context(), "limit-bandwidth", lager.Data{
 		"handle": handle,
 	})
 
-	container, err := s.backend.Lookup(handle)
+	response, err := s.limitBandwidth(r.Context(), hLog, handle, &request)
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeError(w, r, err, hLog)
 		return
 	}
 
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) limitBandwidth(ctx context.Context, logger lager.Logger, handle string, request *protocol.LimitBandwidthRequest) (*protocol.LimitBandwidthResponse, error) {
+	container, err := s.backendFor(ctx).Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
@@ -317,70 +519,80 @@ func (s *GardenServer) handleLimitBandwidth(w http.ResponseWriter, r *http.Reque
 		BurstRateInBytesPerSecond: request.GetBurst(),
 	}
 
-	hLog.Debug("limiting", lager.Data{
+	logger.Debug("limiting", lager.Data{
 		"requested-limits": requestedLimits,
 	})
 
 	err = container.LimitBandwidth(requestedLimits)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
 	limits, err := container.CurrentBandwidthLimits()
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
-	hLog.Info("limited", lager.Data{
+	logger.Info("limited", lager.Data{
 		"resulting-limits": limits,
 	})
 
-	s.writeResponse(w, &protocol.LimitBandwidthResponse{
+	s.publishEvent(garden.EventTypeLimit, container.Handle(), s.eventProperties(container), map[string]string{
+		"kind": "bandwidth",
+	})
+
+	return &protocol.LimitBandwidthResponse{
 		Rate:  proto.Uint64(limits.RateInBytesPerSecond),
 		Burst: proto.Uint64(limits.BurstRateInBytesPerSecond),
-	})
+	}, nil
 }
 
 func (s *GardenServer) handleCurrentBandwidthLimits(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("current-bandwidth-limits", lager.Data{
+	hLog := s.session(r.Context(), "current-bandwidth-limits", lager.Data{
 		"handle": handle,
 	})
 
-	container, err := s.backend.Lookup(handle)
+	response, err := s.currentBandwidthLimits(r.Context(), hLog, handle)
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeError(w, r, err, hLog)
 		return
 	}
 
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) currentBandwidthLimits(ctx context.Context, logger lager.Logger, handle string) (*protocol.LimitBandwidthResponse, error) {
+	container, err := s.backendFor(ctx).Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("getting")
+	logger.Debug("getting")
 
 	limits, err := container.CurrentBandwidthLimits()
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
-	hLog.Info("got", lager.Data{
+	logger.Info("got", lager.Data{
 		"limits": limits,
 	})
 
-	s.writeResponse(w, &protocol.LimitBandwidthResponse{
+	return &protocol.LimitBandwidthResponse{
 		Rate:  proto.Uint64(limits.RateInBytesPerSecond),
 		Burst: proto.Uint64(limits.BurstRateInBytesPerSecond),
-	})
+	}, nil
 }
 
 func (s *GardenServer) handleLimitMemory(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("limit-memory", lager.Data{
+	hLog := s.session(r.Context(), "limit-memory", lager.Data{
 		"handle": handle,
 	})
 
@@ -389,12 +601,21 @@ func (s *GardenServer) handleLimitMemory(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	response, err := s.limitMemory(r.Context(), hLog, handle, &request)
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		return
+	}
+
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) limitMemory(ctx context.Context, logger lager.Logger, handle string, request *protocol.LimitMemoryRequest) (*protocol.LimitMemoryResponse, error) {
 	limitInBytes := request.GetLimitInBytes()
 
-	container, err := s.backend.Lookup(handle)
+	container, err := s.backendFor(ctx).Lookup(handle)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
 	s.bomberman.Pause(container.Handle())
@@ -405,70 +626,80 @@ func (s *GardenServer) handleLimitMemory(w http.ResponseWriter, r *http.Request)
 	}
 
 	if request.LimitInBytes != nil {
-		hLog.Debug("limiting", lager.Data{
+		logger.Debug("limiting", lager.Data{
 			"requested-limits": requestedLimits,
 		})
 
 		err = container.LimitMemory(requestedLimits)
 
 		if err != nil {
-			s.writeError(w, err, hLog)
-			return
+			return nil, err
 		}
 	}
 
 	limits, err := container.CurrentMemoryLimits()
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
-	hLog.Info("limited", lager.Data{
+	logger.Info("limited", lager.Data{
 		"resulting-limits": limits,
 	})
 
-	s.writeResponse(w, &protocol.LimitMemoryResponse{
-		LimitInBytes: proto.Uint64(limits.LimitInBytes),
+	s.publishEvent(garden.EventTypeLimit, container.Handle(), s.eventProperties(container), map[string]string{
+		"kind": "memory",
 	})
+
+	return &protocol.LimitMemoryResponse{
+		LimitInBytes: proto.Uint64(limits.LimitInBytes),
+	}, nil
 }
 
 func (s *GardenServer) handleCurrentMemoryLimits(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("current-memory-limits", lager.Data{
+	hLog := s.session(r.Context(), "current-memory-limits", lager.Data{
 		"handle": handle,
 	})
 
-	container, err := s.backend.Lookup(handle)
+	response, err := s.currentMemoryLimits(r.Context(), hLog, handle)
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeError(w, r, err, hLog)
 		return
 	}
 
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) currentMemoryLimits(ctx context.Context, logger lager.Logger, handle string) (*protocol.LimitMemoryResponse, error) {
+	container, err := s.backendFor(ctx).Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("getting")
+	logger.Debug("getting")
 
 	limits, err := container.CurrentMemoryLimits()
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
-	hLog.Info("got", lager.Data{
+	logger.Info("got", lager.Data{
 		"limits": limits,
 	})
 
-	s.writeResponse(w, &protocol.LimitMemoryResponse{
+	return &protocol.LimitMemoryResponse{
 		LimitInBytes: proto.Uint64(limits.LimitInBytes),
-	})
+	}, nil
 }
 
 func (s *GardenServer) handleLimitDisk(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("limit-disk", lager.Data{
+	hLog := s.session(r.Context(), "limit-disk", lager.Data{
 		"handle": handle,
 	})
 
@@ -477,6 +708,16 @@ func (s *GardenServer) handleLimitDisk(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	response, err := s.limitDisk(r.Context(), hLog, handle, &request)
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		return
+	}
+
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) limitDisk(ctx context.Context, logger lager.Logger, handle string, request *protocol.LimitDiskRequest) (*protocol.LimitDiskResponse, error) {
 	blockSoft := request.GetBlockSoft()
 	blockHard := request.GetBlockHard()
 	inodeSoft := request.GetInodeSoft()
@@ -492,10 +733,9 @@ func (s *GardenServer) handleLimitDisk(w http.ResponseWriter, r *http.Request) {
 		settingLimit = true
 	}
 
-	container, err := s.backend.Lookup(handle)
+	container, err := s.backendFor(ctx).Lookup(handle)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
 	s.bomberman.Pause(container.Handle())
@@ -511,79 +751,89 @@ func (s *GardenServer) handleLimitDisk(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if settingLimit {
-		hLog.Debug("limiting", lager.Data{
+		logger.Debug("limiting", lager.Data{
 			"requested-limits": requestedLimits,
 		})
 
 		err = container.LimitDisk(requestedLimits)
 		if err != nil {
-			s.writeError(w, err, hLog)
-			return
+			return nil, err
 		}
 	}
 
 	limits, err := container.CurrentDiskLimits()
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
-	hLog.Info("limited", lager.Data{
+	logger.Info("limited", lager.Data{
 		"resulting-limits": limits,
 	})
 
-	s.writeResponse(w, &protocol.LimitDiskResponse{
+	s.publishEvent(garden.EventTypeLimit, container.Handle(), s.eventProperties(container), map[string]string{
+		"kind": "disk",
+	})
+
+	return &protocol.LimitDiskResponse{
 		BlockSoft: proto.Uint64(limits.BlockSoft),
 		BlockHard: proto.Uint64(limits.BlockHard),
 		InodeSoft: proto.Uint64(limits.InodeSoft),
 		InodeHard: proto.Uint64(limits.InodeHard),
 		ByteSoft:  proto.Uint64(limits.ByteSoft),
 		ByteHard:  proto.Uint64(limits.ByteHard),
-	})
+	}, nil
 }
 
 func (s *GardenServer) handleCurrentDiskLimits(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("current-disk-limits", lager.Data{
+	hLog := s.session(r.Context(), "current-disk-limits", lager.Data{
 		"handle": handle,
 	})
 
-	container, err := s.backend.Lookup(handle)
+	response, err := s.currentDiskLimits(r.Context(), hLog, handle)
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeError(w, r, err, hLog)
 		return
 	}
 
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) currentDiskLimits(ctx context.Context, logger lager.Logger, handle string) (*protocol.LimitDiskResponse, error) {
+	container, err := s.backendFor(ctx).Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("getting")
+	logger.Debug("getting")
 
 	limits, err := container.CurrentDiskLimits()
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
-	hLog.Info("got", lager.Data{
+	logger.Info("got", lager.Data{
 		"limits": limits,
 	})
 
-	s.writeResponse(w, &protocol.LimitDiskResponse{
+	return &protocol.LimitDiskResponse{
 		BlockSoft: proto.Uint64(limits.BlockSoft),
 		BlockHard: proto.Uint64(limits.BlockHard),
 		InodeSoft: proto.Uint64(limits.InodeSoft),
 		InodeHard: proto.Uint64(limits.InodeHard),
 		ByteSoft:  proto.Uint64(limits.ByteSoft),
 		ByteHard:  proto.Uint64(limits.ByteHard),
-	})
+	}, nil
 }
 
 func (s *GardenServer) handleLimitCPU(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("limit-cpu", lager.Data{
+	hLog := s.session(r.Context(), "limit-cpu", lager.Data{
 		"handle": handle,
 	})
 
@@ -592,12 +842,21 @@ func (s *GardenServer) handleLimitCPU(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	response, err := s.limitCPU(r.Context(), hLog, handle, &request)
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		return
+	}
+
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) limitCPU(ctx context.Context, logger lager.Logger, handle string, request *protocol.LimitCpuRequest) (*protocol.LimitCpuResponse, error) {
 	limitInShares := request.GetLimitInShares()
 
-	container, err := s.backend.Lookup(handle)
+	container, err := s.backendFor(ctx).Lookup(handle)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
 	s.bomberman.Pause(container.Handle())
@@ -608,69 +867,79 @@ func (s *GardenServer) handleLimitCPU(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if request.LimitInShares != nil {
-		hLog.Debug("limiting", lager.Data{
+		logger.Debug("limiting", lager.Data{
 			"requested-limits": requestedLimits,
 		})
 
 		err = container.LimitCPU(requestedLimits)
 		if err != nil {
-			s.writeError(w, err, hLog)
-			return
+			return nil, err
 		}
 	}
 
 	limits, err := container.CurrentCPULimits()
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
-	hLog.Info("limited", lager.Data{
+	logger.Info("limited", lager.Data{
 		"resulting-limits": limits,
 	})
 
-	s.writeResponse(w, &protocol.LimitCpuResponse{
-		LimitInShares: proto.Uint64(limits.LimitInShares),
+	s.publishEvent(garden.EventTypeLimit, container.Handle(), s.eventProperties(container), map[string]string{
+		"kind": "cpu",
 	})
+
+	return &protocol.LimitCpuResponse{
+		LimitInShares: proto.Uint64(limits.LimitInShares),
+	}, nil
 }
 
 func (s *GardenServer) handleCurrentCPULimits(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("current-cpu-limits", lager.Data{
+	hLog := s.session(r.Context(), "current-cpu-limits", lager.Data{
 		"handle": handle,
 	})
 
-	container, err := s.backend.Lookup(handle)
+	response, err := s.currentCPULimits(r.Context(), hLog, handle)
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeError(w, r, err, hLog)
 		return
 	}
 
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) currentCPULimits(ctx context.Context, logger lager.Logger, handle string) (*protocol.LimitCpuResponse, error) {
+	container, err := s.backendFor(ctx).Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("getting")
+	logger.Debug("getting")
 
 	limits, err := container.CurrentCPULimits()
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
-	hLog.Info("got", lager.Data{
+	logger.Info("got", lager.Data{
 		"limits": limits,
 	})
 
-	s.writeResponse(w, &protocol.LimitCpuResponse{
+	return &protocol.LimitCpuResponse{
 		LimitInShares: proto.Uint64(limits.LimitInShares),
-	})
+	}, nil
 }
 
 func (s *GardenServer) handleNetIn(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("net-in", lager.Data{
+	hLog := s.session(r.Context(), "net-in", lager.Data{
 		"handle": handle,
 	})
 
@@ -679,44 +948,57 @@ func (s *GardenServer) handleNetIn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	response, err := s.netIn(r.Context(), hLog, handle, &request)
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		return
+	}
+
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) netIn(ctx context.Context, logger lager.Logger, handle string, request *protocol.NetInRequest) (*protocol.NetInResponse, error) {
 	hostPort := request.GetHostPort()
 	containerPort := request.GetContainerPort()
 
-	container, err := s.backend.Lookup(handle)
+	container, err := s.backendFor(ctx).Lookup(handle)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("port-mapping", lager.Data{
+	logger.Debug("port-mapping", lager.Data{
 		"host-port":      hostPort,
 		"container-port": containerPort,
 	})
 
 	hostPort, containerPort, err = container.NetIn(hostPort, containerPort)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
-	hLog.Info("port-mapped", lager.Data{
+	logger.Info("port-mapped", lager.Data{
 		"host-port":      hostPort,
 		"container-port": containerPort,
 	})
 
-	s.writeResponse(w, &protocol.NetInResponse{
+	s.publishEvent(garden.EventTypeNetIn, container.Handle(), s.eventProperties(container), map[string]string{
+		"host_port":      fmt.Sprintf("%d", hostPort),
+		"container_port": fmt.Sprintf("%d", containerPort),
+	})
+
+	return &protocol.NetInResponse{
 		HostPort:      proto.Uint32(hostPort),
 		ContainerPort: proto.Uint32(containerPort),
-	})
+	}, nil
 }
 
 func (s *GardenServer) handleNetOut(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("net-out", lager.Data{
+	hLog := s.session(r.Context(), "net-out", lager.Data{
 		"handle": handle,
 	})
 
@@ -725,6 +1007,16 @@ func (s *GardenServer) handleNetOut(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	response, err := s.netOut(r.Context(), hLog, handle, &request)
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		return
+	}
+
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) netOut(ctx context.Context, logger lager.Logger, handle string, request *protocol.NetOutRequest) (*protocol.NetOutResponse, error) {
 	var protoc garden.Protocol
 	switch request.GetProtocol() {
 	case protocol.NetOutRequest_TCP:
@@ -736,9 +1028,7 @@ func (s *GardenServer) handleNetOut(w http.ResponseWriter, r *http.Request) {
 	case protocol.NetOutRequest_ALL:
 		protoc = garden.ProtocolAll
 	default:
-		err := fmt.Errorf("invalid protocol: %d", request.GetProtocol())
-		s.writeError(w, err, hLog)
-		return
+		return nil, fmt.Errorf("invalid protocol: %d", request.GetProtocol())
 	}
 
 	var networks []garden.IPRange
@@ -769,10 +1059,9 @@ func (s *GardenServer) handleNetOut(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	container, err := s.backend.Lookup(handle)
+	container, err := s.backendFor(ctx).Lookup(handle)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
 	s.bomberman.Pause(container.Handle())
@@ -786,28 +1075,27 @@ func (s *GardenServer) handleNetOut(w http.ResponseWriter, r *http.Request) {
 		Log:      request.GetLog(),
 	}
 
-	hLog.Debug("allowing-out", lager.Data{
+	logger.Debug("allowing-out", lager.Data{
 		"rule": rule,
 	})
 
 	err = container.NetOut(rule)
 
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
-	hLog.Debug("allowed", lager.Data{
+	logger.Debug("allowed", lager.Data{
 		"rule": rule,
 	})
 
-	s.writeResponse(w, &protocol.NetOutResponse{})
+	return &protocol.NetOutResponse{}, nil
 }
 
 func (s *GardenServer) handleGetProperty(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("get-property", lager.Data{
+	hLog := s.session(r.Context(), "get-property", lager.Data{
 		"handle": handle,
 	})
 
@@ -816,42 +1104,51 @@ func (s *GardenServer) handleGetProperty(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	key := request.GetKey()
-
-	container, err := s.backend.Lookup(handle)
+	response, err := s.getProperty(r.Context(), hLog, handle, request.GetKey())
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeError(w, r, err, hLog)
 		return
 	}
 
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) getProperty(ctx context.Context, logger lager.Logger, handle string, key string) (*protocol.GetPropertyResponse, error) {
+	container, err := s.backendFor(ctx).Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("get-property", lager.Data{
+	logger.Debug("get-property", lager.Data{
 		"key": key,
 	})
 
+	unlock := s.propertyLocks.rlock(container.Handle())
+	defer unlock()
+
 	value, err := container.GetProperty(key)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
-	hLog.Info("got-property", lager.Data{
+	logger.Info("got-property", lager.Data{
 		"key":   key,
 		"value": value,
 	})
 
-	s.writeResponse(w, &protocol.GetPropertyResponse{
+	return &protocol.GetPropertyResponse{
 		Value: proto.String(value),
-	})
+	}, nil
 }
 
 func (s *GardenServer) handleSetProperty(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 	key := r.FormValue(":key")
 
-	hLog := s.logger.Session("set-property", lager.Data{
+	hLog := s.session(r.Context(), "set-property", lager.Data{
 		"handle": handle,
 	})
 
@@ -860,40 +1157,49 @@ func (s *GardenServer) handleSetProperty(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	value := request.GetValue()
-
-	container, err := s.backend.Lookup(handle)
+	response, err := s.setProperty(r.Context(), hLog, handle, key, request.GetValue())
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeError(w, r, err, hLog)
 		return
 	}
 
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) setProperty(ctx context.Context, logger lager.Logger, handle string, key string, value string) (*protocol.SetPropertyResponse, error) {
+	container, err := s.backendFor(ctx).Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("set-property", lager.Data{
+	logger.Debug("set-property", lager.Data{
 		"key":   key,
 		"value": value,
 	})
 
+	unlock := s.propertyLocks.lock(container.Handle())
+	defer unlock()
+
 	err = container.SetProperty(key, value)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
-	hLog.Info("set-property-complete", lager.Data{
+	logger.Info("set-property-complete", lager.Data{
 		"key":   key,
 		"value": value,
 	})
 
-	s.writeResponse(w, &protocol.SetPropertyResponse{})
+	return &protocol.SetPropertyResponse{}, nil
 }
 
 func (s *GardenServer) handleRemoveProperty(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("remove-property", lager.Data{
+	hLog := s.session(r.Context(), "remove-property", lager.Data{
 		"handle": handle,
 	})
 
@@ -902,225 +1208,979 @@ func (s *GardenServer) handleRemoveProperty(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	key := request.GetKey()
-
-	container, err := s.backend.Lookup(handle)
+	response, err := s.removeProperty(r.Context(), hLog, handle, request.GetKey())
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeError(w, r, err, hLog)
 		return
 	}
 
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) removeProperty(ctx context.Context, logger lager.Logger, handle string, key string) (*protocol.RemovePropertyResponse, error) {
+	container, err := s.backendFor(ctx).Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	hLog.Debug("remove-property", lager.Data{
+	logger.Debug("remove-property", lager.Data{
 		"key": key,
 	})
 
+	unlock := s.propertyLocks.lock(container.Handle())
+	defer unlock()
+
 	err = container.RemoveProperty(key)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
-	hLog.Info("removed-property", lager.Data{
+	logger.Info("removed-property", lager.Data{
 		"key": key,
 	})
 
-	s.writeResponse(w, &protocol.RemovePropertyResponse{})
+	return &protocol.RemovePropertyResponse{}, nil
 }
 
-func (s *GardenServer) handleRun(w http.ResponseWriter, r *http.Request) {
+func (s *GardenServer) handleProperties(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	hLog := s.logger.Session("run", lager.Data{
+	hLog := s.session(r.Context(), "properties", lager.Data{
 		"handle": handle,
 	})
 
-	var request protocol.RunRequest
-	if !s.readRequest(&request, w, r) {
+	response, err := s.properties(r.Context(), hLog, handle)
+	if err != nil {
+		s.writeError(w, r, err, hLog)
 		return
 	}
 
-	path := request.GetPath()
-	args := request.GetArgs()
-	dir := request.GetDir()
-	privileged := request.GetPrivileged()
-	user := request.GetUser()
-	env := request.GetEnv()
-	tty := request.GetTty()
+	s.writeResponse(w, r, response)
+}
 
-	container, err := s.backend.Lookup(handle)
+func (s *GardenServer) properties(ctx context.Context, logger lager.Logger, handle string) (*protocol.PropertiesResponse, error) {
+	container, err := s.backendFor(ctx).Lookup(handle)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	processSpec := garden.ProcessSpec{
-		Path:       path,
-		Args:       args,
-		Dir:        dir,
-		Privileged: privileged,
-		User:       user,
-		Env:        convertEnv(env),
-		TTY:        ttySpecFrom(tty),
-	}
+	logger.Debug("properties")
 
-	if request.Rlimits != nil {
-		processSpec.Limits = resourceLimits(request.Rlimits)
+	unlock := s.propertyLocks.rlock(container.Handle())
+	defer unlock()
+
+	properties, err := containerProperties(container)
+	if err != nil {
+		return nil, err
 	}
 
-	hLog.Debug("running", lager.Data{
-		"spec": processSpec,
-	})
+	logger.Info("got-properties")
+
+	return &protocol.PropertiesResponse{
+		Properties: protocolProperties(properties),
+	}, nil
+}
 
-	stdout := make(chan []byte, 1000)
-	stderr := make(chan []byte, 1000)
+func (s *GardenServer) handleSetProperties(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
 
-	stdinR, stdinW := io.Pipe()
+	hLog := s.session(r.Context(), "set-properties", lager.Data{
+		"handle": handle,
+	})
 
-	processIO := garden.ProcessIO{
-		Stdin:  stdinR,
-		Stdout: &chanWriter{stdout},
-		Stderr: &chanWriter{stderr},
+	var request protocol.SetPropertiesRequest
+	if !s.readRequest(&request, w, r) {
+		return
 	}
 
-	process, err := container.Run(processSpec, processIO)
+	response, err := s.setProperties(
+		r.Context(),
+		hLog,
+		handle,
+		request.GetExpected(),
+		gardenProperties(request.GetProperties()),
+		request.GetRemovals(),
+	)
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeError(w, r, err, hLog)
 		return
 	}
 
-	hLog.Info("spawned", lager.Data{
-		"spec": processSpec,
-		"id":   process.ID(),
-	})
+	s.writeResponse(w, r, response)
+}
 
-	w.WriteHeader(http.StatusCreated)
-	w.Header().Set("Content-Type", "application/json")
+// setProperties sets and removes the container's properties atomically, if
+// they meet the expected selector. If the backend cannot, they are compared
+// and changed one at a time while holding the lock of the container's
+// properties.
+func (s *GardenServer) setProperties(ctx context.Context, logger lager.Logger, handle string, expected string, properties garden.Properties, removals []string) (*protocol.SetPropertiesResponse, error) {
+	selector, err := garden.ParseSelector(expected)
+	if err != nil {
+		return nil, malformedRequestError{err}
+	}
 
-	conn, br, err := w.(http.Hijacker).Hijack()
+	err = validPropertyChanges(properties, removals)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		stdinW.Close()
-		return
+		return nil, malformedRequestError{err}
 	}
 
-	defer conn.Close()
+	container, err := s.backendFor(ctx).Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
 
-	transport.WriteMessage(conn, &protocol.ProcessPayload{
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+
+	logger.Debug("set-properties", lager.Data{
+		"expected": expected,
+		"keys":     keys,
+		"removals": removals,
+	})
+
+	if len(selector) > 0 {
+		err = container.CompareAndSetProperties(selector, properties, removals)
+	} else {
+		err = container.SetProperties(properties, removals)
+	}
+
+	if err == garden.ErrNotImplemented {
+		unlock := s.propertyLocks.lock(container.Handle())
+		defer unlock()
+
+		err = compareAndSetEach(logger, container, selector, properties, removals)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("set-properties-complete", lager.Data{
+		"keys":     keys,
+		"removals": removals,
+	})
+
+	return &protocol.SetPropertiesResponse{}, nil
+}
+
+func (s *GardenServer) handleRun(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r.Context(), "run", lager.Data{
+		"handle": handle,
+	})
+
+	var request protocol.RunRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	container, err := s.backendFor(r.Context()).Lookup(handle)
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	process, output, stdinW, err := s.runProcess(hLog, container, &request)
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		return
+	}
+
+	contentType := transport.NegotiateContentType(r.Header.Get("Accept"))
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set(transport.StreamFeaturesHeader, streamFeatures)
+	w.WriteHeader(http.StatusCreated)
+
+	conn, br, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		stdinW.Close()
+		return
+	}
+
+	defer conn.Close()
+
+	messages := transport.NewMessageWriter(conn, contentType)
+
+	messages.WriteMessage(&protocol.ProcessPayload{
 		ProcessId: proto.Uint32(process.ID()),
 	})
 
-	go s.streamInput(json.NewDecoder(br), stdinW, process)
+	control := newStreamControl(r)
+
+	go s.streamInput(transport.NewMessageReader(br, contentType), stdinW, process, control)
+
+	s.processes.Attached(container.Handle(), process.ID())
+	defer s.processes.Detached(container.Handle(), process.ID())
+
+	s.streamProcess(hLog, messages, container.Handle(), process, output, 0, control, stdinW)
+}
+
+func (s *GardenServer) handleAttach(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	var processID uint32
+
+	hLog := s.session(r.Context(), "attach", lager.Data{
+		"handle": handle,
+	})
+
+	_, err := fmt.Sscanf(r.FormValue(":pid"), "%d", &processID)
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		return
+	}
+
+	var offset uint64
+
+	replay := r.FormValue("offset") != ""
+	if replay {
+		_, err := fmt.Sscanf(r.FormValue("offset"), "%d", &offset)
+		if err != nil {
+			s.writeError(w, r, err, hLog)
+			return
+		}
+	}
+
+	container, err := s.backendFor(r.Context()).Lookup(handle)
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	process, output, offset, stdinW, err := s.attachProcess(hLog, container, processID, replay, offset)
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		return
+	}
+
+	contentType := transport.NegotiateContentType(r.Header.Get("Accept"))
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set(transport.StreamFeaturesHeader, streamFeatures)
+	w.WriteHeader(http.StatusOK)
+
+	conn, br, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		stdinW.Close()
+		return
+	}
+
+	defer conn.Close()
+
+	messages := transport.NewMessageWriter(conn, contentType)
+
+	control := newStreamControl(r)
+
+	go s.streamInput(transport.NewMessageReader(br, contentType), stdinW, process, control)
+
+	s.processes.Attached(container.Handle(), process.ID())
+	defer s.processes.Detached(container.Handle(), process.ID())
+
+	s.streamProcess(hLog, messages, container.Handle(), process, output, offset, control, stdinW)
+}
+
+func (s *GardenServer) handleRunWebSocket(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r.Context(), "run-websocket", lager.Data{
+		"handle": handle,
+	})
+
+	container, err := s.backendFor(r.Context()).Lookup(handle)
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	s.serveWebSocket(w, r, func(conn *websocket.Conn) {
+		messages := webSocketMessages{conn}
+
+		var request protocol.RunRequest
+		err := messages.ReadMessage(&request)
+		if err != nil {
+			hLog.Error("failed-to-read-request", err)
+			return
+		}
+
+		process, output, stdinW, err := s.runProcess(hLog, container, &request)
+		if err != nil {
+			hLog.Error("failed", err)
+
+			messages.WriteMessage(&protocol.ProcessPayload{
+				ProcessId: proto.Uint32(0),
+				Error:     proto.String(err.Error()),
+			})
+
+			return
+		}
+
+		messages.WriteMessage(&protocol.ProcessPayload{
+			ProcessId: proto.Uint32(process.ID()),
+		})
+
+		control := newStreamControl(r)
+
+		go s.streamInput(messages, stdinW, process, control)
+
+		s.processes.Attached(container.Handle(), process.ID())
+		defer s.processes.Detached(container.Handle(), process.ID())
+
+		s.streamProcess(hLog, messages, container.Handle(), process, output, 0, control, stdinW)
+	})
+}
+
+func (s *GardenServer) handleAttachWebSocket(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	var processID uint32
+
+	hLog := s.session(r.Context(), "attach-websocket", lager.Data{
+		"handle": handle,
+	})
+
+	_, err := fmt.Sscanf(r.FormValue(":pid"), "%d", &processID)
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		return
+	}
+
+	var offset uint64
+
+	replay := r.FormValue("offset") != ""
+	if replay {
+		_, err := fmt.Sscanf(r.FormValue("offset"), "%d", &offset)
+		if err != nil {
+			s.writeError(w, r, err, hLog)
+			return
+		}
+	}
+
+	container, err := s.backendFor(r.Context()).Lookup(handle)
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	s.serveWebSocket(w, r, func(conn *websocket.Conn) {
+		messages := webSocketMessages{conn}
+
+		process, output, offset, stdinW, err := s.attachProcess(hLog, container, processID, replay, offset)
+		if err != nil {
+			hLog.Error("failed", err)
+
+			messages.WriteMessage(&protocol.ProcessPayload{
+				ProcessId: proto.Uint32(processID),
+				Error:     proto.String(err.Error()),
+			})
+
+			return
+		}
+
+		control := newStreamControl(r)
+
+		go s.streamInput(messages, stdinW, process, control)
+
+		s.processes.Attached(container.Handle(), process.ID())
+		defer s.processes.Detached(container.Handle(), process.ID())
+
+		s.streamProcess(hLog, messages, container.Handle(), process, output, offset, control, stdinW)
+	})
+}
+
+// runProcess runs the requested process with its output buffered for the
+// streams attached to it, returning the buffer and the process's stdin.
+func (s *GardenServer) runProcess(logger lager.Logger, container garden.Container, request *protocol.RunRequest) (garden.Process, *outputBuffer, *io.PipeWriter, error) {
+	processSpec := garden.ProcessSpec{
+		Path:       request.GetPath(),
+		Args:       request.GetArgs(),
+		Dir:        request.GetDir(),
+		Privileged: request.GetPrivileged(),
+		User:       request.GetUser(),
+		Env:        convertEnv(request.GetEnv()),
+		TTY:        ttySpecFrom(request.GetTty()),
+	}
+
+	if request.Rlimits != nil {
+		processSpec.Limits = resourceLimits(request.Rlimits)
+	}
+
+	logger.Debug("running", lager.Data{
+		"spec": processSpec,
+	})
+
+	output := newOutputBuffer(s.outputPolicy, 0, s.countDroppedOutput)
+
+	stdinR, stdinW := io.Pipe()
+
+	process, err := container.Run(processSpec, bufferedIO(stdinR, output))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	logger.Info("spawned", lager.Data{
+		"spec": processSpec,
+		"id":   process.ID(),
+	})
+
+	s.processes.Started(container.Handle(), process.ID(), processSpec)
+	s.processes.Buffered(container.Handle(), process.ID(), output)
+
+	return process, output, stdinW, nil
+}
+
+// attachProcess attaches to the process, returning the buffer its output is
+// streamed from, the offset to stream it from, and the process's stdin.
+// Unless replaying, the stream starts with the output that follows.
+func (s *GardenServer) attachProcess(logger lager.Logger, container garden.Container, processID uint32, replay bool, offset uint64) (garden.Process, *outputBuffer, uint64, *io.PipeWriter, error) {
+	stdinR, stdinW := io.Pipe()
+
+	logger.Debug("attaching", lager.Data{
+		"id":     processID,
+		"replay": replay,
+		"offset": offset,
+	})
+
+	var process garden.Process
+	var err error
+
+	output := s.processes.Output(container.Handle(), processID)
+	if output != nil {
+		// the output is already being buffered; stream it from there
+		process, err = container.Attach(processID, garden.ProcessIO{
+			Stdin:  stdinR,
+			Stdout: ioutil.Discard,
+			Stderr: ioutil.Discard,
+		})
+
+		if !replay {
+			offset = output.End()
+		}
+	} else {
+		output = newOutputBuffer(s.outputPolicy, offset, s.countDroppedOutput)
+
+		if replay {
+			process, err = container.AttachFrom(processID, offset, bufferedIO(stdinR, output))
+		}
+
+		if !replay || err == garden.ErrNotImplemented {
+			process, err = container.Attach(processID, bufferedIO(stdinR, output))
+		}
+
+		if err == nil {
+			s.processes.Buffered(container.Handle(), processID, output)
+		}
+	}
+
+	if err != nil {
+		stdinW.Close()
+		return nil, nil, 0, nil, err
+	}
+
+	logger.Info("attached", lager.Data{
+		"id": process.ID(),
+	})
+
+	return process, output, offset, stdinW, nil
+}
+
+func (s *GardenServer) handleSession(w http.ResponseWriter, r *http.Request) {
+	hLog := s.session(r.Context(), "session")
+
+	contentType := transport.NegotiateContentType(r.Header.Get("Accept"))
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set(transport.StreamFeaturesHeader, streamFeatures)
+	w.WriteHeader(http.StatusOK)
+
+	conn, br, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		return
+	}
+
+	defer conn.Close()
+
+	hLog.Debug("opened")
+
+	session := newProcessSession(transport.NewMessageWriter(conn, contentType))
+	defer session.Disconnect()
+
+	closed := make(chan struct{})
+	defer close(closed)
+
+	go func() {
+		select {
+		case <-s.stopping:
+			conn.Close()
+		case <-closed:
+		}
+	}()
+
+	reader := transport.NewMessageReader(br, contentType)
+
+	for {
+		payload := &protocol.ProcessPayload{}
+
+		err := reader.ReadMessage(payload)
+		if err != nil {
+			hLog.Debug("closed")
+			return
+		}
+
+		if payload.Open != nil {
+			go s.openSessionStream(hLog, session, r, payload)
+		} else if err := session.Deliver(payload); err != nil {
+			hLog.Error("ended-stream", err, lager.Data{
+				"id": payload.GetProcessId(),
+			})
+		}
+	}
+}
+
+// openSessionStream runs or attaches to the process as asked by an open
+// frame, and streams it in the session until it exits or either side closes
+// the stream.
+func (s *GardenServer) openSessionStream(logger lager.Logger, session *processSession, r *http.Request, frame *protocol.ProcessPayload) {
+	open := frame.GetOpen()
+	requestID := open.GetRequestId()
+
+	var handle string
+	switch {
+	case open.Run != nil:
+		handle = open.GetRun().GetHandle()
+	case open.Attach != nil:
+		handle = open.GetAttach().GetHandle()
+	default:
+		session.Refuse(requestID, 0, malformedRequestError{errors.New("nothing to open")})
+		return
+	}
+
+	hLog := logger.Session("open", lager.Data{
+		"handle": handle,
+	})
+
+	container, err := s.backendFor(r.Context()).Lookup(handle)
+	if err != nil {
+		hLog.Error("failed", err)
+		session.Refuse(requestID, 0, err)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	var process garden.Process
+	var output *outputBuffer
+	var offset uint64
+	var stdinW *io.PipeWriter
+	var input *transport.MessageQueue
+
+	if open.Run != nil {
+		process, output, stdinW, err = s.runProcess(hLog, container, open.GetRun())
+		if err != nil {
+			hLog.Error("failed", err)
+			session.Refuse(requestID, 0, err)
+			return
+		}
+
+		var reserved bool
+		input, reserved = session.Reserve(process.ID())
+		if !reserved {
+			// the process is running regardless; the client can attach to it
+			// elsewhere
+			err := fmt.Errorf("process %d is already streaming in this session", process.ID())
+			hLog.Error("failed", err)
+			session.Refuse(requestID, process.ID(), err)
+			return
+		}
+	} else {
+		processID := open.GetAttach().GetProcessId()
+
+		var reserved bool
+		input, reserved = session.Reserve(processID)
+		if !reserved {
+			err := fmt.Errorf("process %d is already streaming in this session", processID)
+			hLog.Error("failed", err)
+			session.Refuse(requestID, processID, err)
+			return
+		}
+
+		process, output, offset, stdinW, err = s.attachProcess(hLog, container, processID, open.Offset != nil, open.GetOffset())
+		if err != nil {
+			session.Release(processID)
+			hLog.Error("failed", err)
+			session.Refuse(requestID, processID, err)
+			return
+		}
+	}
+
+	defer session.End(process.ID())
+
+	session.Acknowledge(requestID, process.ID())
+
+	control := newStreamControl(r)
+
+	go s.streamInput(input, stdinW, process, control)
+
+	s.processes.Attached(container.Handle(), process.ID())
+	defer s.processes.Detached(container.Handle(), process.ID())
+
+	s.streamProcess(hLog, session, container.Handle(), process, output, offset, control, stdinW)
+}
+
+func (s *GardenServer) handleProcesses(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r.Context(), "processes", lager.Data{
+		"handle": handle,
+	})
+
+	response, err := s.listProcesses(r.Context(), hLog, handle)
+	if err != nil {
+		s.writeError(w, r, err, hLog)
+		return
+	}
+
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) listProcesses(ctx context.Context, logger lager.Logger, handle string) (*protocol.ProcessesResponse, error) {
+	container, err := s.backendFor(ctx).Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	logger.Debug("listing")
+
+	processes, err := container.Processes()
+	if err == garden.ErrNotImplemented {
+		processes = s.processes.Processes(container.Handle())
+	} else if err != nil {
+		return nil, err
+	} else {
+		for i, process := range processes {
+			processes[i].AttachedStreams = s.processes.AttachedStreams(container.Handle(), process.ID)
+		}
+	}
+
+	logger.Info("listed", lager.Data{
+		"count": len(processes),
+	})
+
+	response := &protocol.ProcessesResponse{
+		Processes: []*protocol.ProcessesResponse_ProcessInfo{},
+	}
+
+	for _, process := range processes {
+		response.Processes = append(response.Processes, processInfoMessage(process))
+	}
 
-	s.streamProcess(hLog, conn, process, stdout, stderr, stdinW)
+	return response, nil
 }
 
-func (s *GardenServer) handleAttach(w http.ResponseWriter, r *http.Request) {
+func (s *GardenServer) handleInfo(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
-	var processID uint32
-
-	hLog := s.logger.Session("attach", lager.Data{
+	hLog := s.session(r.Context(), "info", lager.Data{
 		"handle": handle,
 	})
 
-	_, err := fmt.Sscanf(r.FormValue(":pid"), "%d", &processID)
+	response, err := s.info(r.Context(), hLog, handle)
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeError(w, r, err, hLog)
 		return
 	}
 
-	container, err := s.backend.Lookup(handle)
+	s.writeResponse(w, r, response)
+}
+
+func (s *GardenServer) info(ctx context.Context, logger lager.Logger, handle string) (*protocol.InfoResponse, error) {
+	container, err := s.backendFor(ctx).Lookup(handle)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		return
+		return nil, err
 	}
 
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	stdout := make(chan []byte, 1000)
-	stderr := make(chan []byte, 1000)
+	logger.Debug("getting-info")
 
-	stdinR, stdinW := io.Pipe()
+	unlock := s.propertyLocks.rlock(container.Handle())
+	defer unlock()
 
-	processIO := garden.ProcessIO{
-		Stdin:  stdinR,
-		Stdout: &chanWriter{stdout},
-		Stderr: &chanWriter{stderr},
+	info, err := container.Info()
+	if err != nil {
+		return nil, err
 	}
 
-	hLog.Debug("attaching", lager.Data{
-		"id": processID,
+	logger.Info("got-info")
+
+	return infoResponse(info), nil
+}
+
+func (s *GardenServer) handleLookup(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.session(r.Context(), "lookup", lager.Data{
+		"handle": handle,
 	})
 
-	process, err := container.Attach(processID, processIO)
+	response, err := s.lookup(r.Context(), hLog, handle)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		stdinW.Close()
+		s.writeError(w, r, err, hLog)
 		return
 	}
 
-	hLog.Info("attached", lager.Data{
-		"id": process.ID(),
-	})
+	s.writeResponse(w, r, response)
+}
 
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
+func (s *GardenServer) lookup(ctx context.Context, logger lager.Logger, handle string) (*protocol.LookupResponse, error) {
+	container, err := s.backendFor(ctx).Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
 
-	conn, br, err := w.(http.Hijacker).Hijack()
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	unlock := s.propertyLocks.rlock(container.Handle())
+	defer unlock()
+
+	summary, err := containerSummary(container)
 	if err != nil {
-		s.writeError(w, err, hLog)
-		stdinW.Close()
-		return
+		return nil, err
 	}
 
-	defer conn.Close()
+	response := &protocol.LookupResponse{
+		Handle:     proto.String(summary.Handle),
+		Properties: protocolProperties(summary.Properties),
+	}
 
-	go s.streamInput(json.NewDecoder(br), stdinW, process)
+	if summary.State != "" {
+		response.State = proto.String(summary.State)
+	}
 
-	s.streamProcess(hLog, conn, process, stdout, stderr, stdinW)
+	return response, nil
 }
 
-func (s *GardenServer) handleInfo(w http.ResponseWriter, r *http.Request) {
-	handle := r.FormValue(":handle")
+func (s *GardenServer) handleBulkInfo(w http.ResponseWriter, r *http.Request) {
+	handles := r.URL.Query()["handles"]
+	if handles == nil {
+		handles = []string{}
+	}
 
-	hLog := s.logger.Session("info", lager.Data{
-		"handle": handle,
+	hLog := s.session(r.Context(), "bulk-info", lager.Data{
+		"handles": handles,
 	})
 
-	container, err := s.backend.Lookup(handle)
+	response, err := s.bulkInfo(r.Context(), hLog, handles)
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeError(w, r, err, hLog)
 		return
 	}
 
-	s.bomberman.Pause(container.Handle())
-	defer s.bomberman.Unpause(container.Handle())
+	s.writeResponse(w, r, response)
+}
 
-	hLog.Debug("getting-info")
+func (s *GardenServer) bulkInfo(ctx context.Context, logger lager.Logger, handles []string) (*protocol.BulkInfoResponse, error) {
+	for _, handle := range handles {
+		s.bomberman.Pause(handle)
+		defer s.bomberman.Unpause(handle)
+	}
 
-	info, err := container.Info()
+	logger.Debug("getting-info")
+
+	entries, err := s.backendFor(ctx).BulkInfo(handles)
+	if err == garden.ErrNotImplemented {
+		logger.Debug("falling-back-to-info")
+		entries = s.infoEach(ctx, handles)
+	} else if err != nil {
+		return nil, err
+	}
+
+	logger.Info("got-info")
+
+	response := &protocol.BulkInfoResponse{}
+	for handle, entry := range entries {
+		responseEntry := &protocol.BulkInfoResponse_ContainerInfoEntry{
+			Handle: proto.String(handle),
+		}
+
+		if entry.Err != nil {
+			_, responseEntry.Error = errorResponse(entry.Err)
+		} else {
+			responseEntry.Info = infoResponse(entry.Info)
+		}
+
+		response.Containers = append(response.Containers, responseEntry)
+	}
+
+	return response, nil
+}
+
+// infoEachWorkers is how many containers' info infoEach gets at once.
+const infoEachWorkers = 16
+
+// infoEach gets the info of each container in parallel, for backends with no
+// native BulkInfo.
+func (s *GardenServer) infoEach(ctx context.Context, handles []string) map[string]garden.ContainerInfoEntry {
+	entries := make(map[string]garden.ContainerInfoEntry, len(handles))
+	entriesL := new(sync.Mutex)
+
+	pending := make(chan string)
+
+	wg := new(sync.WaitGroup)
+
+	for i := 0; i < infoEachWorkers && i < len(handles); i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for handle := range pending {
+				var entry garden.ContainerInfoEntry
+
+				container, err := s.backendFor(ctx).Lookup(handle)
+				if err != nil {
+					entry.Err = err
+				} else {
+					entry.Info, entry.Err = container.Info()
+				}
+
+				entriesL.Lock()
+				entries[handle] = entry
+				entriesL.Unlock()
+			}
+		}()
+	}
+
+	for _, handle := range handles {
+		pending <- handle
+	}
+
+	close(pending)
+
+	wg.Wait()
+
+	return entries
+}
+
+func (s *GardenServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	properties := garden.Properties{}
+	for name, vals := range r.URL.Query() {
+		if len(vals) > 0 {
+			properties[name] = vals[0]
+		}
+	}
+
+	hLog := s.session(r.Context(), "events", lager.Data{
+		"properties": properties,
+	})
+
+	subscription := s.events.Subscribe(properties)
+	defer subscription.Close()
+
+	contentType := transport.NegotiateContentType(r.Header.Get("Accept"))
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+
+	conn, br, err := w.(http.Hijacker).Hijack()
 	if err != nil {
-		s.writeError(w, err, hLog)
+		s.writeError(w, r, err, hLog)
 		return
 	}
 
-	hLog.Info("got-info")
+	defer conn.Close()
+
+	hLog.Debug("streaming")
+
+	messages := transport.NewMessageWriter(conn, contentType)
+
+	disconnected := make(chan struct{})
+
+	go func() {
+		io.Copy(ioutil.Discard, br)
+		close(disconnected)
+	}()
+
+	for {
+		select {
+		case event := <-subscription.Events():
+			if !visible(r.Context(), event.Properties) {
+				continue
+			}
+
+			err := messages.WriteMessage(eventMessage(event))
+			if err != nil {
+				hLog.Error("failed-to-write", err)
+				return
+			}
+
+		case <-disconnected:
+			hLog.Info("disconnected")
+			return
+
+		case <-s.stopping:
+			hLog.Debug("detaching")
+			return
+		}
+	}
+}
+
+func eventMessage(event garden.Event) *protocol.Event {
+	return &protocol.Event{
+		Type:       protocol.Event_Type(event.Type).Enum(),
+		Handle:     proto.String(event.Handle),
+		Timestamp:  proto.Int64(event.Time.UnixNano()),
+		Properties: protocolProperties(event.Properties),
+		Data:       protocolProperties(event.Data),
+	}
+}
 
+func protocolProperties(props map[string]string) []*protocol.Property {
 	properties := []*protocol.Property{}
-	for key, val := range info.Properties {
+	for key, val := range props {
 		properties = append(properties, &protocol.Property{
 			Key:   proto.String(key),
 			Value: proto.String(val),
 		})
 	}
 
+	return properties
+}
+
+func infoResponse(info garden.ContainerInfo) *protocol.InfoResponse {
 	processIDs := make([]uint64, len(info.ProcessIDs))
 	for i, processID := range info.ProcessIDs {
 		processIDs[i] = uint64(processID)
@@ -1134,7 +2194,7 @@ func (s *GardenServer) handleInfo(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	s.writeResponse(w, &protocol.InfoResponse{
+	return &protocol.InfoResponse{
 		State:         proto.String(info.State),
 		Events:        info.Events,
 		HostIp:        proto.String(info.HostIP),
@@ -1143,7 +2203,7 @@ func (s *GardenServer) handleInfo(w http.ResponseWriter, r *http.Request) {
 		ContainerPath: proto.String(info.ContainerPath),
 		ProcessIds:    processIDs,
 
-		Properties: properties,
+		Properties: protocolProperties(info.Properties),
 
 		MemoryStat: &protocol.InfoResponse_MemoryStat{
 			Cache:                   proto.Uint64(info.MemoryStat.Cache),
@@ -1195,7 +2255,7 @@ func (s *GardenServer) handleInfo(w http.ResponseWriter, r *http.Request) {
 		},
 
 		MappedPorts: mappedPorts,
-	})
+	}
 }
 
 func resourceLimits(limits *protocol.ResourceLimits) garden.ResourceLimits {
@@ -1218,39 +2278,132 @@ func resourceLimits(limits *protocol.ResourceLimits) garden.ResourceLimits {
 	}
 }
 
-func (s *GardenServer) writeError(w http.ResponseWriter, err error, logger lager.Logger) {
+func (s *GardenServer) writeError(w http.ResponseWriter, r *http.Request, err error, logger lager.Logger) {
 	logger.Error("failed", err)
 
-	statusCode := http.StatusInternalServerError
-	if _, ok := err.(garden.ContainerNotFoundError); ok {
-		statusCode = http.StatusNotFound
-	}
+	statusCode, response := errorResponse(err)
+
+	contentType := transport.NegotiateContentType(r.Header.Get("Accept"))
 
-	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(statusCode)
-	w.Write([]byte(err.Error()))
+	transport.NewMessageWriter(w, contentType).WriteMessage(response)
 }
 
-func (s *GardenServer) writeResponse(w http.ResponseWriter, msg proto.Message) {
-	w.Header().Set("Content-Type", "application/json")
-	transport.WriteMessage(w, msg)
+func (s *GardenServer) writeResponse(w http.ResponseWriter, r *http.Request, msg proto.Message) {
+	contentType := transport.NegotiateContentType(r.Header.Get("Accept"))
+
+	w.Header().Set("Content-Type", contentType)
+	transport.NewMessageWriter(w, contentType).WriteMessage(msg)
 }
 
 func (s *GardenServer) readRequest(msg proto.Message, w http.ResponseWriter, r *http.Request) bool {
-	if r.Header.Get("Content-Type") != "application/json" {
-		s.writeError(w, ErrInvalidContentType, s.logger)
+	contentType := r.Header.Get("Content-Type")
+	if contentType != transport.JSONContentType && contentType != transport.ProtobufContentType {
+		s.writeError(w, r, garden.InvalidContentTypeError{ContentType: contentType}, s.logger)
 		return false
 	}
 
-	err := json.NewDecoder(r.Body).Decode(msg)
+	err := transport.NewMessageReader(r.Body, contentType).ReadMessage(msg)
 	if err != nil {
-		s.writeError(w, err, s.logger)
+		s.writeError(w, r, malformedRequestError{err}, s.logger)
 		return false
 	}
 
 	return true
 }
 
+func errorResponse(err error) (int, *protocol.ErrorResponse) {
+	response := &protocol.ErrorResponse{
+		Message: proto.String(err.Error()),
+	}
+
+	var statusCode int
+	var errorType protocol.ErrorResponse_Type
+
+	switch e := err.(type) {
+	case garden.ContainerNotFoundError:
+		statusCode = http.StatusNotFound
+		errorType = protocol.ErrorResponse_ContainerNotFound
+		response.Data = proto.String(e.Handle)
+	case garden.ConcurrentDestroyError:
+		statusCode = http.StatusConflict
+		errorType = protocol.ErrorResponse_ConcurrentDestroy
+		response.Data = proto.String(e.Handle)
+	case garden.InvalidContentTypeError:
+		statusCode = http.StatusUnsupportedMediaType
+		errorType = protocol.ErrorResponse_InvalidContentType
+		response.Data = proto.String(e.ContentType)
+	case garden.CapacityExhaustedError:
+		statusCode = http.StatusServiceUnavailable
+		errorType = protocol.ErrorResponse_CapacityExhausted
+	case garden.UnauthenticatedError:
+		statusCode = http.StatusUnauthorized
+		errorType = protocol.ErrorResponse_Unauthenticated
+	case garden.ForbiddenError:
+		statusCode = http.StatusForbidden
+		errorType = protocol.ErrorResponse_Forbidden
+		response.Data = proto.String(e.Operation)
+	case garden.DrainingError:
+		statusCode = http.StatusServiceUnavailable
+		errorType = protocol.ErrorResponse_Draining
+	case garden.PropertyConflictError:
+		statusCode = http.StatusConflict
+		errorType = protocol.ErrorResponse_PropertyConflict
+		response.Data = proto.String(e.Requirement)
+	case malformedRequestError:
+		statusCode = http.StatusBadRequest
+		errorType = protocol.ErrorResponse_Unknown
+	default:
+		statusCode = http.StatusInternalServerError
+		errorType = protocol.ErrorResponse_BackendFailure
+	}
+
+	response.Type = errorType.Enum()
+
+	return statusCode, response
+}
+
+func processInfoMessage(process garden.ProcessInfo) *protocol.ProcessesResponse_ProcessInfo {
+	state := protocol.ProcessesResponse_ProcessInfo_running
+	if process.State == garden.ProcessStateExited {
+		state = protocol.ProcessesResponse_ProcessInfo_exited
+	}
+
+	var startedAt *int64
+	if !process.StartedAt.IsZero() {
+		startedAt = proto.Int64(process.StartedAt.UnixNano())
+	}
+
+	return &protocol.ProcessesResponse_ProcessInfo{
+		ProcessId:       proto.Uint32(process.ID),
+		Path:            proto.String(process.Spec.Path),
+		Args:            process.Spec.Args,
+		Dir:             proto.String(process.Spec.Dir),
+		User:            proto.String(process.Spec.User),
+		Privileged:      proto.Bool(process.Spec.Privileged),
+		Env:             redactedEnv(process.Spec.Env),
+		StartedAt:       startedAt,
+		State:           &state,
+		ExitStatus:      proto.Uint32(uint32(process.ExitStatus)),
+		AttachedStreams: proto.Uint32(uint32(process.AttachedStreams)),
+	}
+}
+
+// redactedEnv keeps only the names of environment variables, as their values
+// often hold credentials.
+func redactedEnv(env []string) []*protocol.EnvironmentVariable {
+	redacted := []*protocol.EnvironmentVariable{}
+	for _, variable := range env {
+		redacted = append(redacted, &protocol.EnvironmentVariable{
+			Key:   proto.String(strings.SplitN(variable, "=", 2)[0]),
+			Value: proto.String("[redacted]"),
+		})
+	}
+
+	return redacted
+}
+
 func convertEnv(env []*protocol.EnvironmentVariable) []string {
 	converted := []string{}
 
@@ -1261,12 +2414,60 @@ func convertEnv(env []*protocol.EnvironmentVariable) []string {
 	return converted
 }
 
-func (s *GardenServer) streamInput(decoder *json.Decoder, in *io.PipeWriter, process garden.Process) {
+// streamControl carries what the input side of a process stream learns from
+// the client over to the output side.
+type streamControl struct {
+	// credit granted by the client, if it controls the flow of output
+	window *outputWindow
+
+	// closed once the client has gone away
+	disconnected chan struct{}
+
+	// whether the client accepts output as raw_data
+	binaryData bool
+}
+
+func newStreamControl(r *http.Request) *streamControl {
+	return streamControlFor(
+		requestsStreamFeature(r, transport.FeatureBinaryData),
+		requestsStreamFeature(r, transport.FeatureFlowControl),
+	)
+}
+
+func streamControlFor(binaryData, flowControl bool) *streamControl {
+	control := &streamControl{
+		disconnected: make(chan struct{}),
+
+		binaryData: binaryData,
+	}
+
+	if flowControl {
+		control.window = newOutputWindow()
+	}
+
+	return control
+}
+
+// requestsStreamFeature returns whether the client opted in to the stream
+// feature, in the request's headers or, for browsers that cannot set them on
+// WebSockets, its features query parameter.
+func requestsStreamFeature(r *http.Request, feature string) bool {
+	if transport.HasStreamFeature(r.Header, feature) {
+		return true
+	}
+
+	query := http.Header{transport.StreamFeaturesHeader: r.URL.Query()["features"]}
+
+	return transport.HasStreamFeature(query, feature)
+}
+
+func (s *GardenServer) streamInput(reader transport.MessageReader, in *io.PipeWriter, process garden.Process, control *streamControl) {
 	for {
 		var payload protocol.ProcessPayload
-		err := decoder.Decode(&payload)
+		err := reader.ReadMessage(&payload)
 		if err != nil {
 			in.CloseWithError(errors.New("Connection closed"))
+			close(control.disconnected)
 			return
 		}
 
@@ -1275,42 +2476,56 @@ func (s *GardenServer) streamInput(decoder *json.Decoder, in *io.PipeWriter, pro
 			process.SetTTY(*ttySpecFrom(payload.GetTty()))
 
 		case payload.Source != nil:
-			if payload.Data == nil {
+			if payload.Data == nil && payload.RawData == nil {
+				// keep reading; the client may still signal or grant credit
 				in.Close()
-				return
 			} else {
-				_, err := in.Write([]byte(payload.GetData()))
+				_, err := in.Write(payloadData(&payload))
 				if err != nil {
-					return
+					// the process no longer reads stdin; drop the data, as the
+					// client may still signal or grant credit
+					s.logger.Error("stream-input-write-failed", err)
 				}
 			}
 
 		case payload.Signal != nil:
-			switch payload.GetSignal() {
-			case protocol.ProcessPayload_kill:
-				process.Signal(garden.SignalKill)
-			case protocol.ProcessPayload_terminate:
-				process.Signal(garden.SignalTerminate)
-			default:
+			signal, found := signals[payload.GetSignal()]
+			if !found {
+				// sent by a newer client; leave the stream usable
 				s.logger.Error("stream-input-unknown-process-payload-signal", nil, lager.Data{"payload": payload})
-				in.Close()
-				return
+				continue
+			}
+
+			err := process.Signal(signal)
+			if err != nil {
+				s.logger.Error("stream-input-signal-failed", err, lager.Data{"signal": signal.String()})
+			}
+
+		case payload.WindowUpdate != nil:
+			if control.window != nil {
+				control.window.Grant(payload.GetWindowUpdate())
 			}
 
 		default:
+			// sent by a newer client; leave the stream usable
 			s.logger.Error("stream-input-unknown-process-payload", nil, lager.Data{"payload": payload})
-			in.Close()
-			return
+			continue
 		}
 	}
 }
 
-func (s *GardenServer) streamProcess(logger lager.Logger, conn net.Conn, process garden.Process, stdout <-chan []byte, stderr <-chan []byte, stdinPipe *io.PipeWriter) {
-	statusCh := make(chan int, 1)
+func (s *GardenServer) streamProcess(logger lager.Logger, messages transport.MessageWriter, handle string, process garden.Process, output *outputBuffer, offset uint64, control *streamControl, stdinPipe *io.PipeWriter) {
+	s.metrics.processStreams.Add(1)
+	defer s.metrics.processStreams.Add(-1)
+
+	s.streamAttached()
+	defer s.streamDetached()
+
+	exitCh := make(chan garden.ExitInfo, 1)
 	errCh := make(chan error, 1)
 
 	go func() {
-		status, err := process.Wait()
+		exitInfo, err := s.waitForExit(handle, process)
 		if err != nil {
 			logger.Error("wait-failed", err, lager.Data{
 				"id": process.ID(),
@@ -1319,48 +2534,48 @@ func (s *GardenServer) streamProcess(logger lager.Logger, conn net.Conn, process
 			errCh <- err
 		} else {
 			logger.Info("exited", lager.Data{
-				"status": status,
+				"status": exitInfo.ExitStatus,
 				"id":     process.ID(),
 			})
 
-			statusCh <- status
+			s.processes.Exited(handle, process.ID(), exitInfo.ExitStatus)
+
+			exitCh <- exitInfo
 		}
 	}()
 
-	stdoutSource := protocol.ProcessPayload_stdout
-	stderrSource := protocol.ProcessPayload_stderr
+	reader := output.Reader(offset)
+	defer reader.Close()
 
 	for {
-		select {
-		case data := <-stdout:
-			transport.WriteMessage(conn, &protocol.ProcessPayload{
-				ProcessId: proto.Uint32(process.ID()),
-				Source:    &stdoutSource,
-				Data:      proto.String(string(data)),
-			})
+		written, ok := s.sendOutput(logger, messages, process, reader, control)
+		if !ok {
+			return
+		}
 
-		case data := <-stderr:
-			transport.WriteMessage(conn, &protocol.ProcessPayload{
-				ProcessId: proto.Uint32(process.ID()),
-				Source:    &stderrSource,
-				Data:      proto.String(string(data)),
-			})
+		select {
+		case <-written:
 
-		case status := <-statusCh:
-			flushProcess(conn, process, stdout, stderr)
+		case exitInfo := <-exitCh:
+			if _, ok := s.sendOutput(logger, messages, process, reader, control); !ok {
+				return
+			}
 
-			transport.WriteMessage(conn, &protocol.ProcessPayload{
+			messages.WriteMessage(&protocol.ProcessPayload{
 				ProcessId:  proto.Uint32(process.ID()),
-				ExitStatus: proto.Uint32(uint32(status)),
+				ExitStatus: proto.Uint32(uint32(exitInfo.ExitStatus)),
+				ExitInfo:   exitInfoMessage(exitInfo),
 			})
 
 			stdinPipe.Close()
 			return
 
 		case err := <-errCh:
-			flushProcess(conn, process, stdout, stderr)
+			if _, ok := s.sendOutput(logger, messages, process, reader, control); !ok {
+				return
+			}
 
-			transport.WriteMessage(conn, &protocol.ProcessPayload{
+			messages.WriteMessage(&protocol.ProcessPayload{
 				ProcessId: proto.Uint32(process.ID()),
 				Error:     proto.String(err.Error()),
 			})
@@ -1373,35 +2588,141 @@ func (s *GardenServer) streamProcess(logger lager.Logger, conn net.Conn, process
 				"id": process.ID(),
 			})
 
+			return
+
+		case <-s.drain.expired:
+			logger.Info("detaching-drained", lager.Data{
+				"id": process.ID(),
+			})
+
+			return
+
+		case <-control.disconnected:
+			logger.Debug("disconnected", lager.Data{
+				"id": process.ID(),
+			})
+
 			return
 		}
 	}
 }
 
-func flushProcess(conn net.Conn, process garden.Process, stdout <-chan []byte, stderr <-chan []byte) {
-	stdoutSource := protocol.ProcessPayload_stdout
-	stderrSource := protocol.ProcessPayload_stderr
+// waitForExit falls back to the exit status and the time since the server
+// started the process for backends that cannot report more.
+func (s *GardenServer) waitForExit(handle string, process garden.Process) (garden.ExitInfo, error) {
+	exitInfo, err := process.WaitForExit()
+	if err != garden.ErrNotImplemented {
+		return exitInfo, err
+	}
 
-	for {
-		select {
-		case data := <-stdout:
-			transport.WriteMessage(conn, &protocol.ProcessPayload{
-				ProcessId: proto.Uint32(process.ID()),
-				Source:    &stdoutSource,
-				Data:      proto.String(string(data)),
-			})
+	status, err := process.Wait()
+	if err != nil {
+		return garden.ExitInfo{}, err
+	}
+
+	exitInfo = garden.ExitInfo{ExitStatus: status}
+
+	startedAt := s.processes.StartedAt(handle, process.ID())
+	if !startedAt.IsZero() {
+		exitInfo.Duration = time.Since(startedAt)
+	}
+
+	return exitInfo, nil
+}
+
+func exitInfoMessage(exitInfo garden.ExitInfo) *protocol.ProcessPayload_ExitInfo {
+	message := &protocol.ProcessPayload_ExitInfo{
+		OomKilled: proto.Bool(exitInfo.OOMKilled),
+		Duration:  proto.Int64(int64(exitInfo.Duration)),
+	}
 
-		case data := <-stderr:
-			transport.WriteMessage(conn, &protocol.ProcessPayload{
+	if exitInfo.Signal != nil {
+		for payloadSignal, signal := range signals {
+			if signal == *exitInfo.Signal {
+				message.Signal = payloadSignal.Enum()
+			}
+		}
+	}
+
+	return message
+}
+
+// sendOutput sends the output the reader has yet to read, as far as the
+// client's credit allows, and returns a channel that is closed once more has
+// been written. It gives up if the client goes away or the server stops.
+func (s *GardenServer) sendOutput(logger lager.Logger, messages transport.MessageWriter, process garden.Process, reader *outputReader, control *streamControl) (<-chan struct{}, bool) {
+	chunks, written := reader.Read()
+
+	offset := reader.Offset()
+	if len(chunks) > 0 && chunks[0].Offset > offset {
+		logger.Info("output-discarded", lager.Data{
+			"id":    process.ID(),
+			"bytes": chunks[0].Offset - offset,
+		})
+	}
+
+	for _, chunk := range chunks {
+		data := chunk.Data
+
+		for len(data) > 0 {
+			length := uint64(len(data))
+
+			if control.window != nil {
+				taken, granted := control.window.Take(length)
+				if taken == 0 {
+					select {
+					case <-granted:
+						continue
+					case <-control.disconnected:
+						return nil, false
+					case <-s.stopping:
+						return nil, false
+					}
+				}
+
+				length = taken
+			}
+
+			payload := &protocol.ProcessPayload{
 				ProcessId: proto.Uint32(process.ID()),
-				Source:    &stderrSource,
-				Data:      proto.String(string(data)),
-			})
+				Source:    chunk.Source.Enum(),
+				Offset:    proto.Uint64(chunk.Offset),
+			}
 
-		default:
-			return
+			if control.binaryData {
+				payload.RawData = data[:length]
+			} else {
+				payload.Data = proto.String(string(data[:length]))
+			}
+
+			messages.WriteMessage(payload)
+
+			chunk.Offset += length
+			data = data[length:]
+
+			reader.Advance(chunk.Offset)
 		}
 	}
+
+	return written, true
+}
+
+// payloadData returns the data sent in the payload, whether as raw_data by a
+// client that sends binary data or as data.
+func payloadData(payload *protocol.ProcessPayload) []byte {
+	if payload.RawData != nil {
+		return payload.GetRawData()
+	}
+
+	return []byte(payload.GetData())
+}
+
+func bufferedIO(stdin io.Reader, output *outputBuffer) garden.ProcessIO {
+	return garden.ProcessIO{
+		Stdin:  stdin,
+		Stdout: output.Writer(protocol.ProcessPayload_stdout),
+		Stderr: output.Writer(protocol.ProcessPayload_stderr),
+	}
 }
 
 func ttySpecFrom(tty *protocol.TTY) *garden.TTYSpec {
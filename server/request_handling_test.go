@@ -2,26 +2,34 @@ package server_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net"
+	"net/http"
 	"os"
 	"path"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
 	"github.com/pivotal-golang/lager/lagertest"
+	"golang.org/x/net/websocket"
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/garden/client"
 	"github.com/cloudfoundry-incubator/garden/client/connection"
 	"github.com/cloudfoundry-incubator/garden/fakes"
+	protocol "github.com/cloudfoundry-incubator/garden/protocol"
 	"github.com/cloudfoundry-incubator/garden/server"
+	"github.com/cloudfoundry-incubator/garden/transport"
 )
 
 var _ = Describe("When a client connects", func() {
@@ -135,6 +143,82 @@ var _ = Describe("When a client connects", func() {
 		})
 	})
 
+	Context("and the client negotiates the content type", func() {
+		var httpClient *http.Client
+
+		BeforeEach(func() {
+			serverBackend.CapacityReturns(garden.Capacity{MaxContainers: 42}, nil)
+
+			httpClient = &http.Client{
+				Transport: &http.Transport{
+					Dial: func(string, string) (net.Conn, error) {
+						return net.Dial("unix", socketPath)
+					},
+				},
+			}
+		})
+
+		getCapacity := func(accept string) *http.Response {
+			request, err := http.NewRequest("GET", "http://api/capacity", nil)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			if accept != "" {
+				request.Header.Set("Accept", accept)
+			}
+
+			response, err := httpClient.Do(request)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			return response
+		}
+
+		It("responds with JSON by default", func() {
+			response := getCapacity("")
+			defer response.Body.Close()
+
+			Ω(response.Header.Get("Content-Type")).Should(Equal(transport.JSONContentType))
+
+			var capacity protocol.CapacityResponse
+			err := json.NewDecoder(response.Body).Decode(&capacity)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(capacity.GetMaxContainers()).Should(Equal(uint64(42)))
+		})
+
+		It("responds with protobuf when it is accepted", func() {
+			response := getCapacity(transport.AcceptHeader)
+			defer response.Body.Close()
+
+			Ω(response.Header.Get("Content-Type")).Should(Equal(transport.ProtobufContentType))
+
+			var capacity protocol.CapacityResponse
+			err := transport.NewMessageReader(response.Body, transport.ProtobufContentType).ReadMessage(&capacity)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(capacity.GetMaxContainers()).Should(Equal(uint64(42)))
+		})
+
+		It("accepts requests encoded as protobuf", func() {
+			body := new(bytes.Buffer)
+			err := transport.WriteProtobufMessage(body, &protocol.CreateRequest{Handle: proto.String("some-handle")})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			serverBackend.CreateReturns(new(fakes.FakeContainer), nil)
+
+			request, err := http.NewRequest("POST", "http://api/containers", body)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			request.Header.Set("Content-Type", transport.ProtobufContentType)
+
+			response, err := httpClient.Do(request)
+			Ω(err).ShouldNot(HaveOccurred())
+			response.Body.Close()
+
+			Ω(response.StatusCode).Should(Equal(http.StatusOK))
+
+			Ω(serverBackend.CreateCallCount()).Should(Equal(1))
+			Ω(serverBackend.CreateArgsForCall(0).Handle).Should(Equal("some-handle"))
+		})
+	})
+
 	Context("and the client sends a CreateRequest", func() {
 		var fakeContainer *fakes.FakeContainer
 
@@ -243,6 +327,26 @@ var _ = Describe("When a client connects", func() {
 				})
 				Ω(err).Should(HaveOccurred())
 			})
+
+			It("returns a BackendError with the same message", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{
+					Handle: "some-handle",
+				})
+				Ω(err).Should(Equal(garden.BackendError{Message: "oh no!"}))
+			})
+		})
+
+		Context("when the backend is out of capacity", func() {
+			BeforeEach(func() {
+				serverBackend.CreateReturns(nil, garden.CapacityExhaustedError{Message: "too many containers"})
+			})
+
+			It("returns a CapacityExhaustedError", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{
+					Handle: "some-handle",
+				})
+				Ω(err).Should(Equal(garden.CapacityExhaustedError{Message: "too many containers"}))
+			})
 		})
 	})
 
@@ -273,7 +377,7 @@ var _ = Describe("When a client connects", func() {
 				<-destroying
 
 				err := apiClient.Destroy("some-handle")
-				Ω(err).Should(HaveOccurred())
+				Ω(err).Should(Equal(garden.ConcurrentDestroyError{Handle: "some-handle"}))
 
 				Ω(serverBackend.DestroyCallCount()).Should(Equal(1))
 			})
@@ -379,219 +483,1143 @@ var _ = Describe("When a client connects", func() {
 		})
 	})
 
-	Context("when a container has been created", func() {
-		var container garden.Container
+	Context("and the client lists a page of containers", func() {
+		Context("when the backend implements List", func() {
+			BeforeEach(func() {
+				container := new(fakes.FakeContainer)
+				container.HandleReturns("some-handle")
 
-		var fakeContainer *fakes.FakeContainer
+				serverBackend.ListReturns(garden.ContainerPage{
+					Containers: []garden.Container{container},
+					NextCursor: "backend-cursor",
+				}, nil)
+			})
 
-		BeforeEach(func() {
-			fakeContainer = new(fakes.FakeContainer)
-			fakeContainer.HandleReturns("some-handle")
+			It("returns the backend's page", func() {
+				options := garden.ListOptions{
+					Selector: garden.Selector{{Key: "owner", Operator: garden.SelectorExists}},
+					SortBy:   "owner",
+					Limit:    1,
+					Cursor:   "some-cursor",
+				}
 
-			serverBackend.CreateReturns(fakeContainer, nil)
-			serverBackend.LookupReturns(fakeContainer, nil)
-		})
+				listed := serverBackend.ContainersCallCount()
 
-		JustBeforeEach(func() {
-			var err error
+				page, err := apiClient.List(options)
+				Ω(err).ShouldNot(HaveOccurred())
 
-			container, err = apiClient.Create(garden.ContainerSpec{})
-			Ω(err).ShouldNot(HaveOccurred())
-		})
+				Ω(page.Containers).Should(HaveLen(1))
+				Ω(page.Containers[0].Handle()).Should(Equal("some-handle"))
+				Ω(page.NextCursor).Should(Equal("backend-cursor"))
 
-		itResetsGraceTimeWhenHandling := func(call func()) {
-			Context("when created with a grace time", func() {
-				graceTime := 200 * time.Millisecond
+				Ω(serverBackend.ListArgsForCall(0)).Should(Equal(options))
+				Ω(serverBackend.ContainersCallCount()).Should(Equal(listed))
+			})
 
-				BeforeEach(func() {
-					serverBackend.GraceTimeReturns(graceTime)
-				})
+			Context("when summarizing the containers", func() {
+				It("returns the backend's summaries", func() {
+					container := new(fakes.FakeContainer)
+					container.HandleReturns("some-handle")
 
-				It("resets the container's grace time", func() {
-					for i := 0; i < 11; i++ {
-						time.Sleep(graceTime / 10)
-						call()
+					summaries := []garden.ContainerSummary{
+						{Handle: "some-handle", State: "active", Properties: garden.Properties{"owner": "me"}},
 					}
 
-					before := time.Now()
+					serverBackend.ListReturns(garden.ContainerPage{
+						Containers: []garden.Container{container},
+						Summaries:  summaries,
+					}, nil)
 
-					Eventually(serverBackend.DestroyCallCount, 2*graceTime).Should(Equal(1))
-					Ω(serverBackend.DestroyArgsForCall(0)).Should(Equal(container.Handle()))
+					page, err := apiClient.List(garden.ListOptions{Summarize: true})
+					Ω(err).ShouldNot(HaveOccurred())
 
-					Ω(time.Since(before)).Should(BeNumerically("~", graceTime, 20*time.Millisecond))
+					Ω(page.Summaries).Should(Equal(summaries))
+					Ω(serverBackend.ListArgsForCall(0).Summarize).Should(BeTrue())
+					Ω(serverBackend.BulkInfoCallCount()).Should(BeZero())
 				})
-			})
-		}
 
-		itFailsWhenTheContainerIsNotFound := func(example func()) {
-			Context("when the container is not found", func() {
-				It("fails", func() {
-					serverBackend.LookupReturns(nil, errors.New("not found"))
-					example()
+				It("reads the containers' info if the backend did not summarize them", func() {
+					serverBackend.BulkInfoReturns(map[string]garden.ContainerInfoEntry{
+						"some-handle": {Info: garden.ContainerInfo{State: "stopped", Properties: garden.Properties{"owner": "you"}}},
+					}, nil)
+
+					page, err := apiClient.List(garden.ListOptions{Summarize: true})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(page.Summaries).Should(Equal([]garden.ContainerSummary{
+						{Handle: "some-handle", State: "stopped", Properties: garden.Properties{"owner": "you"}},
+					}))
 				})
 			})
-		}
+		})
 
-		Describe("stopping", func() {
-			It("stops the container and sends a StopResponse", func() {
-				err := container.Stop(true)
-				Ω(err).ShouldNot(HaveOccurred())
+		Context("when the backend does not implement List", func() {
+			properties := map[string]garden.Properties{
+				"handle-1": {"tenant": "a", "name": "web-1", "rank": "3"},
+				"handle-2": {"tenant": "a", "name": "worker-1", "rank": "1"},
+				"handle-3": {"tenant": "b", "name": "web-2", "rank": "2"},
+				"handle-4": {"tenant": "a", "name": "web-3"},
+				"handle-5": {"tenant": "a", "name": "web-4", "rank": "1", "deleted": "true"},
+			}
 
-				Ω(fakeContainer.StopArgsForCall(0)).Should(Equal(true))
-			})
+			BeforeEach(func() {
+				serverBackend.ListReturns(garden.ContainerPage{}, garden.ErrNotImplemented)
+
+				serverBackend.ContainersStub = func(filter garden.Properties) ([]garden.Container, error) {
+					containers := []garden.Container{}
+					for handle, props := range properties {
+						if garden.SelectorFromProperties(filter).Matches(props) {
+							container := new(fakes.FakeContainer)
+							container.HandleReturns(handle)
+							containers = append(containers, container)
+						}
+					}
 
-			itFailsWhenTheContainerIsNotFound(func() {
-				err := container.Stop(true)
-				Ω(err).Should(HaveOccurred())
-			})
+					return containers, nil
+				}
 
-			Context("when stopping the container fails", func() {
-				BeforeEach(func() {
-					fakeContainer.StopReturns(errors.New("oh no!"))
-				})
+				serverBackend.BulkInfoStub = func(handles []string) (map[string]garden.ContainerInfoEntry, error) {
+					entries := map[string]garden.ContainerInfoEntry{}
+					for _, handle := range handles {
+						entries[handle] = garden.ContainerInfoEntry{
+							Info: garden.ContainerInfo{State: "active", Properties: properties[handle]},
+						}
+					}
 
-				It("returns an error", func() {
-					err := container.Stop(true)
-					Ω(err).Should(HaveOccurred())
-				})
+					return entries, nil
+				}
 			})
 
-			itResetsGraceTimeWhenHandling(
-				func() {
-					err := container.Stop(false)
+			listAll := func(options garden.ListOptions) []string {
+				handles := []string{}
+
+				for {
+					page, err := apiClient.List(options)
 					Ω(err).ShouldNot(HaveOccurred())
-				},
-			)
-		})
 
-		Describe("properties", func() {
-			Describe("getting", func() {
-				Context("when getting the property succeeds", func() {
-					BeforeEach(func() {
-						fakeContainer.GetPropertyReturns("some-property-value", nil)
-					})
+					if options.Limit > 0 {
+						Ω(len(page.Containers)).Should(BeNumerically("<=", options.Limit))
+					}
 
-					It("returns the property from the container", func() {
-						value, err := container.GetProperty("some-property")
-						Ω(err).ShouldNot(HaveOccurred())
+					for _, container := range page.Containers {
+						handles = append(handles, container.Handle())
+					}
 
-						Ω(value).Should(Equal("some-property-value"))
+					if page.NextCursor == "" {
+						return handles
+					}
 
-						Ω(fakeContainer.GetPropertyCallCount()).Should(Equal(1))
+					options.Cursor = page.NextCursor
+				}
+			}
 
-						name := fakeContainer.GetPropertyArgsForCall(0)
-						Ω(name).Should(Equal("some-property"))
-					})
+			It("filters by the properties required to equal a value in the backend, and the rest itself", func() {
+				selector, err := garden.ParseSelector("tenant=a,name^=web-,!deleted")
+				Ω(err).ShouldNot(HaveOccurred())
 
-					itResetsGraceTimeWhenHandling(func() {
-						_, err := container.GetProperty("some-property")
-						Ω(err).ShouldNot(HaveOccurred())
-					})
+				Ω(listAll(garden.ListOptions{Selector: selector})).Should(Equal([]string{"handle-1", "handle-4"}))
 
-					itFailsWhenTheContainerIsNotFound(func() {
-						_, err := container.GetProperty("some-property")
-						Ω(err).Should(HaveOccurred())
-					})
-				})
+				Ω(serverBackend.ContainersArgsForCall(serverBackend.ContainersCallCount() - 1)).Should(Equal(garden.Properties{"tenant": "a"}))
+			})
 
-				Context("when getting the property fails", func() {
-					BeforeEach(func() {
-						fakeContainer.GetPropertyReturns("", errors.New("oh no!"))
-					})
+			It("pages through the containers sorted by handle", func() {
+				Ω(listAll(garden.ListOptions{Limit: 2})).Should(Equal([]string{
+					"handle-1", "handle-2", "handle-3", "handle-4", "handle-5",
+				}))
 
-					It("returns an error", func() {
-						value, err := container.GetProperty("some-property")
-						Ω(err).Should(HaveOccurred())
-						Ω(value).Should(BeZero())
-					})
-				})
+				Ω(serverBackend.BulkInfoCallCount()).Should(BeZero())
 			})
 
-			Describe("setting", func() {
-				Context("when setting the property succeeds", func() {
-					BeforeEach(func() {
-						fakeContainer.SetPropertyReturns(nil)
-					})
+			It("pages through the containers sorted by a property, and then by handle", func() {
+				Ω(listAll(garden.ListOptions{SortBy: "rank", Limit: 2})).Should(Equal([]string{
+					"handle-4", "handle-2", "handle-5", "handle-3", "handle-1",
+				}))
+			})
 
-					It("sets the property on the container", func() {
-						err := container.SetProperty("some-property", "some-value")
-						Ω(err).ShouldNot(HaveOccurred())
+			It("only reads the properties of as many containers as a page needs", func() {
+				selector, err := garden.ParseSelector("!deleted")
+				Ω(err).ShouldNot(HaveOccurred())
 
-						Ω(fakeContainer.SetPropertyCallCount()).Should(Equal(1))
+				page, err := apiClient.List(garden.ListOptions{Selector: selector, Limit: 1})
+				Ω(err).ShouldNot(HaveOccurred())
 
-						name, value := fakeContainer.SetPropertyArgsForCall(0)
-						Ω(name).Should(Equal("some-property"))
-						Ω(value).Should(Equal("some-value"))
-					})
+				Ω(page.Containers).Should(HaveLen(1))
+				Ω(page.Containers[0].Handle()).Should(Equal("handle-1"))
+				Ω(page.NextCursor).ShouldNot(BeEmpty())
 
-					itResetsGraceTimeWhenHandling(func() {
-						err := container.SetProperty("some-property", "some-value")
-						Ω(err).ShouldNot(HaveOccurred())
-					})
+				Ω(serverBackend.BulkInfoCallCount()).Should(Equal(1))
+				Ω(serverBackend.BulkInfoArgsForCall(0)).Should(Equal([]string{"handle-1", "handle-2"}))
+			})
 
-					itFailsWhenTheContainerIsNotFound(func() {
-						err := container.SetProperty("some-property", "some-value")
-						Ω(err).Should(HaveOccurred())
-					})
-				})
+			It("summarizes only the containers in the page", func() {
+				page, err := apiClient.List(garden.ListOptions{Limit: 2, Summarize: true})
+				Ω(err).ShouldNot(HaveOccurred())
 
-				Context("when setting the property fails", func() {
-					BeforeEach(func() {
-						fakeContainer.SetPropertyReturns(errors.New("oh no!"))
-					})
+				Ω(page.Summaries).Should(Equal([]garden.ContainerSummary{
+					{Handle: "handle-1", State: "active", Properties: properties["handle-1"]},
+					{Handle: "handle-2", State: "active", Properties: properties["handle-2"]},
+				}))
 
-					It("returns an error", func() {
-						err := container.SetProperty("some-property", "some-value")
-						Ω(err).Should(HaveOccurred())
-					})
-				})
+				Ω(serverBackend.BulkInfoCallCount()).Should(Equal(1))
+				Ω(serverBackend.BulkInfoArgsForCall(0)).Should(Equal([]string{"handle-1", "handle-2"}))
 			})
 
-			Describe("removing", func() {
-				Context("when removing the property succeeds", func() {
-					BeforeEach(func() {
-						fakeContainer.RemovePropertyReturns(nil)
-					})
+			It("summarizes the containers with the properties already read", func() {
+				page, err := apiClient.List(garden.ListOptions{SortBy: "rank", Limit: 1, Summarize: true})
+				Ω(err).ShouldNot(HaveOccurred())
 
-					It("returns the property from the container", func() {
-						err := container.RemoveProperty("some-property")
-						Ω(err).ShouldNot(HaveOccurred())
+				Ω(page.Summaries).Should(Equal([]garden.ContainerSummary{
+					{Handle: "handle-4", State: "active", Properties: properties["handle-4"]},
+				}))
 
-						Ω(fakeContainer.RemovePropertyCallCount()).Should(Equal(1))
+				Ω(serverBackend.BulkInfoCallCount()).Should(Equal(1))
+			})
 
-						name := fakeContainer.RemovePropertyArgsForCall(0)
-						Ω(name).Should(Equal("some-property"))
-					})
+			It("rejects the cursor of a different sort", func() {
+				page, err := apiClient.List(garden.ListOptions{Limit: 1})
+				Ω(err).ShouldNot(HaveOccurred())
 
-					itResetsGraceTimeWhenHandling(func() {
-						err := container.RemoveProperty("some-property")
-						Ω(err).ShouldNot(HaveOccurred())
-					})
+				_, err = apiClient.List(garden.ListOptions{SortBy: "rank", Limit: 1, Cursor: page.NextCursor})
+				Ω(err).Should(HaveOccurred())
+			})
 
-					itFailsWhenTheContainerIsNotFound(func() {
-						err := container.RemoveProperty("some-property")
-						Ω(err).Should(HaveOccurred())
-					})
-				})
+			It("rejects invalid selectors", func() {
+				httpClient := &http.Client{
+					Transport: &http.Transport{
+						Dial: func(string, string) (net.Conn, error) {
+							return net.Dial("unix", socketPath)
+						},
+					},
+				}
 
-				Context("when setting the property fails", func() {
-					BeforeEach(func() {
-						fakeContainer.RemovePropertyReturns(errors.New("oh no!"))
-					})
+				listed := serverBackend.ContainersCallCount()
 
-					It("returns an error", func() {
-						err := container.RemoveProperty("some-property")
-						Ω(err).Should(HaveOccurred())
-					})
-				})
+				response, err := httpClient.Get("http://api/containers?%24selector=a%3Db%3Dc")
+				Ω(err).ShouldNot(HaveOccurred())
+				response.Body.Close()
+
+				Ω(response.StatusCode).Should(Equal(http.StatusBadRequest))
+				Ω(serverBackend.ContainersCallCount()).Should(Equal(listed))
 			})
 		})
+	})
 
-		Describe("streaming in", func() {
-			It("streams the file in, waits for completion, and succeeds", func() {
-				data := bytes.NewBufferString("chunk-1;chunk-2;chunk-3;")
+	Context("and the client sends a BulkInfoRequest", func() {
+		Context("when the backend implements BulkInfo", func() {
+			BeforeEach(func() {
+				serverBackend.BulkInfoReturns(map[string]garden.ContainerInfoEntry{
+					"handle-a": {Info: garden.ContainerInfo{State: "active"}},
+					"handle-b": {Err: garden.ContainerNotFoundError{Handle: "handle-b"}},
+				}, nil)
+			})
 
-				fakeContainer.StreamInStub = func(dest string, stream io.Reader) error {
+			It("returns the backend's entries", func() {
+				entries, err := apiClient.BulkInfo([]string{"handle-a", "handle-b"})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(serverBackend.BulkInfoArgsForCall(0)).Should(Equal([]string{"handle-a", "handle-b"}))
+
+				Ω(entries).Should(HaveLen(2))
+				Ω(entries["handle-a"].Err).ShouldNot(HaveOccurred())
+				Ω(entries["handle-a"].Info.State).Should(Equal("active"))
+				Ω(entries["handle-b"].Err).Should(Equal(garden.ContainerNotFoundError{Handle: "handle-b"}))
+			})
+
+			It("passes handles containing commas through intact", func() {
+				_, err := apiClient.BulkInfo([]string{"handle,a", "handle-b"})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(serverBackend.BulkInfoArgsForCall(0)).Should(Equal([]string{"handle,a", "handle-b"}))
+			})
+		})
+
+		Context("when the backend does not implement BulkInfo", func() {
+			BeforeEach(func() {
+				serverBackend.BulkInfoReturns(nil, garden.ErrNotImplemented)
+
+				containerA := new(fakes.FakeContainer)
+				containerA.HandleReturns("handle-a")
+				containerA.InfoReturns(garden.ContainerInfo{State: "active"}, nil)
+
+				containerB := new(fakes.FakeContainer)
+				containerB.HandleReturns("handle-b")
+				containerB.InfoReturns(garden.ContainerInfo{}, errors.New("oh no!"))
+
+				serverBackend.LookupStub = func(handle string) (garden.Container, error) {
+					switch handle {
+					case "handle-a":
+						return containerA, nil
+					case "handle-b":
+						return containerB, nil
+					default:
+						return nil, garden.ContainerNotFoundError{Handle: handle}
+					}
+				}
+			})
+
+			It("looks up each container and reports its info or error", func() {
+				entries, err := apiClient.BulkInfo([]string{"handle-a", "handle-b", "handle-c"})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(entries).Should(HaveLen(3))
+				Ω(entries["handle-a"].Info.State).Should(Equal("active"))
+				Ω(entries["handle-b"].Err).Should(Equal(garden.BackendError{Message: "oh no!"}))
+				Ω(entries["handle-c"].Err).Should(Equal(garden.ContainerNotFoundError{Handle: "handle-c"}))
+			})
+
+			It("gets the info of only a bounded number of containers at once", func() {
+				var running, mostRunning int32
+
+				container := new(fakes.FakeContainer)
+				container.InfoStub = func() (garden.ContainerInfo, error) {
+					now := atomic.AddInt32(&running, 1)
+					defer atomic.AddInt32(&running, -1)
+
+					for {
+						most := atomic.LoadInt32(&mostRunning)
+						if now <= most || atomic.CompareAndSwapInt32(&mostRunning, most, now) {
+							break
+						}
+					}
+
+					time.Sleep(10 * time.Millisecond)
+
+					return garden.ContainerInfo{State: "active"}, nil
+				}
+
+				serverBackend.LookupStub = nil
+				serverBackend.LookupReturns(container, nil)
+
+				handles := []string{}
+				for i := 0; i < 100; i++ {
+					handles = append(handles, fmt.Sprintf("handle-%d", i))
+				}
+
+				entries, err := apiClient.BulkInfo(handles)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(entries).Should(HaveLen(100))
+
+				Ω(atomic.LoadInt32(&mostRunning)).Should(BeNumerically("<=", 16))
+			})
+		})
+
+		Context("when getting the info fails", func() {
+			BeforeEach(func() {
+				serverBackend.BulkInfoReturns(nil, errors.New("oh no!"))
+			})
+
+			It("returns an error", func() {
+				_, err := apiClient.BulkInfo([]string{"handle-a"})
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+	})
+
+	Context("and the client sends a LookupRequest", func() {
+		Context("when the container exists", func() {
+			var fakeContainer *fakes.FakeContainer
+
+			BeforeEach(func() {
+				fakeContainer = new(fakes.FakeContainer)
+				fakeContainer.HandleReturns("some-handle")
+				fakeContainer.PropertiesReturns(garden.Properties{"owner": "me"}, nil)
+
+				serverBackend.LookupReturns(fakeContainer, nil)
+			})
+
+			It("looks the container up directly", func() {
+				container, err := apiClient.Lookup("some-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(container.Handle()).Should(Equal("some-handle"))
+
+				Ω(serverBackend.LookupArgsForCall(0)).Should(Equal("some-handle"))
+				Ω(serverBackend.ContainersCallCount()).Should(Equal(1)) // from server start
+			})
+
+			It("looks up a container whose handle matches another route", func() {
+				_, err := apiClient.Lookup("bulk_info")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(serverBackend.LookupArgsForCall(0)).Should(Equal("bulk_info"))
+			})
+
+			It("returns the container's properties without reading its info", func() {
+				summary, err := connection.New("unix", socketPath).Lookup("some-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(summary).Should(Equal(garden.ContainerSummary{
+					Handle:     "some-handle",
+					Properties: garden.Properties{"owner": "me"},
+				}))
+
+				Ω(fakeContainer.InfoCallCount()).Should(Equal(0))
+			})
+
+			Context("when the backend cannot return the properties on their own", func() {
+				BeforeEach(func() {
+					fakeContainer.PropertiesReturns(nil, garden.ErrNotImplemented)
+					fakeContainer.InfoReturns(garden.ContainerInfo{
+						State:      "active",
+						Properties: garden.Properties{"owner": "me"},
+					}, nil)
+				})
+
+				It("returns the container's state and properties from its info", func() {
+					summary, err := connection.New("unix", socketPath).Lookup("some-handle")
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(summary).Should(Equal(garden.ContainerSummary{
+						Handle:     "some-handle",
+						State:      "active",
+						Properties: garden.Properties{"owner": "me"},
+					}))
+				})
+			})
+		})
+
+		Context("when the container cannot be found", func() {
+			BeforeEach(func() {
+				serverBackend.LookupReturns(nil, garden.ContainerNotFoundError{Handle: "some-handle"})
+			})
+
+			It("returns ContainerNotFoundError", func() {
+				_, err := apiClient.Lookup("some-handle")
+				Ω(err).Should(Equal(garden.ContainerNotFoundError{Handle: "some-handle"}))
+			})
+		})
+
+		Context("when getting the container's properties fails", func() {
+			BeforeEach(func() {
+				fakeContainer := new(fakes.FakeContainer)
+				fakeContainer.HandleReturns("some-handle")
+				fakeContainer.PropertiesReturns(nil, errors.New("oh no!"))
+
+				serverBackend.LookupReturns(fakeContainer, nil)
+			})
+
+			It("returns an error", func() {
+				_, err := apiClient.Lookup("some-handle")
+				Ω(err).Should(Equal(garden.BackendError{Message: "oh no!"}))
+			})
+		})
+	})
+
+	Context("and the client subscribes to events", func() {
+		var events garden.EventStream
+
+		JustBeforeEach(func() {
+			var err error
+			events, err = apiClient.Events(nil)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			events.Close()
+		})
+
+		Context("and a container is created", func() {
+			BeforeEach(func() {
+				fakeContainer := new(fakes.FakeContainer)
+				fakeContainer.HandleReturns("some-handle")
+
+				serverBackend.CreateReturns(fakeContainer, nil)
+			})
+
+			It("streams a create event with the container's properties", func() {
+				_, err := apiClient.Create(garden.ContainerSpec{
+					Properties: garden.Properties{"owner": "me"},
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				event, err := events.Next()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(event.Type).Should(Equal(garden.EventTypeCreate))
+				Ω(event.Handle).Should(Equal("some-handle"))
+				Ω(event.Properties).Should(Equal(garden.Properties{"owner": "me"}))
+				Ω(event.Time).Should(BeTemporally("~", time.Now(), time.Second))
+			})
+		})
+
+		Context("and a container is destroyed", func() {
+			var fakeContainer *fakes.FakeContainer
+
+			BeforeEach(func() {
+				fakeContainer = new(fakes.FakeContainer)
+				fakeContainer.HandleReturns("some-handle")
+				fakeContainer.PropertiesReturns(garden.Properties{"owner": "me"}, nil)
+
+				serverBackend.LookupReturns(fakeContainer, nil)
+			})
+
+			It("streams a destroy event with the properties it had before being destroyed", func() {
+				err := apiClient.Destroy("some-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				event, err := events.Next()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(event.Type).Should(Equal(garden.EventTypeDestroy))
+				Ω(event.Handle).Should(Equal("some-handle"))
+				Ω(event.Properties).Should(Equal(garden.Properties{"owner": "me"}))
+
+				Ω(fakeContainer.InfoCallCount()).Should(Equal(0))
+			})
+
+			Context("and the backend cannot return the properties on their own", func() {
+				BeforeEach(func() {
+					fakeContainer.PropertiesReturns(nil, garden.ErrNotImplemented)
+					fakeContainer.InfoReturns(garden.ContainerInfo{
+						Properties: garden.Properties{"owner": "me"},
+					}, nil)
+				})
+
+				It("streams a destroy event with the properties from its info", func() {
+					err := apiClient.Destroy("some-handle")
+					Ω(err).ShouldNot(HaveOccurred())
+
+					event, err := events.Next()
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(event.Type).Should(Equal(garden.EventTypeDestroy))
+					Ω(event.Properties).Should(Equal(garden.Properties{"owner": "me"}))
+				})
+			})
+
+			Context("and destroying fails", func() {
+				BeforeEach(func() {
+					serverBackend.DestroyReturns(errors.New("oh no!"))
+				})
+
+				It("does not stream an event", func() {
+					err := apiClient.Destroy("some-handle")
+					Ω(err).Should(HaveOccurred())
+
+					fakeContainer := new(fakes.FakeContainer)
+					fakeContainer.HandleReturns("some-other-handle")
+					serverBackend.CreateReturns(fakeContainer, nil)
+
+					_, err = apiClient.Create(garden.ContainerSpec{})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					event, err := events.Next()
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(event.Type).Should(Equal(garden.EventTypeCreate))
+				})
+			})
+		})
+
+		Context("and a container is stopped", func() {
+			BeforeEach(func() {
+				fakeContainer := new(fakes.FakeContainer)
+				fakeContainer.HandleReturns("some-handle")
+
+				serverBackend.LookupReturns(fakeContainer, nil)
+			})
+
+			It("streams a stop event", func() {
+				err := connection.New("unix", socketPath).Stop("some-handle", true)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				event, err := events.Next()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(event.Type).Should(Equal(garden.EventTypeStop))
+				Ω(event.Handle).Should(Equal("some-handle"))
+				Ω(event.Data).Should(Equal(map[string]string{"kill": "true"}))
+			})
+		})
+
+		Context("when filtering by properties", func() {
+			JustBeforeEach(func() {
+				events.Close()
+
+				var err error
+				events, err = apiClient.Events(garden.Properties{"owner": "me"})
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			It("only streams events for containers with matching properties", func() {
+				theirs := new(fakes.FakeContainer)
+				theirs.HandleReturns("theirs")
+				serverBackend.CreateReturns(theirs, nil)
+
+				_, err := apiClient.Create(garden.ContainerSpec{
+					Properties: garden.Properties{"owner": "them"},
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				mine := new(fakes.FakeContainer)
+				mine.HandleReturns("mine")
+				serverBackend.CreateReturns(mine, nil)
+
+				_, err = apiClient.Create(garden.ContainerSpec{
+					Properties: garden.Properties{"owner": "me"},
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				event, err := events.Next()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(event.Handle).Should(Equal("mine"))
+			})
+		})
+
+		Context("when the server is stopped", func() {
+			It("ends the stream", func() {
+				isRunning = false
+				apiServer.Stop()
+
+				_, err := events.Next()
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+	})
+
+	Context("when a container has been created", func() {
+		var container garden.Container
+
+		var fakeContainer *fakes.FakeContainer
+
+		BeforeEach(func() {
+			fakeContainer = new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+
+			serverBackend.CreateReturns(fakeContainer, nil)
+			serverBackend.LookupReturns(fakeContainer, nil)
+		})
+
+		JustBeforeEach(func() {
+			var err error
+
+			container, err = apiClient.Create(garden.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		itResetsGraceTimeWhenHandling := func(call func()) {
+			Context("when created with a grace time", func() {
+				graceTime := 200 * time.Millisecond
+
+				BeforeEach(func() {
+					serverBackend.GraceTimeReturns(graceTime)
+				})
+
+				It("resets the container's grace time", func() {
+					for i := 0; i < 11; i++ {
+						time.Sleep(graceTime / 10)
+						call()
+					}
+
+					before := time.Now()
+
+					Eventually(serverBackend.DestroyCallCount, 2*graceTime).Should(Equal(1))
+					Ω(serverBackend.DestroyArgsForCall(0)).Should(Equal(container.Handle()))
+
+					Ω(time.Since(before)).Should(BeNumerically("~", graceTime, 20*time.Millisecond))
+				})
+			})
+		}
+
+		itFailsWhenTheContainerIsNotFound := func(example func()) {
+			Context("when the container is not found", func() {
+				It("fails", func() {
+					serverBackend.LookupReturns(nil, errors.New("not found"))
+					example()
+				})
+			})
+		}
+
+		Context("when the backend cannot find the container", func() {
+			BeforeEach(func() {
+				serverBackend.LookupReturns(nil, garden.ContainerNotFoundError{Handle: "some-handle"})
+			})
+
+			It("returns a ContainerNotFoundError from regular requests", func() {
+				err := container.Stop(false)
+				Ω(err).Should(Equal(garden.ContainerNotFoundError{Handle: "some-handle"}))
+			})
+
+			It("returns a ContainerNotFoundError from hijacked requests", func() {
+				_, err := container.Run(garden.ProcessSpec{Path: "ls"}, garden.ProcessIO{})
+				Ω(err).Should(Equal(garden.ContainerNotFoundError{Handle: "some-handle"}))
+			})
+		})
+
+		Describe("stopping", func() {
+			It("stops the container and sends a StopResponse", func() {
+				err := container.Stop(true)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(fakeContainer.StopArgsForCall(0)).Should(Equal(true))
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				err := container.Stop(true)
+				Ω(err).Should(HaveOccurred())
+			})
+
+			Context("when stopping the container fails", func() {
+				BeforeEach(func() {
+					fakeContainer.StopReturns(errors.New("oh no!"))
+				})
+
+				It("returns an error", func() {
+					err := container.Stop(true)
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+
+			itResetsGraceTimeWhenHandling(
+				func() {
+					err := container.Stop(false)
+					Ω(err).ShouldNot(HaveOccurred())
+				},
+			)
+		})
+
+		Describe("properties", func() {
+			Describe("getting", func() {
+				Context("when getting the property succeeds", func() {
+					BeforeEach(func() {
+						fakeContainer.GetPropertyReturns("some-property-value", nil)
+					})
+
+					It("returns the property from the container", func() {
+						value, err := container.GetProperty("some-property")
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Ω(value).Should(Equal("some-property-value"))
+
+						Ω(fakeContainer.GetPropertyCallCount()).Should(Equal(1))
+
+						name := fakeContainer.GetPropertyArgsForCall(0)
+						Ω(name).Should(Equal("some-property"))
+					})
+
+					itResetsGraceTimeWhenHandling(func() {
+						_, err := container.GetProperty("some-property")
+						Ω(err).ShouldNot(HaveOccurred())
+					})
+
+					itFailsWhenTheContainerIsNotFound(func() {
+						_, err := container.GetProperty("some-property")
+						Ω(err).Should(HaveOccurred())
+					})
+				})
+
+				Context("when getting the property fails", func() {
+					BeforeEach(func() {
+						fakeContainer.GetPropertyReturns("", errors.New("oh no!"))
+					})
+
+					It("returns an error", func() {
+						value, err := container.GetProperty("some-property")
+						Ω(err).Should(HaveOccurred())
+						Ω(value).Should(BeZero())
+					})
+				})
+			})
+
+			Describe("setting", func() {
+				Context("when setting the property succeeds", func() {
+					BeforeEach(func() {
+						fakeContainer.SetPropertyReturns(nil)
+					})
+
+					It("sets the property on the container", func() {
+						err := container.SetProperty("some-property", "some-value")
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Ω(fakeContainer.SetPropertyCallCount()).Should(Equal(1))
+
+						name, value := fakeContainer.SetPropertyArgsForCall(0)
+						Ω(name).Should(Equal("some-property"))
+						Ω(value).Should(Equal("some-value"))
+					})
+
+					itResetsGraceTimeWhenHandling(func() {
+						err := container.SetProperty("some-property", "some-value")
+						Ω(err).ShouldNot(HaveOccurred())
+					})
+
+					itFailsWhenTheContainerIsNotFound(func() {
+						err := container.SetProperty("some-property", "some-value")
+						Ω(err).Should(HaveOccurred())
+					})
+				})
+
+				Context("when setting the property fails", func() {
+					BeforeEach(func() {
+						fakeContainer.SetPropertyReturns(errors.New("oh no!"))
+					})
+
+					It("returns an error", func() {
+						err := container.SetProperty("some-property", "some-value")
+						Ω(err).Should(HaveOccurred())
+					})
+				})
+			})
+
+			Describe("removing", func() {
+				Context("when removing the property succeeds", func() {
+					BeforeEach(func() {
+						fakeContainer.RemovePropertyReturns(nil)
+					})
+
+					It("returns the property from the container", func() {
+						err := container.RemoveProperty("some-property")
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Ω(fakeContainer.RemovePropertyCallCount()).Should(Equal(1))
+
+						name := fakeContainer.RemovePropertyArgsForCall(0)
+						Ω(name).Should(Equal("some-property"))
+					})
+
+					itResetsGraceTimeWhenHandling(func() {
+						err := container.RemoveProperty("some-property")
+						Ω(err).ShouldNot(HaveOccurred())
+					})
+
+					itFailsWhenTheContainerIsNotFound(func() {
+						err := container.RemoveProperty("some-property")
+						Ω(err).Should(HaveOccurred())
+					})
+				})
+
+				Context("when setting the property fails", func() {
+					BeforeEach(func() {
+						fakeContainer.RemovePropertyReturns(errors.New("oh no!"))
+					})
+
+					It("returns an error", func() {
+						err := container.RemoveProperty("some-property")
+						Ω(err).Should(HaveOccurred())
+					})
+				})
+			})
+
+			Describe("getting all", func() {
+				It("returns the properties from the container", func() {
+					fakeContainer.PropertiesReturns(garden.Properties{"a": "1", "b": "2"}, nil)
+
+					properties, err := container.Properties()
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(properties).Should(Equal(garden.Properties{"a": "1", "b": "2"}))
+				})
+
+				itResetsGraceTimeWhenHandling(func() {
+					_, err := container.Properties()
+					Ω(err).ShouldNot(HaveOccurred())
+				})
+
+				itFailsWhenTheContainerIsNotFound(func() {
+					_, err := container.Properties()
+					Ω(err).Should(HaveOccurred())
+				})
+
+				Context("when the backend cannot return them on their own", func() {
+					BeforeEach(func() {
+						fakeContainer.PropertiesReturns(nil, garden.ErrNotImplemented)
+						fakeContainer.InfoReturns(garden.ContainerInfo{
+							Properties: garden.Properties{"a": "1"},
+						}, nil)
+					})
+
+					It("returns those in the container's info", func() {
+						properties, err := container.Properties()
+						Ω(err).ShouldNot(HaveOccurred())
+						Ω(properties).Should(Equal(garden.Properties{"a": "1"}))
+					})
+				})
+			})
+
+			Describe("setting many", func() {
+				It("sets and removes them on the container at once", func() {
+					err := container.SetProperties(garden.Properties{"a": "1", "b": "2"}, []string{"c"})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(fakeContainer.SetPropertiesCallCount()).Should(Equal(1))
+
+					properties, removals := fakeContainer.SetPropertiesArgsForCall(0)
+					Ω(properties).Should(Equal(garden.Properties{"a": "1", "b": "2"}))
+					Ω(removals).Should(Equal([]string{"c"}))
+
+					Ω(fakeContainer.SetPropertyCallCount()).Should(BeZero())
+				})
+
+				itResetsGraceTimeWhenHandling(func() {
+					err := container.SetProperties(garden.Properties{"a": "1"}, nil)
+					Ω(err).ShouldNot(HaveOccurred())
+				})
+
+				itFailsWhenTheContainerIsNotFound(func() {
+					err := container.SetProperties(garden.Properties{"a": "1"}, nil)
+					Ω(err).Should(HaveOccurred())
+				})
+
+				It("refuses to both set and remove a property", func() {
+					err := container.SetProperties(garden.Properties{"a": "1"}, []string{"a"})
+					Ω(err).Should(HaveOccurred())
+
+					Ω(fakeContainer.SetPropertiesCallCount()).Should(BeZero())
+				})
+
+				Context("when the backend cannot set them at once", func() {
+					var (
+						propertiesL sync.Mutex
+						properties  garden.Properties
+					)
+
+					BeforeEach(func() {
+						properties = garden.Properties{"c": "3", "d": "4"}
+
+						fakeContainer.SetPropertiesReturns(garden.ErrNotImplemented)
+						fakeContainer.CompareAndSetPropertiesReturns(garden.ErrNotImplemented)
+						fakeContainer.PropertiesReturns(nil, garden.ErrNotImplemented)
+
+						fakeContainer.InfoStub = func() (garden.ContainerInfo, error) {
+							propertiesL.Lock()
+							defer propertiesL.Unlock()
+
+							snapshot := garden.Properties{}
+							for key, value := range properties {
+								snapshot[key] = value
+							}
+
+							return garden.ContainerInfo{Properties: snapshot}, nil
+						}
+
+						fakeContainer.SetPropertyStub = func(key, value string) error {
+							propertiesL.Lock()
+							defer propertiesL.Unlock()
+
+							properties[key] = value
+							return nil
+						}
+
+						fakeContainer.RemovePropertyStub = func(key string) error {
+							propertiesL.Lock()
+							defer propertiesL.Unlock()
+
+							delete(properties, key)
+							return nil
+						}
+					})
+
+					It("sets and removes them one at a time", func() {
+						err := container.SetProperties(garden.Properties{"a": "1", "b": "2"}, []string{"c"})
+						Ω(err).ShouldNot(HaveOccurred())
+
+						propertiesL.Lock()
+						defer propertiesL.Unlock()
+
+						Ω(properties).Should(Equal(garden.Properties{"a": "1", "b": "2", "d": "4"}))
+					})
+
+					It("does not let other clients read them part way through", func() {
+						settingB := make(chan struct{})
+						setB := make(chan struct{})
+
+						var setBOnce sync.Once
+						releaseB := func() { setBOnce.Do(func() { close(setB) }) }
+						defer releaseB()
+
+						setProperty := fakeContainer.SetPropertyStub
+						fakeContainer.SetPropertyStub = func(key, value string) error {
+							if key == "b" {
+								close(settingB)
+								<-setB
+							}
+
+							return setProperty(key, value)
+						}
+
+						fakeContainer.GetPropertyStub = func(key string) (string, error) {
+							propertiesL.Lock()
+							defer propertiesL.Unlock()
+
+							return properties[key], nil
+						}
+
+						set := make(chan error, 1)
+						go func() {
+							set <- container.SetProperties(garden.Properties{"a": "1", "b": "2"}, nil)
+						}()
+
+						<-settingB
+
+						read := make(chan garden.Properties, 1)
+						go func() {
+							defer GinkgoRecover()
+
+							properties, err := container.Properties()
+							Ω(err).ShouldNot(HaveOccurred())
+							read <- properties
+						}()
+
+						gotB := make(chan string, 1)
+						go func() {
+							defer GinkgoRecover()
+
+							value, err := container.GetProperty("b")
+							Ω(err).ShouldNot(HaveOccurred())
+							gotB <- value
+						}()
+
+						Consistently(read).ShouldNot(Receive())
+						Consistently(gotB).ShouldNot(Receive())
+
+						releaseB()
+
+						Eventually(set).Should(Receive(BeNil()))
+						Eventually(read).Should(Receive(Equal(garden.Properties{"a": "1", "b": "2", "c": "3", "d": "4"})))
+						Eventually(gotB).Should(Receive(Equal("2")))
+					})
+
+					Context("and setting one fails", func() {
+						BeforeEach(func() {
+							setProperty := fakeContainer.SetPropertyStub
+
+							fakeContainer.SetPropertyStub = func(key, value string) error {
+								if key == "b" && value == "2" {
+									return errors.New("oh no!")
+								}
+
+								return setProperty(key, value)
+							}
+						})
+
+						It("undoes those made before it and returns the error", func() {
+							err := container.SetProperties(garden.Properties{"a": "1", "b": "2", "d": "5"}, nil)
+							Ω(err).Should(HaveOccurred())
+
+							propertiesL.Lock()
+							defer propertiesL.Unlock()
+
+							Ω(properties).Should(Equal(garden.Properties{"c": "3", "d": "4"}))
+						})
+					})
+				})
+			})
+
+			Describe("comparing and setting", func() {
+				var owned garden.Selector
+
+				BeforeEach(func() {
+					owned = garden.Selector{{Key: "owner", Operator: garden.SelectorNotExists}}
+				})
+
+				It("compares and sets them on the container at once", func() {
+					err := container.CompareAndSetProperties(owned, garden.Properties{"owner": "me"}, nil)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(fakeContainer.CompareAndSetPropertiesCallCount()).Should(Equal(1))
+
+					expected, properties, _ := fakeContainer.CompareAndSetPropertiesArgsForCall(0)
+					Ω(expected).Should(Equal(owned))
+					Ω(properties).Should(Equal(garden.Properties{"owner": "me"}))
+				})
+
+				Context("when the container's properties do not meet the selector", func() {
+					BeforeEach(func() {
+						fakeContainer.CompareAndSetPropertiesReturns(garden.PropertyConflictError{Requirement: "!owner"})
+					})
+
+					It("returns the requirement that was not met", func() {
+						err := container.CompareAndSetProperties(owned, garden.Properties{"owner": "me"}, nil)
+						Ω(err).Should(Equal(garden.PropertyConflictError{Requirement: "!owner"}))
+					})
+				})
+
+				Context("when the backend cannot compare and set them", func() {
+					var (
+						propertiesL sync.Mutex
+						properties  garden.Properties
+					)
+
+					BeforeEach(func() {
+						properties = garden.Properties{}
+
+						fakeContainer.CompareAndSetPropertiesReturns(garden.ErrNotImplemented)
+
+						fakeContainer.PropertiesStub = func() (garden.Properties, error) {
+							propertiesL.Lock()
+							defer propertiesL.Unlock()
+
+							snapshot := garden.Properties{}
+							for key, value := range properties {
+								snapshot[key] = value
+							}
+
+							return snapshot, nil
+						}
+
+						fakeContainer.SetPropertyStub = func(key, value string) error {
+							// widen the window for a racing comparison
+							time.Sleep(10 * time.Millisecond)
+
+							propertiesL.Lock()
+							defer propertiesL.Unlock()
+
+							properties[key] = value
+							return nil
+						}
+					})
+
+					It("sets them if the properties meet the selector", func() {
+						err := container.CompareAndSetProperties(owned, garden.Properties{"owner": "me"}, nil)
+						Ω(err).ShouldNot(HaveOccurred())
+
+						propertiesL.Lock()
+						defer propertiesL.Unlock()
+
+						Ω(properties).Should(Equal(garden.Properties{"owner": "me"}))
+					})
+
+					It("returns the requirement that was not met, without changing them", func() {
+						propertiesL.Lock()
+						properties["owner"] = "someone-else"
+						propertiesL.Unlock()
+
+						err := container.CompareAndSetProperties(owned, garden.Properties{"owner": "me", "a": "1"}, nil)
+						Ω(err).Should(Equal(garden.PropertyConflictError{Requirement: "!owner"}))
+
+						Ω(fakeContainer.SetPropertyCallCount()).Should(BeZero())
+					})
+
+					It("lets only one of concurrent requests meet the selector", func() {
+						errs := make(chan error, 5)
+
+						for i := 0; i < 5; i++ {
+							go func(i int) {
+								defer GinkgoRecover()
+
+								errs <- container.CompareAndSetProperties(owned, garden.Properties{"owner": fmt.Sprintf("owner-%d", i)}, nil)
+							}(i)
+						}
+
+						succeeded := 0
+						for i := 0; i < 5; i++ {
+							err := <-errs
+							if err == nil {
+								succeeded++
+							} else {
+								Ω(err).Should(Equal(garden.PropertyConflictError{Requirement: "!owner"}))
+							}
+						}
+
+						Ω(succeeded).Should(Equal(1))
+						Ω(fakeContainer.SetPropertyCallCount()).Should(Equal(1))
+					})
+				})
+			})
+		})
+
+		Describe("streaming in", func() {
+			It("streams the file in, waits for completion, and succeeds", func() {
+				data := bytes.NewBufferString("chunk-1;chunk-2;chunk-3;")
+
+				fakeContainer.StreamInStub = func(dest string, stream io.Reader) error {
 					Ω(dest).Should(Equal("/dst/path"))
 					Ω(ioutil.ReadAll(stream)).Should(Equal([]byte("chunk-1;chunk-2;chunk-3;")))
 					return nil
@@ -1213,6 +2241,158 @@ var _ = Describe("When a client connects", func() {
 			})
 		})
 
+		Describe("listing processes", func() {
+			Context("when the backend lists the container's processes", func() {
+				startedAt := time.Unix(123, 456)
+
+				BeforeEach(func() {
+					fakeContainer.ProcessesReturns([]garden.ProcessInfo{
+						{
+							ID: 42,
+							Spec: garden.ProcessSpec{
+								Path:       "/some/script",
+								Args:       []string{"arg1", "arg2"},
+								Dir:        "/some/dir",
+								User:       "alice",
+								Privileged: true,
+								Env:        []string{"PASSWORD=secret", "EMPTY="},
+							},
+							StartedAt:  startedAt,
+							State:      garden.ProcessStateExited,
+							ExitStatus: 2,
+						},
+					}, nil)
+				})
+
+				It("returns them with their environment values redacted", func() {
+					processes, err := container.Processes()
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(processes).Should(Equal([]garden.ProcessInfo{
+						{
+							ID: 42,
+							Spec: garden.ProcessSpec{
+								Path:       "/some/script",
+								Args:       []string{"arg1", "arg2"},
+								Dir:        "/some/dir",
+								User:       "alice",
+								Privileged: true,
+								Env:        []string{"PASSWORD=[redacted]", "EMPTY=[redacted]"},
+							},
+							StartedAt:  startedAt,
+							State:      garden.ProcessStateExited,
+							ExitStatus: 2,
+						},
+					}))
+				})
+
+				itResetsGraceTimeWhenHandling(func() {
+					_, err := container.Processes()
+					Ω(err).ShouldNot(HaveOccurred())
+				})
+			})
+
+			Context("when the backend does not track processes", func() {
+				var exit chan int
+
+				BeforeEach(func() {
+					exit = make(chan int)
+
+					fakeContainer.ProcessesReturns(nil, garden.ErrNotImplemented)
+
+					fakeContainer.RunStub = func(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+						process := new(fakes.FakeProcess)
+						process.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
+
+						process.IDReturns(42)
+
+						process.WaitStub = func() (int, error) {
+							return <-exit, nil
+						}
+
+						return process, nil
+					}
+				})
+
+				It("lists the processes run through the server", func() {
+					process, err := container.Run(garden.ProcessSpec{
+						Path: "/some/script",
+						Env:  []string{"PASSWORD=secret"},
+					}, garden.ProcessIO{})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(func() []garden.ProcessInfo {
+						processes, err := container.Processes()
+						Ω(err).ShouldNot(HaveOccurred())
+						return processes
+					}).Should(HaveLen(1))
+
+					processes, err := container.Processes()
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(processes[0].ID).Should(Equal(uint32(42)))
+					Ω(processes[0].Spec.Path).Should(Equal("/some/script"))
+					Ω(processes[0].Spec.Env).Should(Equal([]string{"PASSWORD=[redacted]"}))
+					Ω(processes[0].StartedAt).Should(BeTemporally("~", time.Now(), time.Second))
+					Ω(processes[0].State).Should(Equal(garden.ProcessStateRunning))
+					Ω(processes[0].AttachedStreams).Should(Equal(1))
+
+					exit <- 3
+
+					status, err := process.Wait()
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(status).Should(Equal(3))
+
+					Eventually(func() garden.ProcessInfo {
+						processes, err := container.Processes()
+						Ω(err).ShouldNot(HaveOccurred())
+						return processes[0]
+					}).Should(And(
+						WithTransform(func(p garden.ProcessInfo) garden.ProcessState { return p.State }, Equal(garden.ProcessStateExited)),
+						WithTransform(func(p garden.ProcessInfo) int { return p.ExitStatus }, Equal(3)),
+						WithTransform(func(p garden.ProcessInfo) int { return p.AttachedStreams }, Equal(0)),
+					))
+				})
+
+				Context("and the container is destroyed", func() {
+					It("forgets its processes", func() {
+						process, err := container.Run(garden.ProcessSpec{Path: "/some/script"}, garden.ProcessIO{})
+						Ω(err).ShouldNot(HaveOccurred())
+
+						exit <- 0
+
+						_, err = process.Wait()
+						Ω(err).ShouldNot(HaveOccurred())
+
+						err = apiClient.Destroy(container.Handle())
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Eventually(func() []garden.ProcessInfo {
+							processes, err := container.Processes()
+							Ω(err).ShouldNot(HaveOccurred())
+							return processes
+						}).Should(BeEmpty())
+					})
+				})
+			})
+
+			Context("when listing the processes fails", func() {
+				BeforeEach(func() {
+					fakeContainer.ProcessesReturns(nil, errors.New("oh no!"))
+				})
+
+				It("fails", func() {
+					_, err := container.Processes()
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+
+			itFailsWhenTheContainerIsNotFound(func() {
+				_, err := container.Processes()
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+
 		Describe("info", func() {
 			containerInfo := garden.ContainerInfo{
 				State:         "active",
@@ -1333,6 +2513,7 @@ var _ = Describe("When a client connects", func() {
 						}()
 
 						process := new(fakes.FakeProcess)
+						process.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
 
 						process.IDReturns(42)
 
@@ -1387,10 +2568,157 @@ var _ = Describe("When a client connects", func() {
 				Ω(err).Should(HaveOccurred())
 			})
 
+			Context("when the process was run through the server", func() {
+				var (
+					runningProcess *fakes.FakeProcess
+					runIO          garden.ProcessIO
+					exit           chan struct{}
+				)
+
+				BeforeEach(func() {
+					exit = make(chan struct{})
+					exit := exit
+
+					runningProcess = new(fakes.FakeProcess)
+					runningProcess.IDReturns(42)
+					runningProcess.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
+					runningProcess.WaitStub = func() (int, error) {
+						<-exit
+						return 0, nil
+					}
+
+					fakeContainer.RunStub = func(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+						runIO = io
+						return runningProcess, nil
+					}
+
+					fakeContainer.AttachReturns(runningProcess, nil)
+				})
+
+				AfterEach(func() {
+					close(exit)
+				})
+
+				JustBeforeEach(func() {
+					stdout := gbytes.NewBuffer()
+
+					_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{Stdout: stdout})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					fmt.Fprintf(runIO.Stdout, "hello ")
+					fmt.Fprintf(runIO.Stderr, "world")
+
+					Eventually(stdout).Should(gbytes.Say("hello "))
+				})
+
+				It("replays the buffered output from the beginning", func() {
+					stdout := gbytes.NewBuffer()
+					stderr := gbytes.NewBuffer()
+
+					_, err := container.AttachFrom(42, 0, garden.ProcessIO{
+						Stdout: stdout,
+						Stderr: stderr,
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(stdout).Should(gbytes.Say("hello "))
+					Eventually(stderr).Should(gbytes.Say("world"))
+
+					fmt.Fprintf(runIO.Stdout, "live")
+					Eventually(stdout).Should(gbytes.Say("live"))
+				})
+
+				It("replays the buffered output from an offset", func() {
+					stdout := gbytes.NewBuffer()
+					stderr := gbytes.NewBuffer()
+
+					_, err := container.AttachFrom(42, 8, garden.ProcessIO{
+						Stdout: stdout,
+						Stderr: stderr,
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(stderr).Should(gbytes.Say("rld"))
+					Consistently(stdout).ShouldNot(gbytes.Say("hello"))
+				})
+
+				It("streams only new output when attaching without replay", func() {
+					stdout := gbytes.NewBuffer()
+
+					_, err := container.Attach(42, garden.ProcessIO{Stdout: stdout})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					fmt.Fprintf(runIO.Stdout, "live")
+					Eventually(stdout).Should(gbytes.Say("live"))
+					Ω(stdout.Contents()).ShouldNot(ContainSubstring("hello"))
+				})
+
+				It("does not ask the backend to replay", func() {
+					_, err := container.AttachFrom(42, 0, garden.ProcessIO{})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(fakeContainer.AttachCallCount).Should(Equal(1))
+					Ω(fakeContainer.AttachFromCallCount()).Should(Equal(0))
+				})
+			})
+
+			Context("when replaying output the server has not buffered", func() {
+				var process *fakes.FakeProcess
+
+				BeforeEach(func() {
+					process = new(fakes.FakeProcess)
+					process.IDReturns(42)
+					process.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
+				})
+
+				Context("and the backend buffers output", func() {
+					BeforeEach(func() {
+						fakeContainer.AttachFromStub = func(id uint32, offset uint64, io garden.ProcessIO) (garden.Process, error) {
+							fmt.Fprintf(io.Stdout, "replayed")
+							return process, nil
+						}
+					})
+
+					It("asks the backend to replay from the offset", func() {
+						stdout := gbytes.NewBuffer()
+
+						process, err := container.AttachFrom(42, 123, garden.ProcessIO{Stdout: stdout})
+						Ω(err).ShouldNot(HaveOccurred())
+
+						id, offset, _ := fakeContainer.AttachFromArgsForCall(0)
+						Ω(id).Should(Equal(uint32(42)))
+						Ω(offset).Should(Equal(uint64(123)))
+
+						_, err = process.Wait()
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Ω(stdout).Should(gbytes.Say("replayed"))
+					})
+				})
+
+				Context("and the backend does not buffer output", func() {
+					BeforeEach(func() {
+						fakeContainer.AttachFromReturns(nil, garden.ErrNotImplemented)
+						fakeContainer.AttachReturns(process, nil)
+					})
+
+					It("attaches without replaying", func() {
+						process, err := container.AttachFrom(42, 123, garden.ProcessIO{})
+						Ω(err).ShouldNot(HaveOccurred())
+
+						_, err = process.Wait()
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Ω(fakeContainer.AttachCallCount()).Should(Equal(1))
+					})
+				})
+			})
+
 			Context("when waiting on the process fails server-side", func() {
 				BeforeEach(func() {
 					fakeContainer.AttachStub = func(id uint32, io garden.ProcessIO) (garden.Process, error) {
 						process := new(fakes.FakeProcess)
+						process.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
 
 						process.IDReturns(42)
 						process.WaitReturns(0, errors.New("oh no!"))
@@ -1490,6 +2818,7 @@ var _ = Describe("When a client connects", func() {
 						}()
 
 						process := new(fakes.FakeProcess)
+						process.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
 
 						process.IDReturns(42)
 
@@ -1515,32 +2844,138 @@ var _ = Describe("When a client connects", func() {
 					process, err := container.Run(processSpec, processIO)
 					Ω(err).ShouldNot(HaveOccurred())
 
-					ranSpec, processIO := fakeContainer.RunArgsForCall(0)
-					Ω(ranSpec).Should(Equal(processSpec))
+					ranSpec, processIO := fakeContainer.RunArgsForCall(0)
+					Ω(ranSpec).Should(Equal(processSpec))
+
+					Eventually(stdout).Should(gbytes.Say("stdout data"))
+					Eventually(stdout).Should(gbytes.Say("mirrored stdin data"))
+					Eventually(stderr).Should(gbytes.Say("stderr data"))
+
+					status, err := process.Wait()
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(status).Should(Equal(123))
+
+					_, err = processIO.Stdin.Read([]byte{})
+					Ω(err).Should(Equal(io.EOF))
+
+					close(done)
+				})
+
+				itResetsGraceTimeWhenHandling(func() {
+					process, err := container.Run(processSpec, garden.ProcessIO{
+						Stdin: bytes.NewBufferString("hello"),
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					status, err := process.Wait()
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(status).Should(Equal(123))
+				})
+			})
+
+			Context("when the process reads and writes binary data", func() {
+				binary := []byte{0x00, 0xff, 0xfe, 0xc3, 0x28, 'a', 0x80, 0x00}
+
+				BeforeEach(func() {
+					fakeContainer.RunStub = func(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+						process := new(fakes.FakeProcess)
+						process.IDReturns(42)
+						process.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
+
+						mirrored := make(chan struct{})
+
+						go func() {
+							defer close(mirrored)
+							defer GinkgoRecover()
+
+							in, err := ioutil.ReadAll(io.Stdin)
+							Ω(err).ShouldNot(HaveOccurred())
+
+							_, err = io.Stdout.Write(in)
+							Ω(err).ShouldNot(HaveOccurred())
+						}()
+
+						process.WaitStub = func() (int, error) {
+							<-mirrored
+							return 0, nil
+						}
+
+						return process, nil
+					}
+				})
+
+				It("round-trips it byte for byte", func() {
+					stdout := new(bytes.Buffer)
+
+					process, err := container.Run(processSpec, garden.ProcessIO{
+						Stdin:  bytes.NewReader(binary),
+						Stdout: stdout,
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					_, err = process.Wait()
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(stdout.Bytes()).Should(Equal(binary))
+				})
+			})
+
+			Context("when the backend reports how the process exited", func() {
+				BeforeEach(func() {
+					signal := garden.SignalKill
+
+					process := new(fakes.FakeProcess)
+					process.IDReturns(42)
+					process.WaitForExitReturns(garden.ExitInfo{
+						ExitStatus: 137,
+						Signal:     &signal,
+						OOMKilled:  true,
+						Duration:   5 * time.Second,
+					}, nil)
 
-					Eventually(stdout).Should(gbytes.Say("stdout data"))
-					Eventually(stdout).Should(gbytes.Say("mirrored stdin data"))
-					Eventually(stderr).Should(gbytes.Say("stderr data"))
+					fakeContainer.RunReturns(process, nil)
+				})
 
-					status, err := process.Wait()
+				It("sends the exit information to the client", func() {
+					process, err := container.Run(processSpec, garden.ProcessIO{})
 					Ω(err).ShouldNot(HaveOccurred())
-					Ω(status).Should(Equal(123))
 
-					_, err = processIO.Stdin.Read([]byte{})
-					Ω(err).Should(Equal(io.EOF))
+					exitInfo, err := process.WaitForExit()
+					Ω(err).ShouldNot(HaveOccurred())
 
-					close(done)
+					signal := garden.SignalKill
+					Ω(exitInfo).Should(Equal(garden.ExitInfo{
+						ExitStatus: 137,
+						Signal:     &signal,
+						OOMKilled:  true,
+						Duration:   5 * time.Second,
+					}))
 				})
+			})
 
-				itResetsGraceTimeWhenHandling(func() {
-					process, err := container.Run(processSpec, garden.ProcessIO{
-						Stdin: bytes.NewBufferString("hello"),
-					})
+			Context("when the backend can only report the exit status", func() {
+				BeforeEach(func() {
+					process := new(fakes.FakeProcess)
+					process.IDReturns(42)
+					process.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
+					process.WaitStub = func() (int, error) {
+						time.Sleep(10 * time.Millisecond)
+						return 3, nil
+					}
+
+					fakeContainer.RunReturns(process, nil)
+				})
+
+				It("sends the exit status and how long the process ran for", func() {
+					process, err := container.Run(processSpec, garden.ProcessIO{})
 					Ω(err).ShouldNot(HaveOccurred())
 
-					status, err := process.Wait()
+					exitInfo, err := process.WaitForExit()
 					Ω(err).ShouldNot(HaveOccurred())
-					Ω(status).Should(Equal(123))
+					Ω(exitInfo.ExitStatus).Should(Equal(3))
+					Ω(exitInfo.Signal).Should(BeNil())
+					Ω(exitInfo.OOMKilled).Should(BeFalse())
+					Ω(exitInfo.Duration).Should(BeNumerically(">=", 10*time.Millisecond))
 				})
 			})
 
@@ -1553,6 +2988,7 @@ var _ = Describe("When a client connects", func() {
 						},
 					}
 					process.IDReturns(42)
+					process.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
 					fakeContainer.RunReturns(process, nil)
 				})
 
@@ -1583,6 +3019,7 @@ var _ = Describe("When a client connects", func() {
 
 				BeforeEach(func() {
 					fakeProcess = new(fakes.FakeProcess)
+					fakeProcess.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
 					fakeProcess.IDReturns(42)
 					fakeProcess.WaitStub = func() (int, error) {
 						select {}
@@ -1609,6 +3046,7 @@ var _ = Describe("When a client connects", func() {
 
 				BeforeEach(func() {
 					fakeProcess = new(fakes.FakeProcess)
+					fakeProcess.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
 					fakeProcess.IDReturns(42)
 					fakeProcess.WaitStub = func() (int, error) {
 						select {}
@@ -1630,11 +3068,130 @@ var _ = Describe("When a client connects", func() {
 				})
 			})
 
+			for signal := range map[garden.Signal]bool{
+				garden.SignalHangUp:             true,
+				garden.SignalInterrupt:          true,
+				garden.SignalQuit:               true,
+				garden.SignalUser1:              true,
+				garden.SignalUser2:              true,
+				garden.SignalWindowChange:       true,
+				garden.SignalPipe:               true,
+				garden.SignalChild:              true,
+				garden.SignalTerminalInput:      true,
+				garden.SignalTerminalOutput:     true,
+				garden.SignalTrap:               true,
+				garden.SignalUrgent:             true,
+				garden.SignalProfile:            true,
+				garden.SignalVirtualAlarm:       true,
+				garden.SignalCPULimit:           true,
+				garden.SignalFileSizeLimit:      true,
+				garden.SignalBadSystemCall:      true,
+				garden.SignalBusError:           true,
+				garden.SignalFloatingPoint:      true,
+				garden.SignalSegmentationFault:  true,
+				garden.SignalIllegalInstruction: true,
+			} {
+				signal := signal
+
+				Context("when the process is sent "+signal.String(), func() {
+					var fakeProcess *fakes.FakeProcess
+
+					BeforeEach(func() {
+						fakeProcess = new(fakes.FakeProcess)
+						fakeProcess.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
+						fakeProcess.IDReturns(42)
+						fakeProcess.WaitStub = func() (int, error) {
+							select {}
+							return 0, nil
+						}
+
+						fakeContainer.RunReturns(fakeProcess, nil)
+					})
+
+					It("is eventually signalled in the backend", func() {
+						process, err := container.Run(processSpec, garden.ProcessIO{})
+						Ω(err).ShouldNot(HaveOccurred())
+
+						err = process.Signal(signal)
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Eventually(fakeProcess.SignalCallCount).Should(Equal(1))
+						Ω(fakeProcess.SignalArgsForCall(0)).Should(Equal(signal))
+					})
+				})
+			}
+
+			Context("when signalling the process fails in the backend", func() {
+				var fakeProcess *fakes.FakeProcess
+
+				BeforeEach(func() {
+					fakeProcess = new(fakes.FakeProcess)
+					fakeProcess.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
+					fakeProcess.IDReturns(42)
+					fakeProcess.WaitStub = func() (int, error) {
+						select {}
+						return 0, nil
+					}
+					fakeProcess.SignalReturns(errors.New("oh no!"))
+
+					fakeContainer.RunReturns(fakeProcess, nil)
+				})
+
+				It("keeps delivering later signals", func() {
+					process, err := container.Run(processSpec, garden.ProcessIO{})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					err = process.Signal(garden.SignalHangUp)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					err = process.Signal(garden.SignalTerminate)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(fakeProcess.SignalCallCount).Should(Equal(2))
+					Ω(fakeProcess.SignalArgsForCall(1)).Should(Equal(garden.SignalTerminate))
+				})
+			})
+
+			Context("when the process stops reading its stdin", func() {
+				var fakeProcess *fakes.FakeProcess
+
+				BeforeEach(func() {
+					fakeProcess = new(fakes.FakeProcess)
+					fakeProcess.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
+					fakeProcess.IDReturns(42)
+					fakeProcess.WaitStub = func() (int, error) {
+						select {}
+						return 0, nil
+					}
+
+					fakeContainer.RunStub = func(spec garden.ProcessSpec, processIO garden.ProcessIO) (garden.Process, error) {
+						processIO.Stdin.(io.Closer).Close()
+						return fakeProcess, nil
+					}
+				})
+
+				It("keeps delivering signals", func() {
+					process, err := container.Run(processSpec, garden.ProcessIO{
+						Stdin: bytes.NewBufferString("some-input"),
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(logger).Should(gbytes.Say("stream-input-write-failed"))
+
+					err = process.Signal(garden.SignalTerminate)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(fakeProcess.SignalCallCount).Should(Equal(1))
+					Ω(fakeProcess.SignalArgsForCall(0)).Should(Equal(garden.SignalTerminate))
+				})
+			})
+
 			Context("when the process's window size is set", func() {
 				var fakeProcess *fakes.FakeProcess
 
 				BeforeEach(func() {
 					fakeProcess = new(fakes.FakeProcess)
+					fakeProcess.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
 					fakeProcess.IDReturns(42)
 					fakeProcess.WaitStub = func() (int, error) {
 						select {}
@@ -1668,6 +3225,7 @@ var _ = Describe("When a client connects", func() {
 				BeforeEach(func() {
 					fakeContainer.RunStub = func(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
 						process := new(fakes.FakeProcess)
+						process.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
 
 						process.IDReturns(42)
 						process.WaitReturns(0, errors.New("oh no!"))
@@ -1702,6 +3260,316 @@ var _ = Describe("When a client connects", func() {
 				})
 			})
 		})
+
+		Describe("streaming processes over WebSockets", func() {
+			stdin := protocol.ProcessPayload_stdin
+			stdout := protocol.ProcessPayload_stdout
+
+			var fakeProcess *fakes.FakeProcess
+
+			dialWebSocket := func(path string, origin string) (*websocket.Conn, error) {
+				config, err := websocket.NewConfig("ws://api"+path, origin)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				conn, err := net.Dial("unix", socketPath)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				return websocket.NewClient(config, conn)
+			}
+
+			BeforeEach(func() {
+				fakeProcess = new(fakes.FakeProcess)
+				fakeProcess.IDReturns(42)
+
+				mirror := func(processIO garden.ProcessIO) (garden.Process, error) {
+					mirrored := make(chan struct{})
+
+					go func() {
+						defer close(mirrored)
+
+						in, _ := ioutil.ReadAll(processIO.Stdin)
+						fmt.Fprintf(processIO.Stdout, "mirrored %s", in)
+					}()
+
+					fakeProcess.WaitForExitStub = func() (garden.ExitInfo, error) {
+						<-mirrored
+						return garden.ExitInfo{ExitStatus: 3}, nil
+					}
+
+					return fakeProcess, nil
+				}
+
+				fakeContainer.RunStub = func(spec garden.ProcessSpec, processIO garden.ProcessIO) (garden.Process, error) {
+					return mirror(processIO)
+				}
+
+				fakeContainer.AttachStub = func(id uint32, processIO garden.ProcessIO) (garden.Process, error) {
+					return mirror(processIO)
+				}
+			})
+
+			It("runs the process, forwarding its input, window size and signals", func() {
+				conn, err := dialWebSocket("/containers/some-handle/processes/websocket", "http://api")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				defer conn.Close()
+
+				err = websocket.JSON.Send(conn, &protocol.RunRequest{
+					Handle: proto.String("some-handle"),
+					Path:   proto.String("/bin/sh"),
+					Tty:    &protocol.TTY{},
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				var payload protocol.ProcessPayload
+				err = websocket.JSON.Receive(conn, &payload)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(payload.GetProcessId()).Should(Equal(uint32(42)))
+
+				spec, _ := fakeContainer.RunArgsForCall(0)
+				Ω(spec.Path).Should(Equal("/bin/sh"))
+				Ω(spec.TTY).ShouldNot(BeNil())
+
+				websocket.JSON.Send(conn, &protocol.ProcessPayload{
+					ProcessId: proto.Uint32(42),
+					Tty: &protocol.TTY{
+						WindowSize: &protocol.TTY_WindowSize{
+							Columns: proto.Uint32(80),
+							Rows:    proto.Uint32(24),
+						},
+					},
+				})
+
+				websocket.JSON.Send(conn, &protocol.ProcessPayload{
+					ProcessId: proto.Uint32(42),
+					Signal:    protocol.ProcessPayload_interrupt.Enum(),
+				})
+
+				websocket.JSON.Send(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stdin, Data: proto.String("hello")})
+				websocket.JSON.Send(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stdin})
+
+				err = websocket.JSON.Receive(conn, &payload)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(payload.GetSource()).Should(Equal(stdout))
+				Ω(payload.GetData()).Should(Equal("mirrored hello"))
+
+				payload = protocol.ProcessPayload{}
+				err = websocket.JSON.Receive(conn, &payload)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(payload.ExitStatus).ShouldNot(BeNil())
+				Ω(payload.GetExitStatus()).Should(Equal(uint32(3)))
+
+				Ω(fakeProcess.SetTTYCallCount()).Should(Equal(1))
+				Ω(fakeProcess.SetTTYArgsForCall(0)).Should(Equal(garden.TTYSpec{
+					WindowSize: &garden.WindowSize{Columns: 80, Rows: 24},
+				}))
+
+				Ω(fakeProcess.SignalCallCount()).Should(Equal(1))
+				Ω(fakeProcess.SignalArgsForCall(0)).Should(Equal(garden.SignalInterrupt))
+			})
+
+			It("attaches to the process, with the stream features asked for in the query", func() {
+				conn, err := dialWebSocket("/containers/some-handle/processes/42/websocket?features=binary-data", "http://api")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				defer conn.Close()
+
+				Eventually(fakeContainer.AttachCallCount).Should(Equal(1))
+
+				id, _ := fakeContainer.AttachArgsForCall(0)
+				Ω(id).Should(Equal(uint32(42)))
+
+				websocket.JSON.Send(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stdin, RawData: []byte("hello")})
+				websocket.JSON.Send(conn, &protocol.ProcessPayload{ProcessId: proto.Uint32(42), Source: &stdin})
+
+				var payload protocol.ProcessPayload
+				err = websocket.JSON.Receive(conn, &payload)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(payload.RawData).Should(Equal([]byte("mirrored hello")))
+				Ω(payload.Data).Should(BeNil())
+			})
+
+			It("refuses pages from other origins", func() {
+				_, err := dialWebSocket("/containers/some-handle/processes/websocket", "http://elsewhere.example.com")
+				Ω(err).Should(HaveOccurred())
+
+				Ω(fakeContainer.RunCallCount()).Should(BeZero())
+			})
+
+			Context("when the container cannot be found", func() {
+				BeforeEach(func() {
+					serverBackend.LookupReturns(nil, garden.ContainerNotFoundError{Handle: "some-handle"})
+				})
+
+				It("refuses the WebSocket", func() {
+					_, err := dialWebSocket("/containers/some-handle/processes/websocket", "http://api")
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("streaming processes in a multiplexed session", func() {
+			var sessionContainer garden.Container
+
+			JustBeforeEach(func() {
+				sessionClient := client.New(connection.New("unix", socketPath, connection.WithMultiplexing()))
+
+				var err error
+				sessionContainer, err = sessionClient.Create(garden.ContainerSpec{})
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			// mirrors stdin to stdout, and exits with the process's ID
+			mirroringProcess := func(id uint32, processIO garden.ProcessIO) garden.Process {
+				mirrored := make(chan struct{})
+
+				go func() {
+					defer close(mirrored)
+
+					in, _ := ioutil.ReadAll(processIO.Stdin)
+					fmt.Fprintf(processIO.Stdout, "%d mirrored %s", id, in)
+				}()
+
+				process := new(fakes.FakeProcess)
+				process.IDReturns(id)
+				process.WaitForExitStub = func() (garden.ExitInfo, error) {
+					<-mirrored
+					return garden.ExitInfo{ExitStatus: int(id)}, nil
+				}
+
+				return process
+			}
+
+			sessionsOpened := func() int {
+				opened := 0
+				for _, log := range logger.Logs() {
+					if strings.HasSuffix(log.Message, ".session.opened") {
+						opened++
+					}
+				}
+
+				return opened
+			}
+
+			Context("when running succeeds", func() {
+				BeforeEach(func() {
+					var lastID uint32
+
+					fakeContainer.RunStub = func(spec garden.ProcessSpec, processIO garden.ProcessIO) (garden.Process, error) {
+						return mirroringProcess(atomic.AddUint32(&lastID, 1), processIO), nil
+					}
+				})
+
+				It("streams every process over one connection", func() {
+					stdouts := []*gbytes.Buffer{}
+					processes := []garden.Process{}
+
+					for i := 0; i < 3; i++ {
+						stdout := gbytes.NewBuffer()
+
+						process, err := sessionContainer.Run(garden.ProcessSpec{Path: "mirror"}, garden.ProcessIO{
+							Stdin:  bytes.NewBufferString(fmt.Sprintf("input %d", i)),
+							Stdout: stdout,
+						})
+						Ω(err).ShouldNot(HaveOccurred())
+
+						stdouts = append(stdouts, stdout)
+						processes = append(processes, process)
+					}
+
+					for i, process := range processes {
+						Eventually(stdouts[i]).Should(gbytes.Say(fmt.Sprintf("%d mirrored input %d", process.ID(), i)))
+
+						status, err := process.Wait()
+						Ω(err).ShouldNot(HaveOccurred())
+						Ω(status).Should(Equal(int(process.ID())))
+					}
+
+					Ω(sessionsOpened()).Should(Equal(1))
+				})
+			})
+
+			Context("when running fails", func() {
+				BeforeEach(func() {
+					fakeContainer.RunReturns(nil, errors.New("oh no!"))
+				})
+
+				It("returns the error", func() {
+					_, err := sessionContainer.Run(garden.ProcessSpec{Path: "mirror"}, garden.ProcessIO{})
+					Ω(err).Should(Equal(garden.BackendError{Message: "oh no!"}))
+				})
+			})
+
+			Context("when attaching succeeds", func() {
+				BeforeEach(func() {
+					fakeContainer.AttachStub = func(id uint32, processIO garden.ProcessIO) (garden.Process, error) {
+						return mirroringProcess(id, processIO), nil
+					}
+				})
+
+				It("streams the process in the session", func() {
+					stdout := gbytes.NewBuffer()
+
+					process, err := sessionContainer.Attach(7, garden.ProcessIO{
+						Stdin:  bytes.NewBufferString("attached input"),
+						Stdout: stdout,
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(stdout).Should(gbytes.Say("7 mirrored attached input"))
+
+					status, err := process.Wait()
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(status).Should(Equal(7))
+
+					Ω(sessionsOpened()).Should(Equal(1))
+				})
+			})
+
+			Context("when a process does not read its stdin", func() {
+				BeforeEach(func() {
+					var lastID uint32
+
+					fakeContainer.RunStub = func(spec garden.ProcessSpec, processIO garden.ProcessIO) (garden.Process, error) {
+						id := atomic.AddUint32(&lastID, 1)
+						if spec.Path == "mirror" {
+							return mirroringProcess(id, processIO), nil
+						}
+
+						process := new(fakes.FakeProcess)
+						process.IDReturns(id)
+						process.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
+						process.WaitStub = func() (int, error) {
+							select {}
+						}
+
+						return process, nil
+					}
+				})
+
+				It("ends its stream once too much input is waiting, without holding up the others", func() {
+					_, err := sessionContainer.Run(garden.ProcessSpec{Path: "ignore-stdin"}, garden.ProcessIO{
+						Stdin: &closeChecker{},
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(logger).Should(gbytes.Say("ended-stream"))
+
+					stdout := gbytes.NewBuffer()
+
+					process, err := sessionContainer.Run(garden.ProcessSpec{Path: "mirror"}, garden.ProcessIO{
+						Stdin:  bytes.NewBufferString("more input"),
+						Stdout: stdout,
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(stdout).Should(gbytes.Say("mirrored more input"))
+
+					_, err = process.Wait()
+					Ω(err).ShouldNot(HaveOccurred())
+				})
+			})
+		})
 	})
 })
 
@@ -1,19 +1,31 @@
 package server
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/garden/routes"
+	"github.com/cloudfoundry-incubator/garden/server/audit"
 	"github.com/cloudfoundry-incubator/garden/server/bomberman"
+	"github.com/cloudfoundry-incubator/garden/server/events"
 	"github.com/gogo/protobuf/proto"
 	"github.com/pivotal-golang/lager"
 	"github.com/tedsuo/rata"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	protocol "github.com/cloudfoundry-incubator/garden/protocol"
+	"github.com/cloudfoundry-incubator/garden/tracing"
+	"github.com/cloudfoundry-incubator/garden/transport"
 )
 
 type GardenServer struct {
@@ -29,16 +41,46 @@ type GardenServer struct {
 	listener net.Listener
 	handling *sync.WaitGroup
 
+	tlsConfig *tls.Config
+
+	authenticator Authenticator
+	auditSink     audit.Sink
+	tracer        tracing.Tracer
+
+	grpcNetwork  string
+	grpcAddr     string
+	grpcListener net.Listener
+	grpcServer   *grpc.Server
+
 	started  bool
 	stopping chan bool
 
+	drain *drainState
+
 	bomberman *bomberman.Bomberman
 
+	events         *events.Hub
+	eventDedup     *events.Deduplicator
+	backendEvents  garden.EventStream
+	backendEventsL sync.Mutex
+
+	processes *processTracker
+
+	outputPolicy  OutputPolicy
+	droppedOutput uint64
+
+	metrics          *serverMetrics
+	containerMetrics *containerMetrics
+
+	webSocketOrigins map[string]struct{}
+
 	conns map[net.Conn]net.Conn
 	mu    sync.Mutex
 
 	destroys  map[string]struct{}
 	destroysL *sync.Mutex
+
+	propertyLocks *propertyLocks
 }
 
 type UnhandledRequestError struct {
@@ -49,11 +91,40 @@ func (e UnhandledRequestError) Error() string {
 	return fmt.Sprintf("unhandled request type: %T", e.Request)
 }
 
+// Option configures optional behaviour of a GardenServer.
+type Option func(*GardenServer)
+
+// WithOutputPolicy sets how much process output is kept and how long writing
+// it may wait for slow streams. The default is DefaultOutputPolicy.
+func WithOutputPolicy(policy OutputPolicy) Option {
+	return func(s *GardenServer) {
+		s.outputPolicy = policy
+	}
+}
+
+// WithTLS serves the API over TLS with the certificate. If clientCAs is given,
+// clients must present a certificate signed by one of them, and are refused
+// otherwise.
+func WithTLS(certificate tls.Certificate, clientCAs *x509.CertPool) Option {
+	return func(s *GardenServer) {
+		s.tlsConfig = &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			MinVersion:   tls.VersionTLS12,
+		}
+
+		if clientCAs != nil {
+			s.tlsConfig.ClientCAs = clientCAs
+			s.tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+}
+
 func New(
 	listenNetwork, listenAddr string,
 	containerGraceTime time.Duration,
 	backend garden.Backend,
 	logger lager.Logger,
+	options ...Option,
 ) *GardenServer {
 	s := &GardenServer{
 		logger: logger.Session("garden-server"),
@@ -66,13 +137,32 @@ func New(
 
 		stopping: make(chan bool),
 
+		drain: newDrainState(),
+
+		events:     events.NewHub(),
+		eventDedup: events.NewDeduplicator(eventDedupWindow),
+
+		processes: newProcessTracker(),
+
+		outputPolicy: DefaultOutputPolicy,
+
+		webSocketOrigins: make(map[string]struct{}),
+
 		handling: new(sync.WaitGroup),
 		conns:    make(map[net.Conn]net.Conn),
 
 		destroys:  make(map[string]struct{}),
 		destroysL: new(sync.Mutex),
+
+		propertyLocks: newPropertyLocks(),
 	}
 
+	for _, option := range options {
+		option(s)
+	}
+
+	s.metrics = newServerMetrics(s)
+
 	handlers := map[string]http.Handler{
 		routes.Ping:                   http.HandlerFunc(s.handlePing),
 		routes.Capacity:               http.HandlerFunc(s.handleCapacity),
@@ -93,11 +183,28 @@ func New(
 		routes.NetIn:                  http.HandlerFunc(s.handleNetIn),
 		routes.NetOut:                 http.HandlerFunc(s.handleNetOut),
 		routes.Info:                   http.HandlerFunc(s.handleInfo),
+		routes.BulkInfo:               http.HandlerFunc(s.handleBulkInfo),
+		routes.Lookup:                 http.HandlerFunc(s.handleLookup),
 		routes.Run:                    http.HandlerFunc(s.handleRun),
 		routes.Attach:                 http.HandlerFunc(s.handleAttach),
+		routes.RunWebSocket:           http.HandlerFunc(s.handleRunWebSocket),
+		routes.AttachWebSocket:        http.HandlerFunc(s.handleAttachWebSocket),
+		routes.Processes:              http.HandlerFunc(s.handleProcesses),
 		routes.GetProperty:            http.HandlerFunc(s.handleGetProperty),
 		routes.SetProperty:            http.HandlerFunc(s.handleSetProperty),
 		routes.RemoveProperty:         http.HandlerFunc(s.handleRemoveProperty),
+		routes.Properties:             http.HandlerFunc(s.handleProperties),
+		routes.SetProperties:          http.HandlerFunc(s.handleSetProperties),
+		routes.Events:                 http.HandlerFunc(s.handleEvents),
+		routes.Session:                http.HandlerFunc(s.handleSession),
+		routes.Metrics:                s.metrics.registry,
+		routes.ContainerMetrics:       http.HandlerFunc(s.handleContainerMetrics),
+		routes.Ready:                  http.HandlerFunc(s.handleReady),
+		routes.Drain:                  http.HandlerFunc(s.handleDrain),
+	}
+
+	for route, handler := range handlers {
+		handlers[route] = s.metrics.instrument(route, s.traced(route, handler))
 	}
 
 	mux, err := rata.NewRouter(routes.Routes, handlers)
@@ -109,6 +216,28 @@ func New(
 
 	s.server = http.Server{
 		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := withRequestContext(
+				withPeer(r.Context(), r.RemoteAddr),
+				r.Header.Get(tracing.RequestIDHeader),
+				r.Header.Get(tracing.TraceParentHeader),
+				r.Header.Get(tracing.TraceStateHeader),
+			)
+
+			r = r.WithContext(ctx)
+
+			w.Header().Set(tracing.RequestIDHeader, tracing.RequestIDFrom(ctx))
+
+			// load balancers probe readiness without credentials
+			if s.authenticator != nil && !isReadinessProbe(r) {
+				identity, err := s.authenticate(r.Header.Get("Authorization"))
+				if err != nil {
+					s.writeError(w, r, err, s.session(ctx, "authenticate"))
+					return
+				}
+
+				r = r.WithContext(withIdentity(r.Context(), identity))
+			}
+
 			mux.ServeHTTP(w, r)
 		}),
 
@@ -143,14 +272,45 @@ func New(
 	return s
 }
 
+// backendFor returns the backend as the caller of a request may use it, with
+// its operations audited if the server keeps an audit log. Backends that take
+// the request's context are given it.
+func (s *GardenServer) backendFor(ctx context.Context) garden.Backend {
+	backend := s.backend
+	if contextual, ok := backend.(garden.ContextualBackend); ok {
+		backend = contextual.WithContext(ctx)
+	}
+
+	backend = s.authorizedBackendFor(ctx, &drainingBackend{
+		Backend: backend,
+		server:  s,
+	})
+
+	if s.auditSink != nil {
+		backend = &auditedBackend{
+			Backend: backend,
+			auditor: s.auditorFor(ctx),
+		}
+	}
+
+	return backend
+}
+
 func (s *GardenServer) Start() error {
 	s.started = true
 
-	err := s.removeExistingSocket()
+	err := s.removeExistingSocket(s.listenNetwork, s.listenAddr)
 	if err != nil {
 		return err
 	}
 
+	if s.grpcAddr != "" {
+		err := s.removeExistingSocket(s.grpcNetwork, s.grpcAddr)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = s.backend.Start()
 	if err != nil {
 		return err
@@ -161,12 +321,57 @@ func (s *GardenServer) Start() error {
 		return err
 	}
 
+	if s.tlsConfig != nil {
+		listener = tls.NewListener(listener, s.tlsConfig)
+	}
+
 	s.listener = listener
 
 	if s.listenNetwork == "unix" {
 		os.Chmod(s.listenAddr, 0777)
 	}
 
+	if s.grpcAddr != "" {
+		grpcListener, err := net.Listen(s.grpcNetwork, s.grpcAddr)
+		if err != nil {
+			return err
+		}
+
+		s.grpcListener = grpcListener
+
+		if s.grpcNetwork == "unix" {
+			os.Chmod(s.grpcAddr, 0777)
+		}
+
+		grpcOptions := []grpc.ServerOption{
+			grpc.ForceServerCodec(transport.GRPCCodec{}),
+		}
+
+		if s.tlsConfig != nil {
+			grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(s.tlsConfig)))
+		}
+
+		unaryInterceptors := []grpc.UnaryServerInterceptor{s.metrics.instrumentUnary, s.traceUnary}
+		streamInterceptors := []grpc.StreamServerInterceptor{s.metrics.instrumentStream, s.traceStream}
+
+		if s.authenticator != nil {
+			unaryInterceptors = append(unaryInterceptors, s.authenticateUnary)
+			streamInterceptors = append(streamInterceptors, s.authenticateStream)
+		}
+
+		grpcOptions = append(grpcOptions,
+			grpc.ChainUnaryInterceptor(unaryInterceptors...),
+			grpc.ChainStreamInterceptor(streamInterceptors...),
+		)
+
+		s.grpcServer = grpc.NewServer(grpcOptions...)
+
+		protocol.RegisterGardenServer(s.grpcServer, &grpcService{
+			server: s,
+			logger: s.logger.Session("grpc"),
+		})
+	}
+
 	containers, err := s.backend.Containers(nil)
 	if err != nil {
 		return err
@@ -178,8 +383,31 @@ func (s *GardenServer) Start() error {
 		s.bomberman.Strap(container)
 	}
 
+	backendEvents, err := s.backend.Events(nil)
+	if err != nil && err != garden.ErrNotImplemented {
+		return err
+	}
+
+	if backendEvents != nil {
+		s.backendEvents = backendEvents
+		go s.forwardBackendEvents(backendEvents)
+	}
+
+	if len(s.drain.signals) > 0 {
+		s.drainOnSignals()
+	}
+
+	if s.containerMetrics != nil {
+		s.containerMetrics.running = true
+		go s.collectContainerMetrics()
+	}
+
 	go s.server.Serve(listener)
 
+	if s.grpcServer != nil {
+		go s.grpcServer.Serve(s.grpcListener)
+	}
+
 	return nil
 }
 
@@ -208,22 +436,58 @@ func (s *GardenServer) Stop() {
 	s.logger.Info("waiting-for-connections-to-close")
 	s.handling.Wait()
 
+	if s.grpcServer != nil {
+		s.logger.Info("waiting-for-grpc-calls-to-finish")
+		s.grpcServer.GracefulStop()
+	}
+
+	s.backendEventsL.Lock()
+	if s.backendEvents != nil {
+		s.backendEvents.Close()
+	}
+	s.backendEventsL.Unlock()
+
+	if s.containerMetrics != nil && s.containerMetrics.running {
+		<-s.containerMetrics.done
+	}
+
 	s.logger.Info("stopping-backend")
 	s.backend.Stop()
 
 	s.logger.Info("stopped")
 }
 
-func (s *GardenServer) removeExistingSocket() error {
-	if s.listenNetwork != "unix" {
+// DroppedOutputBytes returns the number of bytes of process output dropped
+// before an attached stream could send them, because the output policy did
+// not allow waiting any longer.
+func (s *GardenServer) DroppedOutputBytes() uint64 {
+	return atomic.LoadUint64(&s.droppedOutput)
+}
+
+// bombCounts returns the numbers of containers whose grace times are counting
+// down and paused.
+func (s *GardenServer) bombCounts() bomberman.Counts {
+	if s.bomberman == nil {
+		return bomberman.Counts{}
+	}
+
+	return s.bomberman.Counts()
+}
+
+func (s *GardenServer) countDroppedOutput(bytes uint64) {
+	atomic.AddUint64(&s.droppedOutput, bytes)
+}
+
+func (s *GardenServer) removeExistingSocket(network, addr string) error {
+	if network != "unix" {
 		return nil
 	}
 
-	if _, err := os.Stat(s.listenAddr); os.IsNotExist(err) {
+	if _, err := os.Stat(addr); os.IsNotExist(err) {
 		return nil
 	}
 
-	err := os.Remove(s.listenAddr)
+	err := os.Remove(addr)
 
 	if err != nil {
 		return fmt.Errorf("error deleting existing socket: %s", err)
@@ -238,5 +502,147 @@ func (s *GardenServer) reapContainer(container garden.Container) {
 		"grace-time": s.backend.GraceTime(container).String(),
 	})
 
-	s.backend.Destroy(container.Handle())
+	properties := s.eventProperties(container)
+
+	err := s.backend.Destroy(container.Handle())
+	if err != nil {
+		s.logger.Error("failed-to-reap", err, lager.Data{
+			"handle": container.Handle(),
+		})
+
+		return
+	}
+
+	s.processes.Forget(container.Handle())
+
+	s.metrics.reaps.Inc()
+
+	s.publishEvent(garden.EventTypeReap, container.Handle(), properties, nil)
+}
+
+// backendEventsBackoff is how long the server waits before subscribing to the
+// backend's events again after its stream fails. It doubles after each failed
+// attempt, up to maxBackendEventsBackoff.
+const (
+	backendEventsBackoff    = 100 * time.Millisecond
+	maxBackendEventsBackoff = 5 * time.Second
+)
+
+// eventDedupWindow is how long after the server or the backend publishes an
+// event the same event from the other is taken to be a duplicate of it.
+const eventDedupWindow = 30 * time.Second
+
+// forwardBackendEvents publishes the backend's events until the server stops,
+// subscribing to them again if the stream fails.
+func (s *GardenServer) forwardBackendEvents(stream garden.EventStream) {
+	logger := s.logger.Session("backend-events")
+
+	backoff := backendEventsBackoff
+
+	for {
+		event, err := stream.Next()
+		if err == nil {
+			backoff = backendEventsBackoff
+
+			if s.eventDedup.First(events.SourceBackend, event) {
+				s.events.Publish(event)
+			}
+
+			continue
+		}
+
+		select {
+		case <-s.stopping:
+			return
+		default:
+		}
+
+		logger.Error("failed-to-read-event", err)
+
+		stream.Close()
+
+		stream = s.resubscribeToBackendEvents(logger, &backoff)
+		if stream == nil {
+			return
+		}
+	}
+}
+
+// resubscribeToBackendEvents subscribes to the backend's events again, waiting
+// longer before each attempt, and returns nil if the server stops first.
+func (s *GardenServer) resubscribeToBackendEvents(logger lager.Logger, backoff *time.Duration) garden.EventStream {
+	for {
+		select {
+		case <-time.After(*backoff):
+		case <-s.stopping:
+			return nil
+		}
+
+		*backoff *= 2
+		if *backoff > maxBackendEventsBackoff {
+			*backoff = maxBackendEventsBackoff
+		}
+
+		stream, err := s.backend.Events(nil)
+		if err == garden.ErrNotImplemented {
+			logger.Info("backend-stopped-publishing-events")
+			return nil
+		}
+
+		if err != nil {
+			logger.Error("failed-to-resubscribe", err)
+			continue
+		}
+
+		s.backendEventsL.Lock()
+
+		select {
+		case <-s.stopping:
+			s.backendEventsL.Unlock()
+			stream.Close()
+			return nil
+		default:
+		}
+
+		s.backendEvents = stream
+		s.backendEventsL.Unlock()
+
+		logger.Info("resubscribed")
+
+		return stream
+	}
+}
+
+// publishEvent is a no-op unless a client is subscribed to events.
+func (s *GardenServer) publishEvent(eventType garden.EventType, handle string, properties garden.Properties, data map[string]string) {
+	if !s.events.HasSubscribers() {
+		return
+	}
+
+	event := garden.Event{
+		Type:       eventType,
+		Handle:     handle,
+		Time:       time.Now(),
+		Properties: properties,
+		Data:       data,
+	}
+
+	if s.eventDedup.First(events.SourceServer, event) {
+		s.events.Publish(event)
+	}
+}
+
+// eventProperties snapshots the container's properties for events, and only
+// asks the backend for them when a client is subscribed to events.
+func (s *GardenServer) eventProperties(container garden.Container) garden.Properties {
+	if !s.events.HasSubscribers() {
+		return nil
+	}
+
+	properties, err := containerProperties(container)
+	if err != nil {
+		return nil
+	}
+
+	return properties
 }
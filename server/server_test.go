@@ -1,7 +1,9 @@
 package server_test
 
 import (
+	"bytes"
 	"errors"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
@@ -128,6 +130,258 @@ var _ = Describe("The Garden server", func() {
 		Ω(time.Since(before)).Should(BeNumerically(">", 100*time.Millisecond))
 	})
 
+	It("streams an event for containers reaped after their grace time", func() {
+		var err error
+		tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		socketPath := path.Join(tmpdir, "api.sock")
+
+		fakeBackend := new(fakes.FakeBackend)
+
+		doomedContainer := new(fakes.FakeContainer)
+		doomedContainer.HandleReturns("doomed")
+
+		fakeBackend.ContainersReturns([]garden.Container{doomedContainer}, nil)
+		fakeBackend.GraceTimeReturns(500 * time.Millisecond)
+
+		apiServer := server.New("unix", socketPath, 0, fakeBackend, logger)
+
+		err = apiServer.Start()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		defer apiServer.Stop()
+
+		events, err := client.New(connection.New("unix", socketPath)).Events(nil)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		defer events.Close()
+
+		event, err := events.Next()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(event.Type).Should(Equal(garden.EventTypeReap))
+		Ω(event.Handle).Should(Equal("doomed"))
+	})
+
+	Context("when the backend publishes events", func() {
+		var fakeBackend *fakes.FakeBackend
+		var fakeEventStream *fakes.FakeEventStream
+		var backendEvents chan garden.Event
+
+		var apiServer *server.GardenServer
+		var apiClient garden.Client
+		var events garden.EventStream
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath := path.Join(tmpdir, "api.sock")
+
+			backendEvents = make(chan garden.Event)
+			backendEvents := backendEvents
+
+			fakeEventStream = new(fakes.FakeEventStream)
+			fakeEventStream.NextStub = func() (garden.Event, error) {
+				event, ok := <-backendEvents
+				if !ok {
+					return garden.Event{}, errors.New("closed")
+				}
+
+				return event, nil
+			}
+
+			fakeBackend = new(fakes.FakeBackend)
+			fakeBackend.EventsReturns(fakeEventStream, nil)
+
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger)
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			apiClient = client.New(connection.New("unix", socketPath))
+
+			events, err = apiClient.Events(nil)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			events.Close()
+
+			apiServer.Stop()
+			close(backendEvents)
+
+			Ω(fakeEventStream.CloseCallCount()).Should(Equal(1))
+		})
+
+		It("streams them to clients", func() {
+			backendEvents <- garden.Event{
+				Type:   garden.EventTypeCreate,
+				Handle: "some-handle",
+			}
+
+			backendEvents <- garden.Event{
+				Type:   garden.EventTypeOOM,
+				Handle: "some-handle",
+				Time:   time.Unix(123, 456),
+			}
+
+			event, err := events.Next()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(event.Type).Should(Equal(garden.EventTypeCreate))
+
+			event, err = events.Next()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(event.Type).Should(Equal(garden.EventTypeOOM))
+			Ω(event.Handle).Should(Equal("some-handle"))
+			Ω(event.Time).Should(Equal(time.Unix(123, 456)))
+		})
+
+		It("drops those the server has already published", func() {
+			fakeContainer := new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+			fakeBackend.CreateReturns(fakeContainer, nil)
+
+			_, err := apiClient.Create(garden.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			backendEvents <- garden.Event{
+				Type:   garden.EventTypeCreate,
+				Handle: "some-handle",
+			}
+
+			backendEvents <- garden.Event{
+				Type:   garden.EventTypeOOM,
+				Handle: "some-handle",
+			}
+
+			event, err := events.Next()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(event.Type).Should(Equal(garden.EventTypeCreate))
+
+			event, err = events.Next()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(event.Type).Should(Equal(garden.EventTypeOOM))
+		})
+	})
+
+	Context("when the backend's event stream fails", func() {
+		It("logs the error and subscribes again", func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath := path.Join(tmpdir, "api.sock")
+
+			failingStream := new(fakes.FakeEventStream)
+			failingStream.NextReturns(garden.Event{}, errors.New("connection lost"))
+
+			backendEvents := make(chan garden.Event)
+
+			resubscribedStream := new(fakes.FakeEventStream)
+			resubscribedStream.NextStub = func() (garden.Event, error) {
+				event, ok := <-backendEvents
+				if !ok {
+					return garden.Event{}, errors.New("closed")
+				}
+
+				return event, nil
+			}
+
+			fakeBackend := new(fakes.FakeBackend)
+			fakeBackend.EventsStub = func(garden.Properties) (garden.EventStream, error) {
+				switch fakeBackend.EventsCallCount() {
+				case 1:
+					return failingStream, nil
+				case 2:
+					return nil, errors.New("still down")
+				default:
+					return resubscribedStream, nil
+				}
+			}
+
+			apiServer := server.New("unix", socketPath, 0, fakeBackend, logger)
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(logger).Should(gbytes.Say("failed-to-read-event.*connection lost"))
+			Eventually(logger).Should(gbytes.Say("failed-to-resubscribe.*still down"))
+			Eventually(fakeBackend.EventsCallCount).Should(Equal(3))
+
+			Ω(failingStream.CloseCallCount()).Should(Equal(1))
+
+			events, err := client.New(connection.New("unix", socketPath)).Events(nil)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			defer events.Close()
+
+			backendEvents <- garden.Event{Type: garden.EventTypeOOM, Handle: "some-handle"}
+
+			event, err := events.Next()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(event.Type).Should(Equal(garden.EventTypeOOM))
+
+			apiServer.Stop()
+			close(backendEvents)
+
+			Ω(resubscribedStream.CloseCallCount()).Should(Equal(1))
+		})
+	})
+
+	Context("when the backend stops publishing events", func() {
+		It("stops subscribing to them", func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath := path.Join(tmpdir, "api.sock")
+
+			failingStream := new(fakes.FakeEventStream)
+			failingStream.NextReturns(garden.Event{}, errors.New("connection lost"))
+
+			fakeBackend := new(fakes.FakeBackend)
+			fakeBackend.EventsStub = func(garden.Properties) (garden.EventStream, error) {
+				if fakeBackend.EventsCallCount() == 1 {
+					return failingStream, nil
+				}
+
+				return nil, garden.ErrNotImplemented
+			}
+
+			apiServer := server.New("unix", socketPath, 0, fakeBackend, logger)
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			defer apiServer.Stop()
+
+			Eventually(fakeBackend.EventsCallCount).Should(Equal(2))
+			Consistently(fakeBackend.EventsCallCount, 500*time.Millisecond).Should(Equal(2))
+		})
+	})
+
+	Context("when the backend does not publish events", func() {
+		It("starts anyway", func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath := path.Join(tmpdir, "api.sock")
+
+			fakeBackend := new(fakes.FakeBackend)
+			fakeBackend.EventsReturns(nil, garden.ErrNotImplemented)
+
+			apiServer := server.New("unix", socketPath, 0, fakeBackend, logger)
+
+			err = apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
 	Context("when starting the backend fails", func() {
 		disaster := errors.New("oh no!")
 
@@ -167,6 +421,150 @@ var _ = Describe("The Garden server", func() {
 		})
 	})
 
+	Describe("streaming process output", func() {
+		var socketPath string
+
+		var fakeBackend *fakes.FakeBackend
+		var fakeContainer *fakes.FakeContainer
+		var outputPolicy server.OutputPolicy
+
+		var apiServer *server.GardenServer
+		var apiClient garden.Client
+
+		var ranIO chan garden.ProcessIO
+		var exit chan struct{}
+
+		var process garden.Process
+		var stdoutR *io.PipeReader
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			socketPath = path.Join(tmpdir, "api.sock")
+
+			ranIO = make(chan garden.ProcessIO, 1)
+			exit = make(chan struct{})
+			exit := exit
+
+			process = nil
+
+			backendProcess := new(fakes.FakeProcess)
+			backendProcess.IDReturns(42)
+			backendProcess.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
+			backendProcess.WaitStub = func() (int, error) {
+				<-exit
+				return 0, nil
+			}
+
+			fakeContainer = new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+			fakeContainer.RunStub = func(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+				ranIO <- io
+				return backendProcess, nil
+			}
+
+			fakeBackend = new(fakes.FakeBackend)
+			fakeBackend.LookupReturns(fakeContainer, nil)
+
+			apiClient = client.New(connection.New("unix", socketPath))
+		})
+
+		JustBeforeEach(func() {
+			apiServer = server.New("unix", socketPath, 0, fakeBackend, logger, server.WithOutputPolicy(outputPolicy))
+
+			err := apiServer.Start()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			close(exit)
+
+			if process != nil {
+				stdoutR.Close()
+
+				exited := make(chan struct{})
+				go func() {
+					process.Wait()
+					close(exited)
+				}()
+
+				Eventually(exited).Should(BeClosed())
+			}
+
+			apiServer.Stop()
+		})
+
+		// more than fits in the client's window and the socket's buffers
+		output := bytes.Repeat([]byte("0123456789abcdef"), 256*1024)
+
+		writeOutput := func(processIO garden.ProcessIO) <-chan struct{} {
+			written := make(chan struct{})
+
+			go func() {
+				defer close(written)
+
+				for chunk := 0; chunk < len(output); chunk += 1024 {
+					processIO.Stdout.Write(output[chunk : chunk+1024])
+				}
+			}()
+
+			return written
+		}
+
+		Context("when the policy allows writes to block", func() {
+			BeforeEach(func() {
+				outputPolicy = server.OutputPolicy{BufferSize: 1024, MaxBlock: -1}
+			})
+
+			It("holds the process's writes back until a slow client catches up", func() {
+				var stdoutW *io.PipeWriter
+				stdoutR, stdoutW = io.Pipe()
+
+				container, err := apiClient.Lookup("some-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				process, err = container.Run(garden.ProcessSpec{}, garden.ProcessIO{Stdout: stdoutW})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				written := writeOutput(<-ranIO)
+				Consistently(written).ShouldNot(BeClosed())
+
+				received, err := ioutil.ReadAll(io.LimitReader(stdoutR, int64(len(output))))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(bytes.Equal(received, output)).Should(BeTrue())
+
+				Eventually(written).Should(BeClosed())
+				Ω(apiServer.DroppedOutputBytes()).Should(BeZero())
+			})
+		})
+
+		Context("when the policy does not allow writes to block", func() {
+			BeforeEach(func() {
+				outputPolicy = server.OutputPolicy{BufferSize: 1024, MaxBlock: 0}
+			})
+
+			It("drops what a slow client could not keep up with and counts it", func() {
+				var stdoutW *io.PipeWriter
+				stdoutR, stdoutW = io.Pipe()
+
+				container, err := apiClient.Lookup("some-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				process, err = container.Run(garden.ProcessSpec{}, garden.ProcessIO{Stdout: stdoutW})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				written := writeOutput(<-ranIO)
+				Eventually(written).Should(BeClosed())
+
+				Ω(apiServer.DroppedOutputBytes()).Should(BeNumerically(">", 0))
+			})
+		})
+	})
+
 	Describe("shutting down", func() {
 		var socketPath string
 
@@ -263,6 +661,7 @@ var _ = Describe("The Garden server", func() {
 
 				fakeContainer.RunStub = func(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
 					process := new(fakes.FakeProcess)
+					process.WaitForExitReturns(garden.ExitInfo{}, garden.ErrNotImplemented)
 
 					process.WaitStub = func() (int, error) {
 						time.Sleep(time.Minute)
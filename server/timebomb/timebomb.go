@@ -66,6 +66,14 @@ func (b *TimeBomb) Defuse() bool {
 	return timer.Stop()
 }
 
+// Paused returns whether the bomb's countdown is paused.
+func (b *TimeBomb) Paused() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.pauses > 0
+}
+
 func (b *TimeBomb) Unpause() {
 	b.lock.Lock()
 	defer b.lock.Unlock()
@@ -0,0 +1,173 @@
+package server_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden/client"
+	"github.com/cloudfoundry-incubator/garden/client/connection"
+	"github.com/cloudfoundry-incubator/garden/fakes"
+	"github.com/cloudfoundry-incubator/garden/server"
+)
+
+var _ = Describe("When the server is started with TLS", func() {
+	var tmpdir string
+	var socketPath string
+	var grpcSocketPath string
+
+	var serverBackend *fakes.FakeBackend
+
+	var ca *certificateAuthority
+	var clientCAs *x509.CertPool
+
+	var apiServer *server.GardenServer
+
+	clientConfig := func(certificates ...tls.Certificate) *tls.Config {
+		return &tls.Config{
+			Certificates: certificates,
+			RootCAs:      ca.Pool,
+			ServerName:   "garden.test",
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir(os.TempDir(), "api-server-test")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		socketPath = path.Join(tmpdir, "api.sock")
+		grpcSocketPath = path.Join(tmpdir, "grpc.sock")
+
+		serverBackend = new(fakes.FakeBackend)
+
+		ca = newCertificateAuthority()
+		clientCAs = ca.Pool
+	})
+
+	JustBeforeEach(func() {
+		apiServer = server.New(
+			"unix",
+			socketPath,
+			42*time.Second,
+			serverBackend,
+			lagertest.NewTestLogger("test"),
+			server.WithTLS(ca.Issue("garden.test"), clientCAs),
+			server.WithGRPC("unix", grpcSocketPath),
+		)
+
+		err := apiServer.Start()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Eventually(ErrorDialing("unix", socketPath)).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		apiServer.Stop()
+		os.RemoveAll(tmpdir)
+	})
+
+	Context("and the client presents a certificate signed by the client CA", func() {
+		var apiClient garden.Client
+
+		JustBeforeEach(func() {
+			apiClient = client.New(connection.New("unix", socketPath, connection.WithTLS(clientConfig(ca.Issue("client")))))
+		})
+
+		It("serves requests", func() {
+			Ω(apiClient.Ping()).ShouldNot(HaveOccurred())
+		})
+
+		It("streams processes over the hijacked connection", func() {
+			fakeContainer := new(fakes.FakeContainer)
+			fakeContainer.HandleReturns("some-handle")
+			fakeContainer.RunStub = func(spec garden.ProcessSpec, processIO garden.ProcessIO) (garden.Process, error) {
+				processIO.Stdout.Write([]byte("hello over tls"))
+
+				process := new(fakes.FakeProcess)
+				process.IDReturns(42)
+				process.WaitForExitReturns(garden.ExitInfo{ExitStatus: 0}, nil)
+
+				return process, nil
+			}
+
+			serverBackend.CreateReturns(fakeContainer, nil)
+			serverBackend.LookupReturns(fakeContainer, nil)
+
+			container, err := apiClient.Create(garden.ContainerSpec{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			stdout := gbytes.NewBuffer()
+
+			process, err := container.Run(garden.ProcessSpec{Path: "hello"}, garden.ProcessIO{Stdout: stdout})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(stdout).Should(gbytes.Say("hello over tls"))
+
+			status, err := process.Wait()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(status).Should(Equal(0))
+		})
+
+		It("serves the gRPC service too", func() {
+			grpcClient := client.New(connection.NewGRPC("unix", grpcSocketPath, connection.WithTLS(clientConfig(ca.Issue("client")))))
+			Ω(grpcClient.Ping()).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("and the client presents no certificate", func() {
+		It("refuses the client", func() {
+			apiClient := client.New(connection.New("unix", socketPath, connection.WithTLS(clientConfig())))
+			Ω(apiClient.Ping()).Should(HaveOccurred())
+
+			grpcClient := client.New(connection.NewGRPC("unix", grpcSocketPath, connection.WithTLS(clientConfig())))
+			Ω(grpcClient.Ping()).Should(HaveOccurred())
+		})
+
+		Context("when the server has no client CA", func() {
+			BeforeEach(func() {
+				clientCAs = nil
+			})
+
+			It("serves requests", func() {
+				apiClient := client.New(connection.New("unix", socketPath, connection.WithTLS(clientConfig())))
+				Ω(apiClient.Ping()).ShouldNot(HaveOccurred())
+			})
+		})
+	})
+
+	Context("and the client presents a certificate signed by another CA", func() {
+		It("refuses the client", func() {
+			otherCA := newCertificateAuthority()
+
+			apiClient := client.New(connection.New("unix", socketPath, connection.WithTLS(clientConfig(otherCA.Issue("client")))))
+			Ω(apiClient.Ping()).Should(HaveOccurred())
+		})
+	})
+
+	Context("and the client does not trust the server's certificate", func() {
+		It("fails to connect", func() {
+			config := clientConfig(ca.Issue("client"))
+			config.RootCAs = newCertificateAuthority().Pool
+
+			apiClient := client.New(connection.New("unix", socketPath, connection.WithTLS(config)))
+			Ω(apiClient.Ping()).Should(HaveOccurred())
+		})
+	})
+
+	Context("and the client does not use TLS", func() {
+		It("fails to connect", func() {
+			apiClient := client.New(connection.New("unix", socketPath))
+			Ω(apiClient.Ping()).Should(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pivotal-golang/lager"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/cloudfoundry-incubator/garden/tracing"
+)
+
+// WithTracer exports a span to the tracer for every request the server
+// handles.
+func WithTracer(tracer tracing.Tracer) Option {
+	return func(s *GardenServer) {
+		s.tracer = tracer
+	}
+}
+
+// withRequestContext adopts the request ID and trace context a request was
+// sent with, giving it a request ID if it has none.
+func withRequestContext(ctx context.Context, requestID, traceparent, tracestate string) context.Context {
+	if requestID == "" {
+		requestID = tracing.NewRequestID()
+	}
+
+	ctx = tracing.WithRequestID(ctx, requestID)
+
+	if tc, ok := tracing.ParseTraceParent(traceparent, tracestate); ok {
+		ctx = tracing.WithTraceContext(ctx, tc)
+	}
+
+	return ctx
+}
+
+// startSpan starts the span of handling a request, as a child of the caller's
+// span if it sent one, and returns the context of the request within it.
+func startSpan(ctx context.Context, name string) (*tracing.Span, context.Context) {
+	span := &tracing.Span{
+		Name:       name,
+		RequestID:  tracing.RequestIDFrom(ctx),
+		Start:      time.Now(),
+		Attributes: make(map[string]string),
+	}
+
+	var tc tracing.TraceContext
+
+	if parent, ok := tracing.TraceContextFrom(ctx); ok {
+		tc = parent.Child()
+		span.ParentID = parent.SpanID
+	} else {
+		tc = tracing.NewTraceContext()
+	}
+
+	span.TraceID = tc.TraceID
+	span.SpanID = tc.SpanID
+
+	return span, tracing.WithTraceContext(ctx, tc)
+}
+
+func (s *GardenServer) endSpan(span *tracing.Span) {
+	if s.tracer == nil {
+		return
+	}
+
+	span.End = time.Now()
+	s.tracer.ExportSpan(*span)
+}
+
+// traced handles the route's requests within a span of their own.
+func (s *GardenServer) traced(route string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span, ctx := startSpan(r.Context(), route)
+
+		recorder := &statusRecorder{
+			ResponseWriter: w,
+			status:         http.StatusOK,
+			observe:        func(int) {},
+		}
+
+		handler.ServeHTTP(recorder, r.WithContext(ctx))
+
+		if handle := r.FormValue(":handle"); handle != "" {
+			span.Attributes["garden.handle"] = handle
+		}
+
+		span.Attributes["http.status_code"] = strconv.Itoa(recorder.status)
+
+		s.endSpan(span)
+	})
+}
+
+// grpcRequestContext adopts the request ID and trace context in a call's
+// metadata.
+func grpcRequestContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	first := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+
+		return ""
+	}
+
+	return withRequestContext(
+		ctx,
+		first(tracing.RequestIDHeader),
+		first(tracing.TraceParentHeader),
+		first(tracing.TraceStateHeader),
+	)
+}
+
+func (s *GardenServer) endCallSpan(span *tracing.Span, err error) {
+	span.Attributes["rpc.grpc.status_code"] = status.Code(err).String()
+	s.endSpan(span)
+}
+
+func (s *GardenServer) traceUnary(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	span, ctx := startSpan(grpcRequestContext(ctx), grpcMethodName(info.FullMethod))
+
+	response, err := handler(ctx, request)
+	s.endCallSpan(span, err)
+
+	return response, err
+}
+
+func (s *GardenServer) traceStream(service interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	span, ctx := startSpan(grpcRequestContext(stream.Context()), grpcMethodName(info.FullMethod))
+
+	err := handler(service, contextStream{stream, ctx})
+	s.endCallSpan(span, err)
+
+	return err
+}
+
+func grpcMethodName(fullMethod string) string {
+	return strings.TrimPrefix(fullMethod, "/garden.Garden/")
+}
+
+// session returns the logger session of handling a request, whose data
+// carries the request's ID and trace context.
+func (s *GardenServer) session(ctx context.Context, task string, data ...lager.Data) lager.Logger {
+	return s.logger.Session(task, append(data, requestData(ctx))...)
+}
+
+func requestData(ctx context.Context) lager.Data {
+	data := lager.Data{}
+
+	if requestID := tracing.RequestIDFrom(ctx); requestID != "" {
+		data["request-id"] = requestID
+	}
+
+	if tc, ok := tracing.TraceContextFrom(ctx); ok {
+		data["trace-id"] = tc.TraceID.String()
+		data["span-id"] = tc.SpanID.String()
+	}
+
+	return data
+}
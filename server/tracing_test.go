@@ -0,0 +1,259 @@
+package server_test
+
+import (
+	"net"
+	"net/http"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"golang.org/x/net/context"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden/client"
+	"github.com/cloudfoundry-incubator/garden/client/connection"
+	"github.com/cloudfoundry-incubator/garden/fakes"
+	"github.com/cloudfoundry-incubator/garden/server"
+	"github.com/cloudfoundry-incubator/garden/tracing"
+)
+
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []tracing.Span
+}
+
+func (t *recordingTracer) ExportSpan(span tracing.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.spans = append(t.spans, span)
+}
+
+func (t *recordingTracer) Spans() []tracing.Span {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]tracing.Span(nil), t.spans...)
+}
+
+type contextualBackend struct {
+	*fakes.FakeBackend
+
+	mu       sync.Mutex
+	contexts []context.Context
+}
+
+func (b *contextualBackend) WithContext(ctx context.Context) garden.Backend {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.contexts = append(b.contexts, ctx)
+
+	return b.FakeBackend
+}
+
+func (b *contextualBackend) Contexts() []context.Context {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]context.Context(nil), b.contexts...)
+}
+
+var _ = Describe("When requests are traced", func() {
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+
+	var fixture *serverFixture
+	var grpcSocketPath string
+
+	var serverBackend *contextualBackend
+	var fakeContainer *fakes.FakeContainer
+	var tracer *recordingTracer
+
+	BeforeEach(func() {
+		fixture = newServerFixture()
+		grpcSocketPath = fixture.Path("grpc.sock")
+
+		serverBackend = &contextualBackend{FakeBackend: fixture.Backend}
+		tracer = new(recordingTracer)
+
+		fakeContainer = new(fakes.FakeContainer)
+		fakeContainer.HandleReturns("some-handle")
+
+		serverBackend.LookupReturns(fakeContainer, nil)
+
+		fixture.StartWith(
+			serverBackend,
+			server.WithGRPC("unix", grpcSocketPath),
+			server.WithTracer(tracer),
+		)
+	})
+
+	AfterEach(func() {
+		fixture.Stop()
+	})
+
+	request := func(method, path string, header http.Header) *http.Response {
+		httpClient := &http.Client{
+			Transport: &http.Transport{
+				Dial: func(string, string) (net.Conn, error) {
+					return net.Dial("unix", fixture.SocketPath)
+				},
+				DisableKeepAlives: true,
+			},
+		}
+
+		req, err := http.NewRequest(method, "http://api"+path, nil)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		for name, values := range header {
+			req.Header[name] = values
+		}
+
+		response, err := httpClient.Do(req)
+		Ω(err).ShouldNot(HaveOccurred())
+		response.Body.Close()
+
+		return response
+	}
+
+	Context("with a request ID and trace context sent", func() {
+		var response *http.Response
+
+		BeforeEach(func() {
+			response = request("GET", "/containers/some-handle/info", http.Header{
+				"X-Request-Id": {"some-request"},
+				"Traceparent":  {"00-" + traceID + "-" + spanID + "-01"},
+			})
+		})
+
+		It("exports the span of handling it, within the caller's trace", func() {
+			Eventually(tracer.Spans).Should(HaveLen(1))
+
+			span := tracer.Spans()[0]
+			Ω(span.Name).Should(Equal("Info"))
+			Ω(span.RequestID).Should(Equal("some-request"))
+			Ω(span.TraceID.String()).Should(Equal(traceID))
+			Ω(span.ParentID.String()).Should(Equal(spanID))
+			Ω(span.SpanID.String()).ShouldNot(Equal(spanID))
+			Ω(span.End).ShouldNot(BeTemporally("<", span.Start))
+			Ω(span.Attributes).Should(HaveKeyWithValue("garden.handle", "some-handle"))
+			Ω(span.Attributes).Should(HaveKeyWithValue("http.status_code", "200"))
+		})
+
+		It("passes them to the backend", func() {
+			Eventually(tracer.Spans).Should(HaveLen(1))
+
+			contexts := serverBackend.Contexts()
+			Ω(contexts).Should(HaveLen(1))
+
+			Ω(tracing.RequestIDFrom(contexts[0])).Should(Equal("some-request"))
+
+			tc, ok := tracing.TraceContextFrom(contexts[0])
+			Ω(ok).Should(BeTrue())
+			Ω(tc.TraceID.String()).Should(Equal(traceID))
+			Ω(tc.SpanID).Should(Equal(tracer.Spans()[0].SpanID))
+		})
+
+		It("logs them with the request", func() {
+			Ω(fixture.Logger).Should(gbytes.Say(`"request-id":"some-request"`))
+			Ω(fixture.Logger).Should(gbytes.Say(`"trace-id":"` + traceID + `"`))
+		})
+
+		It("echoes the request ID", func() {
+			Ω(response.Header.Get("X-Request-Id")).Should(Equal("some-request"))
+		})
+	})
+
+	Context("with no request ID or trace context sent", func() {
+		It("gives the request its own, starting a trace", func() {
+			response := request("GET", "/ping", nil)
+			Ω(response.Header.Get("X-Request-Id")).Should(HaveLen(32))
+
+			Eventually(tracer.Spans).Should(HaveLen(1))
+
+			span := tracer.Spans()[0]
+			Ω(span.Name).Should(Equal("Ping"))
+			Ω(span.RequestID).Should(Equal(response.Header.Get("X-Request-Id")))
+			Ω(span.ParentID).Should(BeZero())
+		})
+	})
+
+	for _, transport := range []string{"http", "grpc"} {
+		transport := transport
+
+		Context("from a client over "+transport, func() {
+			var caller tracing.TraceContext
+			var apiClient garden.Client
+
+			BeforeEach(func() {
+				caller = tracing.NewTraceContext()
+
+				source := connection.WithTraceContext(func() (tracing.TraceContext, bool) {
+					return caller, true
+				})
+
+				if transport == "grpc" {
+					apiClient = client.New(connection.NewGRPC("unix", grpcSocketPath, source))
+				} else {
+					apiClient = client.New(connection.New("unix", fixture.SocketPath, source))
+				}
+			})
+
+			It("traces requests within the caller's trace", func() {
+				Ω(apiClient.Ping()).Should(Succeed())
+
+				Eventually(tracer.Spans).Should(HaveLen(1))
+
+				span := tracer.Spans()[0]
+				Ω(span.Name).Should(Equal("Ping"))
+				Ω(span.TraceID).Should(Equal(caller.TraceID))
+				Ω(span.ParentID).ShouldNot(Equal(caller.SpanID))
+				Ω(span.ParentID).ShouldNot(BeZero())
+				Ω(span.RequestID).Should(HaveLen(32))
+			})
+
+			It("traces running processes, with the same request ID logged", func() {
+				fakeContainer.RunStub = func(spec garden.ProcessSpec, processIO garden.ProcessIO) (garden.Process, error) {
+					process := new(fakes.FakeProcess)
+					process.IDReturns(42)
+					process.WaitForExitReturns(garden.ExitInfo{ExitStatus: 0}, nil)
+					return process, nil
+				}
+
+				container, err := apiClient.Lookup("some-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				process, err := container.Run(garden.ProcessSpec{Path: "ls"}, garden.ProcessIO{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = process.Wait()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				var run tracing.Span
+				Eventually(func() string {
+					for _, span := range tracer.Spans() {
+						if span.Name == "Run" {
+							run = span
+						}
+					}
+
+					return run.Name
+				}).Should(Equal("Run"))
+
+				Ω(run.TraceID).Should(Equal(caller.TraceID))
+
+				var requestIDs []string
+				for _, ctx := range serverBackend.Contexts() {
+					requestIDs = append(requestIDs, tracing.RequestIDFrom(ctx))
+				}
+
+				Ω(requestIDs).Should(ContainElement(run.RequestID))
+				Ω(fixture.Logger).Should(gbytes.Say(`"request-id":"` + run.RequestID + `"`))
+			})
+		})
+	}
+})
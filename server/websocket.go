@@ -0,0 +1,81 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gogo/protobuf/proto"
+	"golang.org/x/net/websocket"
+
+	"github.com/cloudfoundry-incubator/garden/transport"
+)
+
+var errOriginNotAllowed = errors.New("websocket origin not allowed")
+
+// WithWebSocketOrigins allows browsers on pages from the given origins, e.g.
+// "https://console.example.com", to open process streams over WebSockets.
+// Pages served from the server's own host are always allowed, as are clients
+// that send no origin.
+func WithWebSocketOrigins(origins ...string) Option {
+	return func(s *GardenServer) {
+		for _, origin := range origins {
+			s.webSocketOrigins[origin] = struct{}{}
+		}
+	}
+}
+
+// serveWebSocket upgrades the request to a WebSocket and serves it with the
+// handler, refusing origins that are not allowed.
+func (s *GardenServer) serveWebSocket(w http.ResponseWriter, r *http.Request, handler func(*websocket.Conn)) {
+	websocket.Server{
+		Handshake: func(config *websocket.Config, r *http.Request) error {
+			err := s.checkWebSocketOrigin(config, r)
+			if err != nil {
+				return err
+			}
+
+			config.Header = http.Header{
+				transport.StreamFeaturesHeader: []string{streamFeatures},
+			}
+
+			return nil
+		},
+
+		Handler: handler,
+	}.ServeHTTP(w, r)
+}
+
+func (s *GardenServer) checkWebSocketOrigin(config *websocket.Config, r *http.Request) error {
+	if r.Header.Get("Origin") == "" {
+		return nil
+	}
+
+	origin, err := websocket.Origin(config, r)
+	if err != nil {
+		return err
+	}
+
+	if origin.Host == r.Host {
+		return nil
+	}
+
+	if _, found := s.webSocketOrigins[origin.Scheme+"://"+origin.Host]; found {
+		return nil
+	}
+
+	return errOriginNotAllowed
+}
+
+// webSocketMessages reads and writes messages as JSON, one per WebSocket
+// message.
+type webSocketMessages struct {
+	conn *websocket.Conn
+}
+
+func (m webSocketMessages) ReadMessage(msg proto.Message) error {
+	return websocket.JSON.Receive(m.conn, msg)
+}
+
+func (m webSocketMessages) WriteMessage(msg proto.Message) error {
+	return websocket.JSON.Send(m.conn, msg)
+}
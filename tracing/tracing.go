@@ -0,0 +1,192 @@
+// Package tracing carries request IDs and W3C trace context between Garden
+// clients, servers and backends, and describes the spans servers export for
+// the requests they handle.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// The headers, and gRPC metadata keys, requests carry their request ID and
+// trace context in.
+const (
+	RequestIDHeader   = "x-request-id"
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+)
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// TraceContext is the position of a request within a trace, as carried by
+// the traceparent and tracestate headers.
+type TraceContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+
+	// State is the vendor-specific tracestate, passed on as it was received.
+	State string
+}
+
+// NewTraceContext starts a new, sampled trace.
+func NewTraceContext() TraceContext {
+	tc := TraceContext{Sampled: true}
+	rand.Read(tc.TraceID[:])
+	rand.Read(tc.SpanID[:])
+	return tc
+}
+
+// Child returns the context of a new span within the same trace.
+func (tc TraceContext) Child() TraceContext {
+	child := tc
+	rand.Read(child.SpanID[:])
+	return child
+}
+
+// TraceParent formats the context as a traceparent header.
+func (tc TraceContext) TraceParent() string {
+	flags := 0
+	if tc.Sampled {
+		flags = 1
+	}
+
+	return fmt.Sprintf("00-%s-%s-%02x", tc.TraceID, tc.SpanID, flags)
+}
+
+// ParseTraceParent parses a traceparent header, along with the tracestate
+// that accompanies it. It returns false if the traceparent is missing or
+// invalid, in which case the tracestate is to be ignored too.
+func ParseTraceParent(traceparent, tracestate string) (TraceContext, bool) {
+	fields := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(fields) < 4 {
+		return TraceContext{}, false
+	}
+
+	version, traceID, spanID, flags := fields[0], fields[1], fields[2], fields[3]
+
+	// later versions may append fields, but version 00 has exactly four
+	if len(version) != 2 || version == "ff" || (version == "00" && len(fields) != 4) {
+		return TraceContext{}, false
+	}
+
+	var tc TraceContext
+
+	if !decodeID(tc.TraceID[:], traceID) || !decodeID(tc.SpanID[:], spanID) {
+		return TraceContext{}, false
+	}
+
+	var flagBits [1]byte
+	if !decodeHex(flagBits[:], flags) {
+		return TraceContext{}, false
+	}
+
+	tc.Sampled = flagBits[0]&1 == 1
+	tc.State = tracestate
+
+	return tc, true
+}
+
+// decodeID decodes a trace or span ID, which must not be all zeroes.
+func decodeID(dst []byte, id string) bool {
+	if !decodeHex(dst, id) {
+		return false
+	}
+
+	for _, b := range dst {
+		if b != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decodeHex decodes exactly len(dst) bytes of lowercase hex.
+func decodeHex(dst []byte, s string) bool {
+	if len(s) != hex.EncodedLen(len(dst)) || strings.ToLower(s) != s {
+		return false
+	}
+
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+// NewRequestID returns a random ID for a request that does not carry one.
+func NewRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+type requestIDKey struct{}
+type traceContextKey struct{}
+
+// WithRequestID returns a context carrying the request ID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFrom returns the ID of the request the context is for, if any.
+func RequestIDFrom(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// WithTraceContext returns a context carrying the trace context.
+func WithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// TraceContextFrom returns the trace context of the request the context is
+// for, if it has one. On the server, this is that of the span handling the
+// request.
+func TraceContextFrom(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok
+}
+
+// Span records a server's handling of a request.
+type Span struct {
+	// Name is the request's route, or the gRPC method called.
+	Name string
+
+	RequestID string
+
+	TraceID TraceID
+	SpanID  SpanID
+
+	// ParentID is the span of the caller, if the request carried one.
+	ParentID SpanID
+
+	Start time.Time
+	End   time.Time
+
+	// Attributes describe the request, e.g. the container handle and the
+	// status it was responded to with.
+	Attributes map[string]string
+}
+
+// Tracer exports the spans of the requests a server handles, e.g. to a
+// tracing system. It is called once each request has been handled, and must
+// be safe to call concurrently.
+type Tracer interface {
+	ExportSpan(Span)
+}
@@ -0,0 +1,104 @@
+package tracing_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+
+	"github.com/cloudfoundry-incubator/garden/tracing"
+)
+
+var _ = Describe("Trace context", func() {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	Describe("parsing a traceparent", func() {
+		It("reads its trace and span IDs and whether it is sampled", func() {
+			tc, ok := tracing.ParseTraceParent(traceparent, "vendor=value")
+			Ω(ok).Should(BeTrue())
+
+			Ω(tc.TraceID.String()).Should(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+			Ω(tc.SpanID.String()).Should(Equal("00f067aa0ba902b7"))
+			Ω(tc.Sampled).Should(BeTrue())
+			Ω(tc.State).Should(Equal("vendor=value"))
+		})
+
+		It("formats back to the same traceparent", func() {
+			tc, ok := tracing.ParseTraceParent(traceparent, "")
+			Ω(ok).Should(BeTrue())
+
+			Ω(tc.TraceParent()).Should(Equal(traceparent))
+		})
+
+		It("reads the known fields of later versions", func() {
+			tc, ok := tracing.ParseTraceParent("cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra", "")
+			Ω(ok).Should(BeTrue())
+
+			Ω(tc.SpanID.String()).Should(Equal("00f067aa0ba902b7"))
+			Ω(tc.Sampled).Should(BeFalse())
+		})
+
+		invalid := map[string]string{
+			"that is empty":                   "",
+			"with too few fields":             "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+			"with extra fields in version 00": traceparent + "-extra",
+			"with the invalid version":        "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"with a short trace ID":           "00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+			"with an all-zero trace ID":       "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			"with an all-zero span ID":        "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+			"in uppercase hex":                "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+			"with non-hex flags":              "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-zz",
+		}
+
+		for description, traceparent := range invalid {
+			traceparent := traceparent
+
+			It("rejects one "+description, func() {
+				_, ok := tracing.ParseTraceParent(traceparent, "vendor=value")
+				Ω(ok).Should(BeFalse())
+			})
+		}
+	})
+
+	It("starts child spans within the same trace", func() {
+		tc := tracing.NewTraceContext()
+		child := tc.Child()
+
+		Ω(child.TraceID).Should(Equal(tc.TraceID))
+		Ω(child.SpanID).ShouldNot(Equal(tc.SpanID))
+		Ω(child.Sampled).Should(BeTrue())
+	})
+
+	It("starts new traces with valid IDs", func() {
+		tc := tracing.NewTraceContext()
+
+		parsed, ok := tracing.ParseTraceParent(tc.TraceParent(), "")
+		Ω(ok).Should(BeTrue())
+		Ω(parsed).Should(Equal(tc))
+
+		Ω(tracing.NewTraceContext().TraceID).ShouldNot(Equal(tc.TraceID))
+	})
+
+	It("carries the request ID and trace context in contexts", func() {
+		ctx := context.Background()
+
+		Ω(tracing.RequestIDFrom(ctx)).Should(BeEmpty())
+		_, ok := tracing.TraceContextFrom(ctx)
+		Ω(ok).Should(BeFalse())
+
+		tc := tracing.NewTraceContext()
+
+		ctx = tracing.WithRequestID(ctx, "some-request")
+		ctx = tracing.WithTraceContext(ctx, tc)
+
+		Ω(tracing.RequestIDFrom(ctx)).Should(Equal("some-request"))
+
+		carried, ok := tracing.TraceContextFrom(ctx)
+		Ω(ok).Should(BeTrue())
+		Ω(carried).Should(Equal(tc))
+	})
+
+	It("generates distinct request IDs", func() {
+		Ω(tracing.NewRequestID()).Should(HaveLen(32))
+		Ω(tracing.NewRequestID()).ShouldNot(Equal(tracing.NewRequestID()))
+	})
+})
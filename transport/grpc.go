@@ -0,0 +1,48 @@
+package transport
+
+import (
+	"fmt"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+// GRPCErrorTrailer is the trailer in which the gRPC service sends the
+// ErrorResponse of a failed call, so that clients can rebuild its typed error.
+const GRPCErrorTrailer = "garden-error-bin"
+
+// GRPCCodec encodes the messages of the gRPC service as protobuf. Like the
+// protobuf content type, it tolerates unset required fields.
+type GRPCCodec struct{}
+
+func (GRPCCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("not a protobuf message: %T", v)
+	}
+
+	data, err := proto.Marshal(msg)
+	if _, missingRequired := err.(*proto.RequiredNotSetError); err != nil && !missingRequired {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (GRPCCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("not a protobuf message: %T", v)
+	}
+
+	err := proto.Unmarshal(data, msg)
+	if _, missingRequired := err.(*proto.RequiredNotSetError); err != nil && !missingRequired {
+		return err
+	}
+
+	return nil
+}
+
+// Name is that of gRPC's own protobuf codec, as the encoding is the same.
+func (GRPCCodec) Name() string {
+	return "proto"
+}
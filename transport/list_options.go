@@ -0,0 +1,7 @@
+package transport
+
+// ListOptionsHeader is set by the server on list responses to show that it
+// understands the $-prefixed query parameters carrying the options of listing
+// a page of containers. Older servers take them for properties the containers
+// must have, and list none.
+const ListOptionsHeader = "X-Garden-List-Options"
@@ -0,0 +1,94 @@
+package transport
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+// ErrMessageQueueFull is returned by reads of a queue that was pushed more
+// messages than its limit while they were not being read.
+var ErrMessageQueueFull = errors.New("message queue full")
+
+// MessageQueue is a MessageReader of the messages pushed onto it, used to
+// hand each of the streams multiplexed over one connection its own messages.
+type MessageQueue struct {
+	messages []proto.Message
+	limit    int
+	err      error
+
+	cond *sync.Cond
+}
+
+// NewMessageQueue returns a queue holding at most limit unread messages, or
+// any number if limit is zero.
+func NewMessageQueue(limit int) *MessageQueue {
+	return &MessageQueue{
+		limit: limit,
+		cond:  sync.NewCond(&sync.Mutex{}),
+	}
+}
+
+// Push queues the message to be read. Messages pushed once the queue is
+// closed are discarded. If the queue already holds its limit of unread
+// messages, they are discarded too, and the queue is closed with
+// ErrMessageQueueFull, which is returned.
+func (q *MessageQueue) Push(msg proto.Message) error {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	if q.err != nil {
+		return q.err
+	}
+
+	if q.limit > 0 && len(q.messages) >= q.limit {
+		q.messages = nil
+		q.err = ErrMessageQueueFull
+		q.cond.Broadcast()
+
+		return q.err
+	}
+
+	q.messages = append(q.messages, msg)
+	q.cond.Signal()
+
+	return nil
+}
+
+// Close makes reads return err once the messages already queued have been
+// read. Only the first call has any effect.
+func (q *MessageQueue) Close(err error) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	if q.err != nil {
+		return
+	}
+
+	q.err = err
+	q.cond.Broadcast()
+}
+
+// ReadMessage waits for the next message and copies it into msg.
+func (q *MessageQueue) ReadMessage(msg proto.Message) error {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	for len(q.messages) == 0 && q.err == nil {
+		q.cond.Wait()
+	}
+
+	if len(q.messages) == 0 {
+		return q.err
+	}
+
+	next := q.messages[0]
+	q.messages[0] = nil
+	q.messages = q.messages[1:]
+
+	msg.Reset()
+	proto.Merge(msg, next)
+
+	return nil
+}
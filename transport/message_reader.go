@@ -0,0 +1,73 @@
+package transport
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+// MessageReader reads a stream of messages in a single content type.
+type MessageReader interface {
+	ReadMessage(proto.Message) error
+}
+
+// NewMessageReader returns a reader of messages encoded as contentType,
+// which is either ProtobufContentType or JSON.
+func NewMessageReader(reader io.Reader, contentType string) MessageReader {
+	if contentType == ProtobufContentType {
+		byteReader, ok := reader.(byteReader)
+		if !ok {
+			byteReader = bufio.NewReader(reader)
+		}
+
+		return protobufReader{byteReader}
+	}
+
+	return jsonReader{json.NewDecoder(reader)}
+}
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+type jsonReader struct {
+	decoder *json.Decoder
+}
+
+func (r jsonReader) ReadMessage(msg proto.Message) error {
+	return r.decoder.Decode(msg)
+}
+
+type protobufReader struct {
+	reader byteReader
+}
+
+func (r protobufReader) ReadMessage(msg proto.Message) error {
+	length, err := binary.ReadUvarint(r.reader)
+	if err != nil {
+		return err
+	}
+
+	if length > MaxProtobufMessageSize {
+		return fmt.Errorf("protobuf message too large: %d bytes", length)
+	}
+
+	data := make([]byte, length)
+
+	_, err = io.ReadFull(r.reader, data)
+	if err != nil {
+		return err
+	}
+
+	err = proto.Unmarshal(data, msg)
+	if _, missingRequired := err.(*proto.RequiredNotSetError); err != nil && !missingRequired {
+		return err
+	}
+
+	return nil
+}
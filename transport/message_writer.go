@@ -10,3 +10,34 @@ import (
 func WriteMessage(writer io.Writer, req proto.Message) error {
 	return json.NewEncoder(writer).Encode(req)
 }
+
+// MessageWriter writes a stream of messages in a single content type.
+type MessageWriter interface {
+	WriteMessage(proto.Message) error
+}
+
+// NewMessageWriter returns a writer of messages encoded as contentType,
+// which is either ProtobufContentType or JSON.
+func NewMessageWriter(writer io.Writer, contentType string) MessageWriter {
+	if contentType == ProtobufContentType {
+		return protobufWriter{writer}
+	}
+
+	return jsonWriter{writer}
+}
+
+type jsonWriter struct {
+	writer io.Writer
+}
+
+func (w jsonWriter) WriteMessage(msg proto.Message) error {
+	return WriteMessage(w.writer, msg)
+}
+
+type protobufWriter struct {
+	writer io.Writer
+}
+
+func (w protobufWriter) WriteMessage(msg proto.Message) error {
+	return WriteProtobufMessage(w.writer, msg)
+}
@@ -0,0 +1,53 @@
+package transport
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+const (
+	JSONContentType = "application/json"
+
+	// Messages encoded as protobuf, each preceded by its length as a varint.
+	ProtobufContentType = "application/x-protobuf"
+)
+
+// AcceptHeader is sent by clients that can read either content type,
+// preferring protobuf.
+var AcceptHeader = strings.Join([]string{ProtobufContentType, JSONContentType}, ", ")
+
+// MaxProtobufMessageSize bounds the length a protobuf message may claim, so
+// that a corrupt stream cannot make the reader allocate without limit.
+const MaxProtobufMessageSize = 64 * 1024 * 1024
+
+// NegotiateContentType returns the content type to respond with to a request
+// with the given Accept header. JSON is used unless protobuf is asked for.
+func NegotiateContentType(accept string) string {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(mediaRange, ";", 2)[0])
+		if mediaType == ProtobufContentType {
+			return ProtobufContentType
+		}
+	}
+
+	return JSONContentType
+}
+
+// WriteProtobufMessage writes the message as protobuf, preceded by its
+// length.
+func WriteProtobufMessage(writer io.Writer, msg proto.Message) error {
+	data, err := proto.Marshal(msg)
+	if _, missingRequired := err.(*proto.RequiredNotSetError); err != nil && !missingRequired {
+		return err
+	}
+
+	prefix := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(prefix, uint64(len(data)))
+
+	// write in one go, so that concurrent writers do not interleave
+	_, err = writer.Write(append(prefix[:n], data...))
+	return err
+}
@@ -0,0 +1,41 @@
+package transport
+
+import (
+	"net/http"
+	"strings"
+)
+
+// StreamFeaturesHeader is set by the server on hijacked process stream
+// responses to list the optional stream features it supports, separated by
+// commas. Clients must not use a feature the server does not list.
+const StreamFeaturesHeader = "X-Garden-Stream-Features"
+
+const (
+	// The server delivers every garden.Signal, not just SignalTerminate and
+	// SignalKill.
+	FeatureExtendedSignals = "extended-signals"
+
+	// The server buffers process output and replays it to attach requests
+	// with an offset query parameter. Output payloads carry their offset.
+	FeatureOutputReplay = "output-replay"
+
+	// Output is only sent as far as the credit granted by the client with
+	// window updates allows. Clients opt in by listing it in the same header
+	// on their request, and then grant their initial window.
+	FeatureFlowControl = "flow-control"
+
+	// Process input and output is sent as raw_data, which is base64 encoded
+	// in JSON, rather than as data, which must be valid UTF-8. Clients opt in
+	// to receiving it by listing it in the same header on their request.
+	FeatureBinaryData = "binary-data"
+)
+
+func HasStreamFeature(header http.Header, feature string) bool {
+	for _, supported := range strings.Split(header.Get(StreamFeaturesHeader), ",") {
+		if strings.TrimSpace(supported) == feature {
+			return true
+		}
+	}
+
+	return false
+}